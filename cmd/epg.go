@@ -7,24 +7,14 @@ import (
 	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
 )
 
-// GenEPG generates a new epg.xml.gz file with updated EPG data by first deleting any existing epg.xml.gz file.
-// calls epg.GenXMLGz() to generate the XML, and returns any errors.
+// GenEPG generates a new epg.xml.gz file with updated EPG data.
+// calls epg.GenXMLGz() to generate the XML, which only replaces an existing epg.xml.gz once the new one is complete.
+// Returns any errors.
 func GenEPG() error {
 
-	fmt.Println("Deleting existing EPG file if exists")
-
-	err := os.Remove("epg.xml.gz")
-	if err != nil {
-		// If file does not exist, ignore error
-		if !os.IsNotExist(err) {
-			return err
-		}
-	}
-
 	fmt.Println("Generating new EPG file")
 
-	err = epg.GenXMLGz("epg.xml.gz")
-	return err
+	return epg.GenXMLGz("epg.xml.gz")
 }
 
 // DeleteEPG deletes the existing epg.xml.gz file if it exists.
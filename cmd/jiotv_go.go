@@ -10,7 +10,11 @@ import (
 	"github.com/jiotv-go/jiotv_go/v3/internal/handlers"
 	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/localmedia"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/reminders"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/share"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 	"github.com/jiotv-go/jiotv_go/v3/web"
 
@@ -38,6 +42,21 @@ func Logger() *log.Logger { // Corrected to *log.Logger
 	return utils.Log
 }
 
+// serverConfig returns the config of the Fiber app of the server.
+// Routes match regardless of case and trailing slashes, which the middleware takes into account.
+func serverConfig(views fiber.Views) fiber.Config {
+	return fiber.Config{
+		Views:             views,
+		Network:           fiber.NetworkTCP,
+		StreamRequestBody: true,
+		CaseSensitive:     false,
+		StrictRouting:     false,
+		EnablePrintRoutes: false,
+		ServerHeader:      "JioTV Go",
+		AppName:           fmt.Sprintf("JioTV Go %s", constants.Version),
+	}
+}
+
 type JioTVServerConfig struct {
 	Host        string
 	Port        string
@@ -64,21 +83,20 @@ func JioTVServer(jiotvServerConfig JioTVServerConfig) error {
 	scheduler.Init()
 	defer scheduler.Stop()
 
+	// Schedule the saved programme reminders
+	reminders.Init()
+
+	// Create the secret signing share links
+	if err := share.Init(); err != nil {
+		return err
+	}
+
 	engine := html.NewFileSystem(http.FS(web.GetViewFiles()), ".html")
 	if config.Cfg.Debug {
 		engine.Reload(true)
 	}
 
-	app := fiber.New(fiber.Config{
-		Views:             engine,
-		Network:           fiber.NetworkTCP,
-		StreamRequestBody: true,
-		CaseSensitive:     false,
-		StrictRouting:     false,
-		EnablePrintRoutes: false,
-		ServerHeader:      "JioTV Go",
-		AppName:           fmt.Sprintf("JioTV Go %s", constants.Version),
-	})
+	app := fiber.New(serverConfig(engine))
 
 	app.Use(recover.New(recover.Config{
 		EnableStackTrace: true,
@@ -86,6 +104,10 @@ func JioTVServer(jiotvServerConfig JioTVServerConfig) error {
 
 	app.Use(middleware.CORS())
 
+	app.Use(middleware.Auth())
+
+	app.Use(middleware.Limits())
+
 	app.Use(logger.New(logger.Config{
 		TimeZone: "Asia/Kolkata",
 		Format:   "[${time}] ${status} - ${latency} ${method} ${path} Params:[${queryParams}] ${error}\n",
@@ -104,31 +126,101 @@ func JioTVServer(jiotvServerConfig JioTVServerConfig) error {
 	// Initialize the television object
 	handlers.Init()
 
+	// Schedule the saved recordings
+	handlers.InitDVR()
+
+	// Load the cached channel list and keep it fresh in the background
+	television.InitChannelsCache()
+
+	// Reload custom channels whenever the file changes
+	television.WatchCustomChannels()
+
+	// Play the local media directories as channels
+	localmedia.Init()
+
 	app.Get("/", handlers.IndexHandler)
 	app.Post("/login/sendOTP", handlers.LoginSendOTPHandler)
 	app.Post("/login/verifyOTP", handlers.LoginVerifyOTPHandler)
 	app.Get("/logout", handlers.LogoutHandler)
 	app.Get("/live/:id", handlers.LiveHandler)
 	app.Get("/live/:quality/:id", handlers.LiveQualityHandler)
+	app.Get("/catchup/:channelID", handlers.CatchupHandler)
 	app.Get("/render.m3u8", handlers.RenderHandler)
 	app.Get("/render.ts", handlers.RenderTSHandler)
 	app.Get("/render.key", handlers.RenderKeyHandler)
 	app.Get("/channels", handlers.ChannelsHandler)
+	app.Get("/channels/changes", handlers.ChannelChangesPageHandler)
 	app.Get("/playlist.m3u", handlers.PlaylistHandler)
 	app.Get("/play/:id", handlers.PlayHandler)
 	app.Get("/player/:id", handlers.PlayerHandler)
 	app.Get("/favicon.ico", handlers.FaviconHandler)
 	app.Get("/jtvimage/:file", handlers.ImageHandler)
 	app.Get("/epg.xml.gz", handlers.EPGHandler)
+	app.Get("/epg.xml", handlers.EPGXMLHandler)
 	app.Get("/epg/:channelID/:offset", handlers.WebEPGHandler)
+	app.Get("/local/epg.xml", handlers.LocalEPGHandler)
+	app.Get("/local/:channelID/index.m3u8", handlers.LocalPlaylistHandler)
+	app.Get("/local/:channelID/:segment", handlers.LocalSegmentHandler)
 	app.Get("/jtvposter/:date/:file", handlers.PosterHandler)
 	app.Get("/mpd/:channelID", handlers.LiveMpdHandler)
 	app.Post("/drm", handlers.DRMKeyHandler)
 	app.Get("/dashtime", handlers.DASHTimeHandler)
+	app.Get("/api/favorites", handlers.GetFavoritesHandler)
+	app.Put("/api/favorites", handlers.SetFavoritesHandler)
+	app.Post("/api/favorites/:id", handlers.AddFavoriteHandler)
+	app.Delete("/api/favorites/:id", handlers.RemoveFavoriteHandler)
+	app.Get("/api/channel-order", handlers.GetChannelOrderHandler)
+	app.Put("/api/channel-order", handlers.SetChannelOrderHandler)
+	app.Get("/api/epg/now", handlers.EPGNowHandler)
+	app.Get("/api/epg/now-next", handlers.EPGNowNextHandler)
+	app.Get("/api/epg/search", handlers.EPGSearchHandler)
+	app.Get("/api/epg/:channelID", handlers.EPGChannelHandler)
+	// The versioned API, described by its OpenAPI document at /api/v1/openapi.json
+	for _, operation := range handlers.APIV1Operations() {
+		app.Add(operation.Method, operation.Path, operation.Handler)
+	}
+	app.Get("/api/reminders", handlers.GetRemindersHandler)
+	app.Post("/api/reminders", handlers.AddReminderHandler)
+	app.Get("/api/reminders/events", handlers.ReminderEventsHandler)
+	app.Delete("/api/reminders/:id", handlers.RemoveReminderHandler)
+	app.Get("/recordings", handlers.RecordingsHandler)
+	app.Get("/api/recordings", handlers.GetRecordingsHandler)
+	app.Post("/api/recordings", handlers.AddRecordingHandler)
+	app.Post("/api/recordings/:id/cancel", handlers.CancelRecordingHandler)
+	app.Get("/api/recordings/:id/file", handlers.RecordingFileHandler)
+	app.Delete("/api/recordings/:id", handlers.DeleteRecordingHandler)
+	app.Get("/api/recording-rules", handlers.GetRecordingRulesHandler)
+	app.Post("/api/recording-rules", handlers.AddRecordingRuleHandler)
+	app.Delete("/api/recording-rules/:id", handlers.RemoveRecordingRuleHandler)
+	app.Get("/api/usage", handlers.UsageHandler)
+	app.Get("/api/usage/clients", handlers.ClientsUsageHandler)
+	app.Get("/shares", handlers.SharesHandler)
+	app.Get("/api/shares", handlers.GetSharesHandler)
+	app.Post("/api/shares", handlers.AddShareHandler)
+	app.Delete("/api/shares/:id", handlers.RevokeShareHandler)
+	app.Get("/metrics", handlers.MetricsHandler)
 
 	app.Get("/render.mpd", handlers.MpdHandler)
 	app.Use("/render.dash", handlers.DashHandler)
 
+	// Streams and playlists of a named credential profile
+	profile := app.Group("/p/:profile", handlers.ProfileMiddleware)
+	profile.Get("/live/:id", handlers.LiveHandler)
+	profile.Get("/live/:quality/:id", handlers.LiveQualityHandler)
+	profile.Get("/catchup/:channelID", handlers.CatchupHandler)
+	profile.Get("/render.m3u8", handlers.RenderHandler)
+	profile.Get("/render.ts", handlers.RenderTSHandler)
+	profile.Get("/render.key", handlers.RenderKeyHandler)
+	profile.Get("/play/:id", handlers.PlayHandler)
+	profile.Get("/player/:id", handlers.PlayerHandler)
+	profile.Get("/mpd/:channelID", handlers.LiveMpdHandler)
+	profile.Post("/drm", handlers.DRMKeyHandler)
+	profile.Get("/render.mpd", handlers.MpdHandler)
+	profile.Use("/render.dash", handlers.DashHandler)
+	profile.Get("/channels", handlers.ChannelsHandler)
+	profile.Get("/playlist.m3u", handlers.PlaylistHandler)
+	profile.Get("/logout", handlers.LogoutHandler)
+
 	if jiotvServerConfig.TLS {
 		if jiotvServerConfig.TLSCertPath == "" || jiotvServerConfig.TLSKeyPath == "" {
 			return fmt.Errorf("TLS cert and key paths are required for HTTPS. Please provide them using --tls-cert and --tls-key flags")
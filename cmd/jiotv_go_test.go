@@ -2,7 +2,16 @@ package cmd
 
 import (
 	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -117,3 +126,80 @@ func TestJioTVServer(t *testing.T) {
 		})
 	}
 }
+
+// TestServerRouteAccess checks the access control of routes reached through another case or a trailing slash,
+// which the server routes like the plain path
+func TestServerRouteAccess(t *testing.T) {
+	previous := config.Cfg
+	config.Cfg = config.JioTVConfig{
+		AuthUsername:      "user",
+		AuthPassword:      "secret",
+		AdminUsername:     "admin",
+		AdminPassword:     "root",
+		APIKeys:           []string{"key1"},
+		MetricsToken:      "scrape",
+		ClientMaxChannels: 1,
+		ClientDailyQuota:  1,
+	}
+	defer func() { config.Cfg = previous }()
+	secureurl.Init()
+	channel := func(id string) string {
+		upstream := "https://cdn.example.com/" + id + "/index.m3u8"
+		auth, _ := secureurl.EncryptURL(upstream)
+		return url.Values{"auth": {auth}, "channel_key_id": {id}, "channel_sig": {secureurl.SignChannel(id, upstream)}}.Encode()
+	}
+
+	app := fiber.New(serverConfig(nil))
+	app.Use(middleware.Auth())
+	app.Use(middleware.Limits())
+	ok := func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	}
+	app.Get("/logout", ok)
+	app.Post("/api/shares", ok)
+	app.Get("/metrics", ok)
+	app.Get("/render.m3u8", ok)
+	app.Get("/render.ts", func(c *fiber.Ctx) error {
+		c.Response().SetBody(make([]byte, 1024*1024))
+		middleware.CountStreamBytes(c)
+		return nil
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		basic      string
+		wantStatus int
+	}{
+		{name: "Logout in upper case with the web UI credential", method: http.MethodGet, path: "/Logout", basic: "user:secret", wantStatus: fiber.StatusForbidden},
+		{name: "Logout with a trailing slash with the web UI credential", method: http.MethodGet, path: "/logout/", basic: "user:secret", wantStatus: fiber.StatusForbidden},
+		{name: "Logout in upper case with an API key", method: http.MethodGet, path: "/LOGOUT?token=key1", wantStatus: fiber.StatusForbidden},
+		{name: "Logout of a profile in upper case with an API key", method: http.MethodGet, path: "/P/family/Logout?token=key1", wantStatus: fiber.StatusForbidden},
+		{name: "Share links in upper case with the web UI credential", method: http.MethodPost, path: "/API/shares", basic: "user:secret", wantStatus: fiber.StatusForbidden},
+		{name: "Logout in upper case with the admin credential", method: http.MethodGet, path: "/LOGOUT/", basic: "admin:root", wantStatus: fiber.StatusOK},
+		{name: "Metrics with a trailing slash without the token", method: http.MethodGet, path: "/metrics/", wantStatus: fiber.StatusUnauthorized},
+		{name: "Metrics in upper case without the token", method: http.MethodGet, path: "/Metrics", wantStatus: fiber.StatusUnauthorized},
+		{name: "Metrics in upper case with the token", method: http.MethodGet, path: "/Metrics?token=scrape", wantStatus: fiber.StatusOK},
+		{name: "First channel", method: http.MethodGet, path: "/render.m3u8?" + channel("143") + "&token=key1", wantStatus: fiber.StatusOK},
+		{name: "Second channel in upper case", method: http.MethodGet, path: "/Render.m3u8?" + channel("144") + "&token=key1", wantStatus: fiber.StatusTooManyRequests},
+		{name: "Segment within the quota", method: http.MethodGet, path: "/render.ts?token=key1", wantStatus: fiber.StatusOK},
+		{name: "Segment in upper case over the quota", method: http.MethodGet, path: "/RENDER.TS?token=key1", wantStatus: fiber.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.basic != "" {
+				username, password, _ := strings.Cut(tt.basic, ":")
+				req.SetBasicAuth(username, password)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatalf("%s %s error = %v", tt.method, tt.path, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -11,9 +11,20 @@ import (
 // Logs messages to provide feedback to the user.
 // Returns any errors encountered.
 func Logout() error {
+	return LogoutProfile("")
+}
+
+// LogoutProfile logs out the given profile, or the default profile if empty.
+// Returns any errors encountered.
+func LogoutProfile(profile string) error {
 	fmt.Println("Deleting existing login file if exists")
 
-	err := utils.Logout()
+	if profile == "" {
+		profile = utils.GetDefaultProfile()
+	} else if !utils.ProfileExists(profile) {
+		return fmt.Errorf("%w: %s", utils.ErrProfileNotFound, profile)
+	}
+	err := utils.LogoutProfile(profile)
 	if err != nil {
 		return err
 	}
@@ -28,6 +39,18 @@ func Logout() error {
 // verifies the entered OTP by the user and logs in the user.
 // Returns any error encountered.
 func LoginOTP() error {
+	return LoginOTPProfile("")
+}
+
+// LoginOTPProfile logs in using OTP like LoginOTP and saves the credentials to the given profile.
+// An empty profile logs in to the default profile.
+func LoginOTPProfile(profile string) error {
+	if profile != "" {
+		if err := utils.ValidateProfileName(profile); err != nil {
+			return err
+		}
+	}
+
 	fmt.Print("Enter your mobile number: +91 ")
 	var mobileNumber string
 	fmt.Scanln(&mobileNumber)
@@ -47,7 +70,7 @@ func LoginOTP() error {
 		var otp string
 		fmt.Scanln(&otp)
 
-		resultOTP, err := utils.LoginVerifyOTP(mobileNumber, otp)
+		resultOTP, err := utils.LoginVerifyOTPProfile(profile, mobileNumber, otp)
 		if err != nil {
 			return err
 		}
@@ -61,3 +84,24 @@ func LoginOTP() error {
 
 	return nil
 }
+
+// Profiles prints all credential profiles and marks the default one.
+// If name is given, it is made the default profile first.
+func Profiles(name string) error {
+	if name != "" {
+		if err := utils.SetDefaultProfile(name); err != nil {
+			return err
+		}
+		fmt.Printf("Default profile set to %s\n", name)
+	}
+
+	defaultProfile := utils.GetDefaultProfile()
+	for _, profile := range utils.ListProfiles() {
+		if profile == defaultProfile {
+			fmt.Printf("* %s (default)\n", profile)
+		} else {
+			fmt.Printf("  %s\n", profile)
+		}
+	}
+	return nil
+}
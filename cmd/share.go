@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/handlers"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+// ShareOptions are the options of the share create command
+type ShareOptions struct {
+	// ServerURL is the URL of the running server, like http://localhost:5001
+	ServerURL string
+	Name      string
+	// Channels, Languages and Categories are lists separated by commas.
+	// Languages and categories are names or IDs, like English or 6.
+	Channels   string
+	Languages  string
+	Categories string
+	Expires    time.Duration
+}
+
+// shareClient sends the requests of the share commands
+var shareClient = &http.Client{Timeout: 30 * time.Second}
+
+// ShareCreate mints a share link through the running server and prints its URLs.
+// The server mints it, so that it lists and revokes it like links minted from the web UI.
+func ShareCreate(opts ShareOptions) error {
+	request := handlers.AddShareRequest{
+		Name:      opts.Name,
+		ExpiresIn: opts.Expires.String(),
+	}
+	for _, id := range strings.Split(opts.Channels, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			request.ChannelIDs = append(request.ChannelIDs, id)
+		}
+	}
+	var err error
+	if request.Languages, err = parseMapIDs(opts.Languages, television.LanguageMap); err != nil {
+		return err
+	}
+	if request.Categories, err = parseMapIDs(opts.Categories, television.CategoryMap); err != nil {
+		return err
+	}
+
+	var link handlers.ShareLink
+	if err := shareRequest(http.MethodPost, opts.ServerURL, "/api/shares", request, &link); err != nil {
+		return err
+	}
+	fmt.Printf("Share link %s expires at %s\n", link.ID, link.Expires.Local().Format(time.RFC1123))
+	fmt.Println("Playlist:", link.PlaylistURL)
+	if link.PlayURL != "" {
+		fmt.Println("Player:", link.PlayURL)
+	}
+	return nil
+}
+
+// ShareList prints the share links of the running server which have not expired
+func ShareList(serverURL string) error {
+	var response handlers.SharesResponse
+	if err := shareRequest(http.MethodGet, serverURL, "/api/shares", nil, &response); err != nil {
+		return err
+	}
+	if len(response.Shares) == 0 {
+		fmt.Println("No share links")
+		return nil
+	}
+	for _, link := range response.Shares {
+		fmt.Printf("%s\t%s\texpires %s\n", link.ID, link.Name, link.Expires.Local().Format(time.RFC1123))
+		fmt.Println("\t" + link.PlaylistURL)
+	}
+	return nil
+}
+
+// ShareRevoke revokes a share link of the running server by its ID
+func ShareRevoke(serverURL, id string) error {
+	if id == "" {
+		return fmt.Errorf("the ID of the share link is required")
+	}
+	if err := shareRequest(http.MethodDelete, serverURL, "/api/shares/"+url.PathEscape(id), nil, nil); err != nil {
+		return err
+	}
+	fmt.Println("Share link", id, "revoked")
+	return nil
+}
+
+// shareRequest sends a request to the share links API of the server with the credential of the config.
+// The response is decoded into v unless it is nil.
+func shareRequest(method, serverURL, path string, body, v interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(serverURL, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// The admin credential, or the one standing in for it when there is no admin
+	switch {
+	case config.Cfg.AdminUsername != "" || config.Cfg.AdminPassword != "":
+		req.SetBasicAuth(config.Cfg.AdminUsername, config.Cfg.AdminPassword)
+	case config.Cfg.AuthUsername != "" || config.Cfg.AuthPassword != "":
+		req.SetBasicAuth(config.Cfg.AuthUsername, config.Cfg.AuthPassword)
+	case len(config.Cfg.APIKeys) > 0:
+		req.Header.Set(middleware.APIKeyHeader, strings.TrimSpace(config.Cfg.APIKeys[0]))
+	}
+
+	resp, err := shareClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the server at %s, is it running? %w", serverURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiError struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiError) == nil && apiError.Message != "" {
+			return fmt.Errorf("server responded %d: %s", resp.StatusCode, apiError.Message)
+		}
+		return fmt.Errorf("server responded %d", resp.StatusCode)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// parseMapIDs returns the IDs of a list of names or IDs of a map like LanguageMap, separated by commas
+func parseMapIDs(values string, names map[int]string) ([]int, error) {
+	var ids []int
+	for _, value := range strings.Split(values, ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		if id, err := strconv.Atoi(value); err == nil {
+			ids = append(ids, id)
+			continue
+		}
+		found := false
+		for id, name := range names {
+			if strings.EqualFold(name, value) {
+				ids = append(ids, id)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown language or category: %s", value)
+		}
+	}
+	return ids, nil
+}
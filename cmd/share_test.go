@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func TestParseMapIDs(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []int
+		wantErr bool
+	}{
+		{value: "", want: nil},
+		{value: "english, 1", want: []int{6, 1}},
+		{value: "Klingon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseMapIDs(tt.value, television.LanguageMap)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMapIDs(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMapIDs(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShareRevoke(t *testing.T) {
+	previous := config.Cfg
+	config.Cfg = config.JioTVConfig{AuthUsername: "user", AuthPassword: "secret", AdminUsername: "admin", AdminPassword: "root"}
+	defer func() { config.Cfg = previous }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, _ := r.BasicAuth(); username != "admin" || password != "root" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodDelete || r.URL.Path != "/api/shares/abc" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "share link not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"shares": []}`))
+	}))
+	defer server.Close()
+
+	if err := ShareRevoke(server.URL, "abc"); err != nil {
+		t.Errorf("ShareRevoke() error = %v", err)
+	}
+	if err := ShareRevoke(server.URL, "xyz"); err == nil || err.Error() != "server responded 404: share link not found" {
+		t.Errorf("ShareRevoke() of an unknown link error = %v", err)
+	}
+	if err := ShareRevoke(server.URL, ""); err == nil {
+		t.Error("ShareRevoke() without an ID should fail")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"reflect"
 
 	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
 )
 
 // JioTVConfig defines the configuration options for the JioTV client.
@@ -14,6 +15,16 @@ import (
 type JioTVConfig struct {
 	// Enable Or Disable EPG Generation. Default: false
 	EPG bool `yaml:"epg" env:"JIOTV_EPG" json:"epg" toml:"epg"`
+	// EPGDaysAhead is the number of days after today included in the EPG, up to 7. 0 only includes today. Default: 1
+	EPGDaysAhead int `yaml:"epg_days_ahead" env:"JIOTV_EPG_DAYS_AHEAD" json:"epg_days_ahead" toml:"epg_days_ahead"`
+	// EPGDaysBack is the number of days before today included in the EPG for catch-up, up to 7. Default: 0
+	EPGDaysBack int `yaml:"epg_days_back" env:"JIOTV_EPG_DAYS_BACK" json:"epg_days_back" toml:"epg_days_back"`
+	// EPGLocalImages serves the channel logos and programme posters of the EPG through this server. Default: false
+	EPGLocalImages bool `yaml:"epg_local_images" env:"JIOTV_EPG_LOCAL_IMAGES" json:"epg_local_images" toml:"epg_local_images"`
+	// ReminderWebhook is the URL to which programme reminders are posted as JSON. Default: ""
+	ReminderWebhook string `yaml:"reminder_webhook" env:"JIOTV_REMINDER_WEBHOOK" json:"reminder_webhook" toml:"reminder_webhook"`
+	// ReminderMinutes is the default number of minutes before the start of a programme at which its reminder fires. Default: 5
+	ReminderMinutes int `yaml:"reminder_minutes" env:"JIOTV_REMINDER_MINUTES" json:"reminder_minutes" toml:"reminder_minutes"`
 	// Enable Or Disable Debug Mode. Default: false
 	Debug bool `yaml:"debug" env:"JIOTV_DEBUG" json:"debug" toml:"debug"`
 	// Enable Or Disable TS Handler. While TS Handler is enabled, the server will serve the TS files directly from JioTV API. Default: false
@@ -28,18 +39,48 @@ type JioTVConfig struct {
 	DisableURLEncryption bool `yaml:"disable_url_encryption" env:"JIOTV_DISABLE_URL_ENCRYPTION" json:"disable_url_encryption" toml:"disable_url_encryption"`
 	// Proxy URL. Proxy is useful to bypass geo-restrictions and ip-restrictions for JioTV API. Default: ""
 	Proxy string `yaml:"proxy" env:"JIOTV_PROXY" json:"proxy" toml:"proxy"`
+	// AuthUsername and AuthPassword are the HTTP basic auth credential of the web UI. Leave both empty to not require it. Default: ""
+	AuthUsername string `yaml:"auth_username" env:"JIOTV_AUTH_USERNAME" json:"auth_username" toml:"auth_username"`
+	AuthPassword string `yaml:"auth_password" env:"JIOTV_AUTH_PASSWORD" json:"auth_password" toml:"auth_password"`
+	// APIKeys are accepted for playlists, streams, the EPG and the API, from the X-API-Key header or the token query param. Default: []
+	APIKeys []string `yaml:"api_keys" env:"JIOTV_API_KEYS" json:"api_keys" toml:"api_keys"`
+	// AdminUsername and AdminPassword are the HTTP basic auth credential for login, logout and settings. Default: ""
+	AdminUsername string `yaml:"admin_username" env:"JIOTV_ADMIN_USERNAME" json:"admin_username" toml:"admin_username"`
+	AdminPassword string `yaml:"admin_password" env:"JIOTV_ADMIN_PASSWORD" json:"admin_password" toml:"admin_password"`
+	// MetricsToken is the bearer token for /metrics. Empty leaves /metrics to the admin credential. Default: ""
+	MetricsToken string `yaml:"metrics_token" env:"JIOTV_METRICS_TOKEN" json:"metrics_token" toml:"metrics_token"`
+	// ClientMaxChannels is how many channels a client, an IP address or API key, can watch at once. 0 is unlimited. Default: 0
+	ClientMaxChannels int `yaml:"client_max_channels" env:"JIOTV_CLIENT_MAX_CHANNELS" json:"client_max_channels" toml:"client_max_channels"`
+	// ClientRequestsPerMinute is how many requests a client can make per minute. 0 is unlimited. Default: 0
+	ClientRequestsPerMinute int `yaml:"client_requests_per_minute" env:"JIOTV_CLIENT_REQUESTS_PER_MINUTE" json:"client_requests_per_minute" toml:"client_requests_per_minute"`
+	// ClientDailyQuota is how many MB of streams a client can get per day. 0 is unlimited. Default: 0
+	ClientDailyQuota int `yaml:"client_daily_quota" env:"JIOTV_CLIENT_DAILY_QUOTA" json:"client_daily_quota" toml:"client_daily_quota"`
 	// PathPrefix is the prefix for all file paths managed by JioTV Go. Default: "$HOME/.jiotv_go"
 	PathPrefix string `yaml:"path_prefix" env:"JIOTV_PATH_PREFIX" json:"path_prefix" toml:"path_prefix"`
 	// LogPath is the directory for log files. Default: ""
 	LogPath string `yaml:"log_path" env:"JIOTV_LOG_PATH" json:"log_path" toml:"log_path"`
 	// LogToStdout controls logging to stdout/stderr. Default: true
 	LogToStdout bool `yaml:"log_to_stdout" env:"JIOTV_LOG_TO_STDOUT" json:"log_to_stdout" toml:"log_to_stdout"`
-	// CustomChannelsFile is the path to custom channels configuration file. Default: ""
+	// CustomChannelsFile is the path or URL to custom channels configuration file or M3U playlist. Default: ""
 	CustomChannelsFile string `yaml:"custom_channels_file" env:"JIOTV_CUSTOM_CHANNELS_FILE" json:"custom_channels_file" toml:"custom_channels_file"`
+	// CustomChannelsRefreshInterval is the time in minutes after which custom channels given as URL are fetched again. Default: 60
+	CustomChannelsRefreshInterval int `yaml:"custom_channels_refresh_interval" env:"JIOTV_CUSTOM_CHANNELS_REFRESH_INTERVAL" json:"custom_channels_refresh_interval" toml:"custom_channels_refresh_interval"`
+	// LocalMediaDirs are directories of .ts and .mp4 files, each played as a linear channel. Default: []
+	LocalMediaDirs []string `yaml:"local_media_dirs" env:"JIOTV_LOCAL_MEDIA_DIRS" json:"local_media_dirs" toml:"local_media_dirs"`
 	// DefaultCategories is the list of category IDs to display on the default web page. Default: []
 	DefaultCategories []int `yaml:"default_categories" env:"JIOTV_DEFAULT_CATEGORIES" json:"default_categories" toml:"default_categories"`
 	// DefaultLanguages is the list of language IDs to display on the default web page. Default: []
 	DefaultLanguages []int `yaml:"default_languages" env:"JIOTV_DEFAULT_LANGUAGES" json:"default_languages" toml:"default_languages"`
+	// ChannelsCacheTTL is the time in minutes for which the channel list from JioTV API is reused before refreshing. Default: 30
+	ChannelsCacheTTL int `yaml:"channels_cache_ttl" env:"JIOTV_CHANNELS_CACHE_TTL" json:"channels_cache_ttl" toml:"channels_cache_ttl"`
+	// GhostChannelDays is the number of days for which channels removed from JioTV are kept in the channel list. Default: 7
+	GhostChannelDays int `yaml:"ghost_channel_days" env:"JIOTV_GHOST_CHANNEL_DAYS" json:"ghost_channel_days" toml:"ghost_channel_days"`
+	// Enable Or Disable the shared cache of upstream playlists, segments and keys. Default: false
+	DisableUpstreamCache bool `yaml:"disable_upstream_cache" env:"JIOTV_DISABLE_UPSTREAM_CACHE" json:"disable_upstream_cache" toml:"disable_upstream_cache"`
+	// UpstreamCacheSize is the maximum size in MB of upstream responses kept in memory. Default: 64
+	UpstreamCacheSize int `yaml:"upstream_cache_size" env:"JIOTV_UPSTREAM_CACHE_SIZE" json:"upstream_cache_size" toml:"upstream_cache_size"`
+	// LiveURLCacheTTL is the time in minutes for which live stream URLs without token expiry are reused. Default: 5
+	LiveURLCacheTTL int `yaml:"live_url_cache_ttl" env:"JIOTV_LIVE_URL_CACHE_TTL" json:"live_url_cache_ttl" toml:"live_url_cache_ttl"`
 }
 
 // Cfg is the global config variable
@@ -50,6 +91,14 @@ var Cfg JioTVConfig
 // If no file is found, it loads config from environment variables.
 // It logs messages about which config source is being used.
 func (c *JioTVConfig) Load(filename string) error {
+	// Tells an unset value from 0, as neither config files nor environment variables change it when unset
+	c.EPGDaysAhead = -1
+	defer func() {
+		if c.EPGDaysAhead < 0 {
+			c.EPGDaysAhead = constants.DefaultEPGDaysAhead
+		}
+	}()
+
 	if filename == "" {
 		filename = commonFileExists()
 	}
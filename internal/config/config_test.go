@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -63,6 +64,33 @@ func TestJioTVConfig_Load(t *testing.T) {
 	}
 }
 
+func TestJioTVConfig_Load_EPGDaysAhead(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{name: "Unset takes the default", content: "epg: true\n", want: 1},
+		{name: "Zero only includes today", content: "epg_days_ahead: 0\n", want: 0},
+		{name: "Days ahead", content: "epg_days_ahead: 3\n", want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "config.yml")
+			if err := os.WriteFile(filename, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write config file: %v", err)
+			}
+			var c JioTVConfig
+			if err := c.Load(filename); err != nil {
+				t.Fatalf("JioTVConfig.Load() error = %v", err)
+			}
+			if c.EPGDaysAhead != tt.want {
+				t.Errorf("EPGDaysAhead = %d, want %d", c.EPGDaysAhead, tt.want)
+			}
+		})
+	}
+}
+
 func TestJioTVConfig_Get(t *testing.T) {
 	// Set the global Cfg for Get to work as intended
 	Cfg = JioTVConfig{
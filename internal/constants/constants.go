@@ -9,8 +9,32 @@ const (
 	PathPrefix = ".jiotv_go"
 
 	// Error messages
-	ErrUnsupportedChannelsFormat = "unsupported or invalid custom channels file format. Supported formats: .json, .yml, .yaml, or valid JSON/YAML content"
+	ErrUnsupportedChannelsFormat = "unsupported or invalid custom channels file format. Supported formats: .json, .yml, .yaml, .m3u, .m3u8, or valid JSON/YAML/M3U content"
 
 	// Limits and thresholds
 	MaxRecommendedChannels = 1000
+
+	// Default time in minutes for which the channel list is cached
+	DefaultChannelsCacheTTL = 30
+
+	// Default time in minutes after which custom channels given as URL are fetched again
+	DefaultCustomChannelsRefreshInterval = 60
+
+	// Default size in MB of the upstream response cache
+	DefaultUpstreamCacheSize = 64
+
+	// Default time in minutes for which live stream URLs are reused when their token has no expiry
+	DefaultLiveURLCacheTTL = 5
+
+	// Default number of days after today included in the EPG
+	DefaultEPGDaysAhead = 1
+
+	// Maximum number of days before or after today served by the JioTV EPG API
+	MaxEPGDays = 7
+
+	// Default number of minutes before the start of a programme at which its reminder fires
+	DefaultReminderMinutes = 5
+
+	// Default number of days for which channels removed from JioTV are kept in the channel list
+	DefaultGhostChannelDays = 7
 )
@@ -9,4 +9,13 @@ const (
 
 	// EPG-related tasks
 	EPGTaskID = "jiotv_epg"
+	// ReminderTaskIDPrefix is followed by the reminder ID in the task of each programme reminder
+	ReminderTaskIDPrefix = "jiotv_reminder_"
+	// RecordingTaskIDPrefix is followed by the recording ID in the task starting each recording
+	RecordingTaskIDPrefix = "jiotv_recording_"
+
+	// Channel-related tasks
+	ChannelsRefreshTaskID     = "jiotv_channels_refresh"
+	CustomChannelsWatchTaskID = "jiotv_custom_channels_watch"
+	LocalMediaScanTaskID      = "jiotv_local_media_scan"
 )
@@ -30,6 +30,9 @@ const (
 	EPGURL            = "https://jiotv.data.cdn.jio.com/apis/v1.3/getepg/get/?offset=%d&channel_id=%d"
 	EPGPosterURL      = "https://jiotv.catchup.cdn.jio.com/dare_images/shows"
 	EPGPosterURLSlash = "https://jiotv.catchup.cdn.jio.com/dare_images/shows/"
+
+	// Channel logo URL
+	ChannelImageURL = "https://jiotv.catchup.cdn.jio.com/dare_images/images/"
 )
 
 // URL path patterns (for string formatting)
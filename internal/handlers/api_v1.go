@@ -0,0 +1,554 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// apiDefaultPerPage is the page size of the channels API when per_page is not given
+	apiDefaultPerPage = 100
+	// apiMaxPerPage is the largest page size of the channels API
+	apiMaxPerPage = 1000
+)
+
+// The types below are the response bodies of the versioned API. They are kept apart from the
+// JioTV API structs so that changes of the upstream schema don't change the API.
+
+// APINamedID is a category or a language
+type APINamedID struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// APILanguage is a language with its ISO 639 code, which is empty for unknown languages
+type APILanguage struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Code string `json:"code"`
+}
+
+// APIChannel is a channel of the versioned API
+type APIChannel struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	LogoURL   string     `json:"logo_url"`
+	StreamURL string     `json:"stream_url"`
+	Category  APINamedID `json:"category"`
+	Language  APINamedID `json:"language"`
+	HD        bool       `json:"hd"`
+	Catchup   bool       `json:"catchup"`
+	// Custom is set for channels of the custom channels file and local media
+	Custom bool `json:"custom"`
+	// Ghost is set for channels removed from JioTV which are kept in the list for a grace period
+	Ghost bool `json:"ghost"`
+}
+
+// APIChannelsResponse is a page of channels
+type APIChannelsResponse struct {
+	Channels []APIChannel `json:"channels"`
+	// Total is the number of channels matching the filters, on all pages
+	Total   int `json:"total"`
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+	// FetchedAt is the time the channel list was fetched from JioTV
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// APICategoriesResponse is the body of the categories API
+type APICategoriesResponse struct {
+	Categories []APINamedID `json:"categories"`
+}
+
+// APILanguagesResponse is the body of the languages API
+type APILanguagesResponse struct {
+	Languages []APILanguage `json:"languages"`
+}
+
+// APILoginStatus is the body of the login status API
+type APILoginStatus struct {
+	LoggedIn bool   `json:"logged_in"`
+	Profile  string `json:"profile"`
+}
+
+// APIProgramme is a programme of the EPG
+type APIProgramme struct {
+	ChannelID   string    `json:"channel_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+	Start       time.Time `json:"start"`
+	Stop        time.Time `json:"stop"`
+	PosterURL   string    `json:"poster_url"`
+}
+
+// APIChannelProgrammes is the body of the EPG API of a channel
+type APIChannelProgrammes struct {
+	ChannelID   string         `json:"channel_id"`
+	ChannelName string         `json:"channel_name"`
+	Programmes  []APIProgramme `json:"programmes"`
+}
+
+// APINowNext is the current and the next programme of a channel. Either may be null.
+type APINowNext struct {
+	ChannelID   string        `json:"channel_id"`
+	ChannelName string        `json:"channel_name"`
+	Now         *APIProgramme `json:"now"`
+	Next        *APIProgramme `json:"next"`
+}
+
+// APINowNextResponse is the body of the now-next EPG API
+type APINowNextResponse struct {
+	Channels []APINowNext `json:"channels"`
+}
+
+// APIError is the body of every error response
+type APIError struct {
+	Message string `json:"message"`
+}
+
+// APIParam is a path or query parameter of an API operation
+type APIParam struct {
+	Name string
+	// In is path or query
+	In          string
+	Type        string
+	Description string
+}
+
+// APIOperation is an endpoint of the versioned API. The OpenAPI document is generated from them.
+type APIOperation struct {
+	Method string
+	// Path is the route in Fiber syntax, like /api/v1/channels/:id
+	Path    string
+	Summary string
+	Params  []APIParam
+	// Response is a value of the type of the response body
+	Response interface{}
+	Handler  fiber.Handler
+}
+
+// APIV1Operations returns the operations of the versioned API in the order they are to be routed
+func APIV1Operations() []APIOperation {
+	timeParam := func(name, description string) APIParam {
+		return APIParam{Name: name, In: "query", Type: "string", Description: description + " as RFC 3339 or Unix seconds"}
+	}
+	return []APIOperation{
+		{
+			Method:  fiber.MethodGet,
+			Path:    "/api/v1/channels",
+			Summary: "Lists the channels, filtered, sorted and paginated",
+			Params: []APIParam{
+				{Name: "category", In: "query", Type: "string", Description: "Comma separated category IDs"},
+				{Name: "language", In: "query", Type: "string", Description: "Comma separated language IDs"},
+				{Name: "q", In: "query", Type: "string", Description: "Text in the channel name, ignoring case"},
+				{Name: "hd", In: "query", Type: "boolean", Description: "Only HD or only SD channels"},
+				{Name: "catchup", In: "query", Type: "boolean", Description: "Only channels with or without catch-up"},
+				{Name: "sort", In: "query", Type: "string", Description: "id, name, category or language. Defaults to the JioTV order"},
+				{Name: "order", In: "query", Type: "string", Description: "asc or desc. Defaults to asc"},
+				{Name: "page", In: "query", Type: "integer", Description: "Page number, starting at 1"},
+				{Name: "per_page", In: "query", Type: "integer", Description: fmt.Sprintf("Channels per page, at most %d. Defaults to %d", apiMaxPerPage, apiDefaultPerPage)},
+			},
+			Response: APIChannelsResponse{},
+			Handler:  APIChannelsHandler,
+		},
+		{
+			Method:   fiber.MethodGet,
+			Path:     "/api/v1/channels/changes",
+			Summary:  "Lists the changes of the JioTV channel lineup, newest first",
+			Params:   []APIParam{timeParam("since", "Leaves out older changes, given")},
+			Response: ChannelChangesResponse{},
+			Handler:  ChannelChangesHandler,
+		},
+		{
+			Method:   fiber.MethodGet,
+			Path:     "/api/v1/channels/:id",
+			Summary:  "Returns a channel",
+			Params:   []APIParam{{Name: "id", In: "path", Type: "string", Description: "Channel ID"}},
+			Response: APIChannel{},
+			Handler:  APIChannelHandler,
+		},
+		{
+			Method:   fiber.MethodGet,
+			Path:     "/api/v1/categories",
+			Summary:  "Lists the channel categories",
+			Response: APICategoriesResponse{},
+			Handler:  APICategoriesHandler,
+		},
+		{
+			Method:   fiber.MethodGet,
+			Path:     "/api/v1/languages",
+			Summary:  "Lists the channel languages",
+			Response: APILanguagesResponse{},
+			Handler:  APILanguagesHandler,
+		},
+		{
+			Method:   fiber.MethodGet,
+			Path:     "/api/v1/login/status",
+			Summary:  "Tells if the profile is logged in to JioTV",
+			Response: APILoginStatus{},
+			Handler:  APILoginStatusHandler,
+		},
+		{
+			Method:   fiber.MethodGet,
+			Path:     "/api/v1/epg/now-next",
+			Summary:  "Returns the current and the next programme of channels",
+			Params:   []APIParam{{Name: "channels", In: "query", Type: "string", Description: "Comma separated channel IDs. Defaults to all channels"}},
+			Response: APINowNextResponse{},
+			Handler:  APINowNextHandler,
+		},
+		{
+			Method:  fiber.MethodGet,
+			Path:    "/api/v1/epg/:id",
+			Summary: "Lists the programmes of a channel",
+			Params: []APIParam{
+				{Name: "id", In: "path", Type: "string", Description: "Channel ID"},
+				timeParam("from", "Start of the programmes, defaulting to now, given"),
+				timeParam("to", "End of the programmes, defaulting to the end of the guide, given"),
+			},
+			Response: APIChannelProgrammes{},
+			Handler:  APIChannelProgrammesHandler,
+		},
+		{
+			Method:   fiber.MethodGet,
+			Path:     "/api/v1/openapi.json",
+			Summary:  "Returns this OpenAPI document",
+			Response: map[string]interface{}{},
+			Handler:  OpenAPIHandler,
+		},
+	}
+}
+
+// apiChannelsQuery holds the query params of the channels API
+type apiChannelsQuery struct {
+	categories map[int]bool
+	languages  map[int]bool
+	text       string
+	hd         *bool
+	catchup    *bool
+	sort       string
+	descending bool
+	page       int
+	perPage    int
+}
+
+// parseAPIChannelsQuery reads the query params of the channels API
+func parseAPIChannelsQuery(c *fiber.Ctx) (apiChannelsQuery, error) {
+	query := apiChannelsQuery{
+		text:    strings.ToLower(strings.TrimSpace(c.Query("q"))),
+		sort:    c.Query("sort"),
+		page:    1,
+		perPage: apiDefaultPerPage,
+	}
+	var err error
+	if query.categories, err = parseIDSet(c.Query("category")); err != nil {
+		return query, fmt.Errorf("invalid category: %w", err)
+	}
+	if query.languages, err = parseIDSet(c.Query("language")); err != nil {
+		return query, fmt.Errorf("invalid language: %w", err)
+	}
+	if query.hd, err = parseOptionalBool(c.Query("hd")); err != nil {
+		return query, fmt.Errorf("invalid hd: %w", err)
+	}
+	if query.catchup, err = parseOptionalBool(c.Query("catchup")); err != nil {
+		return query, fmt.Errorf("invalid catchup: %w", err)
+	}
+	switch query.sort {
+	case "", "id", "name", "category", "language":
+	default:
+		return query, fmt.Errorf("invalid sort %q", query.sort)
+	}
+	switch c.Query("order") {
+	case "", "asc":
+	case "desc":
+		query.descending = true
+	default:
+		return query, fmt.Errorf("invalid order %q", c.Query("order"))
+	}
+	if value := c.Query("page"); value != "" {
+		if query.page, err = strconv.Atoi(value); err != nil || query.page < 1 {
+			return query, fmt.Errorf("invalid page %q", value)
+		}
+	}
+	if value := c.Query("per_page"); value != "" {
+		if query.perPage, err = strconv.Atoi(value); err != nil || query.perPage < 1 || query.perPage > apiMaxPerPage {
+			return query, fmt.Errorf("invalid per_page %q, it must be between 1 and %d", value, apiMaxPerPage)
+		}
+	}
+	return query, nil
+}
+
+// parseIDSet parses comma separated integer IDs. An empty value returns nil.
+func parseIDSet(value string) (map[int]bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	ids := make(map[int]bool)
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, nil
+}
+
+// parseOptionalBool parses a boolean query param. An empty value returns nil.
+func parseOptionalBool(value string) (*bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// matches checks if a channel passes the filters of the query
+func (query apiChannelsQuery) matches(channel television.Channel) bool {
+	if query.categories != nil && !query.categories[channel.Category] {
+		return false
+	}
+	if query.languages != nil && !query.languages[channel.Language] {
+		return false
+	}
+	if query.hd != nil && channel.IsHD != *query.hd {
+		return false
+	}
+	if query.catchup != nil && channel.IsCatchupAvailable != *query.catchup {
+		return false
+	}
+	return query.text == "" || strings.Contains(strings.ToLower(channel.Name), query.text)
+}
+
+// less orders two channels by the sort field of the query
+func (query apiChannelsQuery) less(a, b television.Channel) bool {
+	switch query.sort {
+	case "id":
+		// JioTV IDs are numbers, so they are compared as numbers before custom channel IDs
+		idA, errA := strconv.Atoi(a.ID)
+		idB, errB := strconv.Atoi(b.ID)
+		if errA == nil && errB == nil {
+			return idA < idB
+		}
+		if (errA == nil) != (errB == nil) {
+			return errA == nil
+		}
+		return a.ID < b.ID
+	case "name":
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	case "category":
+		return television.CategoryMap[a.Category] < television.CategoryMap[b.Category]
+	case "language":
+		return television.LanguageMap[a.Language] < television.LanguageMap[b.Language]
+	}
+	return false
+}
+
+// queryChannels filters, sorts and paginates channels. It returns the page and the number of channels matching the filters.
+func queryChannels(channels []television.Channel, query apiChannelsQuery) ([]television.Channel, int) {
+	matching := make([]television.Channel, 0, len(channels))
+	for _, channel := range channels {
+		if query.matches(channel) {
+			matching = append(matching, channel)
+		}
+	}
+	if query.sort != "" {
+		sort.SliceStable(matching, func(i, j int) bool {
+			if query.descending {
+				return query.less(matching[j], matching[i])
+			}
+			return query.less(matching[i], matching[j])
+		})
+	} else if query.descending {
+		for i, j := 0, len(matching)-1; i < j; i, j = i+1, j-1 {
+			matching[i], matching[j] = matching[j], matching[i]
+		}
+	}
+	start := min((query.page-1)*query.perPage, len(matching))
+	end := min(start+query.perPage, len(matching))
+	return matching[start:end], len(matching)
+}
+
+// toAPIChannel converts a channel to the versioned API, with URLs on hostURL and on the profile of the request
+func toAPIChannel(c *fiber.Ctx, channel television.Channel, hostURL string) APIChannel {
+	logoURL := channel.LogoURL
+	if logoURL != "" && !strings.HasPrefix(logoURL, "http://") && !strings.HasPrefix(logoURL, "https://") {
+		logoURL = hostURL + "/jtvimage/" + logoURL
+	}
+	return APIChannel{
+		ID:        channel.ID,
+		Name:      channel.Name,
+		LogoURL:   logoURL,
+		StreamURL: fmt.Sprintf("%s%s/live/%s.m3u8", hostURL, profilePrefix(c), channel.ID),
+		Category:  APINamedID{ID: channel.Category, Name: television.CategoryMap[channel.Category]},
+		Language:  APINamedID{ID: channel.Language, Name: television.LanguageMap[channel.Language]},
+		HD:        channel.IsHD,
+		Catchup:   channel.IsCatchupAvailable,
+		Custom:    isCustomChannel(channel.ID),
+		Ghost:     channel.Ghost,
+	}
+}
+
+// APIChannelsHandler lists the channels, filtered, sorted and paginated by the query params
+func APIChannelsHandler(c *fiber.Ctx) error {
+	query, err := parseAPIChannelsQuery(c)
+	if err != nil {
+		return internalUtils.BadRequestError(c, err.Error())
+	}
+	channels, err := television.Channels()
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	internalUtils.SetAgeHeader(c, channels.FetchedAt)
+
+	page, total := queryChannels(channels.Result, query)
+	hostURL := strings.ToLower(c.Protocol()) + "://" + c.Hostname()
+	response := APIChannelsResponse{
+		Channels:  make([]APIChannel, 0, len(page)),
+		Total:     total,
+		Page:      query.page,
+		PerPage:   query.perPage,
+		FetchedAt: channels.FetchedAt,
+	}
+	for _, channel := range page {
+		response.Channels = append(response.Channels, toAPIChannel(c, channel, hostURL))
+	}
+	return c.JSON(response)
+}
+
+// APIChannelHandler returns a channel by its ID
+func APIChannelHandler(c *fiber.Ctx) error {
+	id := c.Params("id")
+	channels, err := television.Channels()
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	for _, channel := range channels.Result {
+		if channel.ID == id {
+			hostURL := strings.ToLower(c.Protocol()) + "://" + c.Hostname()
+			return c.JSON(toAPIChannel(c, channel, hostURL))
+		}
+	}
+	return internalUtils.NotFoundError(c, "Channel "+id+" not found")
+}
+
+// APICategoriesHandler lists the channel categories by ID
+func APICategoriesHandler(c *fiber.Ctx) error {
+	response := APICategoriesResponse{Categories: []APINamedID{}}
+	for _, id := range sortedIDs(television.CategoryMap) {
+		response.Categories = append(response.Categories, APINamedID{ID: id, Name: television.CategoryMap[id]})
+	}
+	internalUtils.SetCacheHeader(c, 3600)
+	return c.JSON(response)
+}
+
+// APILanguagesHandler lists the channel languages by ID
+func APILanguagesHandler(c *fiber.Ctx) error {
+	response := APILanguagesResponse{Languages: []APILanguage{}}
+	for _, id := range sortedIDs(television.LanguageMap) {
+		response.Languages = append(response.Languages, APILanguage{ID: id, Name: television.LanguageMap[id], Code: television.LanguageCodeMap[id]})
+	}
+	internalUtils.SetCacheHeader(c, 3600)
+	return c.JSON(response)
+}
+
+// sortedIDs returns the IDs of a category or language map in order, leaving out 0 which stands for all of them
+func sortedIDs(names map[int]string) []int {
+	ids := make([]int, 0, len(names))
+	for id := range names {
+		if id != 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// APILoginStatusHandler tells if the profile of the request is logged in to JioTV
+func APILoginStatusHandler(c *fiber.Ctx) error {
+	profile := requestProfile(c)
+	_, err := utils.GetProfileCredentials(profile)
+	return c.JSON(APILoginStatus{LoggedIn: err == nil, Profile: profile})
+}
+
+// toAPIProgramme converts an EPG programme to the versioned API
+func toAPIProgramme(programme epg.GuideProgramme) APIProgramme {
+	return APIProgramme{
+		ChannelID:   programme.ChannelID,
+		Title:       programme.Title,
+		Description: programme.Description,
+		Category:    programme.Category,
+		Start:       programme.Start,
+		Stop:        programme.Stop,
+		PosterURL:   programme.Poster,
+	}
+}
+
+// APINowNextHandler returns the current and next programme of the channels in the comma separated channels query param.
+// Without channels, all channels are returned.
+func APINowNextHandler(c *fiber.Ctx) error {
+	guide, err := currentGuide(c)
+	if guide == nil {
+		return err
+	}
+	var channelIDs []string
+	for _, id := range strings.Split(c.Query("channels"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			channelIDs = append(channelIDs, guideChannelID(id))
+		}
+	}
+	response := APINowNextResponse{Channels: []APINowNext{}}
+	for _, nowNext := range guide.NowNext(channelIDs, time.Now()) {
+		item := APINowNext{ChannelID: nowNext.ChannelID, ChannelName: nowNext.ChannelName}
+		if nowNext.Now != nil {
+			programme := toAPIProgramme(*nowNext.Now)
+			item.Now = &programme
+		}
+		if nowNext.Next != nil {
+			programme := toAPIProgramme(*nowNext.Next)
+			item.Next = &programme
+		}
+		response.Channels = append(response.Channels, item)
+	}
+	return c.JSON(response)
+}
+
+// APIChannelProgrammesHandler lists the programmes of a channel between the from and to query params.
+// from defaults to now and to defaults to the end of the guide.
+func APIChannelProgrammesHandler(c *fiber.Ctx) error {
+	guide, err := currentGuide(c)
+	if guide == nil {
+		return err
+	}
+	channelID := guideChannelID(c.Params("id"))
+	if !guide.HasChannel(channelID) {
+		return internalUtils.NotFoundError(c, "Channel "+channelID+" not found in EPG")
+	}
+	from, err := parseGuideTime(c.Query("from"), time.Now())
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid from time")
+	}
+	to, err := parseGuideTime(c.Query("to"), time.Time{})
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid to time")
+	}
+	response := APIChannelProgrammes{ChannelID: channelID, ChannelName: guide.ChannelName(channelID), Programmes: []APIProgramme{}}
+	for _, programme := range guide.Between(channelID, from, to) {
+		response.Programmes = append(response.Programmes, toAPIProgramme(programme))
+	}
+	return c.JSON(response)
+}
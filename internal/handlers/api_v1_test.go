@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestQueryChannels(t *testing.T) {
+	channels := []television.Channel{
+		{ID: "144", Name: "Sports HD", Category: 8, Language: 6, IsHD: true},
+		{ID: "143", Name: "News", Category: 12, Language: 1},
+		{ID: "cc_movies", Name: "movies", Category: 6, Language: 1, IsCatchupAvailable: true},
+		{ID: "20", Name: "Kids", Category: 7, Language: 6},
+	}
+	yes := true
+	tests := []struct {
+		name      string
+		query     apiChannelsQuery
+		wantIDs   string
+		wantTotal int
+	}{
+		{name: "JioTV order", query: apiChannelsQuery{page: 1, perPage: 10}, wantIDs: "144,143,cc_movies,20", wantTotal: 4},
+		{name: "Reversed", query: apiChannelsQuery{descending: true, page: 1, perPage: 10}, wantIDs: "20,cc_movies,143,144", wantTotal: 4},
+		{name: "By ID", query: apiChannelsQuery{sort: "id", page: 1, perPage: 10}, wantIDs: "20,143,144,cc_movies", wantTotal: 4},
+		{name: "By name descending", query: apiChannelsQuery{sort: "name", descending: true, page: 1, perPage: 10}, wantIDs: "144,143,cc_movies,20", wantTotal: 4},
+		{name: "By language", query: apiChannelsQuery{sort: "language", page: 1, perPage: 10}, wantIDs: "144,20,143,cc_movies", wantTotal: 4},
+		{name: "Language filter", query: apiChannelsQuery{languages: map[int]bool{1: true}, page: 1, perPage: 10}, wantIDs: "143,cc_movies", wantTotal: 2},
+		{name: "Category and HD filters", query: apiChannelsQuery{categories: map[int]bool{8: true, 12: true}, hd: &yes, page: 1, perPage: 10}, wantIDs: "144", wantTotal: 1},
+		{name: "Catch-up filter", query: apiChannelsQuery{catchup: &yes, page: 1, perPage: 10}, wantIDs: "cc_movies", wantTotal: 1},
+		{name: "Name search", query: apiChannelsQuery{text: "hd", page: 1, perPage: 10}, wantIDs: "144", wantTotal: 1},
+		{name: "Second page", query: apiChannelsQuery{sort: "id", page: 2, perPage: 3}, wantIDs: "cc_movies", wantTotal: 4},
+		{name: "Page after the end", query: apiChannelsQuery{page: 3, perPage: 3}, wantIDs: "", wantTotal: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, total := queryChannels(append([]television.Channel(nil), channels...), tt.query)
+			ids := make([]string, 0, len(page))
+			for _, channel := range page {
+				ids = append(ids, channel.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.wantIDs || total != tt.wantTotal {
+				t.Errorf("queryChannels() = %s of %d, want %s of %d", got, total, tt.wantIDs, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestAPIV1(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+
+	app := fiber.New()
+	for _, operation := range APIV1Operations() {
+		app.Add(operation.Method, operation.Path, operation.Handler)
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "Categories", target: "/api/v1/categories", wantStatus: fiber.StatusOK, wantBody: `{"id":5,"name":"Entertainment"}`},
+		{name: "Languages", target: "/api/v1/languages", wantStatus: fiber.StatusOK, wantBody: `{"id":1,"name":"Hindi","code":"hi"}`},
+		{name: "Login status", target: "/api/v1/login/status", wantStatus: fiber.StatusOK, wantBody: `"logged_in":false`},
+		{name: "Invalid sort", target: "/api/v1/channels?sort=rating", wantStatus: fiber.StatusBadRequest, wantBody: "invalid sort"},
+		{name: "Invalid page size", target: "/api/v1/channels?per_page=0", wantStatus: fiber.StatusBadRequest, wantBody: "invalid per_page"},
+		{name: "Invalid category", target: "/api/v1/channels?category=news", wantStatus: fiber.StatusBadRequest, wantBody: "invalid category"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.target, nil), -1)
+			if err != nil {
+				t.Fatalf("GET %s error = %v", tt.target, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("GET %s = %d, want %d", tt.target, resp.StatusCode, tt.wantStatus)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("GET %s body = %s, want it to contain %s", tt.target, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	app := fiber.New()
+	app.Get("/api/v1/openapi.json", OpenAPIHandler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/v1/openapi.json", nil), -1)
+	if err != nil {
+		t.Fatalf("GET /api/v1/openapi.json error = %v", err)
+	}
+	var document struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage        `json:"paths"`
+		Components map[string]map[string]map[string]interface{} `json:"components"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		t.Fatalf("decoding the document error = %v", err)
+	}
+	if !strings.HasPrefix(document.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want version 3", document.OpenAPI)
+	}
+	for _, operation := range APIV1Operations() {
+		path := openAPIPathParam.ReplaceAllString(operation.Path, "{$1}")
+		if _, ok := document.Paths[path][strings.ToLower(operation.Method)]; !ok {
+			t.Errorf("document has no %s %s", operation.Method, path)
+		}
+	}
+
+	schemas := document.Components["schemas"]
+	channel, ok := schemas["APIChannel"]
+	if !ok {
+		t.Fatalf("document has no APIChannel schema, got %v", schemas)
+	}
+	properties, _ := channel["properties"].(map[string]interface{})
+	if category, _ := properties["category"].(map[string]interface{}); category["$ref"] != "#/components/schemas/APINamedID" {
+		t.Errorf("APIChannel category = %v, want a reference to APINamedID", properties["category"])
+	}
+	if _, ok := schemas["LineupChange"]; !ok {
+		t.Error("document has no LineupChange schema of the channel changes API")
+	}
+}
@@ -11,16 +11,23 @@ import (
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
 	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/metrics"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 	"github.com/valyala/fasthttp"
 )
 
 var (
-	// tokenRefreshMutex prevents concurrent token refreshes
-	tokenRefreshMutex sync.Mutex
+	// tokenRefreshMutexes prevent concurrent token refreshes of the same profile
+	tokenRefreshMutexes sync.Map
 )
 
+// profileRefreshMutex returns the token refresh mutex of a profile
+func profileRefreshMutex(profile string) *sync.Mutex {
+	mutex, _ := tokenRefreshMutexes.LoadOrStore(profile, &sync.Mutex{})
+	return mutex.(*sync.Mutex)
+}
+
 // IsAccessTokenExpired checks if the AccessToken needs refreshing
 // Returns true if the token is expired or will expire within the next 10 minutes
 func IsAccessTokenExpired(credentials *utils.JIOTV_CREDENTIALS) bool {
@@ -61,13 +68,20 @@ func IsSSOTokenExpired(credentials *utils.JIOTV_CREDENTIALS) bool {
 	return thresholdTime.Before(time.Now())
 }
 
-// EnsureFreshTokens checks and refreshes tokens if needed
+// EnsureFreshTokens checks and refreshes tokens of the default profile if needed
 // This is the main function that should be called before making API requests
 func EnsureFreshTokens() error {
-	tokenRefreshMutex.Lock()
-	defer tokenRefreshMutex.Unlock()
+	return EnsureFreshProfileTokens(utils.GetDefaultProfile())
+}
 
-	credentials, err := utils.GetJIOTVCredentials()
+// EnsureFreshProfileTokens checks and refreshes tokens of a profile if needed.
+// Each profile is refreshed independently of the others.
+func EnsureFreshProfileTokens(profile string) error {
+	mutex := profileRefreshMutex(profile)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	credentials, err := utils.GetProfileCredentials(profile)
 	if err != nil {
 		return fmt.Errorf("failed to get credentials: %v", err)
 	}
@@ -78,11 +92,13 @@ func EnsureFreshTokens() error {
 	if credentials.AccessToken != "" && credentials.RefreshToken != "" {
 		if IsAccessTokenExpired(credentials) {
 			utils.Log.Println("AccessToken is expired, refreshing...")
-			err := LoginRefreshAccessToken()
+			err := LoginRefreshProfileAccessToken(profile)
 			if err != nil {
+				metrics.TokenRefreshes.Inc("access", "failure")
 				utils.Log.Printf("AccessToken refresh failed: %v", err)
 				return err
 			}
+			metrics.TokenRefreshes.Inc("access", "success")
 			refreshed = true
 		}
 	}
@@ -91,22 +107,24 @@ func EnsureFreshTokens() error {
 	if credentials.SSOToken != "" && credentials.UniqueID != "" {
 		if IsSSOTokenExpired(credentials) {
 			utils.Log.Println("SSOToken is expired, refreshing...")
-			err := LoginRefreshSSOToken()
+			err := LoginRefreshProfileSSOToken(profile)
 			if err != nil {
+				metrics.TokenRefreshes.Inc("sso", "failure")
 				utils.Log.Printf("SSOToken refresh failed: %v", err)
 				return err
 			}
+			metrics.TokenRefreshes.Inc("sso", "success")
 			refreshed = true
 		}
 	}
 
 	if refreshed {
 		// Update the TV object with fresh credentials
-		freshCreds, err := utils.GetJIOTVCredentials()
+		freshCreds, err := utils.GetProfileCredentials(profile)
 		if err != nil {
 			return fmt.Errorf("failed to get fresh credentials: %v", err)
 		}
-		TV = television.New(freshCreds)
+		setProfileTV(profile, television.New(freshCreds))
 	}
 
 	return nil
@@ -154,7 +172,13 @@ func LoginVerifyOTPHandler(c *fiber.Ctx) error {
 		return err
 	}
 
-	result, err := utils.LoginVerifyOTP(mobileNumber, otp)
+	profile := formBody.Profile
+	if profile != "" {
+		if err := utils.ValidateProfileName(profile); err != nil {
+			return internalUtils.BadRequestError(c, err.Error())
+		}
+	}
+	result, err := utils.LoginVerifyOTPProfile(profile, mobileNumber, otp)
 	if err != nil {
 		utils.Log.Println(err)
 		return internalUtils.InternalServerError(c, "Internal server error")
@@ -163,10 +187,10 @@ func LoginVerifyOTPHandler(c *fiber.Ctx) error {
 	return c.JSON(result)
 }
 
-// LogoutHandler is used to logout
+// LogoutHandler is used to logout the profile selected by the request
 func LogoutHandler(c *fiber.Ctx) error {
 	if !isLogoutDisabled {
-		err := utils.Logout()
+		err := utils.LogoutProfile(requestProfile(c))
 		if err != nil {
 			utils.Log.Println(err)
 			return internalUtils.InternalServerError(c, "Internal server error")
@@ -176,10 +200,15 @@ func LogoutHandler(c *fiber.Ctx) error {
 	return c.Redirect("/", fiber.StatusFound)
 }
 
-// LoginRefreshAccessToken Function is used to refresh AccessToken
+// LoginRefreshAccessToken Function is used to refresh AccessToken of the default profile
 func LoginRefreshAccessToken() error {
+	return LoginRefreshProfileAccessToken(utils.GetDefaultProfile())
+}
+
+// LoginRefreshProfileAccessToken Function is used to refresh AccessToken of a profile
+func LoginRefreshProfileAccessToken(profile string) error {
 	utils.Log.Println("Refreshing AccessToken...")
-	tokenData, err := utils.GetJIOTVCredentials()
+	tokenData, err := utils.GetProfileCredentials(profile)
 	if err != nil {
 		utils.Log.Printf("Error getting credentials for AccessToken refresh: %v", err)
 		return err
@@ -248,12 +277,12 @@ func LoginRefreshAccessToken() error {
 	if response.AccessToken != "" {
 		tokenData.AccessToken = response.AccessToken
 		tokenData.LastTokenRefreshTime = strconv.FormatInt(time.Now().Unix(), 10)
-		err := utils.WriteJIOTVCredentials(tokenData)
+		err := utils.WriteProfileCredentials(profile, tokenData)
 		if err != nil {
 			utils.Log.Printf("Error saving refreshed credentials: %v", err)
 			return err
 		}
-		TV = television.New(tokenData)
+		setProfileTV(profile, television.New(tokenData))
 		utils.Log.Println("AccessToken refreshed successfully")
 		return nil
 	} else {
@@ -263,10 +292,15 @@ func LoginRefreshAccessToken() error {
 	}
 }
 
-// LoginRefreshSSOToken Function is used to refresh SSOToken
+// LoginRefreshSSOToken Function is used to refresh SSOToken of the default profile
 func LoginRefreshSSOToken() error {
+	return LoginRefreshProfileSSOToken(utils.GetDefaultProfile())
+}
+
+// LoginRefreshProfileSSOToken Function is used to refresh SSOToken of a profile
+func LoginRefreshProfileSSOToken(profile string) error {
 	utils.Log.Println("Refreshing SsoToken...")
-	tokenData, err := utils.GetJIOTVCredentials()
+	tokenData, err := utils.GetProfileCredentials(profile)
 	if err != nil {
 		utils.Log.Printf("Error getting credentials for SSOToken refresh: %v", err)
 		return err
@@ -334,12 +368,12 @@ func LoginRefreshSSOToken() error {
 	if response.SSOToken != "" {
 		tokenData.SSOToken = response.SSOToken
 		tokenData.LastSSOTokenRefreshTime = strconv.FormatInt(time.Now().Unix(), 10)
-		err := utils.WriteJIOTVCredentials(tokenData)
+		err := utils.WriteProfileCredentials(profile, tokenData)
 		if err != nil {
 			utils.Log.Printf("Error saving refreshed SSOToken credentials: %v", err)
 			return err
 		}
-		TV = television.New(tokenData)
+		setProfileTV(profile, television.New(tokenData))
 		utils.Log.Println("SSOToken refreshed successfully")
 		return nil
 	} else {
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// catchupMaxAge is how far back JioTV keeps the archive of a channel
+const catchupMaxAge = constants.MaxEPGDays * 24 * time.Hour
+
+// CatchupHandler handles the catch-up route `/catchup/:channelID?start=&end=`.
+// It plays the part of a channel which aired between start and end through the /render.m3u8 pipeline.
+func CatchupHandler(c *fiber.Ctx) error {
+	id := strings.TrimSuffix(c.Params("channelID"), ".m3u8")
+	if isCustomChannel(id) {
+		return internalUtils.BadRequestError(c, television.ErrCatchupUnavailable.Error())
+	}
+
+	start, end, err := catchupWindow(c.Query("start"), c.Query("end"), epg.CurrentGuide(), guideChannelID(id), time.Now())
+	if err != nil {
+		return internalUtils.BadRequestError(c, err.Error())
+	}
+
+	// Ensure tokens are fresh before making API call
+	if err := EnsureFreshProfileTokens(requestProfile(c)); err != nil {
+		utils.Log.Printf("Failed to ensure fresh tokens: %v", err)
+		// Continue with the request - tokens might still work
+	}
+
+	result, err := tvFor(c).Catchup(id, start, end)
+	var playbackErr *television.PlaybackError
+	if errors.As(err, &playbackErr) && playbackErr.Unauthorized() {
+		// The tokens are no longer accepted, refresh them and retry once
+		if err := EnsureFreshProfileTokens(requestProfile(c)); err != nil {
+			utils.Log.Printf("Failed to refresh tokens after %d: %v", playbackErr.StatusCode, err)
+			return internalUtils.ForbiddenError(c, "Access forbidden. Something went wrong!")
+		}
+		result, err = tvFor(c).Catchup(id, start, end)
+		if errors.As(err, &playbackErr) && playbackErr.Unauthorized() {
+			utils.Log.Println(err)
+			return internalUtils.ForbiddenError(c, "Access forbidden. Something went wrong!")
+		}
+	}
+	if errors.Is(err, television.ErrCatchupUnavailable) {
+		return internalUtils.BadRequestError(c, err.Error())
+	}
+	if errors.As(err, &playbackErr) {
+		utils.Log.Println(err)
+		return internalUtils.ErrorResponse(c, fiber.StatusBadGateway, err.Error())
+	}
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err)
+	}
+
+	quality := c.Query("q", "auto")
+	streamURL := internalUtils.SelectQuality(quality, result.Bitrates.Auto, result.Bitrates.High, result.Bitrates.Medium, result.Bitrates.Low)
+	if streamURL == "" {
+		error_message := "No catch-up stream found for channel id: " + id + " Status: " + result.Message
+		utils.Log.Println(error_message)
+		return internalUtils.NotFoundError(c, error_message)
+	}
+	return renderRedirect(c, streamURL, result.Hdnea, id, quality)
+}
+
+// catchupAttributes returns the M3U attributes with which players request the catch-up of a channel.
+// Players replace {utc} and {utcend} with the start and end of the programme as Unix seconds.
+func catchupAttributes(channel television.Channel, streamURL, quality string) string {
+	if !channel.IsCatchupAvailable || strings.HasPrefix(channel.ID, "sl") {
+		return ""
+	}
+	source := fmt.Sprintf("%s/catchup/%s?start={utc}&end={utcend}", streamURL, channel.ID)
+	if quality != "" {
+		source += "&q=" + url.QueryEscape(quality)
+	}
+	return fmt.Sprintf(" catchup=%q catchup-days=%q catchup-source=%q", "default", strconv.Itoa(constants.MaxEPGDays), source)
+}
+
+// catchupWindow parses the start and end query params of a catch-up request, given as Unix seconds or RFC 3339.
+// Without end, the window ends with the programme of the guide airing at start.
+// An end in the future is moved to now.
+func catchupWindow(startValue, endValue string, guide *epg.Guide, channelID string, now time.Time) (time.Time, time.Time, error) {
+	if startValue == "" {
+		return time.Time{}, time.Time{}, errors.New("start not provided")
+	}
+	start, err := parseGuideTime(startValue, time.Time{})
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid start")
+	}
+	end, err := parseGuideTime(endValue, time.Time{})
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid end")
+	}
+	if end.IsZero() && guide != nil {
+		if programmes := guide.Between(channelID, start, start.Add(time.Second)); len(programmes) > 0 {
+			end = programmes[0].Stop
+		}
+	}
+	if end.IsZero() {
+		return time.Time{}, time.Time{}, errors.New("end not provided and no programme of the guide airs at start")
+	}
+
+	if !start.Before(now) {
+		return time.Time{}, time.Time{}, errors.New("start must be in the past")
+	}
+	if start.Before(now.Add(-catchupMaxAge)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("start must be within the last %d days", constants.MaxEPGDays)
+	}
+	if end.After(now) {
+		end = now
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, errors.New("end must be after start")
+	}
+	return start, end, nil
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"crypto/tls"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/valyala/fasthttp"
+)
+
+func TestCatchupWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC)
+	guide := epg.NewGuide([]epg.Channel{{ID: 143, Display: "News"}}, []epg.Programme{{
+		Channel: "143",
+		Start:   "20240101100000 +0000",
+		Stop:    "20240101110000 +0000",
+		Title:   epg.Title{Value: "Morning"},
+	}})
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		start     string
+		end       string
+		guide     *epg.Guide
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   string
+	}{
+		{name: "Unix seconds", start: "1704103200", end: "1704106800", wantStart: at(10, 0), wantEnd: at(11, 0)},
+		{name: "RFC 3339", start: "2024-01-01T15:30:00+05:30", end: "2024-01-01T16:00:00+05:30", wantStart: at(10, 0), wantEnd: at(10, 30)},
+		{name: "End from the guide", start: "2024-01-01T10:15:00Z", guide: guide, wantStart: at(10, 15), wantEnd: at(11, 0)},
+		{name: "End in the future is now", start: "2024-01-01T12:00:00Z", end: "2024-01-01T13:00:00Z", wantStart: at(12, 0), wantEnd: now},
+		{name: "Missing start", end: "1704106800", wantErr: "start not provided"},
+		{name: "Invalid start", start: "yesterday", end: "1704106800", wantErr: "invalid start"},
+		{name: "Invalid end", start: "1704103200", end: "later", wantErr: "invalid end"},
+		{name: "No end outside the guide", start: "2024-01-01T12:00:00Z", guide: guide, wantErr: "end not provided"},
+		{name: "Start in the future", start: "2024-01-01T13:00:00Z", end: "2024-01-01T14:00:00Z", wantErr: "start must be in the past"},
+		{name: "Start too old", start: "2023-12-20T10:00:00Z", end: "2023-12-20T11:00:00Z", wantErr: "within the last 7 days"},
+		{name: "End before start", start: "2024-01-01T11:00:00Z", end: "2024-01-01T10:00:00Z", wantErr: "end must be after start"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := catchupWindow(tt.start, tt.end, tt.guide, "143", now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("catchupWindow() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("catchupWindow() error = %v", err)
+			}
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("catchupWindow() = %v - %v, want %v - %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestCatchupAttributes(t *testing.T) {
+	channel := television.Channel{ID: "143", IsCatchupAvailable: true}
+	got := catchupAttributes(channel, "http://localhost:5001", "high")
+	want := ` catchup="default" catchup-days="7" catchup-source="http://localhost:5001/catchup/143?start={utc}&end={utcend}&q=high"`
+	if got != want {
+		t.Errorf("catchupAttributes() = %s, want %s", got, want)
+	}
+
+	if got := catchupAttributes(television.Channel{ID: "144"}, "http://localhost:5001", ""); got != "" {
+		t.Errorf("catchupAttributes() of a channel without catch-up = %q, want none", got)
+	}
+	if got := catchupAttributes(television.Channel{ID: "sl291", IsCatchupAvailable: true}, "http://localhost:5001", ""); got != "" {
+		t.Errorf("catchupAttributes() of a Sony channel = %q, want none", got)
+	}
+}
+
+func TestCatchupHandler_PlaybackErrors(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(os.Stdout, "", log.LstdFlags)
+	}
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+
+	originalTV := TV
+	t.Cleanup(func() { TV = originalTV })
+
+	app := fiber.New()
+	app.Get("/catchup/:channelID", CatchupHandler)
+	start := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	end := strconv.FormatInt(time.Now().Add(-30*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus int
+	}{
+		{name: "Window refused", status: http.StatusBadRequest, wantStatus: fiber.StatusBadRequest},
+		{name: "Expired tokens which can't be refreshed", status: 419, wantStatus: fiber.StatusForbidden},
+		{name: "Unauthorized", status: http.StatusUnauthorized, wantStatus: fiber.StatusForbidden},
+		{name: "Server error", status: http.StatusServiceUnavailable, wantStatus: fiber.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"message": "error"}`, tt.status)
+			}))
+			defer server.Close()
+			// The playback API is reached on the test server
+			TV = &television.Television{Client: &fasthttp.Client{
+				TLSConfig: &tls.Config{InsecureSkipVerify: true},
+				Dial: func(addr string) (net.Conn, error) {
+					return net.Dial("tcp", server.Listener.Addr().String())
+				},
+			}}
+
+			path := "/catchup/143?start=" + start + "&end=" + end
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+			if err != nil {
+				t.Fatalf("GET %s error = %v", path, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("GET %s = %d, want %d", path, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// customChannelRedirect redirects to the stream of a custom channel.
+// Channels in proxy mode are sent through the render pipeline so their headers are applied upstream,
+// others are redirected straight to their URL.
+func customChannelRedirect(c *fiber.Ctx, channel television.Channel) error {
+	if !channel.Proxy {
+		return c.Redirect(channel.URL, fiber.StatusFound)
+	}
+	coded_url, err := secureurl.EncryptURL(channel.URL)
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.ForbiddenError(c, err)
+	}
+	return c.Redirect(profilePrefix(c)+"/render.m3u8?auth="+coded_url+"&channel_key_id="+channel.ID+
+		"&channel_sig="+secureurl.SignChannel(channel.ID, channel.URL), fiber.StatusFound)
+}
+
+// proxiedCustomChannel returns the custom channel with the given ID if it is in proxy mode
+func proxiedCustomChannel(channelID string) (television.Channel, bool) {
+	if !isCustomChannel(channelID) {
+		return television.Channel{}, false
+	}
+	channel, exists := television.GetCustomChannelByID(channelID)
+	if !exists || !channel.Proxy {
+		return television.Channel{}, false
+	}
+	return channel, true
+}
+
+// renderCustomChannel fetches a custom channel playlist with the channel's headers
+// and rewrites its URIs to our own server URLs
+func renderCustomChannel(c *fiber.Ctx, channel television.Channel, playlistURL string) error {
+	renderResult, statusCode, finalURL, err := renderCustomPlaylist(playlistURL, channel)
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.ErrorResponse(c, fiber.StatusBadGateway, err.Error())
+	}
+	if statusCode != fiber.StatusOK {
+		utils.Log.Printf("Error rendering M3U8 file of custom channel %s: status code %d", channel.ID, statusCode)
+		return c.Status(statusCode).Send(renderResult)
+	}
+
+	rewriter := television.PlaylistRewriter{
+		PlaylistURL: finalURL,
+		ChannelID:   channel.ID,
+		Custom:      true,
+		Prefix:      profilePrefix(c),
+	}
+	renderResult, err = rewriter.Rewrite(renderResult)
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.ErrorResponse(c, fiber.StatusBadGateway, err.Error())
+	}
+	internalUtils.SetMustRevalidateHeader(c, 3)
+	c.Set(fiber.HeaderContentType, "application/vnd.apple.mpegurl")
+	return c.Send(renderResult)
+}
+
+// customChannelClientHeaders are the headers of the client forwarded to the host of a custom channel.
+// The others, like cookies and credentials, are meant for this server, not for a third party.
+var customChannelClientHeaders = map[string]bool{
+	fiber.HeaderHost:    true,
+	fiber.HeaderRange:   true,
+	fiber.HeaderIfRange: true,
+}
+
+// proxyCustomChannel proxies a segment or key request of a custom channel with the channel's headers
+func proxyCustomChannel(c *fiber.Ctx, channel television.Channel, url string, ttl time.Duration, endpoint string) error {
+	header := &c.Request().Header
+	var dropped []string
+	for key := range header.All() {
+		if name := string(key); !customChannelClientHeaders[http.CanonicalHeaderKey(name)] {
+			dropped = append(dropped, name)
+		}
+	}
+	for _, name := range dropped {
+		header.Del(name)
+	}
+	internalUtils.SetPlayerHeaders(c, PLAYER_USER_AGENT)
+	for key, value := range channel.Headers {
+		c.Request().Header.Set(key, value)
+	}
+	return proxyCached(c, url, customChannelCacheKey(channel, url), ttl, "", endpoint)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/cache"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/valyala/fasthttp"
+)
+
+// TestCustomChannelProxy streams a proxied custom channel through live, render and segment routes
+// and checks that the channel's headers reach the stream host
+func TestCustomChannelProxy(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(os.Stdout, "", log.LstdFlags)
+	}
+	secureurl.Init()
+
+	upstream := http.NewServeMux()
+	checkHeaders := func(w http.ResponseWriter, r *http.Request) bool {
+		if r.UserAgent() != "TestAgent/1.0" || r.Referer() != "https://example.com/" {
+			w.WriteHeader(http.StatusForbidden)
+			return false
+		}
+		// Headers of the client are not for the stream host
+		if r.Header.Get("Authorization") != "" || r.Header.Get("X-Client") != "" || r.Header.Get("Cookie") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return false
+		}
+		return true
+	}
+	upstream.HandleFunc("/live/index.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		if checkHeaders(w, r) {
+			w.Write([]byte("#EXTM3U\n#EXTINF:6.0,\nseg-1.ts\n"))
+		}
+	})
+	upstream.HandleFunc("/live/seg-1.ts", func(w http.ResponseWriter, r *http.Request) {
+		if checkHeaders(w, r) {
+			w.Write([]byte("segment"))
+		}
+	})
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	customChannelsFile := filepath.Join(t.TempDir(), "channels.yml")
+	content := "channels:\n" +
+		"  - id: proxied\n    name: Proxied\n    url: " + server.URL + "/live/index.m3u8\n" +
+		"    user_agent: TestAgent/1.0\n    referer: https://example.com/\n" +
+		"  - id: direct\n    name: Direct\n    url: " + server.URL + "/live/index.m3u8\n" +
+		"  - id: other\n    name: Other\n    url: " + server.URL + "/live/index.m3u8\n" +
+		"    user_agent: Other/1.0\n"
+	if err := os.WriteFile(customChannelsFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write custom channels file: %v", err)
+	}
+
+	originalCustomChannelsFile := config.Cfg.CustomChannelsFile
+	originalTV := TV
+	originalCache := upstreamCache
+	t.Cleanup(func() {
+		config.Cfg.CustomChannelsFile = originalCustomChannelsFile
+		TV = originalTV
+		upstreamCache = originalCache
+		television.InitCustomChannels()
+	})
+	config.Cfg.CustomChannelsFile = customChannelsFile
+	television.InitCustomChannels()
+	TV = &television.Television{Client: &fasthttp.Client{}}
+	upstreamCache = cache.New(1024 * 1024)
+
+	app := fiber.New()
+	app.Get("/live/:id", LiveHandler)
+	app.Get("/render.m3u8", RenderHandler)
+	app.Get("/render.ts", RenderTSHandler)
+
+	get := func(target string) *http.Response {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", target, nil), -1)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", target, err)
+		}
+		return resp
+	}
+
+	t.Run("Direct channel redirects to its URL", func(t *testing.T) {
+		resp := get("/live/cc_direct.m3u8")
+		if location := resp.Header.Get("Location"); location != server.URL+"/live/index.m3u8" {
+			t.Errorf("Location = %q, want stream URL", location)
+		}
+	})
+
+	t.Run("Proxied channel is rendered with its headers", func(t *testing.T) {
+		resp := get("/live/cc_proxied.m3u8")
+		location := resp.Header.Get("Location")
+		if !strings.HasPrefix(location, "/render.m3u8?") || strings.Contains(location, server.URL) {
+			t.Fatalf("Location = %q, want render URL hiding the stream host", location)
+		}
+
+		resp = get(location)
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("render status = %d, body = %s", resp.StatusCode, body)
+		}
+		var segmentURL string
+		for _, line := range strings.Split(string(body), "\n") {
+			if strings.HasPrefix(line, "/render.ts?") {
+				segmentURL = line
+			}
+		}
+		if segmentURL == "" {
+			t.Fatalf("rendered playlist has no proxied segment: %s", body)
+		}
+		parsed, _ := url.Parse(segmentURL)
+		if parsed.Query().Get("channel_key_id") != "cc_proxied" {
+			t.Errorf("segment URL %q is missing channel_key_id", segmentURL)
+		}
+
+		req := httptest.NewRequest("GET", segmentURL, nil)
+		req.Header.Set("Authorization", "Basic dXNlcjpzZWNyZXQ=")
+		req.Header.Set("X-Client", "player")
+		req.Header.Set("Cookie", "session=1")
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", segmentURL, err)
+		}
+		body, _ = io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusOK || string(body) != "segment" {
+			t.Errorf("segment status = %d, body = %q", resp.StatusCode, body)
+		}
+	})
+	t.Run("Channels sharing a URL are cached apart", func(t *testing.T) {
+		// cc_proxied was rendered above, cc_other has the same URL with headers the stream host refuses
+		resp := get(get("/live/cc_other.m3u8").Header.Get("Location"))
+		if resp.StatusCode != fiber.StatusForbidden {
+			t.Errorf("render status of cc_other = %d, want %d from its own request", resp.StatusCode, fiber.StatusForbidden)
+		}
+	})
+}
@@ -11,16 +11,19 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/proxy"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
 	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 	"github.com/valyala/fasthttp"
 )
 
-// getDrmMpd returns required properties for rendering DRM MPD
-func getDrmMpd(channelID, quality string) (*DrmMpdOutput, error) {
+// getDrmMpd returns required properties for rendering DRM MPD.
+// The server URLs are prefixed with prefix, to keep them on the profile of tv.
+func getDrmMpd(tv *television.Television, channelID, quality, prefix string) (*DrmMpdOutput, error) {
 	// Get live stream URL from JioTV API
-	liveResult, err := TV.Live(channelID)
+	liveResult, err := tv.LiveCached(channelID)
 	if err != nil {
 		return nil, err
 	}
@@ -47,12 +50,15 @@ func getDrmMpd(channelID, quality string) (*DrmMpdOutput, error) {
 		return nil, err
 	}
 
+	licenseURL := prefix + "/drm?auth=" + enc_key + "&channel_id=" + channelID + "&channel=" + channel_enc_url +
+		"&channel_sig=" + secureurl.SignChannel(channelID, liveResult.Mpd.Key, tv_url)
+
 	// Quick fix for timesplay channels.
 	if liveResult.AlgoName == "timesplay" {
 		return &DrmMpdOutput{
 			IsDRM:       liveResult.IsDRM,
 			PlayUrl:     tv_url,
-			LicenseUrl:  "/drm?auth=" + enc_key + "&channel_id=" + channelID + "&channel=" + channel_enc_url,
+			LicenseUrl:  licenseURL,
 			Tv_url_host: "",
 			Tv_url_path: "",
 		}, nil
@@ -64,7 +70,8 @@ func getDrmMpd(channelID, quality string) (*DrmMpdOutput, error) {
 		return nil, err
 	}
 	tv_url_split := strings.Split(parsedTvUrl.Path, "/")
-	tv_url_path, err := secureurl.EncryptURL(strings.Join(tv_url_split[:len(tv_url_split)-1], "/") + "/")
+	tv_url_dir := strings.Join(tv_url_split[:len(tv_url_split)-1], "/") + "/"
+	tv_url_path, err := secureurl.EncryptURL(tv_url_dir)
 	if err != nil {
 		utils.Log.Panicln(err)
 		return nil, err
@@ -78,10 +85,11 @@ func getDrmMpd(channelID, quality string) (*DrmMpdOutput, error) {
 
 	return &DrmMpdOutput{
 		IsDRM:       liveResult.IsDRM,
-		PlayUrl:     "/render.mpd?auth=" + channel_enc_url,
-		LicenseUrl:  "/drm?auth=" + enc_key + "&channel_id=" + channelID + "&channel=" + channel_enc_url,
+		PlayUrl:     prefix + "/render.mpd?auth=" + channel_enc_url + "&channel_key_id=" + channelID + "&channel_sig=" + secureurl.SignChannel(channelID, tv_url),
+		LicenseUrl:  licenseURL,
 		Tv_url_host: tv_url_host,
 		Tv_url_path: tv_url_path,
+		Channel_sig: secureurl.SignChannel(channelID, parsedTvUrl.Host, tv_url_dir),
 	}, nil
 }
 
@@ -91,13 +99,17 @@ func LiveMpdHandler(c *fiber.Ctx) error {
 	channelID := c.Params("channelID")
 	quality := c.Query("q")
 
-	drmMpdOutput, err := getDrmMpd(channelID, quality)
+	if err := EnsureFreshProfileTokens(requestProfile(c)); err != nil {
+		utils.Log.Printf("Failed to ensure fresh tokens: %v", err)
+	}
+
+	drmMpdOutput, err := getDrmMpd(tvFor(c), channelID, quality, profilePrefix(c))
 	if err != nil {
 		utils.Log.Panicln(err)
 		return internalUtils.InternalServerError(c, err)
 	}
 	if !drmMpdOutput.IsDRM {
-		play_url := utils.BuildHLSPlayURL(quality, channelID)
+		play_url := profilePrefix(c) + utils.BuildHLSPlayURL(quality, channelID)
 		internalUtils.SetCacheHeader(c, 3600)
 		return c.Render("views/player_hls", fiber.Map{
 			"play_url": play_url,
@@ -109,6 +121,8 @@ func LiveMpdHandler(c *fiber.Ctx) error {
 		"license_url":  drmMpdOutput.LicenseUrl,
 		"channel_host": drmMpdOutput.Tv_url_host,
 		"channel_path": drmMpdOutput.Tv_url_path,
+		"channel_id":   channelID,
+		"channel_sig":  drmMpdOutput.Channel_sig,
 	})
 }
 
@@ -163,18 +177,19 @@ func DRMKeyHandler(c *fiber.Ctx) error {
 	}
 
 	// Add headers to the request
-	c.Request().Header.Set("accesstoken", TV.AccessToken)
+	tv := tvFor(c)
+	c.Request().Header.Set("accesstoken", tv.AccessToken)
 	c.Request().Header.Set("Connection", "keep-alive")
 	c.Request().Header.Set("os", "android")
 	c.Request().Header.Set("appName", "RJIL_JioTV")
-	c.Request().Header.Set("subscriberId", TV.Crm)
+	c.Request().Header.Set("subscriberId", tv.Crm)
 	c.Request().Header.Set("User-Agent", PLAYER_USER_AGENT)
-	c.Request().Header.Set("ssotoken", TV.SsoToken)
+	c.Request().Header.Set("ssotoken", tv.SsoToken)
 	c.Request().Header.Set("x-platform", "android")
 	c.Request().Header.Set("srno", generateDateTime())
-	c.Request().Header.Set("crmid", TV.Crm)
+	c.Request().Header.Set("crmid", tv.Crm)
 	c.Request().Header.Set("channelid", channel_id)
-	c.Request().Header.Set("uniqueId", TV.UniqueID)
+	c.Request().Header.Set("uniqueId", tv.UniqueID)
 	c.Request().Header.Set("versionCode", headers.VersionCode389)
 	c.Request().Header.Set("usergroup", "tvYR7NSNn7rymo3F")
 	c.Request().Header.Set("devicetype", "phone")
@@ -187,7 +202,7 @@ func DRMKeyHandler(c *fiber.Ctx) error {
 	c.Request().Header.Del("Accept")
 	c.Request().Header.Del("Origin")
 
-	if err := proxy.Do(c, decoded_url, TV.Client); err != nil {
+	if err := proxy.Do(c, decoded_url, tv.Client); err != nil {
 		return err
 	}
 
@@ -227,10 +242,12 @@ func MpdHandler(c *fiber.Ctx) error {
 	c.Request().Header.Set("User-Agent", PLAYER_USER_AGENT)
 	// remove Accept-Encoding header
 	c.Request().Header.Del("Accept-Encoding")
-	if err := proxy.Do(c, requestUrl, TV.Client); err != nil {
+	if err := proxy.Do(c, requestUrl, tvFor(c).Client); err != nil {
 		return err
 	}
 	c.Response().Header.Del(fiber.HeaderServer)
+	// The segments are requested on the profile of the manifest
+	dashPath := profilePrefix(c) + "/render.dash"
 
 	// Delete Domain from cookies
 	if c.Response().Header.Peek("Set-Cookie") != nil {
@@ -239,7 +256,7 @@ func MpdHandler(c *fiber.Ctx) error {
 
 		cookies = bytes.Replace(cookies, []byte("Domain="+proxyHost+";"), []byte(""), 1)
 		// Modify path in cookies
-		cookies = bytes.Replace(cookies, []byte("path=/"), []byte("path=/render.dash"), 1)
+		cookies = bytes.Replace(cookies, []byte("path=/"), []byte("path="+dashPath), 1)
 
 		// Modify Set-Cookie header
 		c.Response().Header.SetBytesV("Set-Cookie", cookies)
@@ -250,13 +267,13 @@ func MpdHandler(c *fiber.Ctx) error {
 	// check for match
 	if re.Match(resBody) {
 		resBody = re.ReplaceAllFunc(resBody, func(match []byte) []byte {
-			return []byte("<BaseURL>/render.dash/dash/</BaseURL>")
+			return []byte("<BaseURL>" + dashPath + "/dash/</BaseURL>")
 		})
 	} else {
 		pattern := `<Period(\s+[^>]*?)?\s*\/?>`
 		re = regexp.MustCompile(pattern)
 		resBody = re.ReplaceAllFunc(resBody, func(match []byte) []byte {
-			return []byte(fmt.Sprintf("%s\n<BaseURL>/render.dash/</BaseURL>", match))
+			return []byte(fmt.Sprintf("%s\n<BaseURL>%s/</BaseURL>", match, dashPath))
 		})
 	}
 
@@ -287,17 +304,18 @@ func DashHandler(c *fiber.Ctx) error {
 		return err
 	}
 
-	// remove render.dash from c.Request().URI().RequestURI()
-	requestUri := bytes.Replace(c.Request().URI().RequestURI(), []byte("/render.dash"), []byte(""), 1)
+	// remove render.dash and the profile from c.Request().URI().RequestURI()
+	requestUri := bytes.Replace(c.Request().URI().RequestURI(), []byte(profilePrefix(c)+"/render.dash"), []byte(""), 1)
 
 	proxyUrl := fmt.Sprintf("https://%s%s/%s", proxyHost, proxyPath, requestUri)
 
 	c.Request().Header.Set("User-Agent", PLAYER_USER_AGENT)
 
-	if err := proxy.Do(c, proxyUrl, TV.Client); err != nil {
+	if err := proxy.Do(c, proxyUrl, tvFor(c).Client); err != nil {
 		return err
 	}
 	c.Response().Header.Del(fiber.HeaderServer)
+	middleware.CountStreamBytes(c)
 
 	return nil
 }
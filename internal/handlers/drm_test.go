@@ -46,7 +46,7 @@ func TestGetDrmMpd(t *testing.T) {
 				}
 			}()
 
-			got, err := getDrmMpd(tt.args.channelID, tt.args.quality)
+			got, err := getDrmMpd(TV, tt.args.channelID, tt.args.quality, "")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getDrmMpd() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1,15 +1,24 @@
 package handlers
 
 import (
+	"bufio"
+	"compress/gzip"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/proxy"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
 	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 )
 
@@ -19,15 +28,120 @@ const (
 
 // EPGHandler handles EPG requests
 func EPGHandler(c *fiber.Ctx) error {
+	return sendEPG(c, true)
+}
+
+// EPGXMLHandler handles requests for the uncompressed EPG
+func EPGXMLHandler(c *fiber.Ctx) error {
+	return sendEPG(c, false)
+}
+
+// sendEPG sends the EPG file, keeping only the channels matching the playlist filters of the request.
+// The file is sent as is when it is requested compressed without filters.
+func sendEPG(c *fiber.Ctx, compressed bool) error {
 	epgFilePath := utils.GetPathPrefix() + "epg.xml.gz"
 	// if epg.xml.gz exists, return it
-	if _, err := os.Stat(epgFilePath); err == nil {
-		return c.SendFile(epgFilePath, true)
-	} else {
+	if _, err := os.Stat(epgFilePath); err != nil {
 		err_message := "EPG not found. Please restart the server after setting the environment variable JIOTV_EPG to true."
 		utils.Log.Println(err_message) // Changed from fmt.Println
 		return internalUtils.NotFoundError(c, err_message)
 	}
+
+	channelIDs, err := epgChannelFilter(c)
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	opts := epg.RewriteOptions{ChannelIDs: channelIDs}
+	if config.Cfg.EPGLocalImages {
+		opts.ImageHostURL = strings.ToLower(c.Protocol()) + "://" + c.Hostname()
+	}
+	rewrite := opts.ChannelIDs != nil || opts.ImageHostURL != ""
+	if compressed && !rewrite {
+		return c.SendFile(epgFilePath, true)
+	}
+
+	f, err := os.Open(epgFilePath)
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	if compressed {
+		c.Set(fiber.HeaderContentType, "application/gzip")
+	} else {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	}
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer f.Close()
+		gzReader, err := gzip.NewReader(f)
+		if err != nil {
+			utils.Log.Println("Error reading EPG file:", err)
+			return
+		}
+		var out io.Writer = w
+		if compressed {
+			gzWriter := gzip.NewWriter(w)
+			defer gzWriter.Close()
+			out = gzWriter
+		}
+		if rewrite {
+			err = epg.Rewrite(out, gzReader, opts)
+		} else {
+			_, err = io.Copy(out, gzReader)
+		}
+		if err != nil {
+			utils.Log.Println("Error sending EPG:", err)
+		}
+	})
+	return nil
+}
+
+// epgFilterParams are the query params of the channel filters shared by playlists and the EPG
+var epgFilterParams = []string{"l", "sg", "favorites", "channels"}
+
+// epgChannelFilter returns the IDs of the channels matching the playlist filters of the request.
+// It returns nil if the request has no filters and no share link scope.
+func epgChannelFilter(c *fiber.Ctx) (map[string]bool, error) {
+	if epgFilterQuery(c) == "" && middleware.ShareScope(c) == nil {
+		return nil, nil
+	}
+	channels, err := television.Channels()
+	if err != nil {
+		return nil, err
+	}
+	return filterEPGChannels(c, channels.Result)
+}
+
+// filterEPGChannels returns the IDs of the channels matching the playlist filters of the request
+func filterEPGChannels(c *fiber.Ctx, channels []television.Channel) (map[string]bool, error) {
+	filtered, err := applyChannelPreferences(c, channels)
+	if err != nil {
+		return nil, err
+	}
+	languages := strings.TrimSpace(c.Query("l"))
+	skipGenres := strings.TrimSpace(c.Query("sg"))
+	channelIDs := make(map[string]bool, len(filtered))
+	for _, channel := range filtered {
+		if matchesPlaylistFilters(channel, languages, skipGenres) {
+			channelIDs[guideChannelID(channel.ID)] = true
+		}
+	}
+	return channelIDs, nil
+}
+
+// epgFilterQuery returns the query string with the channel filters of the request, to request an EPG matching a playlist.
+// It is empty if the request has no filters.
+func epgFilterQuery(c *fiber.Ctx) string {
+	query := url.Values{}
+	for _, param := range epgFilterParams {
+		if value := strings.TrimSpace(c.Query(param)); value != "" {
+			query.Set(param, value)
+		}
+	}
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
 }
 
 // WebEPGHandler responds to requests for EPG data for individual channels.
@@ -65,3 +179,124 @@ func PosterHandler(c *fiber.Ctx) error {
 	url := EPG_POSTER_URL + c.Params("date") + "/" + c.Params("file")
 	return internalUtils.ProxyRequest(c, url, TV.Client, "")
 }
+
+// GuideProgrammesResponse is the body of the EPG API listing programmes
+type GuideProgrammesResponse struct {
+	Programmes []epg.GuideProgramme `json:"programmes"`
+}
+
+// GuideNowNextResponse is the body of the now-next EPG API
+type GuideNowNextResponse struct {
+	Channels []epg.NowNext `json:"channels"`
+}
+
+// GuideChannelResponse is the body of the EPG API of a single channel
+type GuideChannelResponse struct {
+	ChannelID   string               `json:"channel_id"`
+	ChannelName string               `json:"channel_name"`
+	Programmes  []epg.GuideProgramme `json:"programmes"`
+}
+
+// GuideSearchResponse is the body of the EPG search API
+type GuideSearchResponse struct {
+	Channels []epg.ChannelProgrammes `json:"channels"`
+}
+
+// currentGuide returns the loaded EPG, sending a 404 if there is none
+func currentGuide(c *fiber.Ctx) (*epg.Guide, error) {
+	guide := epg.CurrentGuide()
+	if guide == nil {
+		return nil, internalUtils.NotFoundError(c, "EPG not loaded. Please enable EPG with the environment variable JIOTV_EPG set to true.")
+	}
+	return guide, nil
+}
+
+// guideChannelID returns the EPG channel ID of a channel ID, which has no sl prefix
+func guideChannelID(channelID string) string {
+	return strings.TrimPrefix(channelID, "sl")
+}
+
+// parseGuideTime parses a time query param given as RFC 3339 or Unix seconds. An empty value returns fallback.
+func parseGuideTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// EPGNowHandler returns the programmes airing now on every channel
+func EPGNowHandler(c *fiber.Ctx) error {
+	guide, err := currentGuide(c)
+	if guide == nil {
+		return err
+	}
+	return c.JSON(GuideProgrammesResponse{Programmes: guide.Now(time.Now())})
+}
+
+// EPGNowNextHandler returns the current and next programme of the channels in the comma separated channels query param.
+// Without channels, all channels are returned.
+func EPGNowNextHandler(c *fiber.Ctx) error {
+	guide, err := currentGuide(c)
+	if guide == nil {
+		return err
+	}
+	var channelIDs []string
+	for _, id := range strings.Split(c.Query("channels"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			channelIDs = append(channelIDs, guideChannelID(id))
+		}
+	}
+	return c.JSON(GuideNowNextResponse{Channels: guide.NowNext(channelIDs, time.Now())})
+}
+
+// EPGChannelHandler returns the programmes of a channel between the from and to query params.
+// from defaults to now and to defaults to the end of the guide.
+func EPGChannelHandler(c *fiber.Ctx) error {
+	guide, err := currentGuide(c)
+	if guide == nil {
+		return err
+	}
+	channelID := guideChannelID(c.Params("channelID"))
+	if !guide.HasChannel(channelID) {
+		return internalUtils.NotFoundError(c, "Channel "+channelID+" not found in EPG")
+	}
+	from, err := parseGuideTime(c.Query("from"), time.Now())
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid from time")
+	}
+	to, err := parseGuideTime(c.Query("to"), time.Time{})
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid to time")
+	}
+	return c.JSON(GuideChannelResponse{
+		ChannelID:   channelID,
+		ChannelName: guide.ChannelName(channelID),
+		Programmes:  guide.Between(channelID, from, to),
+	})
+}
+
+// EPGSearchHandler searches the programmes of every channel by the q and category query params,
+// airing between the from and to query params. from defaults to now and to defaults to the end of the guide.
+func EPGSearchHandler(c *fiber.Ctx) error {
+	guide, err := currentGuide(c)
+	if guide == nil {
+		return err
+	}
+	query := strings.TrimSpace(c.Query("q"))
+	category := strings.TrimSpace(c.Query("category"))
+	if query == "" && category == "" {
+		return internalUtils.BadRequestError(c, "q or category not provided")
+	}
+	from, err := parseGuideTime(c.Query("from"), time.Now())
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid from time")
+	}
+	to, err := parseGuideTime(c.Query("to"), time.Time{})
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid to time")
+	}
+	return c.JSON(GuideSearchResponse{Channels: guide.Search(query, category, from, to)})
+}
@@ -1,9 +1,25 @@
 package handlers
 
 import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 )
 
 func TestWebEPGHandler(t *testing.T) {
@@ -45,3 +61,233 @@ func TestPosterHandler(t *testing.T) {
 		})
 	}
 }
+
+// writeTestGuide writes an EPG with a programme airing now and the next one on channel 143, and loads it
+func writeTestGuide(t *testing.T) {
+	t.Helper()
+	layout := "20060102150405 -0700"
+	now := time.Now().Truncate(time.Hour)
+	programme := func(title string, start time.Time) string {
+		return fmt.Sprintf(`<programme channel="143" start="%s" stop="%s"><title lang="en">%s</title><desc lang="en"></desc><category lang="en">News</category><icon src=""></icon></programme>`,
+			start.Format(layout), start.Add(time.Hour).Format(layout), title)
+	}
+	xml := `<tv><channel id="143"><display-name>News</display-name></channel>` +
+		programme("Before", now.Add(-time.Hour)) + programme("Current", now) + programme("Upcoming", now.Add(time.Hour)) + `</tv>`
+
+	filename := filepath.Join(t.TempDir(), "epg.xml.gz")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(xml))
+	gz.Close()
+	f.Close()
+	if err := epg.LoadGuide(filename); err != nil {
+		t.Fatalf("LoadGuide() error = %v", err)
+	}
+}
+
+func TestEPGAPI(t *testing.T) {
+	app := fiber.New()
+	app.Get("/api/epg/now", EPGNowHandler)
+	app.Get("/api/epg/now-next", EPGNowNextHandler)
+	app.Get("/api/epg/search", EPGSearchHandler)
+	app.Get("/api/epg/:channelID", EPGChannelHandler)
+
+	get := func(path string, v interface{}) int {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil), -1)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		if v != nil && resp.StatusCode == fiber.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatalf("GET %s: decoding response: %v", path, err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	if epg.CurrentGuide() == nil {
+		if status := get("/api/epg/now", nil); status != fiber.StatusNotFound {
+			t.Errorf("GET /api/epg/now without a guide = %d, want 404", status)
+		}
+	}
+	writeTestGuide(t)
+
+	var now GuideProgrammesResponse
+	if status := get("/api/epg/now", &now); status != fiber.StatusOK || len(now.Programmes) != 1 || now.Programmes[0].Title != "Current" {
+		t.Errorf("GET /api/epg/now = %d %+v, want Current", status, now)
+	}
+
+	var nowNext GuideNowNextResponse
+	get("/api/epg/now-next?channels=sl143,999", &nowNext)
+	if len(nowNext.Channels) != 1 || nowNext.Channels[0].Now == nil || nowNext.Channels[0].Now.Title != "Current" ||
+		nowNext.Channels[0].Next == nil || nowNext.Channels[0].Next.Title != "Upcoming" {
+		t.Errorf("GET /api/epg/now-next = %+v, want Current and Upcoming on 143", nowNext)
+	}
+
+	var channel GuideChannelResponse
+	get("/api/epg/143", &channel)
+	if channel.ChannelName != "News" || len(channel.Programmes) != 2 {
+		t.Errorf("GET /api/epg/143 = %+v, want Current and Upcoming", channel)
+	}
+	from := time.Now().Add(-90 * time.Minute).Truncate(time.Hour).Unix()
+	get(fmt.Sprintf("/api/epg/143?from=%d&to=%s", from, time.Now().Format(time.RFC3339)), &channel)
+	if len(channel.Programmes) != 2 || channel.Programmes[0].Title != "Before" {
+		t.Errorf("GET /api/epg/143 with from and to = %+v, want Before and Current", channel)
+	}
+
+	var search GuideSearchResponse
+	get("/api/epg/search?q=upcoming", &search)
+	if len(search.Channels) != 1 || search.Channels[0].ChannelName != "News" || len(search.Channels[0].Programmes) != 1 ||
+		search.Channels[0].Programmes[0].Title != "Upcoming" {
+		t.Errorf("GET /api/epg/search = %+v, want Upcoming on News", search)
+	}
+	get("/api/epg/search?category=news", &search)
+	if len(search.Channels) != 1 || len(search.Channels[0].Programmes) != 2 {
+		t.Errorf("GET /api/epg/search by category = %+v, want Current and Upcoming", search)
+	}
+	if status := get("/api/epg/search", nil); status != fiber.StatusBadRequest {
+		t.Errorf("GET /api/epg/search without q = %d, want 400", status)
+	}
+
+	if status := get("/api/epg/999", nil); status != fiber.StatusNotFound {
+		t.Errorf("GET /api/epg/999 = %d, want 404", status)
+	}
+	if status := get("/api/epg/143?from=yesterday", nil); status != fiber.StatusBadRequest {
+		t.Errorf("GET /api/epg/143 with invalid from = %d, want 400", status)
+	}
+}
+
+// writeTestEPGFile writes a gzipped EPG file with the given XML to the path prefix
+func writeTestEPGFile(t *testing.T, xml string) {
+	t.Helper()
+	f, err := os.Create(utils.GetPathPrefix() + "epg.xml.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(xml))
+	gz.Close()
+	f.Close()
+}
+
+func TestSendEPG(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	originalLocalImages := config.Cfg.EPGLocalImages
+	defer func() { config.Cfg.EPGLocalImages = originalLocalImages }()
+
+	app := fiber.New()
+	app.Get("/epg.xml.gz", EPGHandler)
+	app.Get("/epg.xml", EPGXMLHandler)
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", "http://localhost:5001"+path, nil), -1)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			return resp.StatusCode, ""
+		}
+		var body io.Reader = resp.Body
+		if strings.HasSuffix(path, ".gz") {
+			if body, err = gzip.NewReader(resp.Body); err != nil {
+				t.Fatalf("GET %s is not gzipped: %v", path, err)
+			}
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("GET %s: reading EPG: %v", path, err)
+		}
+		return resp.StatusCode, string(data)
+	}
+
+	if status, _ := get("/epg.xml"); status != fiber.StatusNotFound {
+		t.Errorf("GET /epg.xml without an EPG file = %d, want 404", status)
+	}
+	writeTestEPGFile(t, `<tv><channel id="1"><display-name>News</display-name><icon src="https://jiotv.catchup.cdn.jio.com/dare_images/images/logo.png"></icon></channel></tv>`)
+
+	if _, got := get("/epg.xml"); !strings.HasPrefix(got, `<tv><channel id="1">`) {
+		t.Errorf("GET /epg.xml = %s, want the uncompressed EPG", got)
+	}
+	config.Cfg.EPGLocalImages = false
+	if _, got := get("/epg.xml.gz"); !strings.Contains(got, `src="https://jiotv.catchup.cdn.jio.com/dare_images/images/logo.png"`) {
+		t.Errorf("GET /epg.xml.gz = %s, want the JioTV logo URL", got)
+	}
+	config.Cfg.EPGLocalImages = true
+	if _, got := get("/epg.xml.gz"); !strings.Contains(got, `src="http://localhost:5001/jtvimage/logo.png"`) {
+		t.Errorf("GET /epg.xml.gz = %s, want the local logo URL", got)
+	}
+}
+
+func TestFilterEPGChannels(t *testing.T) {
+	channels := []television.Channel{
+		{ID: "1", Language: 1, Category: 12},  // Hindi News
+		{ID: "2", Language: 6, Category: 12},  // English News
+		{ID: "3", Language: 6, Category: 18},  // English Shopping
+		{ID: "sl4", Language: 6, Category: 8}, // English Sports
+	}
+	tests := []struct {
+		query string
+		want  map[string]bool
+	}{
+		{query: "l=English", want: map[string]bool{"2": true, "3": true, "4": true}},
+		{query: "l=English&sg=Shopping,Sports", want: map[string]bool{"2": true}},
+		{query: "channels=1,3", want: map[string]bool{"1": true, "3": true}},
+		{query: "channels=1,3&l=English", want: map[string]bool{"3": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			app := fiber.New()
+			var got map[string]bool
+			app.Get("/", func(c *fiber.Ctx) error {
+				var err error
+				got, err = filterEPGChannels(c, channels)
+				return err
+			})
+			if _, err := app.Test(httptest.NewRequest("GET", "/?"+tt.query, nil), -1); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterEPGChannels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEPGFilterQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "", want: ""},
+		{query: "q=high&c=split&sort=custom", want: ""},
+		{query: "q=high&l=Hindi,English&sg=Shopping", want: "?l=Hindi%2CEnglish&sg=Shopping"},
+		{query: "favorites=1&channels=143&l=", want: "?channels=143&favorites=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			app := fiber.New()
+			var got string
+			app.Get("/", func(c *fiber.Ctx) error {
+				got = epgFilterQuery(c)
+				return nil
+			})
+			if _, err := app.Test(httptest.NewRequest("GET", "/?"+tt.query, nil), -1); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("epgFilterQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/favorites"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FavoritesResponse is the body of the favorites API
+type FavoritesResponse struct {
+	Favorites []string `json:"favorites"`
+}
+
+// ChannelOrderResponse is the body of the channel order API
+type ChannelOrderResponse struct {
+	Order []string `json:"order"`
+}
+
+// GetFavoritesHandler returns the favorite channel IDs
+func GetFavoritesHandler(c *fiber.Ctx) error {
+	ids, err := favorites.Get()
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return c.JSON(FavoritesResponse{Favorites: ids})
+}
+
+// SetFavoritesHandler replaces the favorite channel IDs
+func SetFavoritesHandler(c *fiber.Ctx) error {
+	var body FavoritesResponse
+	if err := c.BodyParser(&body); err != nil {
+		return internalUtils.BadRequestError(c, "Invalid JSON")
+	}
+	if body.Favorites == nil {
+		return internalUtils.BadRequestError(c, "favorites not provided")
+	}
+	if err := favorites.Set(body.Favorites); err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return GetFavoritesHandler(c)
+}
+
+// AddFavoriteHandler adds a channel to the favorites
+func AddFavoriteHandler(c *fiber.Ctx) error {
+	if err := favorites.Add(c.Params("id")); err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return GetFavoritesHandler(c)
+}
+
+// RemoveFavoriteHandler removes a channel from the favorites
+func RemoveFavoriteHandler(c *fiber.Ctx) error {
+	if err := favorites.Remove(c.Params("id")); err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return GetFavoritesHandler(c)
+}
+
+// GetChannelOrderHandler returns the custom channel order
+func GetChannelOrderHandler(c *fiber.Ctx) error {
+	ids, err := favorites.GetOrder()
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return c.JSON(ChannelOrderResponse{Order: ids})
+}
+
+// SetChannelOrderHandler replaces the custom channel order
+func SetChannelOrderHandler(c *fiber.Ctx) error {
+	var body ChannelOrderResponse
+	if err := c.BodyParser(&body); err != nil {
+		return internalUtils.BadRequestError(c, "Invalid JSON")
+	}
+	if body.Order == nil {
+		return internalUtils.BadRequestError(c, "order not provided")
+	}
+	if err := favorites.SetOrder(body.Order); err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return GetChannelOrderHandler(c)
+}
+
+// applyChannelPreferences applies the scope of a share link and the favorites=1, channels and sort=custom query params to channels
+func applyChannelPreferences(c *fiber.Ctx, channels []television.Channel) ([]television.Channel, error) {
+	if scope := middleware.ShareScope(c); scope != nil {
+		channels = scope.Filter(channels)
+	}
+	if ids := strings.TrimSpace(c.Query("channels")); ids != "" {
+		channels = favorites.Filter(channels, strings.Split(ids, ","))
+	}
+	if c.Query("favorites") == "1" {
+		ids, err := favorites.Get()
+		if err != nil {
+			return nil, err
+		}
+		channels = favorites.Filter(channels, ids)
+	}
+	if c.Query("sort") == "custom" {
+		order, err := favorites.GetOrder()
+		if err != nil {
+			return nil, err
+		}
+		channels = favorites.Sort(channels, order)
+	}
+	return channels, nil
+}
+
+// indexChannels puts the channels of the index page in the custom order with favorites first.
+// It also returns the favorite IDs for the page. Store errors are logged and the channels are kept as they are.
+func indexChannels(channels []television.Channel) ([]television.Channel, []string) {
+	order, err := favorites.GetOrder()
+	if err != nil {
+		utils.Log.Println(err)
+		return channels, []string{}
+	}
+	ids, err := favorites.Get()
+	if err != nil {
+		utils.Log.Println(err)
+		return channels, []string{}
+	}
+	return favorites.First(favorites.Sort(channels, order), ids), ids
+}
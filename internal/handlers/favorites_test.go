@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// setupTestStore points the store at a temporary directory which is removed when the test ends
+func setupTestStore(t *testing.T) {
+	t.Helper()
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	t.Cleanup(cleanup)
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+}
+
+// doJSON sends a request with a JSON body to app and returns the status code of the response.
+// Successful responses are decoded into v unless it is nil.
+func doJSON(t *testing.T, app *fiber.App, method, path, body string, v interface{}) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s error = %v", method, path, err)
+	}
+	if v != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: decoding response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestFavoritesAPI(t *testing.T) {
+	setupTestStore(t)
+
+	app := fiber.New()
+	app.Get("/api/favorites", GetFavoritesHandler)
+	app.Put("/api/favorites", SetFavoritesHandler)
+	app.Post("/api/favorites/:id", AddFavoriteHandler)
+	app.Delete("/api/favorites/:id", RemoveFavoriteHandler)
+	app.Get("/api/channel-order", GetChannelOrderHandler)
+	app.Put("/api/channel-order", SetChannelOrderHandler)
+
+	var favorites FavoritesResponse
+	if status := doJSON(t, app, "GET", "/api/favorites", "", &favorites); status != fiber.StatusOK || len(favorites.Favorites) != 0 {
+		t.Fatalf("GET /api/favorites = %d %v, want empty list", status, favorites.Favorites)
+	}
+	doJSON(t, app, "PUT", "/api/favorites", `{"favorites":["143","144"]}`, nil)
+	doJSON(t, app, "POST", "/api/favorites/145", "", nil)
+	doJSON(t, app, "DELETE", "/api/favorites/143", "", &favorites)
+	if want := []string{"144", "145"}; !reflect.DeepEqual(favorites.Favorites, want) {
+		t.Errorf("favorites = %v, want %v", favorites.Favorites, want)
+	}
+	if status := doJSON(t, app, "PUT", "/api/favorites", `{}`, nil); status != fiber.StatusBadRequest {
+		t.Errorf("PUT /api/favorites without favorites = %d, want 400", status)
+	}
+
+	var order ChannelOrderResponse
+	doJSON(t, app, "PUT", "/api/channel-order", `{"order":["2","1"]}`, nil)
+	if status := doJSON(t, app, "GET", "/api/channel-order", "", &order); status != fiber.StatusOK {
+		t.Fatalf("GET /api/channel-order = %d", status)
+	}
+	if want := []string{"2", "1"}; !reflect.DeepEqual(order.Order, want) {
+		t.Errorf("order = %v, want %v", order.Order, want)
+	}
+	if status := doJSON(t, app, "PUT", "/api/channel-order", `not json`, nil); status != fiber.StatusBadRequest {
+		t.Errorf("PUT /api/channel-order with invalid body = %d, want 400", status)
+	}
+}
+
+func TestPlaylistHandler_Redirect(t *testing.T) {
+	app := fiber.New()
+	app.Get("/playlist.m3u", PlaylistHandler)
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "Without favorites", query: "?q=high", want: "/channels?type=m3u&q=high&c=&l=&sg="},
+		{name: "Favorites in custom order", query: "?favorites=1&sort=custom", want: "/channels?type=m3u&q=&c=&l=&sg=&favorites=1&sort=custom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", "/playlist.m3u"+tt.query, nil))
+			if err != nil {
+				t.Fatalf("GET /playlist.m3u error = %v", err)
+			}
+			if got := resp.Header.Get("Location"); got != tt.want {
+				t.Errorf("Location = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
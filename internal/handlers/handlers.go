@@ -1,9 +1,7 @@
 package handlers
 
 import (
-	"bytes"
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -11,7 +9,9 @@ import (
 	"github.com/jiotv-go/jiotv_go/v3/internal/config"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
 	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/metrics"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
@@ -73,8 +73,13 @@ func Init() {
 		TV = television.New(credentials)
 	}
 
+	// Live URLs of the previous account must not be reused after login or logout
+	television.ClearLiveCache()
+	resetProfileTVs()
+
 	// Initialize custom channels at startup if configured
 	television.InitCustomChannels()
+	initUpstreamCache()
 }
 
 // ErrorMessageHandler handles error messages
@@ -88,7 +93,7 @@ func ErrorMessageHandler(c *fiber.Ctx, err error) error {
 
 // isCustomChannel checks if a given channel ID is a custom channel
 func isCustomChannel(channelID string) bool {
-	if config.Cfg.CustomChannelsFile == "" {
+	if config.Cfg.CustomChannelsFile == "" && !television.HasLocalChannels() {
 		return false
 	}
 
@@ -140,6 +145,7 @@ func IndexHandler(c *fiber.Ctx) error {
 	}
 
 	// Filter channels by query params if provided
+	channels_list := channels.Result
 	if language != "" || category != "" {
 		language_int, err := strconv.Atoi(language)
 		if err != nil {
@@ -149,20 +155,14 @@ func IndexHandler(c *fiber.Ctx) error {
 		if err != nil {
 			return ErrorMessageHandler(c, err)
 		}
-		channels_list := television.FilterChannels(channels.Result, language_int, category_int)
-		indexContext["Channels"] = channels_list
-		return c.Render("views/index", indexContext)
-	}
-
-	// If no query parameters are provided, use default config filtering
-	if len(config.Cfg.DefaultCategories) > 0 || len(config.Cfg.DefaultLanguages) > 0 {
-		channels_list := television.FilterChannelsByDefaults(channels.Result, config.Cfg.DefaultCategories, config.Cfg.DefaultLanguages)
-		indexContext["Channels"] = channels_list
-		return c.Render("views/index", indexContext)
+		channels_list = television.FilterChannels(channels.Result, language_int, category_int)
+	} else if len(config.Cfg.DefaultCategories) > 0 || len(config.Cfg.DefaultLanguages) > 0 {
+		// If no query parameters are provided, use default config filtering
+		channels_list = television.FilterChannelsByDefaults(channels.Result, config.Cfg.DefaultCategories, config.Cfg.DefaultLanguages)
 	}
 
-	// If no query params and no default config, return all channels
-	indexContext["Channels"] = channels.Result
+	// Favorites first, then the rest in the custom order
+	indexContext["Channels"], indexContext["Favorites"] = indexChannels(channels_list)
 	return c.Render("views/index", indexContext)
 }
 
@@ -178,24 +178,24 @@ func LiveHandler(c *fiber.Ctx) error {
 	// remove suffix .m3u8 if exists
 	id = strings.Replace(id, ".m3u8", "", 1)
 
-	// Check if this is a custom channel - serve directly for custom channels
+	// Check if this is a custom channel - custom channels don't need JioTV tokens
 	if isCustomChannel(id) {
 		channel, exists := television.GetCustomChannelByID(id)
 		if !exists {
 			utils.Log.Printf("Custom channel with ID %s not found", id)
 			return internalUtils.NotFoundError(c, fmt.Sprintf("Custom channel with ID %s not found", id))
 		}
-		// For custom channels, redirect directly to the m3u8 URL (no render pipeline needed)
-		return c.Redirect(channel.URL, fiber.StatusFound)
+		// Redirect directly to the m3u8 URL, or through the render pipeline in proxy mode
+		return customChannelRedirect(c, channel)
 	}
 
 	// For regular JioTV channels, ensure tokens are fresh before making API call
-	if err := EnsureFreshTokens(); err != nil {
+	if err := EnsureFreshProfileTokens(requestProfile(c)); err != nil {
 		utils.Log.Printf("Failed to ensure fresh tokens: %v", err)
 		// Continue with the request - tokens might still work
 	}
 
-	liveResult, err := TV.Live(id)
+	liveResult, err := tvFor(c).LiveCached(id)
 	if err != nil {
 		utils.Log.Println(err)
 		return internalUtils.InternalServerError(c, err)
@@ -226,7 +226,8 @@ func LiveHandler(c *fiber.Ctx) error {
 		return internalUtils.ForbiddenError(c, err)
 	}
 	// also add hdnea as an explicit query param for downstream (no client cookie)
-	redirectURL := "/render.m3u8?auth=" + coded_url + "&channel_key_id=" + id
+	redirectURL := profilePrefix(c) + "/render.m3u8?auth=" + coded_url + "&channel_key_id=" + id +
+		"&channel_sig=" + secureurl.SignChannel(id, liveURL)
 	if liveResult.Hdnea != "" {
 		redirectURL += "&hdnea=" + liveResult.Hdnea
 	}
@@ -240,24 +241,24 @@ func LiveQualityHandler(c *fiber.Ctx) error {
 	// remove suffix .m3u8 if exists
 	id = strings.Replace(id, ".m3u8", "", 1)
 
-	// Check if this is a custom channel - serve directly for custom channels
+	// Check if this is a custom channel - custom channels don't need JioTV tokens
 	if isCustomChannel(id) {
 		channel, exists := television.GetCustomChannelByID(id)
 		if !exists {
 			utils.Log.Printf("Custom channel with ID %s not found", id)
 			return internalUtils.NotFoundError(c, fmt.Sprintf("Custom channel with ID %s not found", id))
 		}
-		// For custom channels, redirect directly to the m3u8 URL (no render pipeline needed)
-		return c.Redirect(channel.URL, fiber.StatusFound)
+		// Redirect directly to the m3u8 URL, or through the render pipeline in proxy mode
+		return customChannelRedirect(c, channel)
 	}
 
 	// For regular JioTV channels, ensure tokens are fresh before making API call
-	if err := EnsureFreshTokens(); err != nil {
+	if err := EnsureFreshProfileTokens(requestProfile(c)); err != nil {
 		utils.Log.Printf("Failed to ensure fresh tokens: %v", err)
 		// Continue with the request - tokens might still work
 	}
 
-	liveResult, err := TV.Live(id)
+	liveResult, err := tvFor(c).LiveCached(id)
 	if err != nil {
 		utils.Log.Println(err)
 		return internalUtils.InternalServerError(c, err)
@@ -273,23 +274,29 @@ func LiveQualityHandler(c *fiber.Ctx) error {
 
 	// select quality level based on query parameter
 	liveURL := internalUtils.SelectQuality(quality, Bitrates.Auto, Bitrates.High, Bitrates.Medium, Bitrates.Low)
-	if liveResult.Hdnea != "" && !strings.Contains(liveURL, "hdnea=") {
+	return renderRedirect(c, liveURL, liveResult.Hdnea, id, quality)
+}
+
+// renderRedirect redirects to the /render.m3u8 pipeline playing a stream URL of the playback API of a channel
+func renderRedirect(c *fiber.Ctx, streamURL, hdnea, id, quality string) error {
+	if hdnea != "" && !strings.Contains(streamURL, "hdnea=") {
 		sep := "?"
-		if strings.Contains(liveURL, "?") {
+		if strings.Contains(streamURL, "?") {
 			sep = "&"
 		}
-		liveURL = liveURL + sep + "hdnea=" + liveResult.Hdnea
+		streamURL = streamURL + sep + "hdnea=" + hdnea
 	}
 
 	// quote url as it will be passed as a query parameter
-	coded_url, err := secureurl.EncryptURL(liveURL)
+	coded_url, err := secureurl.EncryptURL(streamURL)
 	if err != nil {
 		utils.Log.Println(err)
 		return internalUtils.ForbiddenError(c, err)
 	}
-	redirectURL := "/render.m3u8?auth=" + coded_url + "&channel_key_id=" + id + "&q=" + quality
-	if liveResult.Hdnea != "" {
-		redirectURL += "&hdnea=" + liveResult.Hdnea
+	redirectURL := profilePrefix(c) + "/render.m3u8?auth=" + coded_url + "&channel_key_id=" + id +
+		"&channel_sig=" + secureurl.SignChannel(id, streamURL) + "&q=" + quality
+	if hdnea != "" {
+		redirectURL += "&hdnea=" + hdnea
 	}
 	return c.Redirect(redirectURL, fiber.StatusFound)
 }
@@ -314,6 +321,11 @@ func RenderHandler(c *fiber.Ctx) error {
 		return err
 	}
 
+	// Custom channels in proxy mode are fetched with their own headers instead of JioTV ones
+	if channel, ok := proxiedCustomChannel(channel_id); ok {
+		return renderCustomChannel(c, channel, decoded_url)
+	}
+
 	// If hdnea is present in query and missing in the decrypted URL, append it so TV.Render can forward as request cookie upstream
 	if hdnea := c.Query("hdnea"); hdnea != "" && !strings.Contains(decoded_url, "hdnea=") {
 		sep := "?"
@@ -323,15 +335,17 @@ func RenderHandler(c *fiber.Ctx) error {
 		decoded_url = decoded_url + sep + "hdnea=" + hdnea
 	}
 
-	renderResult, statusCode, newHdnea := TV.Render(decoded_url)
+	renderResult, statusCode, newHdnea := renderPlaylist(tvFor(c), decoded_url)
 
 	// If we get a 403 (Forbidden), try refreshing tokens and retry once
 	if statusCode == fiber.StatusForbidden {
-		if err := EnsureFreshTokens(); err != nil {
+		// The cached live URL of the channel is no longer accepted
+		tvFor(c).InvalidateLive(channel_id)
+		if err := EnsureFreshProfileTokens(requestProfile(c)); err != nil {
 			utils.Log.Printf("Failed to refresh tokens after 403: %v", err)
 			// Retry the request once after refreshing tokens
 			utils.Log.Println("Retrying render request after token refresh")
-			renderResult, statusCode, newHdnea = TV.Render(decoded_url)
+			renderResult, statusCode, newHdnea = renderPlaylist(tvFor(c), decoded_url)
 		} else {
 			utils.Log.Println("Unable to refresh tokens after expiration")
 			return internalUtils.ForbiddenError(c, "Access forbidden. Something went wrong!")
@@ -339,15 +353,11 @@ func RenderHandler(c *fiber.Ctx) error {
 	}
 	// No client cookie: if upstream rotated __hdnea__, we'll embed the fresh token into rewritten URLs below
 
-	// baseUrl is the part of the url excluding suffix file.m3u8 and params is the part of the url after the suffix
-	split_url_by_params := strings.Split(decoded_url, "?")
-	baseStringUrl := split_url_by_params[0]
-	// Pattern to match file names ending with .m3u8
-	pattern := `[a-z0-9=\_\-A-Z\.]*\.m3u8`
-	re := regexp.MustCompile(pattern)
-	// Add baseUrl to all the file names ending with .m3u8
-	baseUrl := []byte(re.ReplaceAllString(baseStringUrl, ""))
-	params := split_url_by_params[1]
+	// params is the part of the url after the ? and is forwarded to every URI in the playlist
+	params := ""
+	if _, query, found := strings.Cut(decoded_url, "?"); found {
+		params = query
+	}
 	// If upstream rotated __hdnea__, update params so rewritten URLs carry the fresh token value
 	if newHdnea != "" {
 		if strings.Contains(params, "hdnea=") {
@@ -368,44 +378,22 @@ func RenderHandler(c *fiber.Ctx) error {
 		}
 	}
 
-	// replacer replaces all the file names ending with .m3u8 and .ts with our own server URLs
-	// More info: https://golang.org/pkg/regexp/#Regexp.ReplaceAllFunc
-	replacer := func(match []byte) []byte {
-		switch {
-		case bytes.HasSuffix(match, []byte(".m3u8")):
-			return television.ReplaceM3U8(baseUrl, match, params, channel_id, c.Query("q"))
-		case bytes.HasSuffix(match, []byte(".ts")):
-			return television.ReplaceTS(baseUrl, match, params)
-		case bytes.HasSuffix(match, []byte(".aac")):
-			return television.ReplaceAAC(baseUrl, match, params)
-		default:
-			return match
+	// Error responses are not playlists, pass them through as they are
+	if statusCode == fiber.StatusOK {
+		rewriter := television.PlaylistRewriter{
+			PlaylistURL: decoded_url,
+			Params:      params,
+			ChannelID:   channel_id,
+			Quality:     c.Query("q"),
+			Prefix:      profilePrefix(c),
 		}
-	}
-
-	// Pattern to match file names ending with .m3u8 and .ts
-	pattern = `[a-z0-9=\_\-A-Z\/\.]*\.(m3u8|ts|aac)`
-	re = regexp.MustCompile(pattern)
-	// Execute replacer function on renderResult
-	renderResult = re.ReplaceAllFunc(renderResult, replacer)
-
-	// replacer_key replaces all the URLs ending with .key and .pkey with our own server URLs
-	replacer_key := func(match []byte) []byte {
-		switch {
-		case bytes.HasSuffix(match, []byte(".key")) || bytes.HasSuffix(match, []byte(".pkey")):
-			return television.ReplaceKey(match, params, channel_id)
-		default:
-			return match
+		renderResult, err = rewriter.Rewrite(renderResult)
+		if err != nil {
+			utils.Log.Println("Error rewriting M3U8 file:", err)
+			return internalUtils.ErrorResponse(c, fiber.StatusBadGateway, err.Error())
 		}
 	}
 
-	// Pattern to match URLs ending with .key and .pkey
-	pattern_key := `http[\S]+\.(pkey|key)`
-	re_key := regexp.MustCompile(pattern_key)
-
-	// Execute replacer_key function on renderResult
-	renderResult = re_key.ReplaceAllFunc(renderResult, replacer_key)
-
 	if statusCode != fiber.StatusOK {
 		utils.Log.Println("Error rendering M3U8 file")
 		utils.Log.Println(string(renderResult))
@@ -446,38 +434,15 @@ func RenderKeyHandler(c *fiber.Ctx) error {
 		return err
 	}
 
-	// extract params from url
-	params := strings.Split(decoded_url, "?")[1]
-
-	// set params as cookies as JioTV uses cookies to authenticate
-	for _, param := range strings.Split(params, "&") {
-		key := strings.Split(param, "=")[0]
-		value := strings.Split(param, "=")[1]
-		c.Request().Header.SetCookie(key, value)
-	}
-	// ensure __hdnea__ cookie exists if available from params
-	if strings.Contains(params, "hdnea=") {
-		for _, p := range strings.Split(params, "&") {
-			if strings.HasPrefix(p, "hdnea=") {
-				c.Request().Header.SetCookie("__hdnea__", strings.TrimPrefix(p, "hdnea="))
-				break
-			}
-		}
+	// Keys of custom channels don't use JioTV cookies and headers
+	if channel, ok := proxiedCustomChannel(channel_id); ok {
+		return proxyCustomChannel(c, channel, decoded_url, keyCacheTTL, metrics.EndpointKey)
 	}
 
-	// Copy headers from the Television headers map to the request
-	for key, value := range TV.Headers {
-		c.Request().Header.Set(key, value) // Assuming only one value for each header
-	}
-	c.Request().Header.Set("srno", "230203144000")
-	c.Request().Header.Set("ssotoken", TV.SsoToken)
-	c.Request().Header.Set("channelId", channel_id)
-	c.Request().Header.Set("User-Agent", PLAYER_USER_AGENT)
-	if err := proxy.Do(c, decoded_url, TV.Client); err != nil {
-		return err
-	}
-	c.Response().Header.Del(fiber.HeaderServer)
-	return nil
+	// JioTV authenticates key requests with the params of the URL sent as cookies
+	tv := tvFor(c)
+	tv.SetKeyHeaders(&c.Request().Header, decoded_url, channel_id)
+	return proxyCached(c, decoded_url, accountCacheKey(tv, decoded_url), keyCacheTTL, "", metrics.EndpointKey)
 }
 
 // RenderTSHandler loads TS file from JioTV server
@@ -493,7 +458,14 @@ func RenderTSHandler(c *fiber.Ctx) error {
 		utils.Log.Panicln(err)
 		return err
 	}
-	return internalUtils.ProxyRequest(c, decoded_url, TV.Client, PLAYER_USER_AGENT)
+	if channel, ok := proxiedCustomChannel(c.Query("channel_key_id")); ok {
+		err = proxyCustomChannel(c, channel, decoded_url, segmentCacheTTL, metrics.EndpointTS)
+	} else {
+		err = proxyCached(c, decoded_url, decoded_url, segmentCacheTTL, PLAYER_USER_AGENT, metrics.EndpointTS)
+	}
+	// Segments count against the daily quota of the client
+	middleware.CountStreamBytes(c)
+	return err
 }
 
 // ChannelsHandler fetch all channels from JioTV API
@@ -508,29 +480,40 @@ func ChannelsHandler(c *fiber.Ctx) error {
 	if err != nil {
 		return ErrorMessageHandler(c, err)
 	}
+	// Let clients know how old the channel list is
+	internalUtils.SetAgeHeader(c, apiResponse.FetchedAt)
+	apiResponse.Result, err = applyChannelPreferences(c, apiResponse.Result)
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
 	// hostUrl should be request URL like http://localhost:5001
 	hostURL := strings.ToLower(c.Protocol()) + "://" + c.Hostname()
+	// Stream URLs stay on the profile the channels were requested for
+	streamURL := hostURL + profilePrefix(c)
 
 	// Check if the query parameter "type" is set to "m3u"
 	if c.Query("type") == "m3u" {
 		// Create an M3U playlist
-		m3uContent := "#EXTM3U x-tvg-url=\"" + hostURL + "/epg.xml.gz\"\n"
+		// The EPG only has the channels of the playlist
+		epgURL := hostURL + "/epg.xml.gz" + epgFilterQuery(c)
+		// Channels played from local media have their own schedule
+		if television.HasLocalChannels() {
+			epgURL += "," + hostURL + "/local/epg.xml"
+		}
+		m3uContent := "#EXTM3U x-tvg-url=\"" + epgURL + "\"\n"
 		logoURL := hostURL + "/jtvimage"
 		for _, channel := range apiResponse.Result {
 
-			if languages != "" && !utils.ContainsString(television.LanguageMap[channel.Language], strings.Split(languages, ",")) {
-				continue
-			}
-
-			if skipGenres != "" && utils.ContainsString(television.CategoryMap[channel.Category], strings.Split(skipGenres, ",")) {
+			if !matchesPlaylistFilters(channel, languages, skipGenres) {
 				continue
 			}
 
 			var channelURL string
 			if quality != "" {
-				channelURL = fmt.Sprintf("%s/live/%s/%s.m3u8", hostURL, quality, channel.ID)
+				channelURL = fmt.Sprintf("%s/live/%s/%s.m3u8", streamURL, quality, channel.ID)
 			} else {
-				channelURL = fmt.Sprintf("%s/live/%s.m3u8", hostURL, channel.ID)
+				channelURL = fmt.Sprintf("%s/live/%s.m3u8", streamURL, channel.ID)
 			}
 			var channelLogoURL string
 			if strings.HasPrefix(channel.LogoURL, "http://") || strings.HasPrefix(channel.LogoURL, "https://") {
@@ -549,8 +532,9 @@ func ChannelsHandler(c *fiber.Ctx) error {
 			default:
 				groupTitle = television.CategoryMap[channel.Category]
 			}
-			m3uContent += fmt.Sprintf("#EXTINF:-1 tvg-id=%q tvg-name=%q tvg-logo=%q tvg-language=%q tvg-type=%q group-title=%q, %s\n%s\n",
-				channel.ID, channel.Name, channelLogoURL, television.LanguageMap[channel.Language], television.CategoryMap[channel.Category], groupTitle, channel.Name, channelURL)
+			m3uContent += fmt.Sprintf("#EXTINF:-1 tvg-id=%q tvg-name=%q tvg-logo=%q tvg-language=%q tvg-type=%q%s group-title=%q, %s\n%s\n",
+				channel.ID, channel.Name, channelLogoURL, television.LanguageMap[channel.Language], television.CategoryMap[channel.Category],
+				catchupAttributes(channel, streamURL, quality), groupTitle, channel.Name, channelURL)
 		}
 
 		// Set the Content-Disposition header for file download
@@ -560,12 +544,24 @@ func ChannelsHandler(c *fiber.Ctx) error {
 	}
 
 	for i, channel := range apiResponse.Result {
-		apiResponse.Result[i].URL = fmt.Sprintf("%s/live/%s", hostURL, channel.ID)
+		apiResponse.Result[i].URL = fmt.Sprintf("%s/live/%s", streamURL, channel.ID)
 	}
 
 	return c.JSON(apiResponse)
 }
 
+// matchesPlaylistFilters checks if a channel is in one of the comma separated languages of the l query param,
+// and not in one of the categories of the sg query param
+func matchesPlaylistFilters(channel television.Channel, languages, skipGenres string) bool {
+	if languages != "" && !utils.ContainsString(television.LanguageMap[channel.Language], strings.Split(languages, ",")) {
+		return false
+	}
+	if skipGenres != "" && utils.ContainsString(television.CategoryMap[channel.Category], strings.Split(skipGenres, ",")) {
+		return false
+	}
+	return true
+}
+
 // PlayHandler loads HTML Page with video player iframe embedded with video URL
 // URL is generated from the channel ID
 func PlayHandler(c *fiber.Ctx) error {
@@ -573,7 +569,7 @@ func PlayHandler(c *fiber.Ctx) error {
 	quality := c.Query("q")
 
 	// Ensure tokens are fresh before making API call for DRM channels
-	if err := EnsureFreshTokens(); err != nil {
+	if err := EnsureFreshProfileTokens(requestProfile(c)); err != nil {
 		utils.Log.Printf("Failed to ensure fresh tokens: %v", err)
 		// Continue with the request - tokens might still work or it might be a custom channel
 	}
@@ -584,7 +580,7 @@ func PlayHandler(c *fiber.Ctx) error {
 		// In order to check, we need to make additional request to JioTV API
 		// Quick dirty fix, otherwise we need to refactor entire LiveTV Handler approach
 		if utils.ContainsString(id, SONY_LIST) {
-			liveResult, err := TV.Live(id)
+			liveResult, err := tvFor(c).LiveCached(id)
 			if err != nil {
 				utils.Log.Println(err)
 				return internalUtils.InternalServerError(c, err)
@@ -607,7 +603,7 @@ func PlayHandler(c *fiber.Ctx) error {
 	internalUtils.SetCacheHeader(c, 3600)
 	return c.Render("views/play", fiber.Map{
 		"Title":      Title,
-		"player_url": player_url,
+		"player_url": profilePrefix(c) + player_url,
 		"ChannelID":  id,
 	})
 }
@@ -616,7 +612,7 @@ func PlayHandler(c *fiber.Ctx) error {
 func PlayerHandler(c *fiber.Ctx) error {
 	id := c.Params("id")
 	quality := c.Query("q")
-	play_url := utils.BuildHLSPlayURL(quality, id)
+	play_url := profilePrefix(c) + utils.BuildHLSPlayURL(quality, id)
 	internalUtils.SetCacheHeader(c, 3600)
 	return c.Render("views/player_hls", fiber.Map{
 		"play_url": play_url,
@@ -635,12 +631,23 @@ func PlaylistHandler(c *fiber.Ctx) error {
 	splitCategory := c.Query("c")
 	languages := c.Query("l")
 	skipGenres := c.Query("sg")
-	return c.Redirect("/channels?type=m3u&q="+quality+"&c="+splitCategory+"&l="+languages+"&sg="+skipGenres, fiber.StatusMovedPermanently)
+	redirectURL := profilePrefix(c) + "/channels?type=m3u&q=" + quality + "&c=" + splitCategory + "&l=" + languages + "&sg=" + skipGenres
+	// Favorites, custom order and channels are only passed on when requested, so existing playlist URLs stay the same
+	if favorites := c.Query("favorites"); favorites != "" {
+		redirectURL += "&favorites=" + favorites
+	}
+	if sort := c.Query("sort"); sort != "" {
+		redirectURL += "&sort=" + sort
+	}
+	if channels := c.Query("channels"); channels != "" {
+		redirectURL += "&channels=" + channels
+	}
+	return c.Redirect(redirectURL, fiber.StatusMovedPermanently)
 }
 
 // ImageHandler loads image from JioTV server
 func ImageHandler(c *fiber.Ctx) error {
-	url := "https://jiotv.catchup.cdn.jio.com/dare_images/images/" + c.Params("file")
+	url := urls.ChannelImageURL + c.Params("file")
 	return internalUtils.ProxyRequest(c, url, TV.Client, REQUEST_USER_AGENT)
 }
 
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// ChannelChangesResponse is the body of the channel lineup changes API
+type ChannelChangesResponse struct {
+	Changes []television.LineupChange `json:"changes"`
+}
+
+// ChannelChangesHandler returns the changes of the JioTV channel lineup, newest first.
+// The since query param, as RFC 3339 or Unix seconds, leaves out older changes.
+func ChannelChangesHandler(c *fiber.Ctx) error {
+	since, err := parseGuideTime(c.Query("since"), time.Time{})
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid since")
+	}
+	changes, err := television.LineupChanges(since)
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return c.JSON(ChannelChangesResponse{Changes: changes})
+}
+
+// ChannelChangesPageHandler renders the page listing the changes of the JioTV channel lineup
+func ChannelChangesPageHandler(c *fiber.Ctx) error {
+	changes, err := television.LineupChanges(time.Time{})
+	if err != nil {
+		return ErrorMessageHandler(c, err)
+	}
+	return c.Render("views/channel_changes", fiber.Map{
+		"Title":   Title,
+		"Changes": changes,
+	})
+}
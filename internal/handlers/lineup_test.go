@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestChannelChangesHandler(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/v1/channels/changes", ChannelChangesHandler)
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{name: "All changes", target: "/api/v1/channels/changes", wantStatus: fiber.StatusOK},
+		{name: "Changes since a time", target: "/api/v1/channels/changes?since=2024-01-01T00:00:00Z", wantStatus: fiber.StatusOK},
+		{name: "Invalid since", target: "/api/v1/channels/changes?since=yesterday", wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.target, nil), -1)
+			if err != nil {
+				t.Fatalf("GET %s error = %v", tt.target, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("GET %s = %d, want %d", tt.target, resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != fiber.StatusOK {
+				return
+			}
+			var body ChannelChangesResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Changes == nil {
+				t.Errorf("GET %s = %+v, %v, want an empty list of changes", tt.target, body, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/localmedia"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// localScheduleDays is the number of days from the start of today in the schedule of local channels
+const localScheduleDays = 2
+
+// LocalPlaylistHandler serves the live playlist of a channel played from local media files
+func LocalPlaylistHandler(c *fiber.Ctx) error {
+	channel, ok := localmedia.Get(c.Params("channelID"))
+	if !ok {
+		return internalUtils.NotFoundError(c, "Local channel not found")
+	}
+	// The playlist moves on with every segment
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderContentType, "application/vnd.apple.mpegurl")
+	return c.Send(channel.Playlist(time.Now()))
+}
+
+// LocalSegmentHandler serves a segment of a channel played from local media files
+func LocalSegmentHandler(c *fiber.Ctx) error {
+	channel, ok := localmedia.Get(c.Params("channelID"))
+	if !ok {
+		return internalUtils.NotFoundError(c, "Local channel not found")
+	}
+	sequence, ok := localmedia.ParseSegmentName(c.Params("segment"))
+	if !ok {
+		return internalUtils.BadRequestError(c, "Invalid segment")
+	}
+	data, err := channel.Segment(sequence)
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	internalUtils.SetCacheHeader(c, 3600)
+	c.Set(fiber.HeaderContentType, "video/mp2t")
+	err = c.Send(data)
+	// Segments count against the daily quota of the client
+	middleware.CountStreamBytes(c)
+	return err
+}
+
+// LocalEPGHandler serves the XMLTV schedule of the channels played from local media files
+func LocalEPGHandler(c *fiber.Ctx) error {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var b bytes.Buffer
+	if err := localmedia.WriteXMLTV(&b, from, from.AddDate(0, 0, localScheduleDays)); err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	c.Set(fiber.HeaderContentType, "application/xml")
+	return c.Send(b.Bytes())
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestLocalMedia(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	television.SetLocalChannels([]television.Channel{{ID: "cc_local_home", Name: "Home", URL: "/local/cc_local_home/index.m3u8"}})
+	t.Cleanup(func() { television.SetLocalChannels(nil) })
+
+	app := fiber.New()
+	app.Get("/live/:id", LiveHandler)
+	app.Get("/local/epg.xml", LocalEPGHandler)
+	app.Get("/local/:channelID/index.m3u8", LocalPlaylistHandler)
+	app.Get("/local/:channelID/:segment", LocalSegmentHandler)
+
+	tests := []struct {
+		name         string
+		target       string
+		wantStatus   int
+		wantLocation string
+		wantBody     string
+	}{
+		{name: "Live URL of a local channel", target: "/live/cc_local_home.m3u8", wantStatus: fiber.StatusFound, wantLocation: "/local/cc_local_home/index.m3u8"},
+		{name: "Playlist of an unknown channel", target: "/local/cc_local_missing/index.m3u8", wantStatus: fiber.StatusNotFound},
+		{name: "Segment of an unknown channel", target: "/local/cc_local_missing/1.ts", wantStatus: fiber.StatusNotFound},
+		{name: "Schedule", target: "/local/epg.xml", wantStatus: fiber.StatusOK, wantBody: "<tv></tv>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.target, nil), -1)
+			if err != nil {
+				t.Fatalf("GET %s error = %v", tt.target, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("GET %s = %d, want %d", tt.target, resp.StatusCode, tt.wantStatus)
+			}
+			if location := resp.Header.Get("Location"); location != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", location, tt.wantLocation)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("GET %s body = %s, want it to contain %s", tt.target, body, tt.wantBody)
+			}
+		})
+	}
+}
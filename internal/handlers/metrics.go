@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"bytes"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/metrics"
+)
+
+// metricsContentType is the content type of the Prometheus text format
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricsHandler returns the metrics of the server in the Prometheus text format
+func MetricsHandler(c *fiber.Ctx) error {
+	// Channels no longer watched drop out of the gauge
+	metrics.ActiveStreams.Reset()
+	for channel, clients := range middleware.ActiveStreams() {
+		metrics.ActiveStreams.Set(float64(clients), channel)
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.Write(&buf); err != nil {
+		return internalUtils.InternalServerError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, metricsContentType)
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	previous := config.Cfg
+	config.Cfg = config.JioTVConfig{}
+	defer func() { config.Cfg = previous }()
+
+	app := fiber.New()
+	app.Use(middleware.Limits())
+	app.Get("/render.m3u8", func(c *fiber.Ctx) error {
+		return c.SendString("#EXTM3U")
+	})
+	app.Get("/metrics", MetricsHandler)
+
+	secureurl.Init()
+	auth, _ := secureurl.EncryptURL("https://cdn.example.com/143/index.m3u8")
+	query := url.Values{"auth": {auth}, "channel_key_id": {"143"}, "channel_sig": {secureurl.SignChannel("143", "https://cdn.example.com/143/index.m3u8")}}
+	if _, err := app.Test(httptest.NewRequest("GET", "/render.m3u8?"+query.Encode(), nil), -1); err != nil {
+		t.Fatalf("GET /render.m3u8 error = %v", err)
+	}
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil), -1)
+	if err != nil {
+		t.Fatalf("GET /metrics error = %v", err)
+	}
+	if contentType := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %s, want the Prometheus text format", contentType)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, line := range []string{
+		`jiotv_active_streams{channel="143"} 1`,
+		"# TYPE jiotv_upstream_requests_total counter",
+		"# TYPE jiotv_epg_last_success_timestamp_seconds gauge",
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("GET /metrics = %s, want a line %s", body, line)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+)
+
+// openAPIPathParam matches the path params of Fiber routes, like :id
+var openAPIPathParam = regexp.MustCompile(`:(\w+)`)
+
+var (
+	openAPIOnce     sync.Once
+	openAPIDocument map[string]interface{}
+)
+
+// OpenAPIHandler returns the OpenAPI 3 document of the versioned API
+func OpenAPIHandler(c *fiber.Ctx) error {
+	openAPIOnce.Do(func() {
+		openAPIDocument = buildOpenAPIDocument(APIV1Operations())
+	})
+	return c.JSON(openAPIDocument)
+}
+
+// buildOpenAPIDocument generates the OpenAPI 3 document of API operations.
+// The schemas of the response bodies are derived from the Go types of the responses.
+func buildOpenAPIDocument(operations []APIOperation) map[string]interface{} {
+	schemas := map[string]interface{}{}
+	errorSchema := openAPISchema(reflect.TypeOf(APIError{}), schemas)
+
+	paths := map[string]interface{}{}
+	for _, operation := range operations {
+		path := openAPIPathParam.ReplaceAllString(operation.Path, "{$1}")
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+
+		parameters := []interface{}{}
+		for _, param := range operation.Params {
+			parameters = append(parameters, map[string]interface{}{
+				"name":        param.Name,
+				"in":          param.In,
+				"required":    param.In == "path",
+				"description": param.Description,
+				"schema":      map[string]interface{}{"type": param.Type},
+			})
+		}
+		item[strings.ToLower(operation.Method)] = map[string]interface{}{
+			"summary":    operation.Summary,
+			"parameters": parameters,
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "OK",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": openAPISchema(reflect.TypeOf(operation.Response), schemas)},
+					},
+				},
+				"default": map[string]interface{}{
+					"description": "Error",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": errorSchema},
+					},
+				},
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "JioTV Go API",
+			"version": constants.Version,
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+// openAPISchema returns the schema of a type. Structs are added to schemas by name and referenced.
+func openAPISchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return map[string]interface{}{"allOf": []interface{}{openAPISchema(t.Elem(), schemas)}, "nullable": true}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": openAPISchema(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": openAPISchema(t.Elem(), schemas)}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Struct:
+	default:
+		return map[string]interface{}{}
+	}
+
+	ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	if _, ok := schemas[t.Name()]; ok {
+		return ref
+	}
+	// The schema is registered before its fields so that recursive types terminate
+	schema := map[string]interface{}{"type": "object"}
+	schemas[t.Name()] = schema
+	properties := map[string]interface{}{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = openAPISchema(field.Type, schemas)
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	schema["properties"] = properties
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return ref
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"sync"
+
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// profileLocal is the fiber local holding the profile selected by the /p/:profile routes
+const profileLocal = "profile"
+
+var (
+	// profileTVs holds the Television instances of profiles other than the default one, which uses TV
+	profileTVs      = make(map[string]*television.Television)
+	profileTVsMutex sync.Mutex
+)
+
+// ProfileMiddleware selects the credential profile of the /p/:profile routes
+func ProfileMiddleware(c *fiber.Ctx) error {
+	profile := c.Params("profile")
+	if !utils.ProfileExists(profile) {
+		return internalUtils.NotFoundError(c, "Profile "+profile+" not found")
+	}
+	c.Locals(profileLocal, profile)
+	return c.Next()
+}
+
+// requestProfile returns the profile selected by the request, or the default profile
+func requestProfile(c *fiber.Ctx) string {
+	if profile, ok := c.Locals(profileLocal).(string); ok && profile != "" {
+		return profile
+	}
+	return utils.GetDefaultProfile()
+}
+
+// profilePrefix returns the path prefix of the profile selected by the request, to keep generated URLs on that profile.
+// Requests without a profile path get an empty prefix.
+func profilePrefix(c *fiber.Ctx) string {
+	if profile, ok := c.Locals(profileLocal).(string); ok && profile != "" {
+		return "/p/" + profile
+	}
+	return ""
+}
+
+// tvFor returns the Television instance of the profile selected by the request
+func tvFor(c *fiber.Ctx) *television.Television {
+	return tvForProfile(requestProfile(c))
+}
+
+// tvForProfile returns the Television instance of a profile, creating it from the stored credentials on first use
+func tvForProfile(profile string) *television.Television {
+	if profile == utils.GetDefaultProfile() {
+		return TV
+	}
+
+	profileTVsMutex.Lock()
+	defer profileTVsMutex.Unlock()
+	if tv, ok := profileTVs[profile]; ok {
+		return tv
+	}
+	credentials, err := utils.GetProfileCredentials(profile)
+	if err != nil {
+		utils.Log.Printf("Login error for profile %s: %v", profile, err)
+	}
+	tv := television.New(credentials)
+	profileTVs[profile] = tv
+	return tv
+}
+
+// setProfileTV replaces the Television instance of a profile, e.g. after its tokens were refreshed
+func setProfileTV(profile string, tv *television.Television) {
+	if profile == utils.GetDefaultProfile() {
+		TV = tv
+		return
+	}
+	profileTVsMutex.Lock()
+	defer profileTVsMutex.Unlock()
+	profileTVs[profile] = tv
+}
+
+// resetProfileTVs drops the Television instances of all profiles, so they are created again from the store
+func resetProfileTVs() {
+	profileTVsMutex.Lock()
+	defer profileTVsMutex.Unlock()
+	profileTVs = make(map[string]*television.Television)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestProfileRoutes(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(os.Stdout, "", log.LstdFlags)
+	}
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+	if err := utils.WriteProfileCredentials("work", &utils.JIOTV_CREDENTIALS{SSOToken: "sso", CRM: "crm-work", UniqueID: "uid"}); err != nil {
+		t.Fatalf("WriteProfileCredentials() error = %v", err)
+	}
+
+	originalTV := TV
+	t.Cleanup(func() {
+		TV = originalTV
+		resetProfileTVs()
+	})
+	TV = &television.Television{Crm: "crm-default"}
+	resetProfileTVs()
+
+	app := fiber.New()
+	app.Get("/playlist.m3u", PlaylistHandler)
+	profile := app.Group("/p/:profile", ProfileMiddleware)
+	profile.Get("/playlist.m3u", PlaylistHandler)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantURL    string
+	}{
+		{name: "Default profile", path: "/playlist.m3u", wantStatus: fiber.StatusMovedPermanently, wantURL: "/channels?type=m3u&q=&c=&l=&sg="},
+		{name: "Named profile", path: "/p/work/playlist.m3u", wantStatus: fiber.StatusMovedPermanently, wantURL: "/p/work/channels?type=m3u&q=&c=&l=&sg="},
+		{name: "Unknown profile", path: "/p/missing/playlist.m3u", wantStatus: fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			if err != nil {
+				t.Fatalf("GET %s error = %v", tt.path, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if location := resp.Header.Get("Location"); location != tt.wantURL {
+				t.Errorf("Location = %q, want %q", location, tt.wantURL)
+			}
+		})
+	}
+
+	t.Run("Profiles use their own credentials", func(t *testing.T) {
+		if tv := tvForProfile(utils.DefaultProfile); tv != TV {
+			t.Error("default profile should use TV")
+		}
+		work := tvForProfile("work")
+		if work.Crm != "crm-work" {
+			t.Errorf("work profile Crm = %q, want crm-work", work.Crm)
+		}
+		if tvForProfile("work") != work {
+			t.Error("Television of a profile should be reused")
+		}
+		replacement := &television.Television{Crm: "crm-work"}
+		setProfileTV("work", replacement)
+		if tvForProfile("work") != replacement || TV.Crm != "crm-default" {
+			t.Error("setProfileTV() should only replace the Television of that profile")
+		}
+	})
+}
+
+func TestProfileLogout(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(os.Stdout, "", log.LstdFlags)
+	}
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+	if err := utils.WriteJIOTVCredentials(&utils.JIOTV_CREDENTIALS{SSOToken: "sso", CRM: "crm-default", UniqueID: "uid"}); err != nil {
+		t.Fatalf("WriteJIOTVCredentials() error = %v", err)
+	}
+	if err := utils.WriteProfileCredentials("work", &utils.JIOTV_CREDENTIALS{SSOToken: "sso", CRM: "crm-work", UniqueID: "uid"}); err != nil {
+		t.Fatalf("WriteProfileCredentials() error = %v", err)
+	}
+
+	originalTV := TV
+	t.Cleanup(func() {
+		TV = originalTV
+		resetProfileTVs()
+	})
+
+	app := fiber.New()
+	profile := app.Group("/p/:profile", ProfileMiddleware)
+	profile.Get("/logout", LogoutHandler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/p/work/logout", nil))
+	if err != nil {
+		t.Fatalf("GET /p/work/logout error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusFound {
+		t.Errorf("status = %d, want %d", resp.StatusCode, fiber.StatusFound)
+	}
+	if utils.ProfileExists("work") {
+		t.Error("work profile should be logged out")
+	}
+	credentials, err := utils.GetJIOTVCredentials()
+	if err != nil || credentials.CRM != "crm-default" {
+		t.Errorf("default profile credentials = %+v, %v, want them kept", credentials, err)
+	}
+}
+
+// bindingViews renders the bindings of a view as JSON, so tests can check them without the templates
+type bindingViews struct{}
+
+func (bindingViews) Load() error { return nil }
+
+func (bindingViews) Render(w io.Writer, _ string, binding interface{}, _ ...string) error {
+	return json.NewEncoder(w).Encode(binding)
+}
+
+func TestProfilePlayerRoutes(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(os.Stdout, "", log.LstdFlags)
+	}
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+	if err := utils.WriteProfileCredentials("work", &utils.JIOTV_CREDENTIALS{CRM: "crm-work"}); err != nil {
+		t.Fatalf("WriteProfileCredentials() error = %v", err)
+	}
+	secureurl.Init()
+
+	originalTV, originalDRM := TV, EnableDRM
+	t.Cleanup(func() {
+		TV, EnableDRM = originalTV, originalDRM
+		resetProfileTVs()
+	})
+	TV = &television.Television{Crm: "crm-default"}
+	EnableDRM = false
+	resetProfileTVs()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/dash+xml")
+		w.Write([]byte("<MPD><Period><BaseURL>https://cdn.example.com/dash/</BaseURL></Period></MPD>"))
+	}))
+	defer upstream.Close()
+	manifest, err := secureurl.EncryptURL(upstream.URL + "/index.mpd")
+	if err != nil {
+		t.Fatalf("EncryptURL() error = %v", err)
+	}
+
+	app := fiber.New(fiber.Config{Views: bindingViews{}})
+	profile := app.Group("/p/:profile", ProfileMiddleware)
+	profile.Get("/play/:id", PlayHandler)
+	profile.Get("/player/:id", PlayerHandler)
+	profile.Get("/render.mpd", MpdHandler)
+
+	tests := []struct {
+		name     string
+		path     string
+		wantBody string
+	}{
+		{name: "Play page", path: "/p/work/play/143", wantBody: `"player_url":"/p/work/player/143?q="`},
+		{name: "HLS player", path: "/p/work/player/143?q=high", wantBody: `"play_url":"/p/work/live/high/143.m3u8"`},
+		{name: "DASH manifest", path: "/p/work/render.mpd?auth=" + manifest, wantBody: "<BaseURL>/p/work/render.dash/dash/</BaseURL>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil), -1)
+			if err != nil {
+				t.Fatalf("GET %s error = %v", tt.path, err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != fiber.StatusOK || !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("GET %s = %d %s, want it to contain %s", tt.path, resp.StatusCode, body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/dvr"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// RecordingsResponse is the body of the recordings API
+type RecordingsResponse struct {
+	Recordings []dvr.Recording `json:"recordings"`
+}
+
+// RecordingRulesResponse is the body of the recording rules API
+type RecordingRulesResponse struct {
+	Rules []dvr.Rule `json:"rules"`
+}
+
+// AddRecordingRequest is the body of a request scheduling a recording
+type AddRecordingRequest struct {
+	ChannelID string `json:"channel_id"`
+	// Start is the start time of the recording, as RFC 3339 or Unix seconds
+	Start string `json:"start"`
+	// End is the end time of the recording. Without it, the programme of the EPG airing at Start is recorded.
+	End   string `json:"end"`
+	Title string `json:"title"`
+}
+
+// AddRecordingRuleRequest is the body of a request adding a series rule
+type AddRecordingRuleRequest struct {
+	Pattern   string `json:"pattern"`
+	ChannelID string `json:"channel_id"`
+	KeepLast  int    `json:"keep_last"`
+}
+
+// InitDVR schedules the saved recordings, which are recorded with the default profile.
+// It must be called after Init.
+func InitDVR() {
+	dvr.Init(dvrSource{})
+}
+
+// dvrSource fetches the channels recorded by the DVR with the default profile
+type dvrSource struct{}
+
+// PlaylistURL returns the live URL of a channel, refreshing the tokens of the default profile if needed
+func (dvrSource) PlaylistURL(channelID string) (string, error) {
+	if isCustomChannel(channelID) {
+		channel, exists := television.GetCustomChannelByID(channelID)
+		if !exists {
+			return "", fmt.Errorf("custom channel with ID %s not found", channelID)
+		}
+		return channel.URL, nil
+	}
+
+	profile := utils.GetDefaultProfile()
+	if err := EnsureFreshProfileTokens(profile); err != nil {
+		utils.Log.Printf("Failed to ensure fresh tokens: %v", err)
+		// Continue with the request - tokens might still work
+	}
+	liveResult, err := tvForProfile(profile).LiveCached(channelID)
+	if err != nil {
+		return "", err
+	}
+	if liveResult.Bitrates.Auto == "" {
+		return "", fmt.Errorf("no stream found for channel id: %s Status: %s", channelID, liveResult.Message)
+	}
+	return liveResult.Bitrates.Auto, nil
+}
+
+// Invalidate drops the cached live URL of a channel, so the next one is requested with fresh tokens
+func (dvrSource) Invalidate(channelID string) {
+	tvForProfile(utils.GetDefaultProfile()).InvalidateLive(channelID)
+}
+
+// Get fetches a playlist or segment like RenderHandler, or with the channel's headers for custom channels
+func (dvrSource) Get(channelID, url string) ([]byte, int, string, error) {
+	if channel, exists := television.GetCustomChannelByID(channelID); exists && isCustomChannel(channelID) {
+		body, statusCode, _, err := TV.RenderCustom(url, channel)
+		return body, statusCode, "", err
+	}
+	return tvForProfile(utils.GetDefaultProfile()).Fetch(url)
+}
+
+// Key fetches a key with the cookies and headers of RenderKeyHandler
+func (dvrSource) Key(channelID, url string) ([]byte, int, error) {
+	if channel, exists := television.GetCustomChannelByID(channelID); exists && isCustomChannel(channelID) {
+		body, statusCode, _, err := TV.RenderCustom(url, channel)
+		return body, statusCode, err
+	}
+	return tvForProfile(utils.GetDefaultProfile()).Key(url, channelID)
+}
+
+// RecordingsHandler renders the page listing the recordings and series rules
+func RecordingsHandler(c *fiber.Ctx) error {
+	return c.Render("views/recordings", fiber.Map{
+		"Title": Title,
+	})
+}
+
+// GetRecordingsHandler returns the recordings
+func GetRecordingsHandler(c *fiber.Ctx) error {
+	list, err := dvr.List()
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return c.JSON(RecordingsResponse{Recordings: list})
+}
+
+// AddRecordingHandler schedules the recording of a channel between two times, or of a programme of the EPG
+func AddRecordingHandler(c *fiber.Ctx) error {
+	var body AddRecordingRequest
+	if err := c.BodyParser(&body); err != nil {
+		return internalUtils.BadRequestError(c, "Invalid JSON")
+	}
+	if body.ChannelID == "" || body.Start == "" {
+		return internalUtils.BadRequestError(c, "channel_id or start not provided")
+	}
+	start, err := parseGuideTime(body.Start, time.Time{})
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid start")
+	}
+	end, err := parseGuideTime(body.End, time.Time{})
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid end")
+	}
+
+	var recording dvr.Recording
+	if end.IsZero() {
+		recording, err = dvr.ScheduleProgramme(body.ChannelID, start)
+	} else {
+		recording, err = dvr.Schedule(body.ChannelID, body.Title, start, end)
+	}
+	if err != nil {
+		return dvrErrorResponse(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(recording)
+}
+
+// CancelRecordingHandler stops a scheduled or running recording, keeping what was recorded
+func CancelRecordingHandler(c *fiber.Ctx) error {
+	recording, err := dvr.Cancel(c.Params("id"))
+	if err != nil {
+		return dvrErrorResponse(c, err)
+	}
+	return c.JSON(recording)
+}
+
+// DeleteRecordingHandler removes a recording and its file
+func DeleteRecordingHandler(c *fiber.Ctx) error {
+	if err := dvr.Delete(c.Params("id")); err != nil {
+		return dvrErrorResponse(c, err)
+	}
+	return GetRecordingsHandler(c)
+}
+
+// RecordingFileHandler downloads the recorded file of a recording
+func RecordingFileHandler(c *fiber.Ctx) error {
+	recording, err := dvr.Get(c.Params("id"))
+	if err != nil {
+		return dvrErrorResponse(c, err)
+	}
+	if !utils.FileExists(dvr.FilePath(recording)) {
+		return internalUtils.NotFoundError(c, "Nothing was recorded yet")
+	}
+	return c.Download(dvr.FilePath(recording), recording.File)
+}
+
+// GetRecordingRulesHandler returns the series rules
+func GetRecordingRulesHandler(c *fiber.Ctx) error {
+	rules, err := dvr.Rules()
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return c.JSON(RecordingRulesResponse{Rules: rules})
+}
+
+// AddRecordingRuleHandler adds a series rule recording the upcoming programmes matching a title pattern
+func AddRecordingRuleHandler(c *fiber.Ctx) error {
+	var body AddRecordingRuleRequest
+	if err := c.BodyParser(&body); err != nil {
+		return internalUtils.BadRequestError(c, "Invalid JSON")
+	}
+	if body.Pattern == "" {
+		return internalUtils.BadRequestError(c, "pattern not provided")
+	}
+	rule, err := dvr.AddRule(body.Pattern, body.ChannelID, body.KeepLast)
+	if err != nil {
+		return dvrErrorResponse(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// RemoveRecordingRuleHandler removes a series rule and the recordings it scheduled or skipped which have not started
+func RemoveRecordingRuleHandler(c *fiber.Ctx) error {
+	if err := dvr.RemoveRule(c.Params("id")); err != nil {
+		return dvrErrorResponse(c, err)
+	}
+	return GetRecordingRulesHandler(c)
+}
+
+// dvrErrorResponse sends the response of an error of the dvr package
+func dvrErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, dvr.ErrNotFound), errors.Is(err, dvr.ErrRuleNotFound),
+		errors.Is(err, dvr.ErrNoGuide), errors.Is(err, dvr.ErrProgrammeNotFound):
+		return internalUtils.NotFoundError(c, err.Error())
+	case errors.Is(err, dvr.ErrExists), errors.Is(err, dvr.ErrNotActive):
+		return internalUtils.ErrorResponse(c, fiber.StatusConflict, err.Error())
+	case errors.Is(err, dvr.ErrInvalidChannel), errors.Is(err, dvr.ErrInvalidRange), errors.Is(err, dvr.ErrEnded),
+		errors.Is(err, dvr.ErrInvalidPattern), errors.Is(err, dvr.ErrInvalidKeepLast):
+		return internalUtils.BadRequestError(c, err.Error())
+	default:
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/dvr"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+)
+
+func TestRecordingsAPI(t *testing.T) {
+	setupTestStore(t)
+	scheduler.Init()
+	defer scheduler.Stop()
+	writeTestGuide(t)
+
+	app := fiber.New()
+	app.Get("/api/recordings", GetRecordingsHandler)
+	app.Post("/api/recordings", AddRecordingHandler)
+	app.Post("/api/recordings/:id/cancel", CancelRecordingHandler)
+	app.Get("/api/recordings/:id/file", RecordingFileHandler)
+	app.Delete("/api/recordings/:id", DeleteRecordingHandler)
+	app.Get("/api/recording-rules", GetRecordingRulesHandler)
+	app.Post("/api/recording-rules", AddRecordingRuleHandler)
+	app.Delete("/api/recording-rules/:id", RemoveRecordingRuleHandler)
+
+	// The guide has an upcoming programme starting in the next hour
+	upcoming := time.Now().Truncate(time.Hour).Add(time.Hour)
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "Invalid JSON", body: `{`, wantStatus: fiber.StatusBadRequest},
+		{name: "Missing start", body: `{"channel_id": "143"}`, wantStatus: fiber.StatusBadRequest},
+		{name: "Invalid end", body: `{"channel_id": "143", "start": "` + upcoming.Format(time.RFC3339) + `", "end": "later"}`, wantStatus: fiber.StatusBadRequest},
+		{name: "No programme", body: `{"channel_id": "144", "start": "` + upcoming.Format(time.RFC3339) + `"}`, wantStatus: fiber.StatusNotFound},
+		{name: "Ended", body: `{"channel_id": "143", "start": "` + upcoming.Add(-3*time.Hour).Format(time.RFC3339) + `", "end": "` + upcoming.Add(-2*time.Hour).Format(time.RFC3339) + `"}`, wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := doJSON(t, app, "POST", "/api/recordings", tt.body, nil); status != tt.wantStatus {
+				t.Errorf("POST /api/recordings = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+
+	// Without end, the programme of the guide is recorded
+	var recording dvr.Recording
+	body := `{"channel_id": "143", "start": "` + strconv.FormatInt(upcoming.Unix(), 10) + `"}`
+	if status := doJSON(t, app, "POST", "/api/recordings", body, &recording); status != fiber.StatusCreated {
+		t.Fatalf("POST /api/recordings = %d, want 201", status)
+	}
+	if recording.Title != "Upcoming" || !recording.End.Equal(upcoming.Add(time.Hour)) || recording.Status != dvr.StatusScheduled {
+		t.Errorf("POST /api/recordings = %+v, want the scheduled recording of Upcoming", recording)
+	}
+	if status := doJSON(t, app, "POST", "/api/recordings", body, nil); status != fiber.StatusConflict {
+		t.Errorf("POST /api/recordings of a scheduled programme = %d, want 409", status)
+	}
+	if status := doJSON(t, app, "GET", "/api/recordings/"+recording.ID+"/file", "", nil); status != fiber.StatusNotFound {
+		t.Errorf("GET file of a scheduled recording = %d, want 404", status)
+	}
+
+	var cancelled dvr.Recording
+	if status := doJSON(t, app, "POST", "/api/recordings/"+recording.ID+"/cancel", "", &cancelled); status != fiber.StatusOK || cancelled.Status != dvr.StatusCancelled {
+		t.Errorf("POST cancel = %d %+v, want the cancelled recording", status, cancelled)
+	}
+	var list RecordingsResponse
+	if status := doJSON(t, app, "DELETE", "/api/recordings/"+recording.ID, "", &list); status != fiber.StatusOK || len(list.Recordings) != 0 {
+		t.Errorf("DELETE /api/recordings/%s = %d %+v, want no recordings left", recording.ID, status, list)
+	}
+	if status := doJSON(t, app, "DELETE", "/api/recordings/"+recording.ID, "", nil); status != fiber.StatusNotFound {
+		t.Errorf("DELETE of a deleted recording = %d, want 404", status)
+	}
+
+	// A series rule schedules the matching programmes of the guide right away
+	if status := doJSON(t, app, "POST", "/api/recording-rules", `{"pattern": "("}`, nil); status != fiber.StatusBadRequest {
+		t.Errorf("POST /api/recording-rules with an invalid pattern = %d, want 400", status)
+	}
+	var rule dvr.Rule
+	if status := doJSON(t, app, "POST", "/api/recording-rules", `{"pattern": "^upcoming$", "keep_last": 3}`, &rule); status != fiber.StatusCreated {
+		t.Fatalf("POST /api/recording-rules = %d, want 201", status)
+	}
+	if status := doJSON(t, app, "GET", "/api/recordings", "", &list); status != fiber.StatusOK || len(list.Recordings) != 1 || list.Recordings[0].RuleID != rule.ID {
+		t.Errorf("GET /api/recordings after adding a rule = %d %+v, want the recording of Upcoming", status, list)
+	}
+	var rules RecordingRulesResponse
+	if status := doJSON(t, app, "DELETE", "/api/recording-rules/"+rule.ID, "", &rules); status != fiber.StatusOK || len(rules.Rules) != 0 {
+		t.Errorf("DELETE /api/recording-rules/%s = %d %+v, want no rules left", rule.ID, status, rules)
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/reminders"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// reminderEventsKeepAlive is the interval of the comments keeping the reminder event stream open through proxies
+const reminderEventsKeepAlive = 30 * time.Second
+
+// RemindersResponse is the body of the reminders API
+type RemindersResponse struct {
+	Reminders []reminders.Reminder `json:"reminders"`
+}
+
+// AddReminderRequest is the body of a request setting a reminder
+type AddReminderRequest struct {
+	ChannelID string `json:"channel_id"`
+	// Start is the start time of the programme, as RFC 3339 or Unix seconds
+	Start string `json:"start"`
+	// MinutesBefore defaults to the reminder_minutes config
+	MinutesBefore *int `json:"minutes_before"`
+}
+
+// GetRemindersHandler returns the pending reminders
+func GetRemindersHandler(c *fiber.Ctx) error {
+	list, err := reminders.List()
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return c.JSON(RemindersResponse{Reminders: list})
+}
+
+// AddReminderHandler sets a reminder on a programme of the EPG
+func AddReminderHandler(c *fiber.Ctx) error {
+	var body AddReminderRequest
+	if err := c.BodyParser(&body); err != nil {
+		return internalUtils.BadRequestError(c, "Invalid JSON")
+	}
+	if body.ChannelID == "" || body.Start == "" {
+		return internalUtils.BadRequestError(c, "channel_id or start not provided")
+	}
+	start, err := parseGuideTime(body.Start, time.Time{})
+	if err != nil {
+		return internalUtils.BadRequestError(c, "Invalid start")
+	}
+	minutesBefore := reminders.DefaultMinutesBefore()
+	if body.MinutesBefore != nil {
+		minutesBefore = *body.MinutesBefore
+	}
+
+	reminder, err := reminders.Add(guideChannelID(body.ChannelID), start, minutesBefore)
+	switch {
+	case errors.Is(err, reminders.ErrNoGuide), errors.Is(err, reminders.ErrProgrammeNotFound):
+		return internalUtils.NotFoundError(c, err.Error())
+	case errors.Is(err, reminders.ErrProgrammeStarted), errors.Is(err, reminders.ErrInvalidMinutes):
+		return internalUtils.BadRequestError(c, err.Error())
+	case err != nil:
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(reminder)
+}
+
+// RemoveReminderHandler removes a reminder
+func RemoveReminderHandler(c *fiber.Ctx) error {
+	err := reminders.Remove(c.Params("id"))
+	if errors.Is(err, reminders.ErrNotFound) {
+		return internalUtils.NotFoundError(c, err.Error())
+	}
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return GetRemindersHandler(c)
+}
+
+// ReminderEventsHandler streams the reminders firing as server-sent events until the client disconnects
+func ReminderEventsHandler(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	notifications, unsubscribe := reminders.Subscribe()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		ticker := time.NewTicker(reminderEventsKeepAlive)
+		defer ticker.Stop()
+		writeReminderEvents(w, notifications, ticker.C)
+	})
+	return nil
+}
+
+// writeReminderEvents writes a reminder event for each notification and a comment on each keep alive tick.
+// It returns when writing fails because the client disconnected, or when notifications is closed.
+func writeReminderEvents(w *bufio.Writer, notifications <-chan reminders.Notification, keepAlive <-chan time.Time) {
+	// Sending something right away lets the browser know the stream is open
+	fmt.Fprint(w, ": connected\n\n")
+	if err := w.Flush(); err != nil {
+		return
+	}
+	for {
+		select {
+		case notification, ok := <-notifications:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(notification)
+			if err != nil {
+				utils.Log.Println(err)
+				continue
+			}
+			fmt.Fprintf(w, "event: reminder\ndata: %s\n\n", data)
+		case <-keepAlive:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
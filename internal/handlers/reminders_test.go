@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/reminders"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+)
+
+func TestRemindersAPI(t *testing.T) {
+	setupTestStore(t)
+	scheduler.Init()
+	defer scheduler.Stop()
+	writeTestGuide(t)
+
+	app := fiber.New()
+	app.Get("/api/reminders", GetRemindersHandler)
+	app.Post("/api/reminders", AddReminderHandler)
+	app.Delete("/api/reminders/:id", RemoveReminderHandler)
+
+	// The guide has an upcoming programme starting in the next hour
+	upcoming := time.Now().Truncate(time.Hour).Add(time.Hour)
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "Invalid JSON", body: `{`, wantStatus: fiber.StatusBadRequest},
+		{name: "Missing start", body: `{"channel_id": "143"}`, wantStatus: fiber.StatusBadRequest},
+		{name: "Invalid start", body: `{"channel_id": "143", "start": "tomorrow"}`, wantStatus: fiber.StatusBadRequest},
+		{name: "Unknown programme", body: `{"channel_id": "143", "start": "` + upcoming.Add(time.Minute).Format(time.RFC3339) + `"}`, wantStatus: fiber.StatusNotFound},
+		{name: "Started programme", body: `{"channel_id": "143", "start": "` + upcoming.Add(-time.Hour).Format(time.RFC3339) + `"}`, wantStatus: fiber.StatusBadRequest},
+		{name: "Negative minutes", body: `{"channel_id": "143", "start": "` + upcoming.Format(time.RFC3339) + `", "minutes_before": -5}`, wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := doJSON(t, app, "POST", "/api/reminders", tt.body, nil); status != tt.wantStatus {
+				t.Errorf("POST /api/reminders = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+
+	var reminder reminders.Reminder
+	body := `{"channel_id": "sl143", "start": "` + strconv.FormatInt(upcoming.Unix(), 10) + `"}`
+	if status := doJSON(t, app, "POST", "/api/reminders", body, &reminder); status != fiber.StatusCreated {
+		t.Fatalf("POST /api/reminders = %d, want 201", status)
+	}
+	if reminder.ChannelID != "143" || reminder.Title != "Upcoming" || reminder.MinutesBefore != reminders.DefaultMinutesBefore() {
+		t.Errorf("POST /api/reminders = %+v, want a reminder of Upcoming with the default minutes", reminder)
+	}
+
+	var list RemindersResponse
+	if status := doJSON(t, app, "GET", "/api/reminders", "", &list); status != fiber.StatusOK || len(list.Reminders) != 1 {
+		t.Errorf("GET /api/reminders = %d %+v, want the reminder", status, list)
+	}
+	if status := doJSON(t, app, "DELETE", "/api/reminders/"+reminder.ID, "", &list); status != fiber.StatusOK || len(list.Reminders) != 0 {
+		t.Errorf("DELETE /api/reminders/%s = %d %+v, want no reminders left", reminder.ID, status, list)
+	}
+	if status := doJSON(t, app, "DELETE", "/api/reminders/"+reminder.ID, "", nil); status != fiber.StatusNotFound {
+		t.Errorf("DELETE of a removed reminder = %d, want 404", status)
+	}
+}
+
+func TestWriteReminderEvents(t *testing.T) {
+	notifications := make(chan reminders.Notification, 1)
+	keepAlive := make(chan time.Time, 1)
+	notifications <- reminders.Notification{Event: "reminder", Message: "News starts in 5 min on News"}
+	close(notifications)
+	keepAlive <- time.Now()
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	writeReminderEvents(w, notifications, keepAlive)
+
+	got := buf.String()
+	if !strings.HasPrefix(got, ": connected\n\n") {
+		t.Errorf("event stream does not start with a comment: %q", got)
+	}
+	if !strings.Contains(got, "event: reminder\ndata: {\"event\":\"reminder\",\"message\":\"News starts in 5 min on News\"") {
+		t.Errorf("event stream is missing the reminder event: %q", got)
+	}
+}
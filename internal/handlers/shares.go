@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/share"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// ShareLink is a share link with its URLs on this server
+type ShareLink struct {
+	share.Link
+	PlaylistURL string `json:"playlist_url"`
+	// PlayURL is the web player URL of links giving access to a single channel
+	PlayURL string `json:"play_url,omitempty"`
+}
+
+// SharesResponse is the body of the share links API
+type SharesResponse struct {
+	Shares []ShareLink `json:"shares"`
+}
+
+// AddShareRequest is the body of a request minting a share link
+type AddShareRequest struct {
+	Name       string   `json:"name"`
+	ChannelIDs []string `json:"channel_ids"`
+	Languages  []int    `json:"languages"`
+	Categories []int    `json:"categories"`
+	// ExpiresIn is how long the link is valid, as a duration like 24h
+	ExpiresIn string `json:"expires_in"`
+	// Expires is when the link expires, as RFC 3339 or Unix seconds. It is used when ExpiresIn is not set.
+	Expires string `json:"expires"`
+}
+
+// SharesHandler renders the page listing the share links
+func SharesHandler(c *fiber.Ctx) error {
+	return c.Render("views/shares", fiber.Map{
+		"Title":      Title,
+		"Categories": television.CategoryMap,
+		"Languages":  television.LanguageMap,
+	})
+}
+
+// GetSharesHandler returns the share links which have not expired
+func GetSharesHandler(c *fiber.Ctx) error {
+	links, err := share.List(time.Now())
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	response := SharesResponse{Shares: make([]ShareLink, 0, len(links))}
+	for _, link := range links {
+		response.Shares = append(response.Shares, shareLink(c, link))
+	}
+	return c.JSON(response)
+}
+
+// AddShareHandler mints a share link for some channels, languages or categories
+func AddShareHandler(c *fiber.Ctx) error {
+	var body AddShareRequest
+	if err := c.BodyParser(&body); err != nil {
+		return internalUtils.BadRequestError(c, "Invalid JSON")
+	}
+
+	now := time.Now()
+	var expires time.Time
+	if body.ExpiresIn != "" {
+		duration, err := time.ParseDuration(body.ExpiresIn)
+		if err != nil {
+			return internalUtils.BadRequestError(c, "Invalid expires_in")
+		}
+		expires = now.Add(duration)
+	} else {
+		var err error
+		if expires, err = parseGuideTime(body.Expires, time.Time{}); err != nil {
+			return internalUtils.BadRequestError(c, "Invalid expires")
+		}
+		if expires.IsZero() {
+			return internalUtils.BadRequestError(c, "expires_in or expires not provided")
+		}
+	}
+
+	scope := share.Scope{Languages: body.Languages, Categories: body.Categories}
+	for _, id := range body.ChannelIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			scope.ChannelIDs = append(scope.ChannelIDs, id)
+		}
+	}
+	link, err := share.Create(body.Name, scope, expires, now)
+	if errors.Is(err, share.ErrEmptyScope) || errors.Is(err, share.ErrInvalidExpiry) {
+		return internalUtils.BadRequestError(c, err.Error())
+	}
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(shareLink(c, link))
+}
+
+// RevokeShareHandler revokes a share link, so that its token is no longer accepted
+func RevokeShareHandler(c *fiber.Ctx) error {
+	err := share.Revoke(c.Params("id"), time.Now())
+	if errors.Is(err, share.ErrNotFound) {
+		return internalUtils.NotFoundError(c, err.Error())
+	}
+	if err != nil {
+		utils.Log.Println(err)
+		return internalUtils.InternalServerError(c, err.Error())
+	}
+	return GetSharesHandler(c)
+}
+
+// shareLink adds the URLs on this server to a share link
+func shareLink(c *fiber.Ctx, link share.Link) ShareLink {
+	serverURL := strings.ToLower(c.Protocol()) + "://" + c.Hostname()
+	return ShareLink{
+		Link:        link,
+		PlaylistURL: link.PlaylistURL(serverURL),
+		PlayURL:     link.PlayURL(serverURL),
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSharesAPI(t *testing.T) {
+	setupTestStore(t)
+
+	app := fiber.New()
+	app.Get("/api/shares", GetSharesHandler)
+	app.Post("/api/shares", AddShareHandler)
+	app.Delete("/api/shares/:id", RevokeShareHandler)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "Invalid JSON", body: `{`, wantStatus: fiber.StatusBadRequest},
+		{name: "No expiry", body: `{"channel_ids": ["143"]}`, wantStatus: fiber.StatusBadRequest},
+		{name: "Invalid expires_in", body: `{"channel_ids": ["143"], "expires_in": "tomorrow"}`, wantStatus: fiber.StatusBadRequest},
+		{name: "Expired", body: `{"channel_ids": ["143"], "expires": "2020-01-01T00:00:00Z"}`, wantStatus: fiber.StatusBadRequest},
+		{name: "Empty scope", body: `{"channel_ids": [" "], "expires_in": "1h"}`, wantStatus: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := doJSON(t, app, "POST", "/api/shares", tt.body, nil); status != tt.wantStatus {
+				t.Errorf("POST /api/shares = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+
+	var link ShareLink
+	if status := doJSON(t, app, "POST", "/api/shares", `{"name": "friend", "channel_ids": ["143"], "expires_in": "2h"}`, &link); status != fiber.StatusCreated {
+		t.Fatalf("POST /api/shares = %d, want %d", status, fiber.StatusCreated)
+	}
+	if !strings.HasPrefix(link.PlaylistURL, "http://example.com/playlist.m3u?token=") || !strings.HasPrefix(link.PlayURL, "http://example.com/play/143?token=") {
+		t.Errorf("POST /api/shares = %+v, want the playlist and player URLs", link)
+	}
+
+	var list SharesResponse
+	if status := doJSON(t, app, "GET", "/api/shares", "", &list); status != fiber.StatusOK || len(list.Shares) != 1 || list.Shares[0].ID != link.ID {
+		t.Errorf("GET /api/shares = %d %+v, want the link", status, list)
+	}
+	if status := doJSON(t, app, "DELETE", "/api/shares/"+link.ID, "", &list); status != fiber.StatusOK || len(list.Shares) != 0 {
+		t.Errorf("DELETE /api/shares/%s = %d %+v, want no links left", link.ID, status, list)
+	}
+	if status := doJSON(t, app, "DELETE", "/api/shares/"+link.ID, "", nil); status != fiber.StatusNotFound {
+		t.Errorf("DELETE /api/shares/%s twice = %d, want %d", link.ID, status, fiber.StatusNotFound)
+	}
+}
@@ -12,6 +12,8 @@ type LoginVerifyOTPRequestBodyData struct {
 	MobileNumber string `json:"number" xml:"number" form:"number"`
 	// OTP received on mobile number
 	OTP string `json:"otp" xml:"otp" form:"otp"`
+	// Profile to save the credentials to. Defaults to the default profile.
+	Profile string `json:"profile" xml:"profile" form:"profile"`
 }
 
 // RefreshTokenResponse represents Response body for refresh token request
@@ -32,4 +34,6 @@ type DrmMpdOutput struct {
 	PlayUrl     string
 	Tv_url_host string
 	Tv_url_path string
+	// Channel_sig signs the channel together with the host and path of the /render.dash segments
+	Channel_sig string
 }
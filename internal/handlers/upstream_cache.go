@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+	internalUtils "github.com/jiotv-go/jiotv_go/v3/internal/utils"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/cache"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/metrics"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// Media playlists change with every new segment, so they are shared only briefly
+	mediaPlaylistCacheTTL = 2 * time.Second
+	// Master playlists only list the variants of a channel
+	masterPlaylistCacheTTL = 30 * time.Second
+	// Live segments never change once published
+	segmentCacheTTL = 2 * time.Minute
+	// Keys rotate rarely
+	keyCacheTTL = 5 * time.Minute
+)
+
+// upstreamCache is shared by all clients and keyed by the decrypted upstream URL, along with the account
+// for responses fetched with the credentials of a profile, or the channel for custom channels. nil when disabled.
+var upstreamCache *cache.Cache
+
+// initUpstreamCache creates the upstream cache once. The cache is kept when handlers are re-initialized after login.
+func initUpstreamCache() {
+	if config.Cfg.DisableUpstreamCache {
+		upstreamCache = nil
+		return
+	}
+	if upstreamCache != nil {
+		return
+	}
+	size := config.Cfg.UpstreamCacheSize
+	if size <= 0 {
+		size = constants.DefaultUpstreamCacheSize
+	}
+	upstreamCache = cache.New(int64(size) * 1024 * 1024)
+}
+
+// playlistCacheTTL returns how long a playlist can be shared between clients
+func playlistCacheTTL(body []byte) time.Duration {
+	playlist, err := hls.Parse(body)
+	if err != nil {
+		return 0
+	}
+	if playlist.IsMaster() {
+		return masterPlaylistCacheTTL
+	}
+	return mediaPlaylistCacheTTL
+}
+
+// accountCacheKey returns the upstream cache key of a response fetched with the credentials of tv,
+// so that responses of one account are never served to the profiles of another
+func accountCacheKey(tv *television.Television, url string) string {
+	return tv.Crm + " " + url
+}
+
+// customChannelCacheKey returns the upstream cache key of a response of a custom channel. Channels sharing
+// a URL can send different headers upstream, so their responses are cached apart.
+func customChannelCacheKey(channel television.Channel, url string) string {
+	return channel.ID + " " + url
+}
+
+// renderPlaylist fetches a JioTV playlist with tv through the upstream cache.
+// It returns the same values as tv.Render.
+func renderPlaylist(tv *television.Television, url string) ([]byte, int, string) {
+	if upstreamCache == nil {
+		return tv.Render(url)
+	}
+	entry, err := upstreamCache.Fetch(accountCacheKey(tv, url), func() (*cache.Entry, time.Duration, error) {
+		body, statusCode, newHdnea := tv.Render(url)
+		entry := &cache.Entry{Body: body, StatusCode: statusCode, Extra: newHdnea}
+		if statusCode != fiber.StatusOK {
+			return entry, 0, nil
+		}
+		return entry, playlistCacheTTL(body), nil
+	})
+	if err != nil {
+		return []byte(err.Error()), fiber.StatusBadGateway, ""
+	}
+	return entry.Body, entry.StatusCode, entry.Extra
+}
+
+// renderCustomPlaylist fetches a custom channel playlist through the upstream cache.
+// It returns the same values as TV.RenderCustom.
+func renderCustomPlaylist(url string, channel television.Channel) ([]byte, int, string, error) {
+	if upstreamCache == nil {
+		return TV.RenderCustom(url, channel)
+	}
+	entry, err := upstreamCache.Fetch(customChannelCacheKey(channel, url), func() (*cache.Entry, time.Duration, error) {
+		body, statusCode, finalURL, err := TV.RenderCustom(url, channel)
+		if err != nil {
+			return nil, 0, err
+		}
+		entry := &cache.Entry{Body: body, StatusCode: statusCode, Extra: finalURL}
+		if statusCode != fiber.StatusOK {
+			return entry, 0, nil
+		}
+		return entry, playlistCacheTTL(body), nil
+	})
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return entry.Body, entry.StatusCode, entry.Extra, nil
+}
+
+// proxyCached proxies a segment or key request like internalUtils.ProxyRequest, sharing the upstream
+// response between the clients of the same cache key through the upstream cache. Request headers of c
+// must be set up for upstream.
+// Range requests are proxied directly as they only ask for a part of the response.
+// Requests reaching upstream are counted in the metrics of endpoint.
+func proxyCached(c *fiber.Ctx, url, key string, ttl time.Duration, userAgent, endpoint string) error {
+	if upstreamCache == nil || len(c.Request().Header.Peek(fiber.HeaderRange)) > 0 {
+		start := time.Now()
+		err := internalUtils.ProxyRequest(c, url, TV.Client, userAgent)
+		statusCode := c.Response().StatusCode()
+		if err != nil {
+			statusCode = 0
+		}
+		metrics.ObserveUpstream(endpoint, start, statusCode)
+		return err
+	}
+	if userAgent != "" {
+		internalUtils.SetCommonHeaders(c, userAgent)
+	}
+
+	entry, err := upstreamCache.Fetch(key, func() (*cache.Entry, time.Duration, error) {
+		return fetchUpstream(c, url, ttl, endpoint)
+	})
+	if err != nil {
+		return internalUtils.ErrorResponse(c, fiber.StatusBadGateway, err.Error())
+	}
+	if entry.ContentType != "" {
+		c.Set(fiber.HeaderContentType, entry.ContentType)
+	}
+	return c.Status(entry.StatusCode).Send(entry.Body)
+}
+
+// fetchUpstream requests url with the request headers of c and returns the response as a cache entry.
+// Only successful responses are cached.
+func fetchUpstream(c *fiber.Ctx, url string, ttl time.Duration, endpoint string) (*cache.Entry, time.Duration, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	c.Request().Header.CopyTo(&req.Header)
+	req.SetRequestURI(url)
+	// The response is shared with other clients, so it must be complete and uncompressed
+	req.Header.Del(fiber.HeaderConnection)
+	req.Header.Del(fiber.HeaderAcceptEncoding)
+	req.Header.Del(fiber.HeaderIfNoneMatch)
+	req.Header.Del(fiber.HeaderIfModifiedSince)
+
+	start := time.Now()
+	if err := TV.Client.Do(req, resp); err != nil {
+		metrics.ObserveUpstream(endpoint, start, 0)
+		return nil, 0, err
+	}
+	metrics.ObserveUpstream(endpoint, start, resp.StatusCode())
+
+	entry := &cache.Entry{
+		Body:        append([]byte(nil), resp.Body()...),
+		StatusCode:  resp.StatusCode(),
+		ContentType: string(resp.Header.ContentType()),
+	}
+	if entry.StatusCode != fiber.StatusOK {
+		return entry, 0, nil
+	}
+	return entry, ttl, nil
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/valyala/fasthttp"
+)
+
+func TestPlaylistCacheTTL(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want time.Duration
+	}{
+		{name: "Master playlist", body: "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1\nlow.m3u8\n", want: masterPlaylistCacheTTL},
+		{name: "Media playlist", body: "#EXTM3U\n#EXTINF:6.0,\nseg.ts\n", want: mediaPlaylistCacheTTL},
+		{name: "Not a playlist", body: `{"message":"error"}`, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := playlistCacheTTL([]byte(tt.body)); got != tt.want {
+				t.Errorf("playlistCacheTTL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTSHandler_UpstreamCache(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(os.Stdout, "", log.LstdFlags)
+	}
+	secureurl.Init()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/missing.ts" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "video/mp2t")
+		w.Write([]byte("segment-data"))
+	}))
+	defer server.Close()
+
+	originalTV := TV
+	originalCache := upstreamCache
+	originalCfg := config.Cfg
+	t.Cleanup(func() {
+		TV = originalTV
+		upstreamCache = originalCache
+		config.Cfg = originalCfg
+	})
+	TV = &television.Television{Client: &fasthttp.Client{}}
+	config.Cfg.DisableUpstreamCache = false
+	config.Cfg.UpstreamCacheSize = 1
+	upstreamCache = nil
+	initUpstreamCache()
+
+	app := fiber.New()
+	app.Get("/render.ts", RenderTSHandler)
+
+	get := func(upstreamURL string, header map[string]string) (*http.Response, string) {
+		t.Helper()
+		auth, err := secureurl.EncryptURL(upstreamURL)
+		if err != nil {
+			t.Fatalf("EncryptURL() error = %v", err)
+		}
+		req := httptest.NewRequest("GET", "/render.ts?auth="+auth, nil)
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("GET /render.ts error = %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	t.Run("Segments are fetched once", func(t *testing.T) {
+		requests.Store(0)
+		for i := 0; i < 3; i++ {
+			resp, body := get(server.URL+"/seg-1.ts", nil)
+			if resp.StatusCode != fiber.StatusOK || body != "segment-data" {
+				t.Fatalf("response %d = %d %q", i, resp.StatusCode, body)
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != "video/mp2t" {
+				t.Errorf("Content-Type = %q, want video/mp2t", contentType)
+			}
+		}
+		if got := requests.Load(); got != 1 {
+			t.Errorf("upstream requests = %d, want 1", got)
+		}
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		requests.Store(0)
+		for i := 0; i < 2; i++ {
+			if resp, _ := get(server.URL+"/missing.ts", nil); resp.StatusCode != fiber.StatusNotFound {
+				t.Errorf("status = %d, want 404", resp.StatusCode)
+			}
+		}
+		if got := requests.Load(); got != 2 {
+			t.Errorf("upstream requests = %d, want 2", got)
+		}
+	})
+
+	t.Run("Range requests bypass the cache", func(t *testing.T) {
+		requests.Store(0)
+		get(server.URL+"/seg-2.ts", map[string]string{"Range": "bytes=0-3"})
+		get(server.URL+"/seg-2.ts", map[string]string{"Range": "bytes=0-3"})
+		if got := requests.Load(); got != 2 {
+			t.Errorf("upstream requests = %d, want 2", got)
+		}
+	})
+}
+
+func TestRenderKeyHandler_UpstreamCachePerAccount(t *testing.T) {
+	if utils.Log == nil {
+		utils.Log = log.New(os.Stdout, "", log.LstdFlags)
+	}
+	secureurl.Init()
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+	if err := utils.WriteProfileCredentials("work", &utils.JIOTV_CREDENTIALS{SSOToken: "sso", CRM: "crm-work", UniqueID: "uid"}); err != nil {
+		t.Fatalf("WriteProfileCredentials() error = %v", err)
+	}
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("key-" + r.Header.Get("crmid")))
+	}))
+	defer server.Close()
+
+	originalTV := TV
+	originalCache := upstreamCache
+	originalCfg := config.Cfg
+	t.Cleanup(func() {
+		TV = originalTV
+		upstreamCache = originalCache
+		config.Cfg = originalCfg
+		resetProfileTVs()
+	})
+	TV = television.New(&utils.JIOTV_CREDENTIALS{CRM: "crm-default"})
+	resetProfileTVs()
+	config.Cfg.DisableUpstreamCache = false
+	config.Cfg.UpstreamCacheSize = 1
+	upstreamCache = nil
+	initUpstreamCache()
+
+	app := fiber.New()
+	app.Get("/render.key", RenderKeyHandler)
+	profile := app.Group("/p/:profile", ProfileMiddleware)
+	profile.Get("/render.key", RenderKeyHandler)
+
+	auth, err := secureurl.EncryptURL(server.URL + "/key?hdnea=token")
+	if err != nil {
+		t.Fatalf("EncryptURL() error = %v", err)
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/render.key", want: "key-crm-default"},
+		{path: "/p/work/render.key", want: "key-crm-work"},
+		{path: "/render.key", want: "key-crm-default"},
+		{path: "/p/work/render.key", want: "key-crm-work"},
+	}
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest("GET", tt.path+"?channel_key_id=143&auth="+auth, nil), -1)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", tt.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != tt.want {
+			t.Errorf("GET %s = %q, want %q", tt.path, body, tt.want)
+		}
+	}
+	// Each account fetches the key once
+	if got := requests.Load(); got != 2 {
+		t.Errorf("upstream requests = %d, want 2", got)
+	}
+}
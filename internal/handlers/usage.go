@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/limits"
+)
+
+// UsageResponse is the body of the usage API for the client of the request
+type UsageResponse struct {
+	limits.Usage
+	Limits limits.Limits `json:"limits"`
+}
+
+// ClientsUsageResponse is the body of the usage API for every client
+type ClientsUsageResponse struct {
+	Clients []limits.Usage `json:"clients"`
+	Limits  limits.Limits  `json:"limits"`
+}
+
+// UsageHandler returns the usage of the client of the request and its limits
+func UsageHandler(c *fiber.Ctx) error {
+	usage, clientLimits := middleware.ClientUsage(c)
+	return c.JSON(UsageResponse{Usage: usage, Limits: clientLimits})
+}
+
+// ClientsUsageHandler returns the usage of every client seen in the last day
+func ClientsUsageHandler(c *fiber.Ctx) error {
+	usages, clientLimits := middleware.AllClientUsage()
+	return c.JSON(ClientsUsageResponse{Clients: usages, Limits: clientLimits})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
+)
+
+func TestUsageHandler(t *testing.T) {
+	previous := config.Cfg
+	config.Cfg = config.JioTVConfig{ClientMaxChannels: 2}
+	defer func() { config.Cfg = previous }()
+
+	app := fiber.New()
+	app.Use(middleware.Limits())
+	app.Get("/api/usage", UsageHandler)
+	app.Get("/api/usage/clients", ClientsUsageHandler)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/usage", nil), -1)
+	if err != nil {
+		t.Fatalf("GET /api/usage error = %v", err)
+	}
+	var usage UsageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&usage); err != nil {
+		t.Fatalf("GET /api/usage: decoding response: %v", err)
+	}
+	if usage.Client != "ip:0.0.0.0" || usage.Limits.MaxChannels != 2 || usage.Channels == nil {
+		t.Errorf("GET /api/usage = %+v, want the usage of the IP address and its limits", usage)
+	}
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/api/usage/clients", nil), -1)
+	if err != nil {
+		t.Fatalf("GET /api/usage/clients error = %v", err)
+	}
+	var clients ClientsUsageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&clients); err != nil {
+		t.Fatalf("GET /api/usage/clients: decoding response: %v", err)
+	}
+	if len(clients.Clients) != 1 || clients.Clients[0].Client != usage.Client {
+		t.Errorf("GET /api/usage/clients = %+v, want the client of the first request", clients)
+	}
+}
@@ -0,0 +1,304 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/share"
+)
+
+const (
+	// TokenQueryParam is the query param carrying an API key, for players which can't send headers
+	TokenQueryParam = "token"
+	// APIKeyHeader is the header carrying an API key
+	APIKeyHeader = "X-API-Key"
+	// tokenCookie keeps the API key of a browser which opened a page with the token query param
+	tokenCookie = "jiotv_token"
+	// authRealm is the realm of the basic auth prompt
+	authRealm = "JioTV Go"
+)
+
+// access is the credential a route requires
+type access int
+
+const (
+	// accessPublic routes need no credential
+	accessPublic access = iota
+	// accessUser routes take the web UI credential, the admin credential or an API key
+	accessUser
+	// accessAdmin routes take the admin credential only
+	accessAdmin
+	// accessMetrics routes take the metrics token only when it is set, and are admin routes otherwise
+	accessMetrics
+)
+
+// credentials are the configured secrets of the auth middleware
+type credentials struct {
+	user    *basicCredential
+	admin   *basicCredential
+	apiKeys []string
+	// metricsToken is the token of /metrics. It doesn't enable auth on the other routes.
+	metricsToken string
+}
+
+// basicCredential is a username and password of HTTP basic auth
+type basicCredential struct {
+	username string
+	password string
+}
+
+// AuthEnabled checks if any credential is configured, in which case the Auth middleware guards the server
+func AuthEnabled() bool {
+	creds := loadCredentials()
+	return creds.user != nil || creds.admin != nil || len(creds.apiKeys) > 0
+}
+
+// loadCredentials reads the credentials from the config
+func loadCredentials() credentials {
+	creds := credentials{}
+	if config.Cfg.AuthUsername != "" || config.Cfg.AuthPassword != "" {
+		creds.user = &basicCredential{username: config.Cfg.AuthUsername, password: config.Cfg.AuthPassword}
+	}
+	if config.Cfg.AdminUsername != "" || config.Cfg.AdminPassword != "" {
+		creds.admin = &basicCredential{username: config.Cfg.AdminUsername, password: config.Cfg.AdminPassword}
+	}
+	for _, key := range config.Cfg.APIKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			creds.apiKeys = append(creds.apiKeys, key)
+		}
+	}
+	creds.metricsToken = strings.TrimSpace(config.Cfg.MetricsToken)
+	return creds
+}
+
+// Auth middleware guards the server with the credentials of the config.
+// Without any credential configured, every request passes.
+//
+// The web UI takes HTTP basic auth. Playlists, streams, the EPG and the API also take an API key
+// from the X-API-Key header, a Bearer token or the token query param. Login, logout, share links and
+// changes of settings through the API take the admin credential, or the web UI credential when no admin is configured.
+//
+// Requests authenticated by an API key get it added to the URLs of this server in the playlists
+// and redirects they receive, so that players keep sending it.
+//
+// Share tokens are checked whether credentials are configured or not, as their scope also filters
+// the playlists and EPG of the request, see ShareScope.
+//
+// The metrics take the metrics token, as a Bearer token or the token query param, and nothing else
+// when it is set, so that Prometheus gets no access to the rest of the server. Otherwise they are an admin route.
+func Auth() fiber.Handler {
+	creds := loadCredentials()
+	enabled := creds.user != nil || creds.admin != nil || len(creds.apiKeys) > 0
+
+	return func(c *fiber.Ctx) error {
+		level := routeAccess(c.Method(), c.Path())
+		if level == accessPublic {
+			return c.Next()
+		}
+		if level == accessMetrics {
+			if creds.metricsToken != "" {
+				return metricsAccess(c, creds.metricsToken)
+			}
+			level = accessAdmin
+		}
+
+		username, password, hasBasic := basicAuth(c)
+		if enabled && hasBasic {
+			if creds.admin.matches(username, password) {
+				return nextWithoutCredentials(c)
+			}
+			// The web UI credential stands in for the admin one when there is none
+			if creds.user.matches(username, password) && (level == accessUser || creds.admin == nil) {
+				return nextWithoutCredentials(c)
+			}
+		}
+
+		key, fromCookie := requestAPIKey(c)
+		if enabled && key != "" && validAPIKey(creds.apiKeys, key) {
+			// API keys only reach admin routes when there is no basic auth credential to use instead
+			if level == accessAdmin && (creds.admin != nil || creds.user != nil) {
+				return forbidden(c)
+			}
+			return nextWithToken(c, key)
+		}
+		// A share token left in a cookie by a share link doesn't get in the way of the other pages
+		if share.IsToken(key) && (!fromCookie || shareRoute(c.Path())) {
+			return shareAccess(c, key)
+		}
+		if !enabled {
+			return nextWithoutCredentials(c)
+		}
+
+		if hasBasic && level == accessAdmin && creds.user.matches(username, password) {
+			return forbidden(c)
+		}
+		c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="`+authRealm+`", charset="UTF-8"`)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Authentication required",
+		})
+	}
+}
+
+// forbidden rejects a request whose credential is valid but not enough for the route
+func forbidden(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"message": "This needs the admin credential",
+	})
+}
+
+// metricsAccess lets a request to the metrics through when it has the metrics token
+func metricsAccess(c *fiber.Ctx, token string) error {
+	key, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !ok {
+		key = c.Query(TokenQueryParam)
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(key)), []byte(token)) == 1 {
+		return nextWithoutCredentials(c)
+	}
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="`+authRealm+`"`)
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"message": "This needs the metrics token",
+	})
+}
+
+// routeAccess returns the credential a route requires. Routes of a profile under /p/:profile
+// require the same as the route without the profile.
+func routeAccess(method, path string) access {
+	path = routePath(path)
+	switch {
+	case strings.HasPrefix(path, "/static/"), path == "/favicon.ico":
+		return accessPublic
+	case path == "/metrics":
+		return accessMetrics
+	case strings.HasPrefix(path, "/login/"), path == "/logout", path == "/shares", strings.HasPrefix(path, "/api/shares"), path == "/api/usage/clients":
+		return accessAdmin
+	case strings.HasPrefix(path, "/api/"):
+		// Reading through the API is open to users, changing settings is for the admin
+		switch method {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return accessUser
+		}
+		return accessAdmin
+	}
+	return accessUser
+}
+
+// routePath returns the path a request is routed on, without the profile of a route under /p/:profile.
+// The server routes paths regardless of case and trailing slashes, so they are lower-cased and trimmed
+// the same way, and /LOGOUT/ is taken for /logout.
+func routePath(path string) string {
+	path = strings.ToLower(path)
+	if len(path) > 1 {
+		if path = strings.TrimRight(path, "/"); path == "" {
+			path = "/"
+		}
+	}
+	return withoutProfile(path)
+}
+
+// withoutProfile returns the path of a route under /p/:profile without the profile.
+// The /p/ prefix matches regardless of case, like the routes.
+func withoutProfile(path string) string {
+	if len(path) >= 3 && strings.EqualFold(path[:3], "/p/") {
+		if _, route, found := strings.Cut(path[3:], "/"); found {
+			return "/" + route
+		}
+	}
+	return path
+}
+
+// matches checks a username and password against the credential in constant time.
+// A nil credential matches nothing.
+func (b *basicCredential) matches(username, password string) bool {
+	if b == nil {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(b.username)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(b.password)) == 1
+	return userOK && passwordOK
+}
+
+// basicAuth returns the username and password of the Authorization header
+func basicAuth(c *fiber.Ctx) (string, string, bool) {
+	encoded, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Basic ")
+	if !ok {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(decoded), ":")
+}
+
+// requestAPIKey returns the API key of a request from the header, a Bearer token, the query param or the cookie.
+// It also tells if the key is from the cookie.
+func requestAPIKey(c *fiber.Ctx) (string, bool) {
+	if key := c.Get(APIKeyHeader); key != "" {
+		return key, false
+	}
+	if key, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "); ok {
+		return strings.TrimSpace(key), false
+	}
+	if key := c.Query(TokenQueryParam); key != "" {
+		return key, false
+	}
+	key := c.Cookies(tokenCookie)
+	return key, key != ""
+}
+
+// validAPIKey checks a key against the configured ones in constant time
+func validAPIKey(keys []string, key string) bool {
+	valid := false
+	for _, k := range keys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// nextWithToken runs the handlers of a request authenticated by an API key,
+// then adds the key to the URLs of this server in the response
+func nextWithToken(c *fiber.Ctx, key string) error {
+	setClientKey(c, key)
+	fromQuery := c.Query(TokenQueryParam) != ""
+	// Browsers opening a page with the token keep it for the requests of the page
+	if fromQuery && c.Cookies(tokenCookie) != key {
+		c.Cookie(&fiber.Cookie{
+			Name:     tokenCookie,
+			Value:    key,
+			Path:     "/",
+			Expires:  time.Now().Add(30 * 24 * time.Hour),
+			HTTPOnly: true,
+			SameSite: fiber.CookieSameSiteLaxMode,
+		})
+	}
+
+	if err := nextWithoutCredentials(c); err != nil {
+		return err
+	}
+	addTokenToResponse(c, key)
+	return nil
+}
+
+// nextWithoutCredentials runs the handlers of an authenticated request without its credentials.
+// Handlers never see the basic auth credential, the API key or the share token of a request,
+// so that handlers proxying the request don't forward them upstream.
+func nextWithoutCredentials(c *fiber.Ctx) error {
+	uri := c.Request().URI()
+	args := uri.QueryArgs()
+	if args.Has(TokenQueryParam) {
+		args.Del(TokenQueryParam)
+		uri.SetQueryStringBytes(args.QueryString())
+	}
+	header := &c.Request().Header
+	header.Del(fiber.HeaderAuthorization)
+	header.Del(APIKeyHeader)
+	header.DelCookie(tokenCookie)
+	return c.Next()
+}
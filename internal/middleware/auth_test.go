@@ -0,0 +1,262 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+// authApp returns an app guarded by the Auth middleware with the given config
+func authApp(t *testing.T, cfg config.JioTVConfig) *fiber.App {
+	t.Helper()
+	previous := config.Cfg
+	config.Cfg = cfg
+	t.Cleanup(func() { config.Cfg = previous })
+
+	app := fiber.New()
+	app.Use(Auth())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("index")
+	})
+	app.Get("/static/app.js", func(c *fiber.Ctx) error {
+		return c.SendString("js")
+	})
+	app.Get("/logout", func(c *fiber.Ctx) error {
+		return c.SendString("logged out")
+	})
+	app.Put("/api/favorites", func(c *fiber.Ctx) error {
+		return c.SendString("saved")
+	})
+	app.Get("/metrics", func(c *fiber.Ctx) error {
+		return c.SendString("metrics")
+	})
+	app.Get("/live/:id", func(c *fiber.Ctx) error {
+		return c.Redirect("/render.m3u8?auth=abc&channel_key_id=" + c.Params("id"))
+	})
+	app.Get("/render.m3u8", func(c *fiber.Ctx) error {
+		// The token never reaches handlers
+		return c.SendString("#EXTM3U\n#EXTINF:6,\n/render.ts?auth=" + c.Query("auth") + c.Query(TokenQueryParam) + "\n")
+	})
+	app.Get("/render.mpd", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "application/dash+xml")
+		return c.SendString("<MPD><Period><BaseURL>/render.dash/dash/</BaseURL></Period></MPD>")
+	})
+	return app
+}
+
+func TestAuth(t *testing.T) {
+	full := config.JioTVConfig{
+		AuthUsername:  "user",
+		AuthPassword:  "secret",
+		AdminUsername: "admin",
+		AdminPassword: "root",
+		APIKeys:       []string{"key1", " key2 "},
+	}
+	metrics := config.JioTVConfig{AdminUsername: "admin", AdminPassword: "root", APIKeys: []string{"key1"}, MetricsToken: "scrape"}
+	tests := []struct {
+		name         string
+		cfg          config.JioTVConfig
+		method       string
+		path         string
+		basic        string
+		header       string
+		bearer       string
+		wantStatus   int
+		wantLocation string
+		wantBody     string
+	}{
+		{name: "Nothing configured", cfg: config.JioTVConfig{}, method: http.MethodGet, path: "/logout", wantStatus: fiber.StatusOK},
+		{name: "Page without credential", cfg: full, method: http.MethodGet, path: "/", wantStatus: fiber.StatusUnauthorized},
+		{name: "Page with the web UI credential", cfg: full, method: http.MethodGet, path: "/", basic: "user:secret", wantStatus: fiber.StatusOK},
+		{name: "Page with a wrong password", cfg: full, method: http.MethodGet, path: "/", basic: "user:wrong", wantStatus: fiber.StatusUnauthorized},
+		{name: "Static files are public", cfg: full, method: http.MethodGet, path: "/static/app.js", wantStatus: fiber.StatusOK},
+		{name: "Stream with an API key header", cfg: full, method: http.MethodGet, path: "/live/143", header: "key2", wantStatus: fiber.StatusFound, wantLocation: "/render.m3u8?auth=abc&channel_key_id=143&token=key2"},
+		{name: "Stream with an API key query param", cfg: full, method: http.MethodGet, path: "/render.m3u8?auth=abc&token=key1", wantStatus: fiber.StatusOK, wantBody: "/render.ts?auth=abc&token=key1\n"},
+		{name: "DASH manifest with an API key query param", cfg: full, method: http.MethodGet, path: "/render.mpd?auth=abc&token=key1", wantStatus: fiber.StatusOK, wantBody: "<BaseURL>/render.dash/dash/?token=key1</BaseURL>"},
+		{name: "Stream with a wrong API key", cfg: full, method: http.MethodGet, path: "/live/143?token=nope", wantStatus: fiber.StatusUnauthorized},
+		{name: "Logout with the web UI credential", cfg: full, method: http.MethodGet, path: "/logout", basic: "user:secret", wantStatus: fiber.StatusForbidden},
+		{name: "Logout with an API key", cfg: full, method: http.MethodGet, path: "/logout?token=key1", wantStatus: fiber.StatusForbidden},
+		{name: "Logout with the admin credential", cfg: full, method: http.MethodGet, path: "/logout", basic: "admin:root", wantStatus: fiber.StatusOK},
+		{name: "Settings with the admin credential", cfg: full, method: http.MethodPut, path: "/api/favorites", basic: "admin:root", wantStatus: fiber.StatusOK},
+		{name: "Settings with the web UI credential", cfg: full, method: http.MethodPut, path: "/api/favorites", basic: "user:secret", wantStatus: fiber.StatusForbidden},
+		{
+			name:       "Logout with the web UI credential and no admin",
+			cfg:        config.JioTVConfig{AuthUsername: "user", AuthPassword: "secret"},
+			method:     http.MethodGet,
+			path:       "/logout",
+			basic:      "user:secret",
+			wantStatus: fiber.StatusOK,
+		},
+		{
+			name:       "Logout with an API key and no basic auth",
+			cfg:        config.JioTVConfig{APIKeys: []string{"key1"}},
+			method:     http.MethodGet,
+			path:       "/logout?token=key1",
+			wantStatus: fiber.StatusOK,
+		},
+		{name: "Metrics without a metrics token are open without credentials", cfg: config.JioTVConfig{}, method: http.MethodGet, path: "/metrics", wantStatus: fiber.StatusOK},
+		{name: "Metrics without a metrics token take the admin credential", cfg: full, method: http.MethodGet, path: "/metrics", basic: "admin:root", wantStatus: fiber.StatusOK},
+		{name: "Metrics without a metrics token with the web UI credential", cfg: full, method: http.MethodGet, path: "/metrics", basic: "user:secret", wantStatus: fiber.StatusForbidden},
+		{name: "Metrics with the metrics token as a Bearer token", cfg: metrics, method: http.MethodGet, path: "/metrics", bearer: "scrape", wantStatus: fiber.StatusOK},
+		{name: "Metrics with the metrics token as query param", cfg: metrics, method: http.MethodGet, path: "/metrics?token=scrape", wantStatus: fiber.StatusOK},
+		{name: "Metrics with a wrong metrics token", cfg: metrics, method: http.MethodGet, path: "/metrics", bearer: "nope", wantStatus: fiber.StatusUnauthorized},
+		{name: "Metrics with the admin credential when there is a metrics token", cfg: metrics, method: http.MethodGet, path: "/metrics", basic: "admin:root", wantStatus: fiber.StatusUnauthorized},
+		{name: "Metrics with an API key when there is a metrics token", cfg: metrics, method: http.MethodGet, path: "/metrics?token=key1", wantStatus: fiber.StatusUnauthorized},
+		{name: "The metrics token only opens the metrics", cfg: metrics, method: http.MethodGet, path: "/", bearer: "scrape", wantStatus: fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := authApp(t, tt.cfg)
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.basic != "" {
+				username, password, _ := strings.Cut(tt.basic, ":")
+				req.SetBasicAuth(username, password)
+			}
+			if tt.header != "" {
+				req.Header.Set(APIKeyHeader, tt.header)
+			}
+			if tt.bearer != "" {
+				req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.bearer)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatalf("%s %s error = %v", tt.method, tt.path, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus == fiber.StatusUnauthorized && resp.Header.Get(fiber.HeaderWWWAuthenticate) == "" {
+				t.Error("401 response has no WWW-Authenticate header")
+			}
+			if location := resp.Header.Get(fiber.HeaderLocation); location != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", location, tt.wantLocation)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestAuth_StripsCredentials(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    config.JioTVConfig
+		path   string
+		header string
+		value  string
+	}{
+		{name: "Basic auth", cfg: config.JioTVConfig{AuthUsername: "user", AuthPassword: "secret"}, path: "/render.ts", header: fiber.HeaderAuthorization, value: "Basic dXNlcjpzZWNyZXQ="},
+		{name: "Bearer API key", cfg: config.JioTVConfig{APIKeys: []string{"key1"}}, path: "/render.ts", header: fiber.HeaderAuthorization, value: "Bearer key1"},
+		{name: "API key header", cfg: config.JioTVConfig{APIKeys: []string{"key1"}}, path: "/render.ts", header: APIKeyHeader, value: "key1"},
+		{name: "API key cookie", cfg: config.JioTVConfig{APIKeys: []string{"key1"}}, path: "/render.ts", header: fiber.HeaderCookie, value: tokenCookie + "=key1; other=1"},
+		{name: "Without credentials configured", cfg: config.JioTVConfig{}, path: "/render.ts", header: fiber.HeaderAuthorization, value: "Bearer key1"},
+		{name: "Metrics token", cfg: config.JioTVConfig{MetricsToken: "scrape"}, path: "/metrics", header: fiber.HeaderAuthorization, value: "Bearer scrape"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := config.Cfg
+			config.Cfg = tt.cfg
+			defer func() { config.Cfg = previous }()
+
+			app := fiber.New()
+			app.Use(Auth())
+			app.Get("/*", func(c *fiber.Ctx) error {
+				// Proxying handlers copy the request headers upstream
+				return c.SendString(c.Request().Header.String())
+			})
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(tt.header, tt.value)
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatalf("GET %s error = %v", tt.path, err)
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("GET %s = %d, want %d", tt.path, resp.StatusCode, fiber.StatusOK)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			for _, secret := range []string{"dXNlcjpzZWNyZXQ=", "key1", "scrape"} {
+				if strings.Contains(string(body), secret) {
+					t.Errorf("GET %s: handler got the credential in %s", tt.path, body)
+				}
+			}
+			if tt.header == fiber.HeaderCookie && !strings.Contains(string(body), "other=1") {
+				t.Errorf("GET %s: handler lost the other cookies in %s", tt.path, body)
+			}
+		})
+	}
+}
+
+func TestAddTokenToPlaylist(t *testing.T) {
+	server := "http://localhost:5001"
+	tests := []struct {
+		name     string
+		playlist string
+		want     string
+	}{
+		{
+			name:     "HLS media playlist",
+			playlist: "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"/render.key?auth=a\"\n#EXTINF:6.000,\n0.ts\n#EXTINF:6.000,\nhttps://cdn.example.com/1.ts\n",
+			want:     "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"/render.key?auth=a&token=k%2B1\"\n#EXTINF:6.000,\n0.ts?token=k%2B1\n#EXTINF:6.000,\nhttps://cdn.example.com/1.ts\n",
+		},
+		{
+			name: "M3U playlist",
+			playlist: "#EXTM3U x-tvg-url=\"http://localhost:5001/epg.xml.gz,http://localhost:5001/local/epg.xml\"\n" +
+				"#EXTINF:-1 tvg-id=\"143\" tvg-name=\"News\" tvg-logo=\"http://localhost:5001/jtvimage/news.png\" catchup-source=\"http://localhost:5001/catchup/143?start={utc}\" group-title=\"News\", News\n" +
+				"http://localhost:5001/live/143.m3u8\n",
+			want: "#EXTM3U x-tvg-url=\"http://localhost:5001/epg.xml.gz?token=k%2B1,http://localhost:5001/local/epg.xml?token=k%2B1\"\n" +
+				"#EXTINF:-1 tvg-id=\"143\" tvg-name=\"News\" tvg-logo=\"http://localhost:5001/jtvimage/news.png?token=k%2B1\" catchup-source=\"http://localhost:5001/catchup/143?start={utc}&token=k%2B1\" group-title=\"News\", News\n" +
+				"http://localhost:5001/live/143.m3u8?token=k%2B1\n",
+		},
+		{
+			name:     "URLs already carrying the token",
+			playlist: "#EXTM3U\n#EXTINF:6,\n/render.ts?token=k%2B1\n",
+			want:     "#EXTM3U\n#EXTINF:6,\n/render.ts?token=k%2B1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addTokenToPlaylist(tt.playlist, server, "k+1"); got != tt.want {
+				t.Errorf("addTokenToPlaylist() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddTokenToManifest(t *testing.T) {
+	server := "http://localhost:5001"
+	tests := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{
+			name:     "Relative BaseURL",
+			manifest: "<MPD><Period>\n<BaseURL>/render.dash/dash/</BaseURL><AdaptationSet/></Period></MPD>",
+			want:     "<MPD><Period>\n<BaseURL>/render.dash/dash/?token=k%2B1</BaseURL><AdaptationSet/></Period></MPD>",
+		},
+		{
+			name:     "BaseURL with a query",
+			manifest: "<BaseURL>http://localhost:5001/render.dash/?a=1&amp;b=2</BaseURL>",
+			want:     "<BaseURL>http://localhost:5001/render.dash/?a=1&amp;b=2&amp;token=k%2B1</BaseURL>",
+		},
+		{
+			name:     "BaseURL of another server",
+			manifest: "<BaseURL>https://cdn.example.com/dash/</BaseURL>",
+			want:     "<BaseURL>https://cdn.example.com/dash/</BaseURL>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addTokenToManifest(tt.manifest, server, "k+1"); got != tt.want {
+				t.Errorf("addTokenToManifest() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"bytes"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// uriAttribute matches the URI attribute of HLS tags like EXT-X-KEY and EXT-X-MAP
+var uriAttribute = regexp.MustCompile(`URI="([^"]*)"`)
+
+// baseURLElement matches the BaseURL elements of DASH manifests
+var baseURLElement = regexp.MustCompile(`<BaseURL>([^<]*)</BaseURL>`)
+
+// quotedValue matches the quoted values of M3U tags, like tvg-logo and x-tvg-url
+var quotedValue = regexp.MustCompile(`="([^"]*)"`)
+
+// addTokenToResponse adds the API key to the URLs of this server in the redirect and the playlist or DASH
+// manifest of a response
+func addTokenToResponse(c *fiber.Ctx, key string) {
+	serverURL := strings.ToLower(c.Protocol()) + "://" + c.Hostname()
+
+	if location := c.GetRespHeader(fiber.HeaderLocation); location != "" && isServerURL(location, serverURL, true) {
+		c.Set(fiber.HeaderLocation, withToken(location, key))
+	}
+
+	// Only playlists and manifests are read, as reading a stream would buffer it
+	contentType := strings.ToLower(c.GetRespHeader(fiber.HeaderContentType))
+	switch {
+	case strings.Contains(contentType, "mpegurl") || strings.HasSuffix(c.Path(), ".m3u8"):
+		body := c.Response().Body()
+		if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("#EXTM3U")) {
+			return
+		}
+		c.Response().SetBodyString(addTokenToPlaylist(string(body), serverURL, key))
+	case strings.Contains(contentType, "dash+xml") || strings.HasSuffix(c.Path(), ".mpd"):
+		c.Response().SetBodyString(addTokenToManifest(string(c.Response().Body()), serverURL, key))
+	}
+}
+
+// addTokenToManifest adds the API key to the BaseURL elements of a DASH manifest pointing to this server,
+// like the /render.dash/ base of the manifests of /render.mpd
+func addTokenToManifest(manifest, serverURL, key string) string {
+	return baseURLElement.ReplaceAllStringFunc(manifest, func(match string) string {
+		base := html.UnescapeString(baseURLElement.FindStringSubmatch(match)[1])
+		if !isServerURL(strings.TrimSpace(base), serverURL, true) {
+			return match
+		}
+		return "<BaseURL>" + html.EscapeString(withToken(strings.TrimSpace(base), key)) + "</BaseURL>"
+	})
+}
+
+// addTokenToPlaylist adds the API key to the URLs of this server in an HLS or M3U playlist.
+// Relative URIs are taken as URLs of this server, as players resolve them against the playlist URL.
+func addTokenToPlaylist(playlist, serverURL, key string) string {
+	lines := strings.Split(playlist, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#"):
+			line = uriAttribute.ReplaceAllStringFunc(line, func(match string) string {
+				uri := uriAttribute.FindStringSubmatch(match)[1]
+				if !isServerURL(uri, serverURL, true) {
+					return match
+				}
+				return `URI="` + withToken(uri, key) + `"`
+			})
+			// Other attributes hold names too, so only absolute URLs of this server are changed.
+			// x-tvg-url may list several URLs separated by commas.
+			line = quotedValue.ReplaceAllStringFunc(line, func(match string) string {
+				value := quotedValue.FindStringSubmatch(match)[1]
+				urls := strings.Split(value, ",")
+				changed := false
+				for j, u := range urls {
+					if isServerURL(u, serverURL, false) {
+						urls[j] = withToken(u, key)
+						changed = true
+					}
+				}
+				if !changed {
+					return match
+				}
+				return `="` + strings.Join(urls, ",") + `"`
+			})
+			lines[i] = line
+		case isServerURL(trimmed, serverURL, true):
+			lines[i] = strings.Replace(line, trimmed, withToken(trimmed, key), 1)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isServerURL checks if a URL is on this server. Relative URLs are when relative is set.
+func isServerURL(u, serverURL string, relative bool) bool {
+	lower := strings.ToLower(u)
+	if lower == serverURL || strings.HasPrefix(lower, serverURL+"/") || strings.HasPrefix(lower, serverURL+":") || strings.HasPrefix(lower, serverURL+"?") {
+		return true
+	}
+	if !relative || u == "" || strings.HasPrefix(u, "//") {
+		return false
+	}
+	parsed, err := url.Parse(u)
+	return err == nil && parsed.Scheme == "" && parsed.Host == ""
+}
+
+// withToken adds the token query param with the API key to a URL, unless it already has one
+func withToken(u, key string) string {
+	if strings.Contains(u, "?"+TokenQueryParam+"=") || strings.Contains(u, "&"+TokenQueryParam+"=") {
+		return u
+	}
+	fragment := ""
+	if i := strings.Index(u, "#"); i >= 0 {
+		u, fragment = u[:i], u[i:]
+	}
+	separator := "?"
+	if strings.Contains(u, "?") {
+		separator = "&"
+	}
+	return u + separator + TokenQueryParam + "=" + url.QueryEscape(key) + fragment
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/limits"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/metrics"
+)
+
+// clientLocal is the fiber local holding the client of a request made with an API key or a share token
+const clientLocal = "client"
+
+// clientLimits tracks the usage of the clients. It is created by the Limits middleware.
+var clientLimits *limits.Tracker
+
+// ClientID returns the client a request counts against in the limits. Requests made with an API key
+// or a share token count against the key, other requests against their IP address.
+func ClientID(c *fiber.Ctx) string {
+	if id, ok := c.Locals(clientLocal).(string); ok && id != "" {
+		return id
+	}
+	return "ip:" + c.IP()
+}
+
+// setClientKey makes a request count against its API key or share token.
+// The client is named after a hash of the key, so that the usage API doesn't reveal it.
+func setClientKey(c *fiber.Ctx, key string) {
+	sum := sha256.Sum256([]byte(key))
+	c.Locals(clientLocal, "key:"+hex.EncodeToString(sum[:6]))
+}
+
+// Limits middleware enforces the client limits of the config. Requests over a limit get a 429 with the reason.
+// It must come after the Auth middleware, which tells the API key of a request.
+//
+// Every request counts against the request rate. Requests for the playlist of a channel through /render.m3u8
+// count against the channels watched at once, and requests for segments through /render.ts, /render.dash
+// and /local against the daily quota, see CountStreamBytes.
+func Limits() fiber.Handler {
+	clientLimits = limits.New(limits.Limits{
+		MaxChannels:       config.Cfg.ClientMaxChannels,
+		RequestsPerMinute: config.Cfg.ClientRequestsPerMinute,
+		DailyQuota:        int64(config.Cfg.ClientDailyQuota) * 1024 * 1024,
+	})
+	tracker := clientLimits
+
+	return func(c *fiber.Ctx) error {
+		if routeAccess(c.Method(), c.Path()) == accessPublic {
+			return c.Next()
+		}
+		id := ClientID(c)
+		now := time.Now()
+		if err := tracker.Request(id, now); err != nil {
+			return tooManyRequests(c, err)
+		}
+
+		path := routePath(c.Path())
+		switch {
+		case path == "/render.m3u8":
+			// Only a channel signed by the server is trusted, so that changing it doesn't get around the limit
+			channel := signedChannel(c, "channel_key_id", "auth")
+			if channel == "" && tracker.Limits().MaxChannels > 0 {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"message": "The stream URL is not signed by the server",
+				})
+			}
+			if channel != "" {
+				if err := tracker.Stream(id, channel, now); err != nil {
+					return tooManyRequests(c, err)
+				}
+			}
+		case path == "/render.ts", strings.HasPrefix(path, "/render.dash"), localSegment(path):
+			if err := tracker.Quota(id, now); err != nil {
+				return tooManyRequests(c, err)
+			}
+		}
+		return c.Next()
+	}
+}
+
+// localSegment checks if a route path is for a segment of a local media channel
+func localSegment(path string) bool {
+	return strings.HasPrefix(path, "/local/") && path != "/local/epg.xml" && !strings.HasSuffix(path, "/index.m3u8")
+}
+
+// tooManyRequests rejects a request over a limit
+func tooManyRequests(c *fiber.Ctx, err error) error {
+	var limitErr *limits.LimitError
+	if !errors.As(err, &limitErr) {
+		return err
+	}
+	if limitErr.RetryAfter > 0 {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))))
+	}
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"message": limitErr.Reason,
+	})
+}
+
+// CountStreamBytes counts the body of a proxied stream response against the daily quota of the client
+// and in the proxied bytes metric
+func CountStreamBytes(c *fiber.Ctx) {
+	n := int64(len(c.Response().Body()))
+	path := routePath(c.Path())
+	streamType := "ts"
+	switch {
+	case strings.HasPrefix(path, "/render.dash"):
+		streamType = "dash"
+	case localSegment(path):
+		streamType = "local"
+	}
+	metrics.ProxiedBytes.Add(float64(n), streamType)
+	if clientLimits == nil {
+		return
+	}
+	clientLimits.AddBytes(ClientID(c), n, time.Now())
+}
+
+// ActiveStreams returns how many clients watch each channel through /render.m3u8
+func ActiveStreams() map[string]int {
+	if clientLimits == nil {
+		return map[string]int{}
+	}
+	return clientLimits.ActiveStreams(time.Now())
+}
+
+// ClientUsage returns the usage of the client of a request and the limits of every client
+func ClientUsage(c *fiber.Ctx) (limits.Usage, limits.Limits) {
+	if clientLimits == nil {
+		return limits.Usage{Client: ClientID(c), Channels: []string{}}, limits.Limits{}
+	}
+	return clientLimits.Usage(ClientID(c), time.Now()), clientLimits.Limits()
+}
+
+// AllClientUsage returns the usage of every client seen in the last day and the limits of every client
+func AllClientUsage() ([]limits.Usage, limits.Limits) {
+	if clientLimits == nil {
+		return []limits.Usage{}, limits.Limits{}
+	}
+	return clientLimits.AllUsage(time.Now()), clientLimits.Limits()
+}
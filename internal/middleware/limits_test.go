@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+)
+
+func TestLimits(t *testing.T) {
+	previous := config.Cfg
+	config.Cfg = config.JioTVConfig{APIKeys: []string{"key1"}, ClientMaxChannels: 1, ClientDailyQuota: 2}
+	defer func() { config.Cfg = previous }()
+	secureurl.Init()
+	channel143 := signedQuery(t, "channel_key_id", "143", "auth", "https://cdn.example.com/143/index.m3u8")
+	channel144 := signedQuery(t, "channel_key_id", "144", "auth", "https://cdn.example.com/144/index.m3u8")
+
+	app := fiber.New()
+	app.Use(Auth())
+	app.Use(Limits())
+	app.Get("/render.m3u8", func(c *fiber.Ctx) error {
+		return c.SendString("#EXTM3U\n")
+	})
+	app.Get("/render.ts", func(c *fiber.Ctx) error {
+		c.Response().SetBody(make([]byte, 1024*1024))
+		CountStreamBytes(c)
+		return nil
+	})
+	app.Get("/local/:channelID/:segment", func(c *fiber.Ctx) error {
+		c.Response().SetBody(make([]byte, 1024*1024))
+		CountStreamBytes(c)
+		return nil
+	})
+
+	get := func(path string) (int, string, string) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		var body struct {
+			Message string `json:"message"`
+		}
+		if resp.StatusCode == fiber.StatusTooManyRequests {
+			_ = json.NewDecoder(resp.Body).Decode(&body)
+		}
+		return resp.StatusCode, body.Message, resp.Header.Get(fiber.HeaderRetryAfter)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "First channel", path: "/render.m3u8?" + channel143 + "&token=key1", wantStatus: fiber.StatusOK},
+		{name: "Same channel", path: "/render.m3u8?" + channel143 + "&token=key1", wantStatus: fiber.StatusOK},
+		{name: "Second channel", path: "/render.m3u8?" + channel144 + "&token=key1", wantStatus: fiber.StatusTooManyRequests, wantMessage: "Too many channels watched at once"},
+		{name: "Channel changed by the client", path: "/render.m3u8?" + strings.Replace(channel144, "channel_key_id=144", "channel_key_id=143", 1) + "&token=key1", wantStatus: fiber.StatusForbidden},
+		{name: "Unsigned channel", path: "/render.m3u8?channel_key_id=143&token=key1", wantStatus: fiber.StatusForbidden},
+		{name: "Segment within the quota", path: "/render.ts?token=key1", wantStatus: fiber.StatusOK},
+		{name: "Local segment within the quota", path: "/local/news/1.ts?token=key1", wantStatus: fiber.StatusOK},
+		{name: "Segment over the quota", path: "/render.ts?token=key1", wantStatus: fiber.StatusTooManyRequests, wantMessage: "Daily quota of 2 MB exceeded"},
+		{name: "Local segment over the quota", path: "/local/news/2.ts?token=key1", wantStatus: fiber.StatusTooManyRequests, wantMessage: "Daily quota of 2 MB exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message, retryAfter := get(tt.path)
+			if status != tt.wantStatus {
+				t.Fatalf("GET %s = %d, want %d", tt.path, status, tt.wantStatus)
+			}
+			if !strings.HasPrefix(message, tt.wantMessage) {
+				t.Errorf("message = %q, want %q", message, tt.wantMessage)
+			}
+			if status == fiber.StatusTooManyRequests && retryAfter == "" {
+				t.Error("429 response has no Retry-After header")
+			}
+		})
+	}
+
+	clients, _ := AllClientUsage()
+	if len(clients) != 1 || !strings.HasPrefix(clients[0].Client, "key:") || clients[0].BytesToday != 2*1024*1024 {
+		t.Errorf("AllClientUsage() = %+v, want the API key client with 2 MB today", clients)
+	}
+	if strings.Contains(clients[0].Client, "key1") {
+		t.Errorf("AllClientUsage() reveals the API key: %s", clients[0].Client)
+	}
+}
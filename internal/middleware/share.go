@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/share"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+// shareScopeLocal is the fiber local holding the scope of a request made with a share token
+const shareScopeLocal = "share_scope"
+
+// ShareScope returns the scope of a request made with a share token, or nil for other requests
+func ShareScope(c *fiber.Ctx) *share.Scope {
+	if scope, ok := c.Locals(shareScopeLocal).(*share.Scope); ok {
+		return scope
+	}
+	return nil
+}
+
+// shareAccess checks a request made with a share token against the scope of the token
+func shareAccess(c *fiber.Ctx, token string) error {
+	scope, err := share.Verify(token, time.Now())
+	if errors.Is(err, share.ErrInvalidToken) || errors.Is(err, share.ErrExpired) || errors.Is(err, share.ErrRevoked) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	allowed := shareRoute(c.Path())
+	if id, ok := shareChannelID(c); allowed && ok {
+		allowed, err = scopeAllowsChannel(scope, id)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+	}
+	if !allowed {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "This is not part of the share link",
+		})
+	}
+
+	c.Locals(shareScopeLocal, &scope)
+	return nextWithToken(c, token)
+}
+
+// shareRoute checks if a share token may be used on a route at all.
+// Routes of a single channel are also checked against the scope, see shareChannelID.
+func shareRoute(path string) bool {
+	path = routePath(path)
+	switch path {
+	case "/playlist.m3u", "/channels", "/epg.xml", "/epg.xml.gz", "/local/epg.xml",
+		"/render.m3u8", "/render.key", "/render.ts", "/render.mpd", "/render.dash", "/drm", "/dashtime":
+		return true
+	}
+	for _, prefix := range []string{"/live/", "/catchup/", "/play/", "/player/", "/mpd/", "/epg/", "/local/", "/jtvimage/", "/jtvposter/", "/render.dash/"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// shareChannelID returns the channel a request is for, when the route is for a single channel.
+// The channel of a stream URL of the server is only trusted when it is signed, see signedChannel.
+func shareChannelID(c *fiber.Ctx) (string, bool) {
+	path := routePath(c.Path())
+	switch path {
+	case "/render.m3u8", "/render.key", "/render.ts", "/render.mpd":
+		return signedChannel(c, "channel_key_id", "auth"), true
+	case "/drm":
+		return signedChannel(c, "channel_id", "auth", "channel"), true
+	case "/local/epg.xml":
+		return "", false
+	}
+	if path == "/render.dash" || strings.HasPrefix(path, "/render.dash/") {
+		return signedChannel(c, "channel_key_id", "host", "path"), true
+	}
+	// IDs keep their case
+	segments := strings.Split(strings.Trim(withoutProfile(c.Path()), "/"), "/")
+	switch strings.ToLower(segments[0]) {
+	case "live", "catchup", "play", "player", "mpd":
+		// The ID is last, after the quality of /live/:quality/:id
+		return strings.TrimSuffix(segments[len(segments)-1], ".m3u8"), true
+	case "epg", "local":
+		if len(segments) > 1 {
+			return segments[1], true
+		}
+	}
+	return "", false
+}
+
+// signedChannel returns the channel in the idParam query param of a stream URL of the server, if it is
+// signed together with the decrypted upstream URLs in urlParams. It returns "" otherwise, so the channel
+// can't be changed and the URLs can't be swapped for ones of other channels.
+func signedChannel(c *fiber.Ctx, idParam string, urlParams ...string) string {
+	id := c.Query(idParam)
+	urls := make([]string, len(urlParams))
+	for i, param := range urlParams {
+		decrypted, err := secureurl.DecryptURL(c.Query(param))
+		if err != nil {
+			return ""
+		}
+		urls[i] = decrypted
+	}
+	if !secureurl.VerifyChannel(c.Query("channel_sig"), id, urls...) {
+		return ""
+	}
+	return id
+}
+
+// scopeAllowsChannel checks if a channel is in a scope. The channel list is only
+// looked up when the scope has languages or categories.
+func scopeAllowsChannel(scope share.Scope, id string) (bool, error) {
+	if id == "" || !scope.AllowsID(id) {
+		return false, nil
+	}
+	if len(scope.Languages) == 0 && len(scope.Categories) == 0 {
+		return true, nil
+	}
+	channels, err := television.Channels()
+	if err != nil {
+		return false, err
+	}
+	for _, channel := range channels.Result {
+		if channel.ID == id || strings.TrimPrefix(channel.ID, "sl") == strings.TrimPrefix(id, "sl") {
+			return scope.Allows(channel), nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/share"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+)
+
+func TestShareAccess(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+
+	now := time.Now()
+	link, err := share.Create("friend", share.Scope{ChannelIDs: []string{"143"}}, now.Add(time.Hour), now)
+	if err != nil {
+		t.Fatalf("share.Create() error = %v", err)
+	}
+	revoked, err := share.Create("old", share.Scope{ChannelIDs: []string{"143"}}, now.Add(time.Hour), now)
+	if err != nil {
+		t.Fatalf("share.Create() error = %v", err)
+	}
+	if err := share.Revoke(revoked.ID, now); err != nil {
+		t.Fatalf("share.Revoke() error = %v", err)
+	}
+	token := url.QueryEscape(link.Token)
+	secureurl.Init()
+	stream := signedQuery(t, "channel_key_id", "143", "auth", "https://cdn.example.com/143/index.m3u8")
+	otherStream := signedQuery(t, "channel_key_id", "144", "auth", "https://cdn.example.com/144/index.m3u8")
+
+	full := config.JioTVConfig{AuthUsername: "user", AuthPassword: "secret", APIKeys: []string{"my.key"}}
+	tests := []struct {
+		name         string
+		cfg          config.JioTVConfig
+		path         string
+		cookie       string
+		wantStatus   int
+		wantLocation string
+		wantBody     string
+	}{
+		{name: "Channel of the link", cfg: full, path: "/live/143?token=" + token, wantStatus: fiber.StatusFound, wantLocation: "/render.m3u8?auth=abc&channel_key_id=143&token=" + token},
+		{name: "Stream of the link", cfg: full, path: "/render.m3u8?" + stream + "&token=" + token, wantStatus: fiber.StatusOK, wantBody: "&token=" + token},
+		{name: "Other channel", cfg: full, path: "/live/144?token=" + token, wantStatus: fiber.StatusForbidden},
+		{name: "Channel of the link in upper case", cfg: full, path: "/LIVE/143/?token=" + token, wantStatus: fiber.StatusFound, wantLocation: "/render.m3u8?auth=abc&channel_key_id=143&token=" + token},
+		{name: "Other channel in upper case", cfg: full, path: "/Live/144?token=" + token, wantStatus: fiber.StatusForbidden},
+		{name: "Other channel of a profile in upper case", cfg: full, path: "/P/family/Live/144?token=" + token, wantStatus: fiber.StatusForbidden},
+		{name: "Stream of another channel", cfg: full, path: "/render.m3u8?" + otherStream + "&token=" + token, wantStatus: fiber.StatusForbidden},
+		{name: "Scope reaches handlers", cfg: full, path: "/channels?token=" + token, wantStatus: fiber.StatusOK, wantBody: "143"},
+		{name: "Page outside the link", cfg: config.JioTVConfig{}, path: "/?token=" + token, wantStatus: fiber.StatusForbidden},
+		{name: "Revoked link", cfg: full, path: "/live/143?token=" + url.QueryEscape(revoked.Token), wantStatus: fiber.StatusForbidden},
+		{name: "Forged token", cfg: config.JioTVConfig{}, path: "/live/143?token=e30.e30", wantStatus: fiber.StatusForbidden},
+		{name: "API key with a dot", cfg: full, path: "/live/144?token=my.key", wantStatus: fiber.StatusFound, wantLocation: "/render.m3u8?auth=abc&channel_key_id=144&token=my.key"},
+		{name: "Cookie of a link on another page", cfg: config.JioTVConfig{}, path: "/", cookie: link.Token, wantStatus: fiber.StatusOK},
+		{name: "Cookie of a link on a stream", cfg: full, path: "/live/143", cookie: link.Token, wantStatus: fiber.StatusFound, wantLocation: "/render.m3u8?auth=abc&channel_key_id=143&token=" + token},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := authApp(t, tt.cfg)
+			app.Get("/channels", func(c *fiber.Ctx) error {
+				if scope := ShareScope(c); scope != nil {
+					return c.SendString(strings.Join(scope.ChannelIDs, ","))
+				}
+				return c.SendString("all")
+			})
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: tokenCookie, Value: tt.cookie})
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatalf("GET %s error = %v", tt.path, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("GET %s = %d, want %d", tt.path, resp.StatusCode, tt.wantStatus)
+			}
+			if location := resp.Header.Get(fiber.HeaderLocation); location != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", location, tt.wantLocation)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+// signedQuery returns the query of a stream URL of the server for a channel, with its upstream URLs
+// encrypted into urlParams and the channel signed together with them
+func signedQuery(t *testing.T, idParam, id string, urlParams ...string) string {
+	t.Helper()
+	query := url.Values{idParam: {id}}
+	var urls []string
+	for i := 0; i+1 < len(urlParams); i += 2 {
+		encrypted, err := secureurl.EncryptURL(urlParams[i+1])
+		if err != nil {
+			t.Fatalf("EncryptURL() error = %v", err)
+		}
+		query.Set(urlParams[i], encrypted)
+		urls = append(urls, urlParams[i+1])
+	}
+	query.Set("channel_sig", secureurl.SignChannel(id, urls...))
+	return query.Encode()
+}
+
+func TestShareAccess_StreamRoutes(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+	secureurl.Init()
+
+	now := time.Now()
+	link, err := share.Create("friend", share.Scope{ChannelIDs: []string{"143"}}, now.Add(time.Hour), now)
+	if err != nil {
+		t.Fatalf("share.Create() error = %v", err)
+	}
+	token := url.QueryEscape(link.Token)
+
+	app := authApp(t, config.JioTVConfig{APIKeys: []string{"key"}})
+	app.All("/*", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	upstream := "https://cdn.example.com/143/index.m3u8"
+	// The signature of channel 143 with the channel changed to 144
+	changed := strings.Replace(signedQuery(t, "channel_key_id", "143", "auth", upstream), "channel_key_id=143", "channel_key_id=144", 1)
+	// The signature of channel 143 with the upstream URL swapped for another one
+	swapped, _ := secureurl.EncryptURL("https://attacker.example.com/")
+	swappedQuery := signedQuery(t, "channel_key_id", "143", "auth", upstream)
+	swappedValues, _ := url.ParseQuery(swappedQuery)
+	swappedValues.Set("auth", swapped)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"Playlist of the link", http.MethodGet, "/render.m3u8?" + signedQuery(t, "channel_key_id", "143", "auth", upstream), fiber.StatusOK},
+		{"Playlist of another channel", http.MethodGet, "/render.m3u8?" + signedQuery(t, "channel_key_id", "144", "auth", upstream), fiber.StatusForbidden},
+		{"Playlist without signature", http.MethodGet, "/render.m3u8?auth=abc&channel_key_id=143", fiber.StatusForbidden},
+		{"Playlist with the channel changed", http.MethodGet, "/render.m3u8?" + changed, fiber.StatusForbidden},
+		{"Playlist with the URL swapped", http.MethodGet, "/render.m3u8?" + swappedValues.Encode(), fiber.StatusForbidden},
+		{"Key of the link", http.MethodGet, "/render.key?" + signedQuery(t, "channel_key_id", "143", "auth", "https://tv.media.jio.com/key.pkey"), fiber.StatusOK},
+		{"Key without signature", http.MethodGet, "/render.key?auth=abc&channel_key_id=143", fiber.StatusForbidden},
+		{"Segment of the link", http.MethodGet, "/render.ts?" + signedQuery(t, "channel_key_id", "143", "auth", "https://cdn.example.com/143/seg-1.ts"), fiber.StatusOK},
+		{"Segment without channel", http.MethodGet, "/render.ts?auth=abc", fiber.StatusForbidden},
+		{"Segment of another channel", http.MethodGet, "/render.ts?" + signedQuery(t, "channel_key_id", "144", "auth", "https://cdn.example.com/144/seg-1.ts"), fiber.StatusForbidden},
+		{"Manifest of the link", http.MethodGet, "/render.mpd?" + signedQuery(t, "channel_key_id", "143", "auth", "https://cdn.example.com/143/index.mpd"), fiber.StatusOK},
+		{"Manifest without signature", http.MethodGet, "/render.mpd?auth=abc", fiber.StatusForbidden},
+		{"DASH segment of the link", http.MethodGet, "/render.dash/dash/seg-1.m4s?" + signedQuery(t, "channel_key_id", "143", "host", "cdn.example.com", "path", "/143/"), fiber.StatusOK},
+		{"DASH segment without signature", http.MethodGet, "/render.dash/dash/seg-1.m4s?host=abc&path=abc", fiber.StatusForbidden},
+		{"DASH segment of another channel", http.MethodGet, "/render.dash/dash/seg-1.m4s?" + signedQuery(t, "channel_key_id", "144", "host", "cdn.example.com", "path", "/144/"), fiber.StatusForbidden},
+		{"Licence of the link", http.MethodPost, "/drm?" + signedQuery(t, "channel_id", "143", "auth", "https://tv.media.jio.com/license", "channel", "https://cdn.example.com/143/index.mpd"), fiber.StatusOK},
+		{"Licence without signature", http.MethodPost, "/drm?auth=abc&channel=abc&channel_id=143", fiber.StatusForbidden},
+		{"Licence of another channel", http.MethodPost, "/drm?" + signedQuery(t, "channel_id", "144", "auth", "https://tv.media.jio.com/license", "channel", "https://cdn.example.com/144/index.mpd"), fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.path+"&token="+token, nil), -1)
+			if err != nil {
+				t.Fatalf("%s %s error = %v", tt.method, tt.path, err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/proxy"
@@ -125,3 +127,11 @@ func SetCacheHeader(c *fiber.Ctx, maxAge int) {
 func SetMustRevalidateHeader(c *fiber.Ctx, maxAge int) {
 	c.Response().Header.Set("Cache-Control", fmt.Sprintf("public, must-revalidate, max-age=%d", maxAge))
 }
+
+// SetAgeHeader sets the Age header to the number of seconds since the given time
+func SetAgeHeader(c *fiber.Ctx, since time.Time) {
+	if since.IsZero() {
+		return
+	}
+	c.Response().Header.Set(fiber.HeaderAge, strconv.FormatInt(int64(time.Since(since).Seconds()), 10))
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/jiotv-go/jiotv_go/v3/cmd"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+	"github.com/jiotv-go/jiotv_go/v3/internal/middleware"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
@@ -72,6 +73,9 @@ func main() {
 						cmd.Logger().Println("INFO: You are exposing your server to outside your local network (public)!")
 						cmd.Logger().Println("INFO: Overwriting host to [::] for public access")
 						host = "[::]"
+						if !middleware.AuthEnabled() {
+							cmd.Logger().Println("WARNING: No credential is configured, so anyone reaching the server can use it. Set auth_username, api_keys or admin_username in the config.")
+						}
 					}
 					port := c.String("port")
 					tls := c.Bool("tls")
@@ -136,18 +140,109 @@ func main() {
 						Name:        "otp",
 						Aliases:     []string{"o"},
 						Usage:       "Login using OTP",
-						Description: "The otp command logs you in using OTP. It will send OTP to your mobile number, and you have to enter the OTP to login.",
+						Description: "The otp command logs you in using OTP. It will send OTP to your mobile number, and you have to enter the OTP to login. Use --profile to save the login as a separate named profile.",
+						Flags: []cli.Flag{
+							utils.ProfileFlag(),
+						},
 						Action: func(c *cli.Context) error {
-							return cmd.LoginOTP()
+							return cmd.LoginOTPProfile(c.String("profile"))
 						},
 					},
 					{
 						Name:        "reset",
 						Aliases:     []string{"lo", "logout"},
 						Usage:       "Logout",
-						Description: "The logout command logs you out. It will delete the login file.",
+						Description: "The logout command logs you out. It will delete the login file. Use --profile to log out a named profile.",
+						Flags: []cli.Flag{
+							utils.ProfileFlag(),
+						},
+						Action: func(c *cli.Context) error {
+							return cmd.LogoutProfile(c.String("profile"))
+						},
+					},
+					{
+						Name:        "profiles",
+						Aliases:     []string{"p"},
+						Usage:       "List profiles or set the default profile",
+						ArgsUsage:   "[default profile]",
+						Description: "The profiles command lists all login profiles. If a profile name is given, it becomes the default profile used by routes without /p/:profile.",
+						Action: func(c *cli.Context) error {
+							return cmd.Profiles(c.Args().First())
+						},
+					},
+				},
+			},
+			{
+				Name:        "share",
+				Usage:       "Manage share links",
+				Description: "The share command manages share links, which give access to some channels until they expire without an API key. It talks to the running server, using the admin credential of the config.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "url",
+						Aliases: []string{"u"},
+						Value:   "http://localhost:5001",
+						Usage:   "URL of the running JioTV Go server",
+					},
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:        "create",
+						Aliases:     []string{"c", "new"},
+						Usage:       "Create a share link",
+						Description: "The create command creates a share link for some channels, languages or categories, and prints its playlist URL. Languages and categories are names or IDs, like English or 6.",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "name",
+								Aliases: []string{"n"},
+								Usage:   "Name of the link, like who it is for",
+							},
+							&cli.StringFlag{
+								Name:  "channels",
+								Usage: "Channel IDs separated by commas",
+							},
+							&cli.StringFlag{
+								Name:  "languages",
+								Usage: "Languages separated by commas",
+							},
+							&cli.StringFlag{
+								Name:  "categories",
+								Usage: "Categories separated by commas",
+							},
+							&cli.DurationFlag{
+								Name:    "expires",
+								Aliases: []string{"e"},
+								Value:   24 * time.Hour,
+								Usage:   "How long the link is valid, like 2h or 168h",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return cmd.ShareCreate(cmd.ShareOptions{
+								ServerURL:  c.String("url"),
+								Name:       c.String("name"),
+								Channels:   c.String("channels"),
+								Languages:  c.String("languages"),
+								Categories: c.String("categories"),
+								Expires:    c.Duration("expires"),
+							})
+						},
+					},
+					{
+						Name:        "list",
+						Aliases:     []string{"ls"},
+						Usage:       "List share links",
+						Description: "The list command lists the share links which have not expired.",
+						Action: func(c *cli.Context) error {
+							return cmd.ShareList(c.String("url"))
+						},
+					},
+					{
+						Name:        "revoke",
+						Aliases:     []string{"rm"},
+						Usage:       "Revoke a share link",
+						ArgsUsage:   "<id>",
+						Description: "The revoke command revokes a share link by its ID, so that it stops working before it expires.",
 						Action: func(c *cli.Context) error {
-							return cmd.Logout()
+							return cmd.ShareRevoke(c.String("url"), c.Args().First())
 						},
 					},
 				},
@@ -0,0 +1,183 @@
+// Package cache provides an in-memory cache for upstream responses.
+//
+// The cache is capped by the total size of cached bodies, evicting least recently used entries first.
+// Every entry has its own TTL, and concurrent requests for the same missing key share a single fetch.
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errFetchPanicked is returned to callers waiting on a fetch which panicked
+var errFetchPanicked = errors.New("upstream fetch panicked")
+
+// Entry is a cached upstream response. Entries are shared between callers and must not be modified.
+type Entry struct {
+	Body        []byte
+	StatusCode  int
+	ContentType string
+	// Extra holds a value which comes with the response, like a rotated token or the final URL after redirects
+	Extra string
+}
+
+// Stats holds counters of cache usage
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Coalesced uint64
+	Entries   int
+	Bytes     int64
+}
+
+// item is an entry in the LRU list
+type item struct {
+	key     string
+	entry   *Entry
+	expires time.Time
+}
+
+// call is an in-flight fetch shared by concurrent callers
+type call struct {
+	done  chan struct{}
+	entry *Entry
+	err   error
+}
+
+// Cache is a byte-capped LRU cache with per-entry TTL and request coalescing
+type Cache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	lru      *list.List
+	items    map[string]*list.Element
+	calls    map[string]*call
+	stats    Stats
+}
+
+// New creates a cache which holds at most maxBytes of response bodies
+func New(maxBytes int64) *Cache {
+	return &Cache{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+		calls:    make(map[string]*call),
+	}
+}
+
+// Get returns the cached entry for key if it exists and has not expired
+func (c *Cache) Get(key string) (*Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.getLocked(key)
+}
+
+// getLocked returns the cached entry for key. c.mu must be held.
+func (c *Cache) getLocked(key string) (*Entry, bool) {
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	cached := element.Value.(*item)
+	if time.Now().After(cached.expires) {
+		c.removeElement(element)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return cached.entry, true
+}
+
+// Set stores an entry for ttl. Entries with ttl <= 0, or bigger than the whole cache, are not stored.
+func (c *Cache) Set(key string, entry *Entry, ttl time.Duration) {
+	size := int64(len(entry.Body))
+	if ttl <= 0 || size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+	c.items[key] = c.lru.PushFront(&item{key: key, entry: entry, expires: time.Now().Add(ttl)})
+	c.size += size
+
+	for c.size > c.maxBytes {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// Delete removes the entry for key
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+// removeElement removes an entry from the LRU list. c.mu must be held.
+func (c *Cache) removeElement(element *list.Element) {
+	cached := c.lru.Remove(element).(*item)
+	delete(c.items, cached.key)
+	c.size -= int64(len(cached.entry.Body))
+}
+
+// Fetch returns the cached entry for key. On a miss, fetch is called once for all concurrent callers
+// of the same key and its entry is stored for the returned TTL. Errors are never cached.
+func (c *Cache) Fetch(key string, fetch func() (*Entry, time.Duration, error)) (*Entry, error) {
+	c.mu.Lock()
+	if entry, ok := c.getLocked(key); ok {
+		c.stats.Hits++
+		c.mu.Unlock()
+		return entry, nil
+	}
+	if inFlight, ok := c.calls[key]; ok {
+		c.stats.Coalesced++
+		c.mu.Unlock()
+		<-inFlight.done
+		return inFlight.entry, inFlight.err
+	}
+	c.stats.Misses++
+	current := &call{done: make(chan struct{})}
+	c.calls[key] = current
+	c.mu.Unlock()
+
+	c.doFetch(key, current, fetch)
+	return current.entry, current.err
+}
+
+// doFetch runs fetch for an in-flight call and releases the callers waiting on it,
+// even if fetch panics
+func (c *Cache) doFetch(key string, current *call, fetch func() (*Entry, time.Duration, error)) {
+	returned := false
+	defer func() {
+		if !returned {
+			current.err = errFetchPanicked
+		}
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(current.done)
+	}()
+
+	entry, ttl, err := fetch()
+	returned = true
+	current.entry, current.err = entry, err
+	if err == nil && entry != nil {
+		c.Set(key, entry, ttl)
+	}
+}
+
+// Stats returns the current cache counters
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.items)
+	stats.Bytes = c.size
+	return stats
+}
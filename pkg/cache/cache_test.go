@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_SetGet(t *testing.T) {
+	c := New(1024)
+	c.Set("a", &Entry{Body: []byte("hello"), StatusCode: 200}, time.Minute)
+
+	entry, ok := c.Get("a")
+	if !ok || string(entry.Body) != "hello" {
+		t.Fatalf("Get() = %v, %v, want cached entry", entry, ok)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Error("Get() of missing key returned an entry")
+	}
+
+	c.Set("zero", &Entry{Body: []byte("x")}, 0)
+	if _, ok := c.Get("zero"); ok {
+		t.Error("entry with zero TTL should not be stored")
+	}
+
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get() after Delete() returned an entry")
+	}
+	if stats := c.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("Stats() = %+v, want empty cache", stats)
+	}
+}
+
+func TestCache_Expiry(t *testing.T) {
+	c := New(1024)
+	c.Set("a", &Entry{Body: []byte("hello")}, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get() returned an expired entry")
+	}
+	if stats := c.Stats(); stats.Bytes != 0 {
+		t.Errorf("expired entry still counted: %+v", stats)
+	}
+}
+
+func TestCache_SizeCap(t *testing.T) {
+	c := New(10)
+	c.Set("a", &Entry{Body: []byte("1234")}, time.Minute)
+	c.Set("b", &Entry{Body: []byte("1234")}, time.Minute)
+	// Use a so b is the least recently used entry
+	c.Get("a")
+	c.Set("c", &Entry{Body: []byte("1234")}, time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("least recently used entry should be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("recently used entry should be kept")
+	}
+	if stats := c.Stats(); stats.Bytes > 10 {
+		t.Errorf("cache size %d exceeds cap", stats.Bytes)
+	}
+
+	c.Set("big", &Entry{Body: make([]byte, 11)}, time.Minute)
+	if _, ok := c.Get("big"); ok {
+		t.Error("entry bigger than the cache should not be stored")
+	}
+}
+
+func TestCache_FetchCoalescing(t *testing.T) {
+	c := New(1024)
+	var fetches atomic.Int32
+	release := make(chan struct{})
+
+	fetch := func() (*Entry, time.Duration, error) {
+		fetches.Add(1)
+		<-release
+		return &Entry{Body: []byte("segment"), StatusCode: 200}, time.Minute, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*Entry, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			entry, err := c.Fetch("seg", fetch)
+			if err != nil {
+				t.Errorf("Fetch() error = %v", err)
+			}
+			results[i] = entry
+		}(i)
+	}
+
+	// Wait until all callers joined the in-flight fetch
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if stats := c.Stats(); stats.Misses+stats.Coalesced == 5 {
+			break
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("fetch called %d times, want 1", got)
+	}
+	for i, entry := range results {
+		if entry == nil || string(entry.Body) != "segment" {
+			t.Errorf("caller %d got %v", i, entry)
+		}
+	}
+
+	// The result is cached for later callers
+	if _, err := c.Fetch("seg", fetch); err != nil || fetches.Load() != 1 {
+		t.Errorf("Fetch() after caching called upstream again")
+	}
+	if stats := c.Stats(); stats.Hits != 1 {
+		t.Errorf("Stats().Hits = %d, want 1", stats.Hits)
+	}
+}
+
+func TestCache_FetchError(t *testing.T) {
+	c := New(1024)
+	wantErr := errors.New("upstream down")
+	if _, err := c.Fetch("a", func() (*Entry, time.Duration, error) {
+		return nil, time.Minute, wantErr
+	}); !errors.Is(err, wantErr) {
+		t.Errorf("Fetch() error = %v, want %v", err, wantErr)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Error("errors should not be cached")
+	}
+}
+
+func TestCache_FetchPanic(t *testing.T) {
+	c := New(1024)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	go func() {
+		defer func() { recover() }()
+		c.Fetch("a", func() (*Entry, time.Duration, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	waiterErr := make(chan error)
+	go func() {
+		_, err := c.Fetch("a", func() (*Entry, time.Duration, error) {
+			return &Entry{}, time.Minute, nil
+		})
+		waiterErr <- err
+	}()
+	// Give the waiter time to join the in-flight fetch
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if c.Stats().Coalesced == 1 {
+			break
+		}
+	}
+	close(release)
+
+	select {
+	case err := <-waiterErr:
+		if !errors.Is(err, errFetchPanicked) {
+			t.Errorf("waiter error = %v, want errFetchPanicked", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not released after the fetch panicked")
+	}
+}
@@ -0,0 +1,472 @@
+// Package dvr records live channels to disk.
+// A recording follows the HLS playlist of a channel from its start to its end and appends every segment,
+// decrypted, to a continuous .ts file under the recordings directory.
+// Recordings and series rules are saved in the store and scheduled again when the server starts.
+package dvr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/tasks"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// recordingsKey is the store key of the recordings
+	recordingsKey = "dvr_recordings"
+	// recordingsDir is the directory of the recorded files under the path prefix
+	recordingsDir = "recordings"
+)
+
+var (
+	// ErrNoGuide is returned when a programme is recorded before the EPG is loaded
+	ErrNoGuide = errors.New("EPG not available")
+	// ErrProgrammeNotFound is returned when the EPG has no programme of the channel at the start time
+	ErrProgrammeNotFound = errors.New("programme not found")
+	// ErrInvalidChannel is returned for channel IDs which can't be used in a file name
+	ErrInvalidChannel = errors.New("invalid channel ID")
+	// ErrInvalidRange is returned when the end of a recording is not after its start
+	ErrInvalidRange = errors.New("end must be after start")
+	// ErrEnded is returned when the end of a recording has already passed
+	ErrEnded = errors.New("end has already passed")
+	// ErrExists is returned when the programme of a recording is already recorded
+	ErrExists = errors.New("recording already exists")
+	// ErrNotFound is returned for recordings which do not exist
+	ErrNotFound = errors.New("recording not found")
+	// ErrNotActive is returned when cancelling a recording which is neither scheduled nor recording
+	ErrNotActive = errors.New("recording is not scheduled or recording")
+)
+
+// Status is the state of a recording
+type Status string
+
+const (
+	// StatusScheduled recordings wait for their start
+	StatusScheduled Status = "scheduled"
+	// StatusRecording recordings are being written
+	StatusRecording Status = "recording"
+	// StatusCompleted recordings were recorded until their end, or until the stream ended
+	StatusCompleted Status = "completed"
+	// StatusFailed recordings stopped because the stream could not be fetched
+	StatusFailed Status = "failed"
+	// StatusCancelled recordings were cancelled by the user. What was recorded before is kept.
+	StatusCancelled Status = "cancelled"
+	// StatusSkipped recordings of a series rule were deleted by the user before their start,
+	// and are kept so that the rule doesn't schedule them again
+	StatusSkipped Status = "skipped"
+)
+
+// Recording is a recording of a channel between Start and End
+type Recording struct {
+	ID          string    `json:"id"`
+	ChannelID   string    `json:"channel_id"`
+	ChannelName string    `json:"channel_name"`
+	Title       string    `json:"title"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Status      Status    `json:"status"`
+	// File is the name of the recorded file in the recordings directory
+	File string `json:"file"`
+	// Size is the size of the recorded file in bytes
+	Size  int64  `json:"size"`
+	Error string `json:"error,omitempty"`
+	// RuleID is the series rule which scheduled the recording
+	RuleID string `json:"rule_id,omitempty"`
+}
+
+// Active reports whether the recording is scheduled or being recorded
+func (r Recording) Active() bool {
+	return r.Status == StatusScheduled || r.Status == StatusRecording
+}
+
+// job is a running recording
+type job struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	// mu serializes read-modify-write updates of the recordings and rules, and guards jobs
+	mu sync.Mutex
+	// jobs are the running recordings by ID
+	jobs = map[string]*job{}
+	// source fetches the recorded streams
+	source Source
+
+	// channelIDPattern matches the channel IDs which are safe to use in file names
+	channelIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// Init schedules the saved recordings and applies the series rules to the current EPG.
+// Recordings interrupted by a restart are resumed if their end has not passed.
+// It must be called after the store and the scheduler are initialized.
+func Init(src Source) {
+	mu.Lock()
+	source = src
+	mu.Unlock()
+	epg.OnGuideLoaded(ApplyRules)
+
+	mu.Lock()
+	recordings, err := load()
+	if err != nil {
+		mu.Unlock()
+		utils.Log.Printf("ERROR: Failed to load recordings: %v", err)
+		return
+	}
+	now := time.Now()
+	scheduled := 0
+	for i := range recordings {
+		recording := &recordings[i]
+		if !recording.Active() {
+			continue
+		}
+		if !recording.End.After(now) {
+			recording.Status = StatusFailed
+			recording.Error = "the server was not running"
+			continue
+		}
+		schedule(*recording)
+		scheduled++
+	}
+	if err := save(recordings); err != nil {
+		utils.Log.Printf("ERROR: Failed to save recordings: %v", err)
+	}
+	mu.Unlock()
+	if scheduled > 0 {
+		utils.Log.Printf("Scheduled %d recordings", scheduled)
+	}
+
+	if guide := epg.CurrentGuide(); guide != nil {
+		ApplyRules(guide)
+	}
+}
+
+// Dir returns the directory of the recorded files
+func Dir() string {
+	return filepath.Join(utils.GetPathPrefix(), recordingsDir)
+}
+
+// FilePath returns the path of the file of a recording
+func FilePath(recording Recording) string {
+	return filepath.Join(Dir(), recording.File)
+}
+
+// List returns the recordings sorted by start
+func List() ([]Recording, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return load()
+}
+
+// Get returns a recording by ID
+func Get(id string) (Recording, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	recordings, err := load()
+	if err != nil {
+		return Recording{}, err
+	}
+	if i := index(recordings, id); i >= 0 {
+		return recordings[i], nil
+	}
+	return Recording{}, ErrNotFound
+}
+
+// Schedule records a channel between start and end. A start in the past starts the recording right away.
+func Schedule(channelID, title string, start, end time.Time) (Recording, error) {
+	if !channelIDPattern.MatchString(channelID) {
+		return Recording{}, ErrInvalidChannel
+	}
+	if !end.After(start) {
+		return Recording{}, ErrInvalidRange
+	}
+	if !end.After(time.Now()) {
+		return Recording{}, ErrEnded
+	}
+	recording := newRecording(channelID, title, start, end)
+	if guide := epg.CurrentGuide(); guide != nil {
+		recording.ChannelName = guide.ChannelName(channelID)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	recordings, err := load()
+	if err != nil {
+		return Recording{}, err
+	}
+	if i := index(recordings, recording.ID); i >= 0 {
+		if recordings[i].Status != StatusSkipped {
+			return Recording{}, ErrExists
+		}
+		// A programme skipped by a series rule can still be recorded by hand
+		recordings = append(recordings[:i], recordings[i+1:]...)
+	}
+	if err := save(append(recordings, recording)); err != nil {
+		return Recording{}, err
+	}
+	schedule(recording)
+	return recording, nil
+}
+
+// ScheduleProgramme records the programme of the EPG airing on a channel at start
+func ScheduleProgramme(channelID string, start time.Time) (Recording, error) {
+	guide := epg.CurrentGuide()
+	if guide == nil {
+		return Recording{}, ErrNoGuide
+	}
+	programmes := guide.Between(channelID, start, start.Add(time.Second))
+	if len(programmes) == 0 {
+		return Recording{}, ErrProgrammeNotFound
+	}
+	programme := programmes[0]
+	return Schedule(channelID, programme.Title, programme.Start, programme.Stop)
+}
+
+// Cancel stops a recording which is scheduled or being recorded.
+// What was recorded before is kept. It returns the updated recording.
+func Cancel(id string) (Recording, error) {
+	mu.Lock()
+	recordings, err := load()
+	if err != nil {
+		mu.Unlock()
+		return Recording{}, err
+	}
+	i := index(recordings, id)
+	if i < 0 {
+		mu.Unlock()
+		return Recording{}, ErrNotFound
+	}
+	if !recordings[i].Active() {
+		mu.Unlock()
+		return Recording{}, ErrNotActive
+	}
+	scheduler.Remove(tasks.RecordingTaskIDPrefix + id)
+	running := jobs[id]
+	if running == nil {
+		recordings[i].Status = StatusCancelled
+		err = save(recordings)
+	}
+	mu.Unlock()
+	if err != nil {
+		return Recording{}, err
+	}
+	if running != nil {
+		// The recording sets its status once it stopped
+		running.cancel()
+		<-running.done
+	}
+	return Get(id)
+}
+
+// Delete removes a recording and its file. A running recording is stopped first.
+// Recordings of a series rule which have not started are kept as skipped instead.
+func Delete(id string) error {
+	mu.Lock()
+	running := jobs[id]
+	mu.Unlock()
+	if running != nil {
+		running.cancel()
+		<-running.done
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	recordings, err := load()
+	if err != nil {
+		return err
+	}
+	i := index(recordings, id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	scheduler.Remove(tasks.RecordingTaskIDPrefix + id)
+	if err := removeFile(recordings[i]); err != nil {
+		return err
+	}
+	// The rule would schedule the programme again when the EPG is generated
+	if recordings[i].RuleID != "" && recordings[i].Start.After(time.Now()) {
+		recordings[i].Status = StatusSkipped
+		recordings[i].Size = 0
+		recordings[i].Error = ""
+		return save(recordings)
+	}
+	return save(append(recordings[:i], recordings[i+1:]...))
+}
+
+// newRecording returns a scheduled recording. A programme has at most one recording, so its ID is made of
+// the channel ID and the start time.
+func newRecording(channelID, title string, start, end time.Time) Recording {
+	id := channelID + "-" + strconv.FormatInt(start.Unix(), 10)
+	if title == "" {
+		title = fmt.Sprintf("%s %s", channelID, start.Format("2006-01-02 15:04"))
+	}
+	return Recording{
+		ID:        id,
+		ChannelID: channelID,
+		Title:     title,
+		Start:     start,
+		End:       end,
+		Status:    StatusScheduled,
+		File:      id + ".ts",
+	}
+}
+
+// schedule adds the task starting a recording at its start
+func schedule(recording Recording) {
+	scheduler.AddOnce(tasks.RecordingTaskIDPrefix+recording.ID, time.Until(recording.Start), func() error {
+		return start(recording.ID)
+	})
+}
+
+// start marks a recording as recording and records it in the background
+func start(id string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	recordings, err := load()
+	if err != nil {
+		return err
+	}
+	i := index(recordings, id)
+	if i < 0 || !recordings[i].Active() || jobs[id] != nil {
+		return nil
+	}
+	recordings[i].Status = StatusRecording
+	if err := save(recordings); err != nil {
+		return err
+	}
+
+	recording := recordings[i]
+	ctx, cancel := context.WithDeadline(context.Background(), recording.End)
+	running := &job{cancel: cancel, done: make(chan struct{})}
+	jobs[id] = running
+	go run(ctx, running, recording)
+	return nil
+}
+
+// run records until the end of the recording or until it is cancelled, then saves its final status
+func run(ctx context.Context, running *job, recording Recording) {
+	defer close(running.done)
+	defer running.cancel()
+	utils.Log.Printf("Recording %s on channel %s", recording.Title, recording.ChannelID)
+
+	err := record(ctx, recording)
+	status := StatusCompleted
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		status = StatusCancelled
+	case err != nil:
+		status = StatusFailed
+		utils.Log.Printf("ERROR: Recording %s failed: %v", recording.ID, err)
+	}
+
+	mu.Lock()
+	delete(jobs, recording.ID)
+	recordings, loadErr := load()
+	if loadErr == nil {
+		if i := index(recordings, recording.ID); i >= 0 {
+			recordings[i].Status = status
+			recordings[i].Error = ""
+			if err != nil && status == StatusFailed {
+				recordings[i].Error = err.Error()
+			}
+			if info, statErr := os.Stat(FilePath(recordings[i])); statErr == nil {
+				recordings[i].Size = info.Size()
+			}
+			loadErr = save(recordings)
+		}
+	}
+	mu.Unlock()
+	if loadErr != nil {
+		utils.Log.Printf("ERROR: Failed to save recording %s: %v", recording.ID, loadErr)
+		return
+	}
+	utils.Log.Printf("Recording %s on channel %s %s", recording.Title, recording.ChannelID, status)
+
+	if status == StatusCompleted && recording.RuleID != "" {
+		applyRetention(recording.RuleID)
+	}
+}
+
+// record appends the stream of the channel to the file of the recording.
+// Appending lets a recording interrupted by a restart resume into the same file.
+func record(ctx context.Context, recording Recording) error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(FilePath(recording), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	mu.Lock()
+	src := source
+	mu.Unlock()
+	if src == nil {
+		return errors.New("no stream source to record from")
+	}
+	r := newRecorder(src, recording.ChannelID, file)
+	return r.record(ctx)
+}
+
+// removeFile deletes the file of a recording if it exists
+func removeFile(recording Recording) error {
+	if err := os.Remove(FilePath(recording)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// index returns the index of the recording with the given ID, or -1
+func index(recordings []Recording, id string) int {
+	for i := range recordings {
+		if recordings[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// load reads the recordings from the store. A missing key is an empty list.
+func load() ([]Recording, error) {
+	value, err := store.Get(recordingsKey)
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return []Recording{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var recordings []Recording
+	if err := json.Unmarshal([]byte(value), &recordings); err != nil {
+		return nil, fmt.Errorf("invalid recordings in store: %w", err)
+	}
+	if recordings == nil {
+		recordings = []Recording{}
+	}
+	return recordings, nil
+}
+
+// save writes the recordings to the store sorted by start
+func save(recordings []Recording) error {
+	sort.SliceStable(recordings, func(i, j int) bool {
+		return recordings[i].Start.Before(recordings[j].Start)
+	})
+	value, err := json.Marshal(recordings)
+	if err != nil {
+		return err
+	}
+	return store.Set(recordingsKey, string(value))
+}
@@ -0,0 +1,325 @@
+package dvr
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/tasks"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestMain(m *testing.M) {
+	utils.Log = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// setup initializes the store and the scheduler, with a source serving an ended stream of channel 143
+func setup(t *testing.T) *fakeSource {
+	t.Helper()
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	t.Cleanup(cleanup)
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+	scheduler.Init()
+	t.Cleanup(scheduler.Stop)
+
+	src := &fakeSource{
+		playlistURLs: []string{"https://cdn.test/143/index.m3u8"},
+		files: map[string][]byte{
+			"/143/index.m3u8": []byte("#EXTM3U\n#EXTINF:1,\n1.ts\n#EXTINF:1,\n2.ts\n#EXT-X-ENDLIST\n"),
+			"/143/1.ts":       []byte("one"),
+			"/143/2.ts":       []byte("two"),
+		},
+	}
+	mu.Lock()
+	source = src
+	mu.Unlock()
+	return src
+}
+
+// guideOf builds a guide of channel 143 with programmes of an hour starting at the given times
+func guideOf(programmes map[string]time.Time) *epg.Guide {
+	layout := "20060102150405 -0700"
+	var list []epg.Programme
+	for title, start := range programmes {
+		list = append(list, epg.Programme{
+			Channel: "143",
+			Start:   start.Format(layout),
+			Stop:    start.Add(time.Hour).Format(layout),
+			Title:   epg.Title{Value: title},
+		})
+	}
+	return epg.NewGuide([]epg.Channel{{ID: 143, Display: "News"}}, list)
+}
+
+// waitForStatus waits until a recording has the given status
+func waitForStatus(t *testing.T, id string, status Status) Recording {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		recording, err := Get(id)
+		if err == nil && recording.Status == status {
+			return recording
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("recording %s = %+v, %v, want status %s", id, recording, err, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	setup(t)
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name      string
+		channelID string
+		start     time.Time
+		end       time.Time
+		wantErr   error
+	}{
+		{name: "Path in channel ID", channelID: "../143", start: start, end: start.Add(time.Hour), wantErr: ErrInvalidChannel},
+		{name: "End before start", channelID: "143", start: start, end: start, wantErr: ErrInvalidRange},
+		{name: "Ended", channelID: "143", start: start.Add(-3 * time.Hour), end: start.Add(-2 * time.Hour), wantErr: ErrEnded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Schedule(tt.channelID, "", tt.start, tt.end); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Schedule() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	recording, err := Schedule("143", "Later", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+	if recording.Status != StatusScheduled || recording.File != recording.ID+".ts" {
+		t.Errorf("Schedule() = %+v, want a scheduled recording", recording)
+	}
+	if _, err := scheduler.Scheduler.Lookup(tasks.RecordingTaskIDPrefix + recording.ID); err != nil {
+		t.Errorf("recording was not scheduled: %v", err)
+	}
+	if _, err := Schedule("143", "Later", start, start.Add(time.Hour)); !errors.Is(err, ErrExists) {
+		t.Errorf("Schedule() of the same programme error = %v, want %v", err, ErrExists)
+	}
+
+	cancelled, err := Cancel(recording.ID)
+	if err != nil || cancelled.Status != StatusCancelled {
+		t.Errorf("Cancel() = %+v, %v, want a cancelled recording", cancelled, err)
+	}
+	if _, err := scheduler.Scheduler.Lookup(tasks.RecordingTaskIDPrefix + recording.ID); err == nil {
+		t.Error("cancelled recording is still scheduled")
+	}
+	if _, err := Cancel(recording.ID); !errors.Is(err, ErrNotActive) {
+		t.Errorf("Cancel() of a cancelled recording error = %v, want %v", err, ErrNotActive)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	setup(t)
+	// A recording starting in the past starts right away
+	recording, err := Schedule("143", "Now", time.Now().Add(-time.Minute), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+	recording = waitForStatus(t, recording.ID, StatusCompleted)
+
+	data, err := os.ReadFile(FilePath(recording))
+	if err != nil {
+		t.Fatalf("reading recorded file: %v", err)
+	}
+	if string(data) != "onetwo" || recording.Size != 6 {
+		t.Errorf("recorded %q of size %d, want onetwo", data, recording.Size)
+	}
+
+	if err := Delete(recording.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := os.Stat(FilePath(recording)); !os.IsNotExist(err) {
+		t.Errorf("file of the deleted recording still exists: %v", err)
+	}
+	if err := Delete(recording.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() of a deleted recording error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestCancel_Recording(t *testing.T) {
+	src := setup(t)
+	// A stream which never ends is recorded until cancelled
+	src.files["/143/index.m3u8"] = []byte("#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXTINF:1,\n1.ts\n")
+
+	recording, err := Schedule("143", "Live", time.Now().Add(-time.Minute), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+	waitForStatus(t, recording.ID, StatusRecording)
+	cancelled, err := Cancel(recording.ID)
+	if err != nil || cancelled.Status != StatusCancelled {
+		t.Errorf("Cancel() = %+v, %v, want a cancelled recording", cancelled, err)
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	setup(t)
+	start := time.Now().Add(2 * time.Hour).Truncate(time.Minute)
+
+	if _, err := AddRule("(", "", 0); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("AddRule() of an invalid pattern error = %v, want %v", err, ErrInvalidPattern)
+	}
+	rule, err := AddRule("^the news", "143", 2)
+	if err != nil {
+		t.Fatalf("AddRule() error = %v", err)
+	}
+
+	ApplyRules(guideOf(map[string]time.Time{"The News": start, "Movie": start.Add(time.Hour), "The News Tonight": start.Add(2 * time.Hour)}))
+	list, _ := List()
+	var titles []string
+	for _, recording := range list {
+		titles = append(titles, recording.Title)
+		if recording.RuleID != rule.ID || recording.ChannelName != "News" {
+			t.Errorf("recording %+v was not scheduled by the rule", recording)
+		}
+	}
+	if got, want := strings.Join(titles, ","), "The News,The News Tonight"; got != want {
+		t.Errorf("recordings after applying the rule = %s, want %s", got, want)
+	}
+
+	// The regenerated EPG no longer has the late news
+	ApplyRules(guideOf(map[string]time.Time{"The News": start}))
+	if list, _ := List(); len(list) != 1 || list[0].Title != "The News" {
+		t.Errorf("recordings after the EPG changed = %+v, want only The News", list)
+	}
+
+	if err := RemoveRule(rule.ID); err != nil {
+		t.Fatalf("RemoveRule() error = %v", err)
+	}
+	if list, _ := List(); len(list) != 0 {
+		t.Errorf("recordings after removing the rule = %+v, want none", list)
+	}
+	if err := RemoveRule(rule.ID); !errors.Is(err, ErrRuleNotFound) {
+		t.Errorf("RemoveRule() of a removed rule error = %v, want %v", err, ErrRuleNotFound)
+	}
+}
+
+func TestApplyRules_DeletedRecording(t *testing.T) {
+	setup(t)
+	start := time.Now().Add(2 * time.Hour).Truncate(time.Minute)
+	guide := guideOf(map[string]time.Time{"The News": start})
+
+	if _, err := AddRule("^the news", "143", 0); err != nil {
+		t.Fatalf("AddRule() error = %v", err)
+	}
+	ApplyRules(guide)
+	list, _ := List()
+	if len(list) != 1 {
+		t.Fatalf("recordings after applying the rule = %+v, want one", list)
+	}
+	id := list[0].ID
+
+	if err := Delete(id); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if recording, err := Get(id); err != nil || recording.Status != StatusSkipped {
+		t.Fatalf("deleted recording of a rule = %+v, %v, want it skipped", recording, err)
+	}
+	// The regenerated EPG must not schedule the deleted recording again
+	ApplyRules(guide)
+	if recording, _ := Get(id); recording.Status != StatusSkipped {
+		t.Errorf("recording after applying the rule again = %+v, want it still skipped", recording)
+	}
+
+	// A skipped programme can still be recorded by hand
+	recording, err := Schedule("143", "The News", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Schedule() of a skipped programme error = %v", err)
+	}
+	if recording.Status != StatusScheduled || recording.RuleID != "" {
+		t.Errorf("recording scheduled by hand = %+v, want it scheduled without rule", recording)
+	}
+	if err := Delete(id); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := Get(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of a deleted recording error = %v, want %v", err, ErrNotFound)
+	}
+
+	// Skipped recordings are dropped once their programme started
+	mu.Lock()
+	err = save([]Recording{{ID: "143-1", ChannelID: "143", Start: time.Now().Add(-time.Hour), End: time.Now(), Status: StatusSkipped, RuleID: "rule"}})
+	mu.Unlock()
+	if err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+	ApplyRules(guide)
+	if _, err := Get("143-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of a started skipped recording error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	setup(t)
+	rule, err := AddRule("news", "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Add(-72 * time.Hour)
+	var recordings []Recording
+	for i := 0; i < 4; i++ {
+		recording := newRecording("143", "News", start.Add(time.Duration(i)*24*time.Hour), start.Add(time.Duration(i)*24*time.Hour+time.Hour))
+		recording.Status = StatusCompleted
+		recording.RuleID = rule.ID
+		if err := os.MkdirAll(Dir(), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(FilePath(recording), []byte("ts"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		recordings = append(recordings, recording)
+	}
+	if err := save(append([]Recording(nil), recordings...)); err != nil {
+		t.Fatal(err)
+	}
+
+	applyRetention(rule.ID)
+
+	list, _ := List()
+	if len(list) != 2 || list[0].ID != recordings[2].ID || list[1].ID != recordings[3].ID {
+		t.Errorf("recordings after retention = %+v, want the last 2", list)
+	}
+	if _, err := os.Stat(FilePath(recordings[0])); !os.IsNotExist(err) {
+		t.Errorf("file of the oldest recording still exists: %v", err)
+	}
+}
+
+func TestInit(t *testing.T) {
+	src := setup(t)
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+	missed := newRecording("143", "Missed", start.Add(-3*time.Hour), start.Add(-2*time.Hour))
+	upcoming := newRecording("143", "Upcoming", start, start.Add(time.Hour))
+	if err := save([]Recording{missed, upcoming}); err != nil {
+		t.Fatal(err)
+	}
+
+	Init(src)
+
+	if _, err := scheduler.Scheduler.Lookup(tasks.RecordingTaskIDPrefix + upcoming.ID); err != nil {
+		t.Errorf("saved recording was not scheduled: %v", err)
+	}
+	if recording, _ := Get(missed.ID); recording.Status != StatusFailed {
+		t.Errorf("recording missed while the server was down = %+v, want failed", recording)
+	}
+}
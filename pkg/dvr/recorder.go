@@ -0,0 +1,358 @@
+package dvr
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// maxFailures is the number of failed playlist polls in a row after which a recording fails
+	maxFailures = 10
+	// defaultPollInterval is used for playlists without a target duration
+	defaultPollInterval = 3 * time.Second
+)
+
+// retryDelay is the time to wait after a failed playlist poll
+var retryDelay = 3 * time.Second
+
+// Source fetches the streams of the recorded channels
+type Source interface {
+	// PlaylistURL returns the URL of the HLS playlist of a channel, with fresh tokens
+	PlaylistURL(channelID string) (string, error)
+	// Invalidate is called when the playlist URL of a channel is no longer accepted, e.g. after a 403
+	Invalidate(channelID string)
+	// Get fetches a playlist or a segment of a channel. It returns the rotated hdnea token, if any.
+	Get(channelID, url string) ([]byte, int, string, error)
+	// Key fetches an AES-128 key of a channel's stream
+	Key(channelID, url string) ([]byte, int, error)
+}
+
+// recorder follows the media playlist of a channel and writes its segments
+type recorder struct {
+	source    Source
+	channelID string
+	w         io.Writer
+
+	// playlistURL is the media playlist being followed. It is resolved again after a failure.
+	playlistURL string
+	// hdnea is the latest token rotated by upstream, sent instead of the one in the URLs
+	hdnea string
+	// lastSequence is the media sequence number of the last written segment
+	lastSequence int64
+	// keys are the fetched keys by URL
+	keys map[string][]byte
+}
+
+func newRecorder(source Source, channelID string, w io.Writer) *recorder {
+	return &recorder{
+		source:       source,
+		channelID:    channelID,
+		w:            w,
+		lastSequence: -1,
+		keys:         map[string][]byte{},
+	}
+}
+
+// record polls the playlist until ctx is done or the playlist ends.
+// Failures make the source invalidate the playlist URL, so a fresh one with new tokens is used on the next poll.
+func (r *recorder) record(ctx context.Context) error {
+	failures := 0
+	for ctx.Err() == nil {
+		wait, ended, err := r.safePoll()
+		if ended {
+			return nil
+		}
+		if err != nil {
+			failures++
+			if failures >= maxFailures {
+				return err
+			}
+			utils.Log.Printf("Recording of channel %s: %v, retrying", r.channelID, err)
+			r.source.Invalidate(r.channelID)
+			// The fresh playlist URL comes with its own token
+			r.playlistURL, r.hdnea = "", ""
+			wait = retryDelay
+		} else {
+			failures = 0
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+	}
+	return nil
+}
+
+// safePoll polls like poll, turning a panic of the source into an error.
+// Recordings run in their own goroutine, where a panic would take the whole server down.
+func (r *recorder) safePoll() (wait time.Duration, ended bool, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			wait, ended, err = 0, false, fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return r.poll()
+}
+
+// poll fetches the media playlist and writes its new segments.
+// It returns the time to wait before the next poll, and whether the playlist has ended.
+func (r *recorder) poll() (time.Duration, bool, error) {
+	if r.playlistURL == "" {
+		playlistURL, err := r.source.PlaylistURL(r.channelID)
+		if err != nil {
+			return 0, false, err
+		}
+		r.playlistURL = playlistURL
+	}
+	playlist, err := r.fetchPlaylist(r.playlistURL)
+	if err != nil {
+		return 0, false, err
+	}
+	// Follow the variant with the highest bandwidth of a master playlist
+	if playlist.IsMaster() {
+		variant, err := bestVariant(playlist)
+		if err != nil {
+			return 0, false, err
+		}
+		if r.playlistURL, err = resolve(r.playlistURL, variant); err != nil {
+			return 0, false, err
+		}
+		if playlist, err = r.fetchPlaylist(r.playlistURL); err != nil {
+			return 0, false, err
+		}
+	}
+
+	wait := defaultPollInterval
+	ended := false
+	sequence := int64(0)
+	var key *hls.Attributes
+	for _, line := range playlist.Lines {
+		switch line.Type {
+		case hls.LineTag:
+			switch line.Name {
+			case "EXT-X-TARGETDURATION":
+				if seconds, err := strconv.Atoi(line.Value); err == nil && seconds > 0 {
+					// Polling at half the target duration keeps up with the live edge
+					wait = time.Duration(seconds) * time.Second / 2
+				}
+			case "EXT-X-MEDIA-SEQUENCE":
+				if n, err := strconv.ParseInt(line.Value, 10, 64); err == nil {
+					sequence = n
+				}
+			case "EXT-X-KEY":
+				attributes := line.Attributes
+				key = &attributes
+			case "EXT-X-ENDLIST":
+				ended = true
+			}
+		case hls.LineURI:
+			if sequence > r.lastSequence {
+				if err := r.writeSegment(line.Text, sequence, key); err != nil {
+					return 0, false, err
+				}
+				r.lastSequence = sequence
+			}
+			sequence++
+		}
+	}
+	return wait, ended, nil
+}
+
+// fetchPlaylist fetches and parses a playlist
+func (r *recorder) fetchPlaylist(playlistURL string) (*hls.Playlist, error) {
+	body, err := r.get(playlistURL)
+	if err != nil {
+		return nil, err
+	}
+	return hls.Parse(body)
+}
+
+// writeSegment downloads a segment of the media playlist, decrypts it and writes it
+func (r *recorder) writeSegment(uri string, sequence int64, key *hls.Attributes) error {
+	segmentURL, err := resolve(r.playlistURL, uri)
+	if err != nil {
+		return err
+	}
+	data, err := r.get(segmentURL)
+	if err != nil {
+		return err
+	}
+	if key != nil {
+		if data, err = r.decrypt(data, sequence, *key); err != nil {
+			return err
+		}
+	}
+	_, err = r.w.Write(data)
+	return err
+}
+
+// decrypt decrypts a segment with the key of the EXT-X-KEY tag before it
+func (r *recorder) decrypt(data []byte, sequence int64, attributes hls.Attributes) ([]byte, error) {
+	method, _ := attributes.Get("METHOD")
+	switch method {
+	case "NONE":
+		return data, nil
+	case "AES-128":
+	default:
+		return nil, fmt.Errorf("unsupported encryption method %q", method)
+	}
+
+	uri, _ := attributes.Get("URI")
+	keyURL, err := resolve(r.playlistURL, uri)
+	if err != nil {
+		return nil, err
+	}
+	keyURL = setParam(keyURL, "hdnea", r.hdnea)
+	key, ok := r.keys[keyURL]
+	if !ok {
+		body, statusCode, err := r.source.Key(r.channelID, keyURL)
+		if err != nil {
+			return nil, err
+		}
+		if statusCode != 200 {
+			return nil, fmt.Errorf("key returned status %d", statusCode)
+		}
+		key = body
+		r.keys[keyURL] = key
+	}
+
+	iv, err := segmentIV(attributes, sequence)
+	if err != nil {
+		return nil, err
+	}
+	return decryptAES128(data, key, iv)
+}
+
+// get fetches a playlist or segment with the latest hdnea token and remembers a rotated one
+func (r *recorder) get(rawURL string) ([]byte, error) {
+	body, statusCode, hdnea, err := r.source.Get(r.channelID, setParam(rawURL, "hdnea", r.hdnea))
+	if err != nil {
+		return nil, err
+	}
+	if hdnea != "" {
+		r.hdnea = hdnea
+	}
+	if statusCode != 200 {
+		return nil, fmt.Errorf("%s returned status %d", strings.SplitN(rawURL, "?", 2)[0], statusCode)
+	}
+	return body, nil
+}
+
+// bestVariant returns the URI of the variant stream with the highest bandwidth of a master playlist
+func bestVariant(playlist *hls.Playlist) (string, error) {
+	best, bestBandwidth := "", int64(-1)
+	bandwidth := int64(0)
+	for _, line := range playlist.Lines {
+		switch {
+		case line.Type == hls.LineTag && line.Name == "EXT-X-STREAM-INF":
+			value, _ := line.Attributes.Get("BANDWIDTH")
+			bandwidth, _ = strconv.ParseInt(value, 10, 64)
+		case line.Type == hls.LineURI && line.URIType == hls.URIVariant:
+			if bandwidth > bestBandwidth {
+				best, bestBandwidth = line.Text, bandwidth
+			}
+		}
+	}
+	if best == "" {
+		return "", errors.New("master playlist has no variant streams")
+	}
+	return best, nil
+}
+
+// resolve resolves a URI of a playlist against the playlist URL.
+// The query params of the playlist URL, like JioTV auth tokens, are added when the URI doesn't have them.
+func resolve(playlistURL, uri string) (string, error) {
+	base, err := url.Parse(playlistURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid playlist URL: %w", err)
+	}
+	ref, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid URI %q in playlist: %w", uri, err)
+	}
+	resolved := base.ResolveReference(ref)
+	for _, param := range strings.Split(base.RawQuery, "&") {
+		name, _, _ := strings.Cut(param, "=")
+		if name != "" && !hasParam(resolved.RawQuery, name) {
+			if resolved.RawQuery != "" {
+				resolved.RawQuery += "&"
+			}
+			resolved.RawQuery += param
+		}
+	}
+	return resolved.String(), nil
+}
+
+// hasParam reports whether a raw query has a param with the given name
+func hasParam(rawQuery, name string) bool {
+	for _, param := range strings.Split(rawQuery, "&") {
+		if key, _, _ := strings.Cut(param, "="); key == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setParam replaces the value of a query param of a URL which has it. The raw value is kept unescaped,
+// as JioTV tokens are sent as they were received. An empty value leaves the URL unchanged.
+func setParam(rawURL, name, value string) string {
+	base, rawQuery, found := strings.Cut(rawURL, "?")
+	if value == "" || !found {
+		return rawURL
+	}
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		if key, _, _ := strings.Cut(param, "="); key == name {
+			params[i] = name + "=" + value
+		}
+	}
+	return base + "?" + strings.Join(params, "&")
+}
+
+// segmentIV returns the IV of a segment, which is the media sequence number when the key has no IV
+func segmentIV(attributes hls.Attributes, sequence int64) ([]byte, error) {
+	value, ok := attributes.Get("IV")
+	if !ok {
+		iv := make([]byte, aes.BlockSize)
+		binary.BigEndian.PutUint64(iv[8:], uint64(sequence))
+		return iv, nil
+	}
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+	iv, err := hex.DecodeString(value)
+	if err != nil || len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid IV %q", value)
+	}
+	return iv, nil
+}
+
+// decryptAES128 decrypts an AES-128-CBC encrypted segment and removes its PKCS#7 padding
+func decryptAES128(data, key, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("encrypted segment is not a multiple of the block size")
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("invalid padding of decrypted segment")
+	}
+	return plain[:len(plain)-padding], nil
+}
@@ -0,0 +1,245 @@
+package dvr
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSource serves playlists, segments and keys by URL path
+type fakeSource struct {
+	mu sync.Mutex
+	// playlistURLs are returned by PlaylistURL, one for each call, repeating the last one
+	playlistURLs []string
+	// playlistFailures make the first calls of PlaylistURL fail, with the error or with a panic for "panic"
+	playlistFailures []string
+	files            map[string][]byte
+	keys             map[string][]byte
+	// forbidden are the URL paths answered with 403
+	forbidden map[string]bool
+	// rotate is returned as the rotated hdnea token by the first playlist request
+	rotate      string
+	requests    []string
+	invalidated int
+}
+
+func (s *fakeSource) PlaylistURL(channelID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.playlistFailures) > 0 {
+		failure := s.playlistFailures[0]
+		s.playlistFailures = s.playlistFailures[1:]
+		if failure == "panic" {
+			panic("playback request failed")
+		}
+		return "", errors.New(failure)
+	}
+	playlistURL := s.playlistURLs[0]
+	if len(s.playlistURLs) > 1 {
+		s.playlistURLs = s.playlistURLs[1:]
+	}
+	return playlistURL, nil
+}
+
+func (s *fakeSource) Invalidate(channelID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invalidated++
+}
+
+func (s *fakeSource) Get(channelID, rawURL string) ([]byte, int, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if s.forbidden[u.Path] {
+		return []byte("forbidden"), 403, "", nil
+	}
+	body, ok := s.files[u.Path]
+	if !ok {
+		return []byte("not found"), 404, "", nil
+	}
+	rotated := ""
+	if strings.HasSuffix(u.Path, ".m3u8") {
+		rotated, s.rotate = s.rotate, ""
+	}
+	return body, 200, rotated, nil
+}
+
+func (s *fakeSource) Key(channelID, rawURL string) ([]byte, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, 0, err
+	}
+	key, ok := s.keys[u.Path]
+	if !ok {
+		return nil, 404, nil
+	}
+	return key, 200, nil
+}
+
+// encrypt encrypts a segment with AES-128-CBC and PKCS#7 padding, using the media sequence number as IV
+func encrypt(t *testing.T, data, key []byte, sequence int64) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	data = append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	iv := make([]byte, aes.BlockSize)
+	iv[15] = byte(sequence)
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, data)
+	return out
+}
+
+func TestRecorder(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	source := &fakeSource{
+		playlistURLs: []string{"https://cdn.test/live/master.m3u8?hdnea=old&srno=1"},
+		files: map[string][]byte{
+			"/live/master.m3u8": []byte("#EXTM3U\n" +
+				"#EXT-X-STREAM-INF:BANDWIDTH=100\nlow.m3u8\n" +
+				"#EXT-X-STREAM-INF:BANDWIDTH=300\nhigh.m3u8\n" +
+				"#EXT-X-STREAM-INF:BANDWIDTH=200\nmedium.m3u8\n"),
+			"/live/high.m3u8": []byte("#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:5\n" +
+				"#EXT-X-KEY:METHOD=AES-128,URI=\"keys/1.key\"\n" +
+				"#EXTINF:1,\nseg5.ts\n#EXTINF:1,\nseg6.ts\n#EXT-X-ENDLIST\n"),
+			"/live/seg5.ts": encrypt(t, []byte("segment five"), key, 5),
+			"/live/seg6.ts": encrypt(t, []byte("segment six"), key, 6),
+		},
+		keys:   map[string][]byte{"/live/keys/1.key": key},
+		rotate: "new",
+	}
+
+	var out bytes.Buffer
+	r := newRecorder(source, "143", &out)
+	if err := r.record(context.Background()); err != nil {
+		t.Fatalf("record() error = %v", err)
+	}
+	if got, want := out.String(), "segment fivesegment six"; got != want {
+		t.Errorf("recorded %q, want %q", got, want)
+	}
+	// The rotated token replaces the one of the playlist URL in every later request
+	for _, request := range source.requests[1:] {
+		if !strings.Contains(request, "hdnea=new") || !strings.Contains(request, "srno=1") {
+			t.Errorf("request %s does not carry the rotated token and the playlist params", request)
+		}
+	}
+}
+
+func TestRecorder_Forbidden(t *testing.T) {
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = 3 * time.Second }()
+
+	source := &fakeSource{
+		playlistURLs: []string{"https://cdn.test/expired/index.m3u8?hdnea=old", "https://cdn.test/fresh/index.m3u8?hdnea=fresh"},
+		files: map[string][]byte{
+			"/fresh/index.m3u8": []byte("#EXTM3U\n#EXT-X-MEDIA-SEQUENCE:1\n#EXTINF:1,\n1.ts\n#EXT-X-ENDLIST\n"),
+			"/fresh/1.ts":       []byte("fresh"),
+		},
+		forbidden: map[string]bool{"/expired/index.m3u8": true},
+	}
+	var out bytes.Buffer
+	if err := newRecorder(source, "143", &out).record(context.Background()); err != nil {
+		t.Fatalf("record() error = %v", err)
+	}
+	if out.String() != "fresh" || source.invalidated != 1 {
+		t.Errorf("recorded %q after %d invalidations, want the fresh stream after 1", out.String(), source.invalidated)
+	}
+
+	// A stream which keeps failing fails the recording
+	source = &fakeSource{playlistURLs: []string{"https://cdn.test/expired/index.m3u8"}, forbidden: map[string]bool{"/expired/index.m3u8": true}}
+	err := newRecorder(source, "143", &out).record(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("record() error = %v, want the 403 after %d failures", err, maxFailures)
+	}
+}
+
+func TestRecorder_PlaylistURLFails(t *testing.T) {
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = 3 * time.Second }()
+
+	source := &fakeSource{
+		playlistURLs:     []string{"https://cdn.test/live/index.m3u8"},
+		playlistFailures: []string{"status code 403", "panic"},
+		files: map[string][]byte{
+			"/live/index.m3u8": []byte("#EXTM3U\n#EXT-X-MEDIA-SEQUENCE:1\n#EXTINF:1,\n1.ts\n#EXT-X-ENDLIST\n"),
+			"/live/1.ts":       []byte("live"),
+		},
+	}
+	var out bytes.Buffer
+	if err := newRecorder(source, "143", &out).record(context.Background()); err != nil {
+		t.Fatalf("record() error = %v", err)
+	}
+	if out.String() != "live" || source.invalidated != 2 {
+		t.Errorf("recorded %q after %d invalidations, want the stream after 2", out.String(), source.invalidated)
+	}
+
+	// A source which keeps panicking fails the recording instead of the server
+	failures := make([]string, maxFailures)
+	for i := range failures {
+		failures[i] = "panic"
+	}
+	source = &fakeSource{playlistURLs: []string{"https://cdn.test/live/index.m3u8"}, playlistFailures: failures}
+	err := newRecorder(source, "143", &out).record(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "playback request failed") {
+		t.Errorf("record() error = %v, want the panic after %d failures", err, maxFailures)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		playlistURL string
+		uri         string
+		want        string
+	}{
+		{"https://cdn.test/live/index.m3u8?hdnea=a", "seg1.ts", "https://cdn.test/live/seg1.ts?hdnea=a"},
+		{"https://cdn.test/live/index.m3u8?hdnea=a&x=1", "/other/seg1.ts?x=2", "https://cdn.test/other/seg1.ts?x=2&hdnea=a"},
+		{"https://cdn.test/live/index.m3u8", "https://other.test/seg1.ts", "https://other.test/seg1.ts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			got, err := resolve(tt.playlistURL, tt.uri)
+			if err != nil || got != tt.want {
+				t.Errorf("resolve(%q, %q) = %q, %v, want %q", tt.playlistURL, tt.uri, got, err, tt.want)
+			}
+		})
+	}
+
+	if got, want := setParam("https://cdn.test/a.ts?x=1&hdnea=st=1~exp=2", "hdnea", "st=3~exp=4"), "https://cdn.test/a.ts?x=1&hdnea=st=3~exp=4"; got != want {
+		t.Errorf("setParam() = %q, want %q", got, want)
+	}
+}
+
+func TestDecryptAES128(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	for _, data := range []string{"", "short", "exactly 16 bytes"} {
+		t.Run(fmt.Sprintf("%d bytes", len(data)), func(t *testing.T) {
+			iv := make([]byte, aes.BlockSize)
+			iv[15] = 7
+			got, err := decryptAES128(encrypt(t, []byte(data), key, 7), key, iv)
+			if err != nil || string(got) != data {
+				t.Errorf("decryptAES128() = %q, %v, want %q", got, err, data)
+			}
+		})
+	}
+	if _, err := decryptAES128([]byte("not a block"), key, make([]byte, aes.BlockSize)); err == nil {
+		t.Error("decryptAES128() of a partial block succeeded")
+	}
+}
@@ -0,0 +1,306 @@
+package dvr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/tasks"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// rulesKey is the store key of the series rules
+const rulesKey = "dvr_rules"
+
+var (
+	// ErrInvalidPattern is returned for rule patterns which are not valid regular expressions
+	ErrInvalidPattern = errors.New("invalid pattern")
+	// ErrInvalidKeepLast is returned for a negative number of recordings to keep
+	ErrInvalidKeepLast = errors.New("keep_last must not be negative")
+	// ErrRuleNotFound is returned for rules which do not exist
+	ErrRuleNotFound = errors.New("rule not found")
+)
+
+// Rule records every upcoming programme of the EPG whose title matches Pattern.
+// Rules are matched again each time the EPG is generated.
+type Rule struct {
+	ID string `json:"id"`
+	// Pattern is a regular expression matched against programme titles, ignoring case
+	Pattern string `json:"pattern"`
+	// ChannelID restricts the rule to a channel. Empty matches all channels.
+	ChannelID string `json:"channel_id,omitempty"`
+	// KeepLast is the number of completed recordings of the rule to keep. 0 keeps all of them.
+	KeepLast int `json:"keep_last"`
+}
+
+// compiledRule is a rule with its compiled pattern
+type compiledRule struct {
+	Rule
+	pattern *regexp.Regexp
+}
+
+// compile compiles the pattern of a rule
+func (r Rule) compile() (compiledRule, error) {
+	pattern, err := regexp.Compile("(?i)" + r.Pattern)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("%w: %v", ErrInvalidPattern, err)
+	}
+	return compiledRule{Rule: r, pattern: pattern}, nil
+}
+
+// matches reports whether a programme of a channel matches the rule
+func (r compiledRule) matches(channelID, title string) bool {
+	return (r.ChannelID == "" || r.ChannelID == channelID) && r.pattern.MatchString(title)
+}
+
+// Rules returns the series rules
+func Rules() ([]Rule, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return loadRules()
+}
+
+// AddRule adds a series rule and schedules the matching programmes of the current EPG
+func AddRule(pattern, channelID string, keepLast int) (Rule, error) {
+	if keepLast < 0 {
+		return Rule{}, ErrInvalidKeepLast
+	}
+	if channelID != "" && !channelIDPattern.MatchString(channelID) {
+		return Rule{}, ErrInvalidChannel
+	}
+	rule := Rule{
+		ID:        strconv.FormatInt(time.Now().UnixNano(), 36),
+		Pattern:   pattern,
+		ChannelID: channelID,
+		KeepLast:  keepLast,
+	}
+	if _, err := rule.compile(); err != nil {
+		return Rule{}, err
+	}
+
+	mu.Lock()
+	rules, err := loadRules()
+	if err == nil {
+		err = saveRules(append(rules, rule))
+	}
+	mu.Unlock()
+	if err != nil {
+		return Rule{}, err
+	}
+	if guide := epg.CurrentGuide(); guide != nil {
+		ApplyRules(guide)
+	}
+	return rule, nil
+}
+
+// RemoveRule deletes a series rule and the recordings it scheduled or skipped which have not started yet
+func RemoveRule(id string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	rules, err := loadRules()
+	if err != nil {
+		return err
+	}
+	kept := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.ID != id {
+			kept = append(kept, rule)
+		}
+	}
+	if len(kept) == len(rules) {
+		return ErrRuleNotFound
+	}
+	if err := saveRules(kept); err != nil {
+		return err
+	}
+
+	recordings, err := load()
+	if err != nil {
+		return err
+	}
+	remaining := make([]Recording, 0, len(recordings))
+	for _, recording := range recordings {
+		if recording.RuleID == id && (recording.Status == StatusScheduled || recording.Status == StatusSkipped) {
+			scheduler.Remove(tasks.RecordingTaskIDPrefix + recording.ID)
+			continue
+		}
+		remaining = append(remaining, recording)
+	}
+	return save(remaining)
+}
+
+// ApplyRules schedules the upcoming programmes of the guide matching a series rule, except skipped ones.
+// Scheduled recordings of a rule whose programme is no longer in the guide, or no longer matches, are dropped.
+// Skipped recordings are dropped once their programme started, as it can't be scheduled again.
+// Channels without any programme in the guide are left alone, as their EPG may have failed to download.
+func ApplyRules(guide *epg.Guide) {
+	mu.Lock()
+	defer mu.Unlock()
+	rules, err := loadRules()
+	if err != nil {
+		utils.Log.Printf("ERROR: Failed to load recording rules: %v", err)
+		return
+	}
+	recordings, err := load()
+	if err != nil {
+		utils.Log.Printf("ERROR: Failed to load recordings: %v", err)
+		return
+	}
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		if c, err := rule.compile(); err == nil {
+			compiled = append(compiled, c)
+		}
+	}
+
+	// The upcoming programmes matching a rule, by recording ID
+	now := time.Now()
+	wanted := map[string]Recording{}
+	for _, channel := range guide.Search("", "", now, time.Time{}) {
+		if !channelIDPattern.MatchString(channel.ChannelID) {
+			continue
+		}
+		for _, programme := range channel.Programmes {
+			if !programme.Start.After(now) {
+				continue
+			}
+			for _, rule := range compiled {
+				if rule.matches(channel.ChannelID, programme.Title) {
+					recording := newRecording(channel.ChannelID, programme.Title, programme.Start, programme.Stop)
+					recording.ChannelName = channel.ChannelName
+					recording.RuleID = rule.ID
+					wanted[recording.ID] = recording
+					break
+				}
+			}
+		}
+	}
+
+	kept := make([]Recording, 0, len(recordings)+len(wanted))
+	dropped := 0
+	for _, recording := range recordings {
+		delete(wanted, recording.ID)
+		if recording.Status == StatusSkipped && !recording.Start.After(now) {
+			dropped++
+			continue
+		}
+		if recording.RuleID != "" && recording.Status == StatusScheduled && guide.HasProgrammes(recording.ChannelID) {
+			programme, ok := guide.Programme(recording.ChannelID, recording.Start)
+			if !ok || !matchesRule(compiled, recording.RuleID, recording.ChannelID, programme.Title) {
+				scheduler.Remove(tasks.RecordingTaskIDPrefix + recording.ID)
+				dropped++
+				continue
+			}
+		}
+		kept = append(kept, recording)
+	}
+	added := make([]Recording, 0, len(wanted))
+	for _, recording := range wanted {
+		added = append(added, recording)
+	}
+	if len(added) == 0 && dropped == 0 {
+		return
+	}
+	if err := save(append(kept, added...)); err != nil {
+		utils.Log.Printf("ERROR: Failed to save recordings: %v", err)
+		return
+	}
+	for _, recording := range added {
+		schedule(recording)
+	}
+	utils.Log.Printf("Recording rules scheduled %d and dropped %d recordings", len(added), dropped)
+}
+
+// matchesRule reports whether the rule with the given ID still exists and matches a programme
+func matchesRule(rules []compiledRule, ruleID, channelID, title string) bool {
+	for _, rule := range rules {
+		if rule.ID == ruleID {
+			return rule.matches(channelID, title)
+		}
+	}
+	return false
+}
+
+// applyRetention deletes the oldest completed recordings of a rule beyond the number it keeps
+func applyRetention(ruleID string) {
+	mu.Lock()
+	defer mu.Unlock()
+	rules, err := loadRules()
+	if err != nil {
+		utils.Log.Printf("ERROR: Failed to load recording rules: %v", err)
+		return
+	}
+	keepLast := 0
+	for _, rule := range rules {
+		if rule.ID == ruleID {
+			keepLast = rule.KeepLast
+		}
+	}
+	if keepLast == 0 {
+		return
+	}
+	recordings, err := load()
+	if err != nil {
+		utils.Log.Printf("ERROR: Failed to load recordings: %v", err)
+		return
+	}
+
+	// Recordings are sorted by start, so the newest ones are counted first from the end
+	completed := 0
+	kept := make([]Recording, 0, len(recordings))
+	for i := len(recordings) - 1; i >= 0; i-- {
+		recording := recordings[i]
+		if recording.RuleID == ruleID && recording.Status == StatusCompleted {
+			completed++
+			if completed > keepLast {
+				if err := removeFile(recording); err != nil {
+					utils.Log.Printf("ERROR: Failed to delete recording %s: %v", recording.ID, err)
+					kept = append(kept, recording)
+				} else {
+					utils.Log.Printf("Deleted recording %s, as its rule keeps the last %d", recording.ID, keepLast)
+				}
+				continue
+			}
+		}
+		kept = append(kept, recording)
+	}
+	if len(kept) != len(recordings) {
+		if err := save(kept); err != nil {
+			utils.Log.Printf("ERROR: Failed to save recordings: %v", err)
+		}
+	}
+}
+
+// loadRules reads the series rules from the store. A missing key is an empty list.
+func loadRules() ([]Rule, error) {
+	value, err := store.Get(rulesKey)
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return []Rule{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil, fmt.Errorf("invalid recording rules in store: %w", err)
+	}
+	if rules == nil {
+		rules = []Rule{}
+	}
+	return rules, nil
+}
+
+// saveRules writes the series rules to the store
+func saveRules(rules []Rule) error {
+	value, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	return store.Set(rulesKey, string(value))
+}
@@ -5,17 +5,25 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
 
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/tasks"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/metrics"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 	"github.com/schollz/progressbar/v3"
 	"github.com/valyala/fasthttp"
@@ -33,6 +41,8 @@ const (
 	// Default values for random scheduling when crypto/rand fails
 	defaultRandomHour   = 2
 	defaultRandomMinute = 30
+	// xmlHeader is written before the tv element of the EPG
+	xmlHeader = xml.Header + `<!DOCTYPE tv SYSTEM "http://www.w3.org/2006/05/tv">` + "\n"
 )
 
 // Init initializes EPG generation and schedules it for the next day.
@@ -69,13 +79,20 @@ func Init() {
 		if err != nil {
 			utils.Log.Printf("ERROR: Failed to generate EPG file: %v", err)
 			fmt.Println("\tEPG file generation failed. Server will continue running without EPG.")
+			// Serve the previous guide, which a failed generation leaves untouched
+			if CurrentGuide() == nil && utils.FileExists(epgFile) {
+				loadGuide(epgFile)
+			}
 			return nil
 		}
+		loadGuide(epgFile)
 		return nil
 	}
 
 	if flag {
 		genepg()
+	} else {
+		loadGuide(epgFile)
 	}
 	// setup random time to avoid server load
 	random_hour_bigint, err := rand.Int(rand.Reader, big.NewInt(3))
@@ -98,86 +115,160 @@ func Init() {
 	go scheduler.Add(EPG_TASK_ID, time.Until(schedule_time), genepg)
 }
 
-// NewProgramme creates a new Programme with the given parameters.
-func NewProgramme(channelID int, start, stop, title, desc, category, iconSrc string) Programme {
-	iconURL := fmt.Sprintf("%s/%s", EPG_POSTER_URL, iconSrc)
-	return Programme{
-		Channel: fmt.Sprint(channelID),
-		Start:   start,
-		Stop:    stop,
+// loadGuide loads the EPG file into the guide served by the EPG API, logging any error
+func loadGuide(epgFile string) {
+	if err := LoadGuide(epgFile); err != nil {
+		utils.Log.Printf("ERROR: Failed to load EPG file: %v", err)
+		return
+	}
+	utils.Log.Println("Loaded EPG file")
+}
+
+// NewProgramme creates a new Programme of a channel from a programme of JioTV EPG API.
+// JioTV has no episode titles, so the short episode description is used as the sub-title.
+func NewProgramme(channel Channel, programme EPGObject) Programme {
+	lang := channel.Language
+	p := Programme{
+		Channel: fmt.Sprint(channel.ID),
+		Start:   formatTime(time.UnixMilli(programme.StartEpoch)),
+		Stop:    formatTime(time.UnixMilli(programme.EndEpoch)),
 		Title: Title{
-			Value: title,
-			Lang:  "en",
+			Value: programme.Title,
+			Lang:  lang,
 		},
 		Desc: Desc{
-			Value: desc,
-			Lang:  "en",
-		},
-		Category: Category{
-			Value: category,
-			Lang:  "en",
-		},
-		Icon: Icon{
-			Src: iconURL,
+			Value: programme.Description,
+			Lang:  lang,
 		},
 	}
+	if programme.EpisodeDesc != "" && programme.EpisodeDesc != programme.Description {
+		p.SubTitle = &SubTitle{Value: programme.EpisodeDesc, Lang: lang}
+	}
+
+	// The category comes first, followed by the genres
+	for _, category := range append([]string{programme.ShowCategory}, programme.ShowGenre...) {
+		if category == "" || containsCategory(p.Categories, category) {
+			continue
+		}
+		p.Categories = append(p.Categories, Category{Value: category, Lang: lang})
+	}
+
+	if programme.Poster != "" {
+		p.Icon = &Icon{Src: fmt.Sprintf("%s/%s", EPG_POSTER_URL, programme.Poster)}
+	}
+	if programme.EpisodeNum > 0 {
+		p.EpisodeNums = []EpisodeNum{
+			// xmltv_ns numbers are zero based
+			{System: "xmltv_ns", Value: fmt.Sprintf(".%d.", programme.EpisodeNum-1)},
+			{System: "onscreen", Value: fmt.Sprintf("E%d", programme.EpisodeNum)},
+		}
+	}
+	return p
 }
 
-// genXML generates XML EPG from JioTV API and returns it as a byte slice.
-func genXML() ([]byte, error) {
-	// Create a reusable fasthttp client with common headers
-	client := utils.GetRequestClient()
+// containsCategory checks if categories has a category with the given value, ignoring case
+func containsCategory(categories []Category, value string) bool {
+	for _, category := range categories {
+		if strings.EqualFold(category.Value, value) {
+			return true
+		}
+	}
+	return false
+}
 
-	// Create channels and programmes slices with initial capacity
-	var channels []Channel
-	var programmes []Programme
+// channelProgrammes is the result of fetching the EPG of one channel
+type channelProgrammes struct {
+	channel    Channel
+	programmes []Programme
+	err        error
+}
 
-	// Define a worker function for fetching EPG data
-	fetchEPG := func(channel Channel, bar *progressbar.ProgressBar) {
-		req := fasthttp.AcquireRequest()
-		req.Header.SetUserAgent(headers.UserAgentOkHttp)
-		defer fasthttp.ReleaseRequest(req)
+// errNoProgrammes is returned when no programme could be fetched, so an existing guide is not replaced by an empty one
+var errNoProgrammes = errors.New("no programmes fetched")
 
-		resp := fasthttp.AcquireResponse()
+var (
+	// channelsURL and epgURL are variables so tests can point them at a local server
+	channelsURL = CHANNEL_URL
+	epgURL      = EPG_URL
+)
 
-		for offset := 0; offset < 2; offset++ {
-			reqUrl := fmt.Sprintf(EPG_URL, offset, channel.ID)
-			req.SetRequestURI(reqUrl)
+// numWorkers is the number of channels whose EPG is fetched concurrently
+const numWorkers = 20
 
-			if err := client.Do(req, resp); err != nil {
-				// Handle error
-				utils.Log.Printf("Error fetching EPG for channel %d, offset %d: %v", channel.ID, offset, err)
-				continue
-			}
+// requestDelay is the pause between the requests for the days of one channel, to go easy on JioTV API
+var requestDelay = 200 * time.Millisecond
 
-			var epgResponse EPGResponse
-			if err := json.Unmarshal(resp.Body(), &epgResponse); err != nil {
-				// Handle error
-				utils.Log.Printf("Error unmarshaling EPG response for channel %d, offset %d: %v", channel.ID, offset, err)
-				// Print response body for debugging
-				utils.Log.Printf("Response body: %s", resp.Body())
-				continue
-			}
+// epgOffsets returns the day offsets to fetch from JioTV API, from EPGDaysBack days before today
+// to EPGDaysAhead days after today. Both are limited to the days the API serves.
+// A negative EPGDaysAhead takes the default.
+func epgOffsets() []int {
+	ahead := config.Cfg.EPGDaysAhead
+	if ahead < 0 {
+		ahead = constants.DefaultEPGDaysAhead
+	}
+	back := max(config.Cfg.EPGDaysBack, 0)
+	ahead = min(ahead, constants.MaxEPGDays)
+	back = min(back, constants.MaxEPGDays)
 
-			for _, programme := range epgResponse.EPG {
-				startTime := formatTime(time.UnixMilli(programme.StartEpoch))
-				endTime := formatTime(time.UnixMilli(programme.EndEpoch))
-				programmes = append(programmes, NewProgramme(channel.ID, startTime, endTime, programme.Title, programme.Description, programme.ShowCategory, programme.Poster))
-			}
+	offsets := make([]int, 0, back+ahead+1)
+	for offset := -back; offset <= ahead; offset++ {
+		offsets = append(offsets, offset)
+	}
+	return offsets
+}
+
+// fetchChannelEPG fetches the programmes of a channel for the given day offsets from JioTV API.
+// It stops at the first failed request, so a channel with errors is skipped instead of being retried for every day.
+func fetchChannelEPG(client *fasthttp.Client, channel Channel, offsets []int) ([]Programme, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	req.Header.SetUserAgent(headers.UserAgentOkHttp)
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	var programmes []Programme
+	for i, offset := range offsets {
+		if i > 0 {
+			time.Sleep(requestDelay)
+		}
+		req.SetRequestURI(fmt.Sprintf(epgURL, offset, channel.ID))
+
+		start := time.Now()
+		if err := client.Do(req, resp); err != nil {
+			metrics.ObserveUpstream(metrics.EndpointEPG, start, 0)
+			return nil, fmt.Errorf("offset %d: %w", offset, err)
+		}
+		metrics.ObserveUpstream(metrics.EndpointEPG, start, resp.StatusCode())
+		if resp.StatusCode() != fasthttp.StatusOK {
+			return nil, fmt.Errorf("offset %d: status code %d", offset, resp.StatusCode())
+		}
+
+		var epgResponse EPGResponse
+		if err := json.Unmarshal(resp.Body(), &epgResponse); err != nil {
+			return nil, fmt.Errorf("offset %d: %w", offset, err)
+		}
+
+		for _, epgObject := range epgResponse.EPG {
+			programmes = append(programmes, NewProgramme(channel, epgObject))
 		}
-		bar.Add(1)
-		fasthttp.ReleaseResponse(resp)
 	}
+	return programmes, nil
+}
 
-	// Fetch channels data
+// fetchChannels fetches the list of channels for the EPG from JioTV API
+func fetchChannels(client *fasthttp.Client) ([]Channel, error) {
 	utils.Log.Println("Fetching channels")
+	start := time.Now()
 	resp, err := utils.MakeHTTPRequest(utils.HTTPRequestConfig{
-		URL:    CHANNEL_URL,
+		URL:    channelsURL,
 		Method: "GET",
 	}, client)
 	if err != nil {
+		metrics.ObserveUpstream(metrics.EndpointChannels, start, 0)
 		return nil, utils.LogAndReturnError(err, "Failed to fetch channels")
 	}
+	metrics.ObserveUpstream(metrics.EndpointChannels, start, resp.StatusCode())
 	defer fasthttp.ReleaseResponse(resp)
 
 	var channelsResponse ChannelsResponse
@@ -185,82 +276,170 @@ func genXML() ([]byte, error) {
 		return nil, utils.LogAndReturnError(err, "Failed to parse channels response")
 	}
 
+	channels := make([]Channel, 0, len(channelsResponse.Channels))
 	for _, channel := range channelsResponse.Channels {
-		channels = append(channels, Channel{
-			ID:      channel.ChannelID,
-			Display: channel.ChannelName,
-		})
+		epgChannel := Channel{
+			ID:       channel.ChannelID,
+			Display:  channel.ChannelName,
+			Language: television.LanguageCodeMap[channel.LanguageID],
+		}
+		if channel.LogoURL != "" {
+			epgChannel.Icon = &Icon{Src: urls.ChannelImageURL + channel.LogoURL}
+		}
+		channels = append(channels, epgChannel)
 	}
 	utils.Log.Println("Fetched", len(channels), "channels")
-	// Use a worker pool to fetch EPG data concurrently
-	const numWorkers = 20 // Adjust the number of workers based on your needs
-	channelQueue := make(chan Channel, len(channels))
-	var wg sync.WaitGroup
+	return channels, nil
+}
 
-	// Create a progress bar
-	totalChannels := len(channels) // Replace with the actual number of channels
-	bar := progressbar.Default(int64(totalChannels))
+// writeXML generates XML EPG from JioTV API and streams it to w. It returns the number of programmes written.
+// Workers fetch the EPG of each channel and send it to this goroutine, which is the only one writing,
+// so only the programmes of a few channels are held in memory at a time.
+func writeXML(w io.Writer) (int, error) {
+	// Create a reusable fasthttp client with common headers
+	client := utils.GetRequestClient()
+
+	channels, err := fetchChannels(client)
+	if err != nil {
+		return 0, err
+	}
 
+	encoder := xml.NewEncoder(w)
+	if _, err := io.WriteString(w, xmlHeader); err != nil {
+		return 0, err
+	}
+	tv := xml.StartElement{Name: xml.Name{Local: "tv"}}
+	if err := encoder.EncodeToken(tv); err != nil {
+		return 0, err
+	}
+	// XMLTV lists all channels before the programmes
+	for _, channel := range channels {
+		if err := encoder.Encode(channel); err != nil {
+			return 0, err
+		}
+	}
+
+	offsets := epgOffsets()
+	utils.Log.Printf("Fetching EPG from %d to %d days from today", offsets[0], offsets[len(offsets)-1])
+
+	// Use a worker pool to fetch EPG data concurrently
+	channelQueue := make(chan Channel)
+	results := make(chan channelProgrammes, numWorkers)
+	done := make(chan struct{})
+	defer close(done)
+
+	var wg sync.WaitGroup
 	utils.Log.Println("Fetching EPG for channels")
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for channel := range channelQueue {
-				fetchEPG(channel, bar)
+				programmes, err := fetchChannelEPG(client, channel, offsets)
+				select {
+				case results <- channelProgrammes{channel: channel, programmes: programmes, err: err}:
+				case <-done:
+					return
+				}
 			}
 		}()
 	}
 	// Queue channels for processing
-	for _, channel := range channels {
-		channelQueue <- channel
+	go func() {
+		defer close(channelQueue)
+		for _, channel := range channels {
+			select {
+			case channelQueue <- channel:
+			case <-done:
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Create a progress bar
+	bar := progressbar.Default(int64(len(channels)))
+	programmeCount, skipped := 0, 0
+	for result := range results {
+		if result.err != nil {
+			utils.Log.Printf("Skipping EPG of channel %d: %v", result.channel.ID, result.err)
+			skipped++
+			bar.Add(1)
+			continue
+		}
+		for _, programme := range result.programmes {
+			if err := encoder.Encode(programme); err != nil {
+				return 0, err
+			}
+		}
+		programmeCount += len(result.programmes)
+		bar.Add(1)
+	}
+	utils.Log.Println("Fetched", programmeCount, "programmes")
+	if skipped > 0 {
+		utils.Log.Println("Skipped", skipped, "channels with errors")
+	}
+	if programmeCount == 0 {
+		return 0, errNoProgrammes
 	}
-	close(channelQueue)
-	wg.Wait()
 
-	utils.Log.Println("Fetched programmes")
-	// Create EPG and marshal it to XML
-	epg := EPG{
-		Channel:   channels,
-		Programme: programmes,
+	if err := encoder.EncodeToken(tv.End()); err != nil {
+		return 0, err
 	}
-	xml, err := xml.Marshal(epg)
-	if err != nil {
-		return nil, err
+	if err := encoder.Flush(); err != nil {
+		return 0, err
 	}
-	return xml, nil
+	return programmeCount, nil
 }
 
 // formatTime formats the given time to the string representation "20060102150405 -0700".
 func formatTime(t time.Time) string {
-	return t.Format("20060102150405 -0700")
+	return t.Format(timeLayout)
 }
 
 // GenXMLGz generates XML EPG from JioTV API and writes it to a compressed gzip file.
+// The guide is written to a temporary file next to filename, which replaces filename only
+// once the guide is complete. A failed run leaves an existing file untouched.
 func GenXMLGz(filename string) error {
 	utils.Log.Println("Generating XML")
-	xml, err := genXML()
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
 	if err != nil {
 		return err
 	}
-	// Add XML header
-	xmlHeader := `<?xml version="1.0" encoding="UTF-8"?>
-	<!DOCTYPE tv SYSTEM "http://www.w3.org/2006/05/tv">`
-	xml = append([]byte(xmlHeader), xml...)
-	// write to file
-	f, err := os.Create(filename)
+	tmpName := tmp.Name()
+	// Remove the temporary file unless it was renamed to filename
+	defer os.Remove(tmpName)
+	defer tmp.Close() // skipcq: GO-S2307
+
+	start := time.Now()
+	gz := gzip.NewWriter(tmp)
+	programmes, err := writeXML(gz)
 	if err != nil {
 		return err
 	}
-	defer f.Close() // skipcq: GO-S2307
-
-	utils.Log.Println("Writing XML to gzip file")
-	gz := gzip.NewWriter(f)
-	defer gz.Close()
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// CreateTemp creates files only readable by the owner
+	if err := os.Chmod(tmpName, 0o644); err != nil {
+		return err
+	}
 
-	if _, err := gz.Write(xml); err != nil {
+	if err := os.Rename(tmpName, filename); err != nil {
 		return err
 	}
+	metrics.EPGLastSuccess.Set(float64(time.Now().Unix()))
+	metrics.EPGDuration.Set(time.Since(start).Seconds())
+	metrics.EPGProgrammes.Set(float64(programmes))
 	fmt.Println("\tEPG file generated successfully")
 	return nil
 }
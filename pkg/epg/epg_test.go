@@ -1,8 +1,26 @@
 package epg
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 )
 
 func TestInit(t *testing.T) {
@@ -22,14 +40,10 @@ func TestInit(t *testing.T) {
 }
 
 func TestNewProgramme(t *testing.T) {
+	start := time.Date(2023, 12, 25, 12, 0, 0, 0, time.Local)
 	type args struct {
-		channelID int
-		start     string
-		stop      string
-		title     string
-		desc      string
-		category  string
-		iconSrc   string
+		channel   Channel
+		programme EPGObject
 	}
 	tests := []struct {
 		name string
@@ -39,105 +53,316 @@ func TestNewProgramme(t *testing.T) {
 		{
 			name: "Create new programme",
 			args: args{
-				channelID: 123,
-				start:     "20231225120000 +0530",
-				stop:      "20231225130000 +0530",
-				title:     "Test Show",
-				desc:      "Test Description",
-				category:  "Entertainment",
-				iconSrc:   "test_icon.jpg",
+				channel: Channel{ID: 123, Language: "hi"},
+				programme: EPGObject{
+					StartEpoch:   start.UnixMilli(),
+					EndEpoch:     start.Add(time.Hour).UnixMilli(),
+					Title:        "Test Show",
+					Description:  "Test Description",
+					EpisodeDesc:  "The one with the test",
+					EpisodeNum:   12,
+					ShowCategory: "Entertainment",
+					ShowGenre:    []string{"Drama", "entertainment", ""},
+					Poster:       "test_icon.jpg",
+				},
 			},
 			want: Programme{
-				Channel: "123",
-				Start:   "20231225120000 +0530",
-				Stop:    "20231225130000 +0530",
-				Title: Title{
-					Value: "Test Show",
-					Lang:  "en",
-				},
-				Desc: Desc{
-					Value: "Test Description",
-					Lang:  "en",
+				Channel:  "123",
+				Start:    formatTime(start),
+				Stop:     formatTime(start.Add(time.Hour)),
+				Title:    Title{Value: "Test Show", Lang: "hi"},
+				SubTitle: &SubTitle{Value: "The one with the test", Lang: "hi"},
+				Desc:     Desc{Value: "Test Description", Lang: "hi"},
+				Categories: []Category{
+					{Value: "Entertainment", Lang: "hi"},
+					{Value: "Drama", Lang: "hi"},
 				},
-				Category: Category{
-					Value: "Entertainment",
-					Lang:  "en",
-				},
-				Icon: Icon{
-					Src: "https://jiotv.catchup.cdn.jio.com/dare_images/shows/test_icon.jpg",
+				Icon: &Icon{Src: "https://jiotv.catchup.cdn.jio.com/dare_images/shows/test_icon.jpg"},
+				EpisodeNums: []EpisodeNum{
+					{System: "xmltv_ns", Value: ".11."},
+					{System: "onscreen", Value: "E12"},
 				},
 			},
 		},
 		{
 			name: "Create programme with empty values",
 			args: args{
-				channelID: 0,
-				start:     "",
-				stop:      "",
-				title:     "",
-				desc:      "",
-				category:  "",
-				iconSrc:   "",
+				channel:   Channel{ID: 0},
+				programme: EPGObject{StartEpoch: start.UnixMilli(), EndEpoch: start.UnixMilli()},
 			},
 			want: Programme{
 				Channel: "0",
-				Start:   "",
-				Stop:    "",
-				Title: Title{
-					Value: "",
-					Lang:  "en",
-				},
-				Desc: Desc{
-					Value: "",
-					Lang:  "en",
-				},
-				Category: Category{
-					Value: "",
-					Lang:  "en",
-				},
-				Icon: Icon{
-					Src: "https://jiotv.catchup.cdn.jio.com/dare_images/shows/",
-				},
+				Start:   formatTime(start),
+				Stop:    formatTime(start),
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := NewProgramme(tt.args.channelID, tt.args.start, tt.args.stop, tt.args.title, tt.args.desc, tt.args.category, tt.args.iconSrc)
-			if got.Channel != tt.want.Channel {
-				t.Errorf("NewProgramme().Channel = %v, want %v", got.Channel, tt.want.Channel)
-			}
-			if got.Start != tt.want.Start {
-				t.Errorf("NewProgramme().Start = %v, want %v", got.Start, tt.want.Start)
+			if got := NewProgramme(tt.args.channel, tt.args.programme); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewProgramme() = %+v, want %+v", got, tt.want)
 			}
-			if got.Title.Value != tt.want.Title.Value {
-				t.Errorf("NewProgramme().Title.Value = %v, want %v", got.Title.Value, tt.want.Title.Value)
+		})
+	}
+}
+
+func TestNewProgramme_XML(t *testing.T) {
+	programme := NewProgramme(Channel{ID: 1, Language: "ta"}, EPGObject{
+		Title:        "Show",
+		EpisodeNum:   3,
+		ShowCategory: "Kids",
+		Poster:       "poster.jpg",
+	})
+	data, err := xml.Marshal(programme)
+	if err != nil {
+		t.Fatalf("xml.Marshal() error = %v", err)
+	}
+	got := string(data)
+	// Elements follow the order of the XMLTV DTD
+	order := []string{`<title lang="ta">Show</title>`, `<desc lang="ta"></desc>`, `<category lang="ta">Kids</category>`, `<icon src=`, `<episode-num system="xmltv_ns">.2.</episode-num>`}
+	last := -1
+	for _, element := range order {
+		i := strings.Index(got, element)
+		if i < 0 || i < last {
+			t.Fatalf("programme XML = %s, want %s after the previous elements", got, element)
+		}
+		last = i
+	}
+	if strings.Contains(got, "sub-title") {
+		t.Errorf("programme XML = %s, want no sub-title without an episode description", got)
+	}
+}
+
+func TestEpisodeNumber_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data string
+		want EpisodeNumber
+	}{
+		{data: `12`, want: 12},
+		{data: `"34"`, want: 34},
+		{data: `""`, want: 0},
+		{data: `null`, want: 0},
+		{data: `"pilot"`, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			var got EpisodeNumber
+			if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+				t.Fatalf("UnmarshalJSON() error = %v", err)
 			}
-			if got.Icon.Src != tt.want.Icon.Src {
-				t.Errorf("NewProgramme().Icon.Src = %v, want %v", got.Icon.Src, tt.want.Icon.Src)
+			if got != tt.want {
+				t.Errorf("UnmarshalJSON() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func TestGenXML(t *testing.T) {
+// setupEPGServer points the EPG generator at a local server with the given channels.
+// Each channel has one programme per offset, which repeats the same episode, except channel IDs in failing.
+func setupEPGServer(t *testing.T, channelIDs []int, failing map[int]bool) {
+	t.Helper()
+	if utils.Log == nil {
+		utils.Log = log.New(io.Discard, "", 0)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/channels" {
+			var response ChannelsResponse
+			for _, id := range channelIDs {
+				response.Channels = append(response.Channels, ChannelObject{ChannelID: id, ChannelName: fmt.Sprintf("Channel %d", id), LogoURL: fmt.Sprintf("logo%d.png", id), LanguageID: 6})
+			}
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+		id, _ := strconv.Atoi(r.URL.Query().Get("channel_id"))
+		if failing[id] {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("error"))
+			return
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		start := time.Date(2024, 1, 1+offset, 10, 0, 0, 0, time.UTC)
+		json.NewEncoder(w).Encode(EPGResponse{EPG: []EPGObject{{
+			StartEpoch: start.UnixMilli(),
+			EndEpoch:   start.Add(time.Hour).UnixMilli(),
+			Title:      fmt.Sprintf("Show %d-%d", id, offset),
+			// The same episode airs every day
+			ShowID:     fmt.Sprintf("show%d", id),
+			EpisodeNum: 1,
+		}}})
+	}))
+	t.Cleanup(server.Close)
+
+	originalChannelsURL, originalEPGURL, originalDelay := channelsURL, epgURL, requestDelay
+	t.Cleanup(func() {
+		channelsURL, epgURL, requestDelay = originalChannelsURL, originalEPGURL, originalDelay
+	})
+	channelsURL = server.URL + "/channels"
+	epgURL = server.URL + "/epg?offset=%d&channel_id=%d"
+	requestDelay = 0
+}
+
+// setEPGDays sets the EPG days config for the duration of a test
+func setEPGDays(t *testing.T, ahead, back int) {
+	t.Helper()
+	originalAhead, originalBack := config.Cfg.EPGDaysAhead, config.Cfg.EPGDaysBack
+	t.Cleanup(func() {
+		config.Cfg.EPGDaysAhead, config.Cfg.EPGDaysBack = originalAhead, originalBack
+	})
+	config.Cfg.EPGDaysAhead, config.Cfg.EPGDaysBack = ahead, back
+}
 
+func TestEpgOffsets(t *testing.T) {
 	tests := []struct {
-		name    string
-		wantErr bool
+		name  string
+		ahead int
+		back  int
+		want  []int
 	}{
-		{
-			name:    "Generate XML with mock server",
-			wantErr: false,
-		},
+		{name: "Defaults to today and tomorrow", ahead: -1, want: []int{0, 1}},
+		{name: "Only today", ahead: 0, want: []int{0}},
+		{name: "Days ahead", ahead: 3, want: []int{0, 1, 2, 3}},
+		{name: "Days back", ahead: 1, back: 2, want: []int{-2, -1, 0, 1}},
+		{name: "Negative days back are ignored", ahead: 1, back: -3, want: []int{0, 1}},
+		{name: "Limited to the days served by the API", ahead: 30, back: 30, want: []int{-7, -6, -5, -4, -3, -2, -1, 0, 1, 2, 3, 4, 5, 6, 7}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// TODO
+			setEPGDays(t, tt.ahead, tt.back)
+			if got := epgOffsets(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("epgOffsets() = %v, want %v", got, tt.want)
+			}
 		})
 	}
 }
 
+func TestGenXML(t *testing.T) {
+	channelIDs := make([]int, 50)
+	for i := range channelIDs {
+		channelIDs[i] = i + 1
+	}
+	setupEPGServer(t, channelIDs, map[int]bool{7: true})
+	setEPGDays(t, 1, 0)
+
+	var buf bytes.Buffer
+	if _, err := writeXML(&buf); err != nil {
+		t.Fatalf("writeXML() error = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Error("EPG should start with the XML header")
+	}
+
+	var epg EPG
+	if err := xml.Unmarshal(buf.Bytes(), &epg); err != nil {
+		t.Fatalf("generated EPG is not valid XML: %v", err)
+	}
+	if len(epg.Channel) != len(channelIDs) {
+		t.Errorf("channels = %d, want %d", len(epg.Channel), len(channelIDs))
+	}
+	// Two days for every channel except the failing one
+	if want := 2 * (len(channelIDs) - 1); len(epg.Programme) != want {
+		t.Errorf("programmes = %d, want %d", len(epg.Programme), want)
+	}
+	for _, programme := range epg.Programme {
+		if programme.Channel == "7" {
+			t.Error("failing channel should have no programmes")
+		}
+		if programme.Title.Lang != "en" {
+			t.Errorf("programme language = %q, want en", programme.Title.Lang)
+		}
+	}
+	// JioTV doesn't tell repeats from new episodes, so neither is flagged
+	if strings.Contains(buf.String(), "previously-shown") || strings.Contains(buf.String(), "<new") {
+		t.Error("EPG should not flag repeats or new episodes")
+	}
+	if icon := epg.Channel[0].Icon; icon == nil || icon.Src != "https://jiotv.catchup.cdn.jio.com/dare_images/images/logo1.png" {
+		t.Errorf("channel icon = %+v, want the channel logo", icon)
+	}
+}
+
+func TestGenXML_Days(t *testing.T) {
+	setupEPGServer(t, []int{1, 2}, nil)
+	setEPGDays(t, 2, 1)
+
+	var buf bytes.Buffer
+	if _, err := writeXML(&buf); err != nil {
+		t.Fatalf("writeXML() error = %v", err)
+	}
+	var epg EPG
+	if err := xml.Unmarshal(buf.Bytes(), &epg); err != nil {
+		t.Fatalf("generated EPG is not valid XML: %v", err)
+	}
+	// One day back, today and two days ahead
+	if want := 2 * 4; len(epg.Programme) != want {
+		t.Errorf("programmes = %d, want %d", len(epg.Programme), want)
+	}
+	starts := make(map[string]bool)
+	for _, programme := range epg.Programme {
+		starts[programme.Start] = true
+	}
+	if past := formatTime(time.Date(2023, 12, 31, 10, 0, 0, 0, time.UTC).Local()); !starts[past] {
+		t.Errorf("EPG has no programme of the day before, starts = %v", starts)
+	}
+}
+
+func TestFetchChannelEPG_Error(t *testing.T) {
+	setupEPGServer(t, []int{1}, map[int]bool{1: true})
+	programmes, err := fetchChannelEPG(utils.GetRequestClient(), Channel{ID: 1}, []int{0, 1})
+	if err == nil {
+		t.Fatal("fetchChannelEPG() should fail for a channel returning errors")
+	}
+	if !strings.Contains(err.Error(), "500") {
+		t.Errorf("error should contain the status code, got %v", err)
+	}
+	if programmes != nil {
+		t.Errorf("programmes = %v, want none", programmes)
+	}
+}
+
+func TestGenXMLGz(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "epg.xml.gz")
+
+	t.Run("Writes a complete gzip file", func(t *testing.T) {
+		setupEPGServer(t, []int{1, 2}, nil)
+		setEPGDays(t, 1, 0)
+		if err := GenXMLGz(filename); err != nil {
+			t.Fatalf("GenXMLGz() error = %v", err)
+		}
+		f, err := os.Open(filename)
+		if err != nil {
+			t.Fatalf("EPG file not created: %v", err)
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("EPG file is not gzipped: %v", err)
+		}
+		var epg EPG
+		if err := xml.NewDecoder(gz).Decode(&epg); err != nil {
+			t.Fatalf("EPG file is not valid XML: %v", err)
+		}
+		if len(epg.Channel) != 2 || len(epg.Programme) != 4 {
+			t.Errorf("EPG has %d channels and %d programmes, want 2 and 4", len(epg.Channel), len(epg.Programme))
+		}
+	})
+
+	t.Run("Failed run keeps the existing file", func(t *testing.T) {
+		if err := os.WriteFile(filename, []byte("good guide"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		setupEPGServer(t, []int{1, 2}, map[int]bool{1: true, 2: true})
+		if err := GenXMLGz(filename); !errors.Is(err, errNoProgrammes) {
+			t.Errorf("GenXMLGz() error = %v, want errNoProgrammes", err)
+		}
+		if data, _ := os.ReadFile(filename); string(data) != "good guide" {
+			t.Error("existing EPG file was replaced by a failed run")
+		}
+		entries, _ := os.ReadDir(filepath.Dir(filename))
+		if len(entries) != 1 {
+			t.Errorf("temporary files left behind: %v", entries)
+		}
+	})
+}
+
 func TestFormatTime(t *testing.T) {
 	type args struct {
 		t time.Time
@@ -172,26 +397,6 @@ func TestFormatTime(t *testing.T) {
 	}
 }
 
-func TestGenXMLGz(t *testing.T) {
-
-	tests := []struct {
-		name     string
-		filename string
-		wantErr  bool
-	}{
-		{
-			name:     "Generate gzipped XML with mock server",
-			filename: "/tmp/test_epg.xml.gz",
-			wantErr:  false,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// TODO
-		})
-	}
-}
-
 func TestEpochString_UnmarshalJSON(t *testing.T) {
 	type args struct {
 		data []byte
@@ -0,0 +1,332 @@
+package epg
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeLayout is the layout of the start and stop times of programmes in the XMLTV file
+const timeLayout = "20060102150405 -0700"
+
+// GuideProgramme is a programme of the in-memory guide
+type GuideProgramme struct {
+	ChannelID   string    `json:"channel_id"`
+	Start       time.Time `json:"start"`
+	Stop        time.Time `json:"stop"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+	Poster      string    `json:"poster"`
+	// searchText is the lower case title, description and category matched by Search
+	searchText string
+}
+
+// ChannelProgrammes is a channel with some of its programmes
+type ChannelProgrammes struct {
+	ChannelID   string           `json:"channel_id"`
+	ChannelName string           `json:"channel_name"`
+	Programmes  []GuideProgramme `json:"programmes"`
+}
+
+// NowNext is the current and the next programme of a channel. Either may be nil.
+type NowNext struct {
+	ChannelID   string          `json:"channel_id"`
+	ChannelName string          `json:"channel_name"`
+	Now         *GuideProgramme `json:"now"`
+	Next        *GuideProgramme `json:"next"`
+}
+
+// Guide is the EPG indexed by channel, with the programmes of each channel sorted by start time.
+// A Guide is not modified after it is built, so it is safe for concurrent use.
+type Guide struct {
+	names      map[string]string
+	channelIDs []string
+	programmes map[string][]GuideProgramme
+}
+
+var (
+	// current is the guide loaded from the last generated EPG file
+	current      *Guide
+	currentMutex sync.RWMutex
+
+	// loadListeners are called with each guide loaded by LoadGuide
+	loadListeners      []func(*Guide)
+	loadListenersMutex sync.Mutex
+)
+
+// CurrentGuide returns the guide loaded from the EPG file, or nil if no guide was loaded yet
+func CurrentGuide() *Guide {
+	currentMutex.RLock()
+	defer currentMutex.RUnlock()
+	return current
+}
+
+// setCurrentGuide replaces the guide served by CurrentGuide
+func setCurrentGuide(guide *Guide) {
+	currentMutex.Lock()
+	defer currentMutex.Unlock()
+	current = guide
+}
+
+// OnGuideLoaded registers a function called with the new guide whenever LoadGuide loads one
+func OnGuideLoaded(listener func(*Guide)) {
+	loadListenersMutex.Lock()
+	defer loadListenersMutex.Unlock()
+	loadListeners = append(loadListeners, listener)
+}
+
+// LoadGuide reads a gzipped XMLTV file and makes it the guide returned by CurrentGuide
+func LoadGuide(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	guide, err := ReadGuide(gz)
+	if err != nil {
+		return err
+	}
+	setCurrentGuide(guide)
+
+	loadListenersMutex.Lock()
+	listeners := append(([]func(*Guide))(nil), loadListeners...)
+	loadListenersMutex.Unlock()
+	for _, listener := range listeners {
+		listener(guide)
+	}
+	return nil
+}
+
+// ReadGuide builds a guide from an XMLTV document.
+// Programmes with invalid start or stop times are skipped.
+func ReadGuide(r io.Reader) (*Guide, error) {
+	decoder := xml.NewDecoder(r)
+	var channels []Channel
+	var programmes []Programme
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "channel":
+			var channel Channel
+			if err := decoder.DecodeElement(&channel, &start); err != nil {
+				return nil, err
+			}
+			channels = append(channels, channel)
+		case "programme":
+			var programme Programme
+			if err := decoder.DecodeElement(&programme, &start); err != nil {
+				return nil, err
+			}
+			programmes = append(programmes, programme)
+		}
+	}
+	return NewGuide(channels, programmes), nil
+}
+
+// NewGuide builds a guide from the channels and programmes of an EPG
+func NewGuide(channels []Channel, programmes []Programme) *Guide {
+	guide := &Guide{
+		names:      make(map[string]string, len(channels)),
+		channelIDs: make([]string, 0, len(channels)),
+		programmes: make(map[string][]GuideProgramme, len(channels)),
+	}
+	for _, channel := range channels {
+		id := strconv.Itoa(channel.ID)
+		if _, ok := guide.names[id]; !ok {
+			guide.channelIDs = append(guide.channelIDs, id)
+		}
+		guide.names[id] = channel.Display
+	}
+
+	for _, programme := range programmes {
+		start, err := time.Parse(timeLayout, programme.Start)
+		if err != nil {
+			continue
+		}
+		stop, err := time.Parse(timeLayout, programme.Stop)
+		if err != nil {
+			continue
+		}
+		if _, ok := guide.names[programme.Channel]; !ok {
+			guide.names[programme.Channel] = ""
+			guide.channelIDs = append(guide.channelIDs, programme.Channel)
+		}
+		guideProgramme := GuideProgramme{
+			ChannelID:   programme.Channel,
+			Start:       start,
+			Stop:        stop,
+			Title:       programme.Title.Value,
+			Description: programme.Desc.Value,
+		}
+		if len(programme.Categories) > 0 {
+			guideProgramme.Category = programme.Categories[0].Value
+		}
+		if programme.Icon != nil {
+			guideProgramme.Poster = programme.Icon.Src
+		}
+		guideProgramme.searchText = strings.ToLower(guideProgramme.Title + "\n" + guideProgramme.Description + "\n" + guideProgramme.Category)
+		guide.programmes[programme.Channel] = append(guide.programmes[programme.Channel], guideProgramme)
+	}
+	for _, channelProgrammes := range guide.programmes {
+		sort.SliceStable(channelProgrammes, func(i, j int) bool {
+			return channelProgrammes[i].Start.Before(channelProgrammes[j].Start)
+		})
+	}
+	return guide
+}
+
+// HasChannel reports whether the guide has a channel with the given ID
+func (g *Guide) HasChannel(channelID string) bool {
+	_, ok := g.names[channelID]
+	return ok
+}
+
+// ChannelName returns the display name of a channel
+func (g *Guide) ChannelName(channelID string) string {
+	return g.names[channelID]
+}
+
+// ChannelIDs returns the IDs of all channels in the order of the EPG
+func (g *Guide) ChannelIDs() []string {
+	return append([]string(nil), g.channelIDs...)
+}
+
+// HasProgrammes reports whether the guide has any programme of a channel
+func (g *Guide) HasProgrammes(channelID string) bool {
+	return len(g.programmes[channelID]) > 0
+}
+
+// Programme returns the programme of a channel starting at start
+func (g *Guide) Programme(channelID string, start time.Time) (GuideProgramme, bool) {
+	channelProgrammes := g.programmes[channelID]
+	i := sort.Search(len(channelProgrammes), func(i int) bool {
+		return !channelProgrammes[i].Start.Before(start)
+	})
+	if i < len(channelProgrammes) && channelProgrammes[i].Start.Equal(start) {
+		return channelProgrammes[i], true
+	}
+	return GuideProgramme{}, false
+}
+
+// Now returns the programmes airing at t on every channel
+func (g *Guide) Now(t time.Time) []GuideProgramme {
+	programmes := make([]GuideProgramme, 0, len(g.channelIDs))
+	for _, id := range g.channelIDs {
+		if programme, _ := g.nowNext(id, t); programme != nil {
+			programmes = append(programmes, *programme)
+		}
+	}
+	return programmes
+}
+
+// NowNext returns the programme airing at t and the one after it for each of the given channels.
+// Channels missing from the guide are skipped. No channel IDs means all channels.
+func (g *Guide) NowNext(channelIDs []string, t time.Time) []NowNext {
+	if len(channelIDs) == 0 {
+		channelIDs = g.channelIDs
+	}
+	result := make([]NowNext, 0, len(channelIDs))
+	for _, id := range channelIDs {
+		if !g.HasChannel(id) {
+			continue
+		}
+		now, next := g.nowNext(id, t)
+		result = append(result, NowNext{
+			ChannelID:   id,
+			ChannelName: g.names[id],
+			Now:         now,
+			Next:        next,
+		})
+	}
+	return result
+}
+
+// Between returns the programmes of a channel airing between from and to.
+// A zero to means up to the end of the guide.
+func (g *Guide) Between(channelID string, from, to time.Time) []GuideProgramme {
+	channelProgrammes := g.programmes[channelID]
+	// Programmes are sorted by start, so the first one still airing at from is found by its stop time
+	i := sort.Search(len(channelProgrammes), func(i int) bool {
+		return channelProgrammes[i].Stop.After(from)
+	})
+	programmes := []GuideProgramme{}
+	for ; i < len(channelProgrammes); i++ {
+		if !to.IsZero() && !channelProgrammes[i].Start.Before(to) {
+			break
+		}
+		programmes = append(programmes, channelProgrammes[i])
+	}
+	return programmes
+}
+
+// Search returns the programmes airing between from and to whose title, description or category contains query,
+// and whose category is category, both ignoring case. An empty query or category matches every programme.
+// A zero to means up to the end of the guide. The programmes are grouped by channel in the order of the EPG.
+func (g *Guide) Search(query, category string, from, to time.Time) []ChannelProgrammes {
+	query = strings.ToLower(strings.TrimSpace(query))
+	category = strings.TrimSpace(category)
+	results := []ChannelProgrammes{}
+	for _, id := range g.channelIDs {
+		var matches []GuideProgramme
+		for _, programme := range g.Between(id, from, to) {
+			if category != "" && !strings.EqualFold(programme.Category, category) {
+				continue
+			}
+			if query != "" && !strings.Contains(programme.searchText, query) {
+				continue
+			}
+			matches = append(matches, programme)
+		}
+		if len(matches) > 0 {
+			results = append(results, ChannelProgrammes{
+				ChannelID:   id,
+				ChannelName: g.names[id],
+				Programmes:  matches,
+			})
+		}
+	}
+	return results
+}
+
+// nowNext returns the programme of a channel airing at t, and the first programme starting after t
+func (g *Guide) nowNext(channelID string, t time.Time) (now, next *GuideProgramme) {
+	channelProgrammes := g.programmes[channelID]
+	// Index of the first programme starting after t
+	i := sort.Search(len(channelProgrammes), func(i int) bool {
+		return channelProgrammes[i].Start.After(t)
+	})
+	if i > 0 && channelProgrammes[i-1].Stop.After(t) {
+		programme := channelProgrammes[i-1]
+		now = &programme
+	}
+	if i < len(channelProgrammes) {
+		programme := channelProgrammes[i]
+		next = &programme
+	}
+	return now, next
+}
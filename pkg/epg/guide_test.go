@@ -0,0 +1,198 @@
+package epg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testGuideXML = `<?xml version="1.0" encoding="UTF-8"?>
+<tv>
+<channel id="143"><display-name>News</display-name></channel>
+<channel id="144"><display-name>Sports</display-name></channel>
+<programme channel="143" start="20240101110000 +0000" stop="20240101120000 +0000"><title lang="en">Late</title><desc lang="en"></desc><category lang="en">News</category><icon src="https://example.com/late.jpg"></icon></programme>
+<programme channel="143" start="20240101100000 +0000" stop="20240101110000 +0000"><title lang="en">Morning</title><desc lang="en">Headlines</desc><category lang="en">News</category><icon src="https://example.com/morning.jpg"></icon></programme>
+<programme channel="143" start="20240101120000 +0000" stop="20240101130000 +0000"><title lang="en">Noon</title><desc lang="en"></desc><category lang="en">News</category><icon src=""></icon></programme>
+<programme channel="144" start="20240101120000 +0000" stop="20240101140000 +0000"><title lang="en">Match</title><desc lang="en"></desc><category lang="en">Sports</category><icon src=""></icon></programme>
+<programme channel="144" start="invalid" stop="20240101140000 +0000"><title lang="en">Broken</title><desc lang="en"></desc><category lang="en"></category><icon src=""></icon></programme>
+</tv>`
+
+func testGuide(t *testing.T) *Guide {
+	t.Helper()
+	guide, err := ReadGuide(strings.NewReader(testGuideXML))
+	if err != nil {
+		t.Fatalf("ReadGuide() error = %v", err)
+	}
+	return guide
+}
+
+func at(hour, minute int) time.Time {
+	return time.Date(2024, 1, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func titles(programmes []GuideProgramme) []string {
+	titles := make([]string, len(programmes))
+	for i, programme := range programmes {
+		titles[i] = programme.Title
+	}
+	return titles
+}
+
+func TestReadGuide(t *testing.T) {
+	guide := testGuide(t)
+	if got := guide.ChannelIDs(); len(got) != 2 || got[0] != "143" || got[1] != "144" {
+		t.Errorf("ChannelIDs() = %v, want [143 144]", got)
+	}
+	if got := guide.ChannelName("144"); got != "Sports" {
+		t.Errorf("ChannelName() = %q, want Sports", got)
+	}
+	// Programmes are sorted by start and the one with an invalid time is skipped
+	if got := titles(guide.Between("143", time.Time{}, time.Time{})); strings.Join(got, ",") != "Morning,Late,Noon" {
+		t.Errorf("programmes of 143 = %v, want [Morning Late Noon]", got)
+	}
+	if got := titles(guide.Between("144", time.Time{}, time.Time{})); strings.Join(got, ",") != "Match" {
+		t.Errorf("programmes of 144 = %v, want [Match]", got)
+	}
+}
+
+func TestGuide_Now(t *testing.T) {
+	guide := testGuide(t)
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{name: "Before the guide", t: at(9, 0), want: ""},
+		{name: "Only one channel airing", t: at(10, 30), want: "Morning"},
+		{name: "At a programme boundary", t: at(12, 0), want: "Noon,Match"},
+		{name: "After the guide", t: at(15, 0), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(titles(guide.Now(tt.t)), ","); got != tt.want {
+				t.Errorf("Now() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuide_NowNext(t *testing.T) {
+	guide := testGuide(t)
+
+	got := guide.NowNext([]string{"144", "999", "143"}, at(11, 30))
+	if len(got) != 2 {
+		t.Fatalf("NowNext() returned %d channels, want 2 without the unknown one", len(got))
+	}
+	if got[0].ChannelID != "144" || got[0].Now != nil || got[0].Next == nil || got[0].Next.Title != "Match" {
+		t.Errorf("NowNext() of 144 = %+v, want nothing now and Match next", got[0])
+	}
+	if got[1].ChannelName != "News" || got[1].Now == nil || got[1].Now.Title != "Late" || got[1].Next.Title != "Noon" {
+		t.Errorf("NowNext() of 143 = %+v, want Late now and Noon next", got[1])
+	}
+
+	if all := guide.NowNext(nil, at(11, 30)); len(all) != 2 {
+		t.Errorf("NowNext() without channels returned %d channels, want 2", len(all))
+	}
+}
+
+func TestGuide_Between(t *testing.T) {
+	guide := testGuide(t)
+	tests := []struct {
+		name      string
+		channelID string
+		from, to  time.Time
+		want      string
+	}{
+		{name: "Includes the programme airing at from", channelID: "143", from: at(10, 30), to: at(11, 30), want: "Morning,Late"},
+		{name: "Zero to means the end of the guide", channelID: "143", from: at(11, 0), want: "Late,Noon"},
+		{name: "Excludes the programme starting at to", channelID: "143", from: at(10, 0), to: at(11, 0), want: "Morning"},
+		{name: "Unknown channel", channelID: "999", from: at(10, 0), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(titles(guide.Between(tt.channelID, tt.from, tt.to)), ","); got != tt.want {
+				t.Errorf("Between() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuide_Programme(t *testing.T) {
+	guide := testGuide(t)
+	if programme, ok := guide.Programme("143", at(11, 0)); !ok || programme.Title != "Late" {
+		t.Errorf("Programme(143, 11:00) = %+v, %v, want Late", programme, ok)
+	}
+	if _, ok := guide.Programme("143", at(11, 30)); ok {
+		t.Error("Programme() should not find a programme starting in the middle of another")
+	}
+	if _, ok := guide.Programme("999", at(11, 0)); ok {
+		t.Error("Programme() should not find a programme of an unknown channel")
+	}
+	if !guide.HasProgrammes("144") || guide.HasProgrammes("999") {
+		t.Error("HasProgrammes() should only report channels with programmes")
+	}
+}
+
+func TestLoadGuide(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "epg.xml.gz")
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(testGuideXML))
+	gz.Close()
+	if err := os.WriteFile(filename, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	original := CurrentGuide()
+	t.Cleanup(func() { setCurrentGuide(original) })
+	var loaded *Guide
+	OnGuideLoaded(func(guide *Guide) { loaded = guide })
+	t.Cleanup(func() { loadListeners = nil })
+
+	if err := LoadGuide(filepath.Join(t.TempDir(), "missing.xml.gz")); err == nil {
+		t.Error("LoadGuide() should fail for a missing file")
+	}
+	if err := LoadGuide(filename); err != nil {
+		t.Fatalf("LoadGuide() error = %v", err)
+	}
+	if guide := CurrentGuide(); guide == nil || !guide.HasChannel("143") {
+		t.Error("CurrentGuide() should return the loaded guide")
+	}
+	if loaded == nil || loaded != CurrentGuide() {
+		t.Error("OnGuideLoaded() listener should be called with the loaded guide")
+	}
+}
+
+func TestGuide_Search(t *testing.T) {
+	guide := testGuide(t)
+	tests := []struct {
+		name     string
+		query    string
+		category string
+		from, to time.Time
+		want     string
+	}{
+		{name: "Matches title ignoring case", query: "MATCH", want: "144:Match"},
+		{name: "Matches description", query: "headlines", want: "143:Morning"},
+		{name: "Matches category", query: "news", want: "143:Morning,Late,Noon"},
+		{name: "Filters by category", query: "o", category: "news", want: "143:Morning,Noon"},
+		{name: "Category only", category: "SPORTS", want: "144:Match"},
+		{name: "Limited to the time range", query: "n", from: at(11, 30), to: at(12, 30), want: "143:Late,Noon"},
+		{name: "No match", query: "weather", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var groups []string
+			for _, channel := range guide.Search(tt.query, tt.category, tt.from, tt.to) {
+				groups = append(groups, channel.ChannelID+":"+strings.Join(titles(channel.Programmes), ","))
+			}
+			if got := strings.Join(groups, " "); got != tt.want {
+				t.Errorf("Search() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package epg
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
+)
+
+// RewriteOptions selects how Rewrite changes an XMLTV document
+type RewriteOptions struct {
+	// ChannelIDs are the channels to keep. nil keeps all channels.
+	ChannelIDs map[string]bool
+	// ImageHostURL points the icons of channels and programmes at the /jtvimage and /jtvposter
+	// routes of the server at this URL instead of JioTV. Empty keeps the JioTV URLs.
+	ImageHostURL string
+}
+
+// Rewrite copies an XMLTV document from r to w, dropping the channels and programmes which are not
+// in opts.ChannelIDs and rewriting image URLs. The document is streamed, so it is never fully held in memory.
+func Rewrite(w io.Writer, r io.Reader, opts RewriteOptions) error {
+	var replacer *strings.Replacer
+	if opts.ImageHostURL != "" {
+		replacer = strings.NewReplacer(
+			urls.EPGPosterURLSlash, opts.ImageHostURL+"/jtvposter/",
+			urls.ChannelImageURL, opts.ImageHostURL+"/jtvimage/",
+		)
+	}
+	decoder := xml.NewDecoder(r)
+	encoder := xml.NewEncoder(w)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if opts.ChannelIDs != nil && !keepElement(start, opts.ChannelIDs) {
+				if err := decoder.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if replacer != nil && start.Name.Local == "icon" {
+				for i, attr := range start.Attr {
+					if attr.Name.Local == "src" {
+						start.Attr[i].Value = replacer.Replace(attr.Value)
+					}
+				}
+				token = start
+			}
+		}
+		if err := encoder.EncodeToken(token); err != nil {
+			return err
+		}
+	}
+	return encoder.Flush()
+}
+
+// keepElement checks if a channel or programme element belongs to one of the channels to keep.
+// Other elements are always kept.
+func keepElement(start xml.StartElement, channelIDs map[string]bool) bool {
+	var attrName string
+	switch start.Name.Local {
+	case "channel":
+		attrName = "id"
+	case "programme":
+		attrName = "channel"
+	default:
+		return true
+	}
+	for _, attr := range start.Attr {
+		if attr.Name.Local == attrName {
+			return channelIDs[attr.Value]
+		}
+	}
+	return false
+}
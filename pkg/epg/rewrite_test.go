@@ -0,0 +1,62 @@
+package epg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+const testRewriteXML = xml.Header + `<!DOCTYPE tv SYSTEM "http://www.w3.org/2006/05/tv">
+<tv><channel id="1"><display-name>A &amp; B</display-name><icon src="https://jiotv.catchup.cdn.jio.com/dare_images/images/logo.png"></icon></channel>` +
+	`<channel id="2"><display-name>Two</display-name></channel>` +
+	`<programme channel="1" start="20240101100000 +0000" stop="20240101110000 +0000"><title lang="en">Show</title>` +
+	`<icon src="https://jiotv.catchup.cdn.jio.com/dare_images/shows/2024-01-01/poster.jpg"></icon></programme>` +
+	`<programme channel="2" start="20240101110000 +0000" stop="20240101120000 +0000"><title lang="en">Other</title>` +
+	`<icon src="https://example.com/poster.jpg"></icon></programme></tv>`
+
+func rewrite(t *testing.T, opts RewriteOptions) (string, EPG) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Rewrite(&buf, strings.NewReader(testRewriteXML), opts); err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+	var epg EPG
+	if err := xml.Unmarshal(buf.Bytes(), &epg); err != nil {
+		t.Fatalf("rewritten EPG is not valid XML: %v\n%s", err, buf.String())
+	}
+	return buf.String(), epg
+}
+
+func TestRewrite_ImageURLs(t *testing.T) {
+	got, epg := rewrite(t, RewriteOptions{ImageHostURL: "http://localhost:5001"})
+	for _, want := range []string{
+		`<!DOCTYPE tv SYSTEM "http://www.w3.org/2006/05/tv">`,
+		`<icon src="http://localhost:5001/jtvimage/logo.png">`,
+		`<icon src="http://localhost:5001/jtvposter/2024-01-01/poster.jpg">`,
+		`<icon src="https://example.com/poster.jpg">`,
+		`A &amp; B`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Rewrite() output is missing %s:\n%s", want, got)
+		}
+	}
+	if len(epg.Channel) != 2 || len(epg.Programme) != 2 {
+		t.Errorf("rewritten EPG has %d channels and %d programmes, want 2 and 2", len(epg.Channel), len(epg.Programme))
+	}
+}
+
+func TestRewrite_ChannelIDs(t *testing.T) {
+	_, epg := rewrite(t, RewriteOptions{ChannelIDs: map[string]bool{"2": true}})
+	if len(epg.Channel) != 1 || epg.Channel[0].ID != 2 {
+		t.Errorf("channels = %+v, want only channel 2", epg.Channel)
+	}
+	if len(epg.Programme) != 1 || epg.Programme[0].Channel != "2" {
+		t.Errorf("programmes = %+v, want only the programme of channel 2", epg.Programme)
+	}
+
+	_, epg = rewrite(t, RewriteOptions{ChannelIDs: map[string]bool{}})
+	if len(epg.Channel) != 0 || len(epg.Programme) != 0 {
+		t.Errorf("rewritten EPG has %d channels and %d programmes, want none", len(epg.Channel), len(epg.Programme))
+	}
+}
@@ -8,12 +8,14 @@ import (
 
 // Channel XML tag structure for the EPG
 type Channel struct {
-	XMLName xml.Name `xml:"channel"`      // XML tag name
-	ID      int      `xml:"id,attr"`      // ID is attribute of channel tag
-	Display string   `xml:"display-name"` // Display name of the channel
+	XMLName  xml.Name `xml:"channel"`      // XML tag name
+	ID       int      `xml:"id,attr"`      // ID is attribute of channel tag
+	Display  string   `xml:"display-name"` // Display name of the channel
+	Icon     *Icon    `xml:"icon"`         // Logo of the channel
+	Language string   `xml:"-"`            // Language code of the programmes of the channel
 }
 
-// Icon XML tag for Programme XML tag in EPG
+// Icon XML tag for Channel and Programme XML tags in EPG
 type Icon struct {
 	XMLName xml.Name `xml:"icon"`     // XML tag name
 	Src     string   `xml:"src,attr"` // Src is attribute of the icon tag
@@ -23,36 +25,54 @@ type Icon struct {
 // Title is the name of the programme or show being aired on the channel
 type Title struct {
 	XMLName xml.Name `xml:"title"`
-	Value   string   `xml:",chardata"` // Title of the programme
-	Lang    string   `xml:"lang,attr"` // Language of the title
+	Value   string   `xml:",chardata"`           // Title of the programme
+	Lang    string   `xml:"lang,attr,omitempty"` // Language of the title
+}
+
+// SubTitle XML tag for Programme XML tag in EPG
+// SubTitle describes the episode being aired
+type SubTitle struct {
+	XMLName xml.Name `xml:"sub-title"`
+	Value   string   `xml:",chardata"`           // Sub-title of the programme
+	Lang    string   `xml:"lang,attr,omitempty"` // Language of the sub-title
 }
 
 // Category XML tag for Programme XML tag in EPG
 // Category is the type of the programme or show being aired on the channel
 type Category struct {
 	XMLName xml.Name `xml:"category"`
-	Value   string   `xml:",chardata"` // Category of the programme
-	Lang    string   `xml:"lang,attr"` // Language of the category
+	Value   string   `xml:",chardata"`           // Category of the programme
+	Lang    string   `xml:"lang,attr,omitempty"` // Language of the category
 }
 
 // Desc represents Description XML tag for Programme XML tag in EPG
 type Desc struct {
 	XMLName xml.Name `xml:"desc"`
-	Value   string   `xml:",chardata"` // Description of the programme
-	Lang    string   `xml:"lang,attr"` // Language of the description
+	Value   string   `xml:",chardata"`           // Description of the programme
+	Lang    string   `xml:"lang,attr,omitempty"` // Language of the description
+}
+
+// EpisodeNum XML tag for Programme XML tag in EPG
+type EpisodeNum struct {
+	XMLName xml.Name `xml:"episode-num"`
+	Value   string   `xml:",chardata"`   // Episode number in the format of System
+	System  string   `xml:"system,attr"` // Numbering system, like xmltv_ns or onscreen
 }
 
 // Programme XML tag structure for EPG
-// Each programme tag represents a show being aired on a channel
+// Each programme tag represents a show being aired on a channel.
+// Fields are in the order of the XMLTV DTD.
 type Programme struct {
-	XMLName  xml.Name `xml:"programme"`    // XML tag name
-	Channel  string   `xml:"channel,attr"` // Channel is attribute of programme tag
-	Start    string   `xml:"start,attr"`   // Start time of the programme
-	Stop     string   `xml:"stop,attr"`    // Stop time of the programme
-	Title    Title    `xml:"title"`        // Title of the programme
-	Desc     Desc     `xml:"desc"`         // Description of the programme
-	Category Category `xml:"category"`     // Category of the programme
-	Icon     Icon     `xml:"icon"`         // Icon of the programme
+	XMLName     xml.Name     `xml:"programme"`    // XML tag name
+	Channel     string       `xml:"channel,attr"` // Channel is attribute of programme tag
+	Start       string       `xml:"start,attr"`   // Start time of the programme
+	Stop        string       `xml:"stop,attr"`    // Stop time of the programme
+	Title       Title        `xml:"title"`        // Title of the programme
+	SubTitle    *SubTitle    `xml:"sub-title"`    // Sub-title of the programme
+	Desc        Desc         `xml:"desc"`         // Description of the programme
+	Categories  []Category   `xml:"category"`     // Categories of the programme
+	Icon        *Icon        `xml:"icon"`         // Icon of the programme
+	EpisodeNums []EpisodeNum `xml:"episode-num"`  // Episode numbers of the programme
 }
 
 // EPG XML tag structure
@@ -66,9 +86,10 @@ type EPG struct {
 
 // ChannelObject represents Individual channel detail from JioTV API response
 type ChannelObject struct {
-	ChannelID   int    `json:"channel_id"`   // Channel ID
-	ChannelName string `json:"channel_name"` // Channel name
-	LogoURL     string `json:"logoUrl"`      // Channel logo URL
+	ChannelID   int    `json:"channel_id"`        // Channel ID
+	ChannelName string `json:"channel_name"`      // Channel name
+	LogoURL     string `json:"logoUrl"`           // Channel logo URL
+	LanguageID  int    `json:"channelLanguageId"` // Channel language ID
 }
 
 // ChannelsResponse represents Channel details from JioTV API response
@@ -80,15 +101,19 @@ type ChannelsResponse struct {
 
 // EPGObject represents Individual EPG detail from JioTV EPG API response
 type EPGObject struct {
-	StartEpoch   int64  `json:"startEpoch"`       // Start time of the programme
-	EndEpoch     int64  `json:"endEpoch"`         // End time of the programme
-	ChannelID    uint16 `json:"channel_id"`       // Channel ID
-	ChannelName  string `json:"channel_name"`     // Channel name
-	ShowCategory string `json:"showCategory"`     // Category of the show
-	Description  string `json:"description"`      // Description of the show
-	Title        string `json:"showname"`         // Title of the show
-	Thumbnail    string `json:"episodeThumbnail"` // Thumbnail of the show
-	Poster       string `json:"episodePoster"`    // Poster of the show
+	StartEpoch   int64         `json:"startEpoch"`       // Start time of the programme
+	EndEpoch     int64         `json:"endEpoch"`         // End time of the programme
+	ChannelID    uint16        `json:"channel_id"`       // Channel ID
+	ChannelName  string        `json:"channel_name"`     // Channel name
+	ShowID       string        `json:"showId"`           // ID of the show
+	ShowCategory string        `json:"showCategory"`     // Category of the show
+	ShowGenre    []string      `json:"showGenre"`        // Genres of the show
+	Description  string        `json:"description"`      // Description of the show
+	Title        string        `json:"showname"`         // Title of the show
+	EpisodeNum   EpisodeNumber `json:"episode_num"`      // Episode number, 0 if unknown
+	EpisodeDesc  string        `json:"episode_desc"`     // Short description of the episode
+	Thumbnail    string        `json:"episodeThumbnail"` // Thumbnail of the show
+	Poster       string        `json:"episodePoster"`    // Poster of the show
 }
 
 // EPGResponse represents EPG details from JioTV EPG API response
@@ -121,3 +146,23 @@ func (id *EpochString) UnmarshalJSON(data []byte) error {
 func (id *EpochString) String() string {
 	return string(*id)
 }
+
+// EpisodeNumber is a custom type for episode numbers, which JioTV EPG API sends as integers or strings
+type EpisodeNumber int
+
+// UnmarshalJSON unmarshals episode numbers from integers or strings. Values which aren't numbers are 0.
+func (n *EpisodeNumber) UnmarshalJSON(data []byte) error {
+	var intValue int
+	if err := json.Unmarshal(data, &intValue); err == nil {
+		*n = EpisodeNumber(intValue)
+		return nil
+	}
+	var stringValue string
+	if err := json.Unmarshal(data, &stringValue); err != nil {
+		*n = 0
+		return nil
+	}
+	intValue, _ = strconv.Atoi(stringValue)
+	*n = EpisodeNumber(intValue)
+	return nil
+}
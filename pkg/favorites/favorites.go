@@ -0,0 +1,176 @@
+// Package favorites stores the favorite channels and the custom channel order of the user.
+// Both are lists of channel IDs saved in the store, so they are shared by every client of the server.
+package favorites
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+const (
+	// favoritesKey is the store key of the favorite channel IDs
+	favoritesKey = "favorites"
+	// channelOrderKey is the store key of the custom channel order
+	channelOrderKey = "channel_order"
+)
+
+// mu serializes read-modify-write updates of the lists
+var mu sync.Mutex
+
+// Get returns the favorite channel IDs in the order they were added
+func Get() ([]string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return getList(favoritesKey)
+}
+
+// Set replaces the favorite channel IDs. Empty and duplicate IDs are dropped.
+func Set(ids []string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	return setList(favoritesKey, ids)
+}
+
+// Add adds a channel to the end of the favorites if it is not a favorite yet
+func Add(id string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	ids, err := getList(favoritesKey)
+	if err != nil {
+		return err
+	}
+	return setList(favoritesKey, append(ids, id))
+}
+
+// Remove removes a channel from the favorites
+func Remove(id string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	ids, err := getList(favoritesKey)
+	if err != nil {
+		return err
+	}
+	kept := ids[:0]
+	for _, favorite := range ids {
+		if favorite != id {
+			kept = append(kept, favorite)
+		}
+	}
+	return setList(favoritesKey, kept)
+}
+
+// GetOrder returns the custom channel order
+func GetOrder() ([]string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return getList(channelOrderKey)
+}
+
+// SetOrder replaces the custom channel order. Empty and duplicate IDs are dropped.
+func SetOrder(ids []string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	return setList(channelOrderKey, ids)
+}
+
+// Filter returns the channels which are in ids, keeping their order
+func Filter(channels []television.Channel, ids []string) []television.Channel {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	filtered := make([]television.Channel, 0, len(ids))
+	for _, channel := range channels {
+		if set[channel.ID] {
+			filtered = append(filtered, channel)
+		}
+	}
+	return filtered
+}
+
+// First returns the channels with the ones in ids moved to the front. Both groups keep their order.
+func First(channels []television.Channel, ids []string) []television.Channel {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	first := make([]television.Channel, 0, len(channels))
+	var rest []television.Channel
+	for _, channel := range channels {
+		if set[channel.ID] {
+			first = append(first, channel)
+		} else {
+			rest = append(rest, channel)
+		}
+	}
+	return append(first, rest...)
+}
+
+// Sort returns the channels in the given order. Channels missing from order follow in their original order.
+func Sort(channels []television.Channel, order []string) []television.Channel {
+	position := make(map[string]int, len(order))
+	for i, id := range order {
+		if _, ok := position[id]; !ok {
+			position[id] = i
+		}
+	}
+	ordered := make([]television.Channel, len(order))
+	found := make([]bool, len(order))
+	sorted := make([]television.Channel, 0, len(channels))
+	var rest []television.Channel
+	for _, channel := range channels {
+		if i, ok := position[channel.ID]; ok && !found[i] {
+			ordered[i] = channel
+			found[i] = true
+		} else {
+			rest = append(rest, channel)
+		}
+	}
+	for i, channel := range ordered {
+		if found[i] {
+			sorted = append(sorted, channel)
+		}
+	}
+	return append(sorted, rest...)
+}
+
+// getList reads a list of channel IDs from the store. A missing key is an empty list.
+func getList(key string) ([]string, error) {
+	value, err := store.Get(key)
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	if err := json.Unmarshal([]byte(value), &ids); err != nil {
+		return nil, err
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+	return ids, nil
+}
+
+// setList saves a list of channel IDs to the store without empty and duplicate IDs
+func setList(key string, ids []string) error {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	value, err := json.Marshal(unique)
+	if err != nil {
+		return err
+	}
+	return store.Set(key, string(value))
+}
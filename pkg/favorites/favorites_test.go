@@ -0,0 +1,96 @@
+package favorites
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func setupStore(t *testing.T) {
+	t.Helper()
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	t.Cleanup(cleanup)
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+}
+
+func channelIDs(channels []television.Channel) []string {
+	ids := make([]string, len(channels))
+	for i, channel := range channels {
+		ids[i] = channel.ID
+	}
+	return ids
+}
+
+func TestFavorites(t *testing.T) {
+	setupStore(t)
+
+	if ids, err := Get(); err != nil || len(ids) != 0 {
+		t.Fatalf("Get() = %v, %v, want empty list", ids, err)
+	}
+
+	if err := Set([]string{"143", "", "144", "143"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := Add("145"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := Add("144"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := Remove("143"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	ids, err := Get()
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if want := []string{"144", "145"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Get() = %v, want %v", ids, want)
+	}
+}
+
+func TestOrder(t *testing.T) {
+	setupStore(t)
+
+	if err := SetOrder([]string{"3", "1", "3"}); err != nil {
+		t.Fatalf("SetOrder() error = %v", err)
+	}
+	order, err := GetOrder()
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if want := []string{"3", "1"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("GetOrder() = %v, want %v", order, want)
+	}
+}
+
+func TestChannelHelpers(t *testing.T) {
+	channels := []television.Channel{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}}
+
+	tests := []struct {
+		name string
+		got  []television.Channel
+		want []string
+	}{
+		{name: "Filter", got: Filter(channels, []string{"4", "2", "9"}), want: []string{"2", "4"}},
+		{name: "Filter none", got: Filter(channels, nil), want: []string{}},
+		{name: "Sort", got: Sort(channels, []string{"3", "9", "1"}), want: []string{"3", "1", "2", "4"}},
+		{name: "Sort empty order", got: Sort(channels, nil), want: []string{"1", "2", "3", "4"}},
+		{name: "First", got: First(channels, []string{"4", "2"}), want: []string{"2", "4", "1", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := channelIDs(tt.got); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
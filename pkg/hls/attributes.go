@@ -0,0 +1,91 @@
+package hls
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Attribute is a single NAME=VALUE pair of an attribute list
+type Attribute struct {
+	Name  string
+	Value string
+	// Quoted is set for quoted-string values, which are written back in double quotes
+	Quoted bool
+}
+
+// Attributes is an attribute list in the order it appears in the tag
+type Attributes []Attribute
+
+// ParseAttributes parses an attribute list like METHOD=AES-128,URI="key.bin"
+func ParseAttributes(value string) (Attributes, error) {
+	attributes := Attributes{}
+	for value != "" {
+		name, rest, found := strings.Cut(value, "=")
+		if !found {
+			return nil, fmt.Errorf("attribute %q has no value", value)
+		}
+		attribute := Attribute{Name: strings.TrimSpace(name)}
+
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated quoted string in attribute %s", attribute.Name)
+			}
+			attribute.Value = rest[1 : end+1]
+			attribute.Quoted = true
+			rest = rest[end+2:]
+		} else if i := strings.IndexByte(rest, ','); i != -1 {
+			attribute.Value = rest[:i]
+			rest = rest[i:]
+		} else {
+			attribute.Value = rest
+			rest = ""
+		}
+
+		attributes = append(attributes, attribute)
+		value = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+	return attributes, nil
+}
+
+// Get returns the value of the named attribute
+func (a Attributes) Get(name string) (string, bool) {
+	for _, attribute := range a {
+		if attribute.Name == name {
+			return attribute.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set replaces the value of the named attribute, or appends it if it doesn't exist
+func (a *Attributes) Set(name, value string, quoted bool) {
+	for i := range *a {
+		if (*a)[i].Name == name {
+			(*a)[i].Value = value
+			(*a)[i].Quoted = quoted
+			return
+		}
+	}
+	*a = append(*a, Attribute{Name: name, Value: value, Quoted: quoted})
+}
+
+// String returns the attribute list as it is written in a tag
+func (a Attributes) String() string {
+	var sb strings.Builder
+	for i, attribute := range a {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(attribute.Name)
+		sb.WriteByte('=')
+		if attribute.Quoted {
+			sb.WriteByte('"')
+			sb.WriteString(attribute.Value)
+			sb.WriteByte('"')
+		} else {
+			sb.WriteString(attribute.Value)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,247 @@
+// Package hls parses and serializes HLS master and media playlists (RFC 8216).
+//
+// Playlists keep every line in order, including tags this package doesn't know about,
+// so a playlist can be modified and written back without losing information.
+package hls
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotPlaylist is returned when the data doesn't start with #EXTM3U
+var ErrNotPlaylist = errors.New("not an HLS playlist: missing #EXTM3U header")
+
+// LineType is the type of a playlist line
+type LineType int
+
+const (
+	// LineBlank is an empty line
+	LineBlank LineType = iota
+	// LineComment is a line starting with # which is not a tag
+	LineComment
+	// LineTag is a line starting with #EXT
+	LineTag
+	// LineURI is a URI of a media segment or variant stream
+	LineURI
+)
+
+// URIType tells what a URI in a playlist points to
+type URIType int
+
+const (
+	// URISegment is a media segment, a partial segment (EXT-X-PART) or a preload hint
+	URISegment URIType = iota
+	// URIMap is a media initialization section (EXT-X-MAP)
+	URIMap
+	// URIKey is an encryption key (EXT-X-KEY, EXT-X-SESSION-KEY)
+	URIKey
+	// URIVariant is a variant stream playlist following EXT-X-STREAM-INF
+	URIVariant
+	// URIMedia is a rendition playlist (EXT-X-MEDIA), such as alternative audio or subtitles
+	URIMedia
+	// URIIFrame is an I-frame playlist (EXT-X-I-FRAME-STREAM-INF)
+	URIIFrame
+	// URIRenditionReport is a playlist of a rendition report (EXT-X-RENDITION-REPORT)
+	URIRenditionReport
+	// URISessionData is a JSON file with session data (EXT-X-SESSION-DATA)
+	URISessionData
+)
+
+// IsPlaylist reports whether URIs of this type point to another playlist
+func (t URIType) IsPlaylist() bool {
+	switch t {
+	case URIVariant, URIMedia, URIIFrame, URIRenditionReport:
+		return true
+	default:
+		return false
+	}
+}
+
+// uriAttributeTypes maps tags with a URI attribute to the type of that URI
+var uriAttributeTypes = map[string]URIType{
+	"EXT-X-KEY":                URIKey,
+	"EXT-X-SESSION-KEY":        URIKey,
+	"EXT-X-MAP":                URIMap,
+	"EXT-X-MEDIA":              URIMedia,
+	"EXT-X-I-FRAME-STREAM-INF": URIIFrame,
+	"EXT-X-PART":               URISegment,
+	"EXT-X-PRELOAD-HINT":       URISegment,
+	"EXT-X-RENDITION-REPORT":   URIRenditionReport,
+	"EXT-X-SESSION-DATA":       URISessionData,
+}
+
+// attributeListTags are tags whose value is an attribute list
+var attributeListTags = map[string]bool{
+	"EXT-X-KEY":                true,
+	"EXT-X-SESSION-KEY":        true,
+	"EXT-X-MAP":                true,
+	"EXT-X-MEDIA":              true,
+	"EXT-X-STREAM-INF":         true,
+	"EXT-X-I-FRAME-STREAM-INF": true,
+	"EXT-X-PART":               true,
+	"EXT-X-PART-INF":           true,
+	"EXT-X-PRELOAD-HINT":       true,
+	"EXT-X-RENDITION-REPORT":   true,
+	"EXT-X-SESSION-DATA":       true,
+	"EXT-X-DATERANGE":          true,
+	"EXT-X-SERVER-CONTROL":     true,
+	"EXT-X-START":              true,
+	"EXT-X-SKIP":               true,
+	"EXT-X-DEFINE":             true,
+	"EXT-X-CONTENT-STEERING":   true,
+}
+
+// Line is a single line of a playlist
+type Line struct {
+	Type LineType
+	// Name is the tag name without the leading #, e.g. EXT-X-KEY
+	Name string
+	// Value is the raw tag value after the colon, for tags without an attribute list
+	// and tags whose attribute list can't be parsed
+	Value string
+	// Attributes is the parsed attribute list of tags like EXT-X-KEY, nil for other tags
+	Attributes Attributes
+	// Text is the URI of URI lines, or the full text of comments
+	Text string
+	// URIType is the type of URI lines
+	URIType URIType
+}
+
+// String returns the line as it is written in a playlist
+func (l *Line) String() string {
+	switch l.Type {
+	case LineTag:
+		switch {
+		case l.Attributes != nil:
+			return "#" + l.Name + ":" + l.Attributes.String()
+		case l.Value != "":
+			return "#" + l.Name + ":" + l.Value
+		default:
+			return "#" + l.Name
+		}
+	case LineURI, LineComment:
+		return l.Text
+	default:
+		return ""
+	}
+}
+
+// Playlist is a parsed HLS playlist
+type Playlist struct {
+	Lines []*Line
+}
+
+// Parse parses an HLS master or media playlist.
+// Tags whose attribute list can't be parsed are kept unchanged, with their URI left out of RewriteURIs.
+func Parse(data []byte) (*Playlist, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // UTF-8 BOM
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("#EXTM3U")) {
+		return nil, ErrNotPlaylist
+	}
+
+	playlist := &Playlist{}
+	// Set by EXT-X-STREAM-INF, the next URI line is a variant stream
+	nextIsVariant := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		line := &Line{}
+		switch {
+		case text == "":
+			line.Type = LineBlank
+		case strings.HasPrefix(text, "#EXT"):
+			line.Type = LineTag
+			name, value, _ := strings.Cut(text[1:], ":")
+			line.Name = name
+			if !attributeListTags[name] {
+				line.Value = value
+			} else if attributes, err := ParseAttributes(value); err == nil {
+				line.Attributes = attributes
+			} else {
+				// A malformed attribute list is kept as it is, so that one bad tag doesn't break the whole playlist
+				line.Value = value
+			}
+			if name == "EXT-X-STREAM-INF" {
+				nextIsVariant = true
+			}
+		case strings.HasPrefix(text, "#"):
+			line.Type = LineComment
+			line.Text = text
+		default:
+			line.Type = LineURI
+			line.Text = text
+			if nextIsVariant {
+				line.URIType = URIVariant
+			} else {
+				line.URIType = URISegment
+			}
+			nextIsVariant = false
+		}
+		playlist.Lines = append(playlist.Lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read playlist: %w", err)
+	}
+	return playlist, nil
+}
+
+// IsMaster reports whether the playlist is a master playlist
+func (p *Playlist) IsMaster() bool {
+	for _, line := range p.Lines {
+		if line.Type == LineTag && (line.Name == "EXT-X-STREAM-INF" || line.Name == "EXT-X-I-FRAME-STREAM-INF") {
+			return true
+		}
+	}
+	return false
+}
+
+// RewriteURIs calls rewrite for every URI in the playlist, both URI lines and URI attributes of tags,
+// and replaces the URI with the returned value. Rewriting stops at the first error.
+func (p *Playlist) RewriteURIs(rewrite func(uri string, uriType URIType) (string, error)) error {
+	for _, line := range p.Lines {
+		switch line.Type {
+		case LineURI:
+			uri, err := rewrite(line.Text, line.URIType)
+			if err != nil {
+				return err
+			}
+			line.Text = uri
+		case LineTag:
+			uriType, ok := uriAttributeTypes[line.Name]
+			if !ok {
+				continue
+			}
+			value, ok := line.Attributes.Get("URI")
+			if !ok {
+				continue
+			}
+			if line.Name == "EXT-X-PRELOAD-HINT" {
+				if hintType, _ := line.Attributes.Get("TYPE"); hintType == "MAP" {
+					uriType = URIMap
+				}
+			}
+			uri, err := rewrite(value, uriType)
+			if err != nil {
+				return err
+			}
+			line.Attributes.Set("URI", uri, true)
+		}
+	}
+	return nil
+}
+
+// Bytes serializes the playlist
+func (p *Playlist) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, line := range p.Lines {
+		buf.WriteString(line.String())
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
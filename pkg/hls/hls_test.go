@@ -0,0 +1,233 @@
+package hls
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const testMediaPlaylist = `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-KEY:METHOD=AES-128,URI="key.bin",IV=0x0000000000000000000000000000abcd
+#EXT-X-MAP:URI="init.mp4",BYTERANGE="720@0"
+# a comment
+#EXTINF:6.000,
+#EXT-X-BYTERANGE:1000@720
+seg-100.m4s
+#EXT-X-PART:DURATION=1.0,URI="part-101.0.m4s"
+#EXT-X-PRELOAD-HINT:TYPE=MAP,URI="init-2.mp4"
+
+#EXTINF:6.000,title
+seg-101.ts?token=abc
+#EXT-X-RENDITION-REPORT:URI="../low/index.m3u8",LAST-MSN=101
+`
+
+func TestParse(t *testing.T) {
+	playlist, err := Parse([]byte(testMediaPlaylist))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if playlist.IsMaster() {
+		t.Error("IsMaster() = true for a media playlist")
+	}
+
+	key := playlist.Lines[4]
+	if key.Type != LineTag || key.Name != "EXT-X-KEY" {
+		t.Fatalf("line 4 = %+v, want EXT-X-KEY tag", key)
+	}
+	if method, _ := key.Attributes.Get("METHOD"); method != "AES-128" {
+		t.Errorf("METHOD = %q, want AES-128", method)
+	}
+	if uri, _ := key.Attributes.Get("URI"); uri != "key.bin" {
+		t.Errorf("URI = %q, want key.bin", uri)
+	}
+
+	if comment := playlist.Lines[6]; comment.Type != LineComment || comment.Text != "# a comment" {
+		t.Errorf("line 6 = %+v, want comment", comment)
+	}
+	if extinf := playlist.Lines[7]; extinf.Name != "EXTINF" || extinf.Value != "6.000," {
+		t.Errorf("line 7 = %+v, want EXTINF with value 6.000,", extinf)
+	}
+	if segment := playlist.Lines[9]; segment.Type != LineURI || segment.URIType != URISegment || segment.Text != "seg-100.m4s" {
+		t.Errorf("line 9 = %+v, want segment URI", segment)
+	}
+}
+
+func TestParse_NotPlaylist(t *testing.T) {
+	for _, data := range []string{"", `{"code":403}`, "seg-1.ts\n#EXTM3U\n"} {
+		if _, err := Parse([]byte(data)); !errors.Is(err, ErrNotPlaylist) {
+			t.Errorf("Parse(%q) error = %v, want ErrNotPlaylist", data, err)
+		}
+	}
+}
+
+func TestParse_InvalidAttributes(t *testing.T) {
+	data := "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\n#EXTINF:6.0,\nseg-1.ts\n"
+	playlist, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse() error = %v, want the malformed tag kept", err)
+	}
+	if got := string(playlist.Bytes()); got != data {
+		t.Errorf("Bytes() =\n%s\nwant the malformed tag unchanged\n%s", got, data)
+	}
+	var uris []string
+	playlist.RewriteURIs(func(uri string, uriType URIType) (string, error) {
+		uris = append(uris, uri)
+		return uri, nil
+	})
+	if len(uris) != 1 || uris[0] != "seg-1.ts" {
+		t.Errorf("rewritten URIs = %v, want only the segment", uris)
+	}
+}
+
+func TestPlaylist_RoundTrip(t *testing.T) {
+	playlist, err := Parse([]byte("\xef\xbb\xbf" + testMediaPlaylist))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := string(playlist.Bytes()); got != testMediaPlaylist {
+		t.Errorf("Bytes() =\n%s\nwant\n%s", got, testMediaPlaylist)
+	}
+}
+
+func TestPlaylist_RewriteURIs(t *testing.T) {
+	tests := []struct {
+		name     string
+		playlist string
+		want     []URIType
+	}{
+		{
+			name:     "Media playlist",
+			playlist: testMediaPlaylist,
+			want:     []URIType{URIKey, URIMap, URISegment, URISegment, URIMap, URISegment, URIRenditionReport},
+		},
+		{
+			name: "Master playlist",
+			playlist: `#EXTM3U
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES,URI="skd://key"
+#EXT-X-SESSION-DATA:DATA-ID="com.example",URI="data.json"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aud",NAME="English",DEFAULT=YES,URI="audio.m3u8"
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",NAME="English",INSTREAM-ID="CC1"
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,CODECS="avc1.4d401f,mp4a.40.2",AUDIO="aud"
+video/720p
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=86000,URI="iframe.m3u8"
+`,
+			want: []URIType{URIKey, URISessionData, URIMedia, URIVariant, URIIFrame},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			playlist, err := Parse([]byte(tt.playlist))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			var got []URIType
+			err = playlist.RewriteURIs(func(uri string, uriType URIType) (string, error) {
+				got = append(got, uriType)
+				return "/proxy/" + uri, nil
+			})
+			if err != nil {
+				t.Fatalf("RewriteURIs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("URI types = %v, want %v", got, tt.want)
+			}
+
+			// Rewritten URIs end up in the serialized playlist
+			reparsed, err := Parse(playlist.Bytes())
+			if err != nil {
+				t.Fatalf("Parse() of rewritten playlist error = %v", err)
+			}
+			reparsed.RewriteURIs(func(uri string, uriType URIType) (string, error) {
+				if len(uri) < 7 || uri[:7] != "/proxy/" {
+					t.Errorf("URI %q was not rewritten", uri)
+				}
+				return uri, nil
+			})
+		})
+	}
+}
+
+func TestPlaylist_RewriteURIsError(t *testing.T) {
+	playlist, err := Parse([]byte(testMediaPlaylist))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	wantErr := errors.New("rewrite failed")
+	err = playlist.RewriteURIs(func(uri string, uriType URIType) (string, error) {
+		return "", wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("RewriteURIs() error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestParseAttributes(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Attributes
+		wantErr bool
+	}{
+		{
+			name:  "Quoted and unquoted values",
+			value: `BANDWIDTH=1280000,CODECS="avc1.4d401f,mp4a.40.2",RESOLUTION=1280x720`,
+			want: Attributes{
+				{Name: "BANDWIDTH", Value: "1280000"},
+				{Name: "CODECS", Value: "avc1.4d401f,mp4a.40.2", Quoted: true},
+				{Name: "RESOLUTION", Value: "1280x720"},
+			},
+		},
+		{
+			name:  "Empty quoted value",
+			value: `URI="",METHOD=NONE`,
+			want: Attributes{
+				{Name: "URI", Value: "", Quoted: true},
+				{Name: "METHOD", Value: "NONE"},
+			},
+		},
+		{
+			name:  "Empty list",
+			value: "",
+			want:  Attributes{},
+		},
+		{
+			name:    "Missing value",
+			value:   "METHOD",
+			wantErr: true,
+		},
+		{
+			name:    "Unterminated quote",
+			value:   `URI="key.bin`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAttributes(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAttributes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAttributes() = %+v, want %+v", got, tt.want)
+			}
+			if got.String() != tt.value {
+				t.Errorf("String() = %q, want %q", got.String(), tt.value)
+			}
+		})
+	}
+}
+
+func TestAttributes_Set(t *testing.T) {
+	attributes := Attributes{{Name: "METHOD", Value: "AES-128"}, {Name: "URI", Value: "key.bin", Quoted: true}}
+	attributes.Set("URI", "/render.key?auth=x", true)
+	attributes.Set("IV", "0x01", false)
+	if got := attributes.String(); got != `METHOD=AES-128,URI="/render.key?auth=x",IV=0x01` {
+		t.Errorf("String() = %q", got)
+	}
+}
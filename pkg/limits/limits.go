@@ -0,0 +1,269 @@
+// Package limits tracks the usage of the clients of the server and enforces per client limits.
+//
+// A client is an IP address or an API key. Clients are limited in how many channels they watch at once,
+// how many requests they make per minute and how many bytes of streams they get per day.
+// Usage is kept in memory, so it starts over when the server restarts.
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StreamTimeout is how long a channel counts as watched after its last playlist request.
+// Players request the playlist of a live stream every few seconds.
+const StreamTimeout = 30 * time.Second
+
+// idleTimeout is how long a client is kept after its last request
+const idleTimeout = 24 * time.Hour
+
+var (
+	ErrTooManyChannels = errors.New("too many channels watched at once")
+	ErrRateLimited     = errors.New("too many requests")
+	ErrQuotaExceeded   = errors.New("daily quota exceeded")
+)
+
+// Limits are the limits of each client. Zero values don't limit.
+type Limits struct {
+	// MaxChannels is how many channels a client can watch at once
+	MaxChannels int `json:"max_channels"`
+	// RequestsPerMinute is how many requests a client can make per minute, in bursts of at most as many
+	RequestsPerMinute int `json:"requests_per_minute"`
+	// DailyQuota is how many bytes of streams a client can get per day
+	DailyQuota int64 `json:"daily_quota"`
+}
+
+// Usage is the current usage of a client
+type Usage struct {
+	Client string `json:"client"`
+	// Channels are the channels the client is watching
+	Channels []string `json:"channels"`
+	// BytesToday are the bytes of streams the client got today
+	BytesToday int64     `json:"bytes_today"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// LimitError is the error of a request over a limit
+type LimitError struct {
+	Err error
+	// Reason tells which limit the request is over
+	Reason string
+	// RetryAfter is when the request can be made again
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return e.Reason
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// client is the usage of a client
+type client struct {
+	// channels are the times of the last playlist request of the watched channels
+	channels map[string]time.Time
+	// tokens are the requests the client can make right away, refilled over time
+	tokens   float64
+	refilled time.Time
+	day      string
+	bytes    int64
+	lastSeen time.Time
+}
+
+// Tracker tracks the usage of clients against limits
+type Tracker struct {
+	mu        sync.Mutex
+	limits    Limits
+	clients   map[string]*client
+	lastPrune time.Time
+}
+
+// New creates a tracker enforcing limits
+func New(limits Limits) *Tracker {
+	return &Tracker{
+		limits:  limits,
+		clients: make(map[string]*client),
+	}
+}
+
+// Limits returns the limits of the tracker
+func (t *Tracker) Limits() Limits {
+	return t.limits
+}
+
+// Enabled checks if any limit is set
+func (t *Tracker) Enabled() bool {
+	return t.limits.MaxChannels > 0 || t.limits.RequestsPerMinute > 0 || t.limits.DailyQuota > 0
+}
+
+// get returns the usage of a client, creating it on first use. t.mu must be held.
+func (t *Tracker) get(id string, now time.Time) *client {
+	t.prune(now)
+	c, ok := t.clients[id]
+	if !ok {
+		c = &client{
+			channels: make(map[string]time.Time),
+			tokens:   float64(t.limits.RequestsPerMinute),
+			refilled: now,
+		}
+		t.clients[id] = c
+	}
+	c.lastSeen = now
+	c.refresh(now)
+	return c
+}
+
+// refresh starts the quota over on a new day and drops the channels no longer watched
+func (c *client) refresh(now time.Time) {
+	if day := now.Format(time.DateOnly); c.day != day {
+		c.day = day
+		c.bytes = 0
+	}
+	for channel, last := range c.channels {
+		if now.Sub(last) > StreamTimeout {
+			delete(c.channels, channel)
+		}
+	}
+}
+
+// prune drops the clients idle for a day, at most once an hour. t.mu must be held.
+func (t *Tracker) prune(now time.Time) {
+	if now.Sub(t.lastPrune) < time.Hour {
+		return
+	}
+	t.lastPrune = now
+	for id, c := range t.clients {
+		if now.Sub(c.lastSeen) > idleTimeout {
+			delete(t.clients, id)
+		}
+	}
+}
+
+// Request counts a request of a client against the request rate
+func (t *Tracker) Request(id string, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c := t.get(id, now)
+	if t.limits.RequestsPerMinute <= 0 {
+		return nil
+	}
+
+	perSecond := float64(t.limits.RequestsPerMinute) / 60
+	capacity := float64(t.limits.RequestsPerMinute)
+	c.tokens = math.Min(capacity, c.tokens+now.Sub(c.refilled).Seconds()*perSecond)
+	c.refilled = now
+	if c.tokens < 1 {
+		return &LimitError{
+			Err:        ErrRateLimited,
+			Reason:     fmt.Sprintf("Too many requests, the limit is %d per minute", t.limits.RequestsPerMinute),
+			RetryAfter: time.Duration(math.Ceil((1-c.tokens)/perSecond)) * time.Second,
+		}
+	}
+	c.tokens--
+	return nil
+}
+
+// Stream counts a playlist request of a client for a channel. It fails when the channel
+// is not watched yet and the client already watches as many channels as allowed.
+func (t *Tracker) Stream(id, channel string, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c := t.get(id, now)
+	if _, watched := c.channels[channel]; !watched && t.limits.MaxChannels > 0 && len(c.channels) >= t.limits.MaxChannels {
+		// The oldest channel stops counting first
+		oldest := now
+		for _, last := range c.channels {
+			if last.Before(oldest) {
+				oldest = last
+			}
+		}
+		return &LimitError{
+			Err:        ErrTooManyChannels,
+			Reason:     fmt.Sprintf("Too many channels watched at once, the limit is %d", t.limits.MaxChannels),
+			RetryAfter: oldest.Add(StreamTimeout).Sub(now),
+		}
+	}
+	c.channels[channel] = now
+	return nil
+}
+
+// Quota checks if a client has bytes left of its daily quota
+func (t *Tracker) Quota(id string, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c := t.get(id, now)
+	if t.limits.DailyQuota <= 0 || c.bytes < t.limits.DailyQuota {
+		return nil
+	}
+	year, month, day := now.Date()
+	return &LimitError{
+		Err:        ErrQuotaExceeded,
+		Reason:     fmt.Sprintf("Daily quota of %d MB exceeded", t.limits.DailyQuota/1024/1024),
+		RetryAfter: time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()).Sub(now),
+	}
+}
+
+// AddBytes counts bytes of streams sent to a client
+func (t *Tracker) AddBytes(id string, n int64, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.get(id, now).bytes += n
+}
+
+// Usage returns the usage of a client
+func (t *Tracker) Usage(id string, now time.Time) Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.get(id, now).usage(id)
+}
+
+// AllUsage returns the usage of every client seen in the last day, sorted by client
+func (t *Tracker) AllUsage(now time.Time) []Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prune(now)
+	usages := make([]Usage, 0, len(t.clients))
+	for id, c := range t.clients {
+		c.refresh(now)
+		usages = append(usages, c.usage(id))
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Client < usages[j].Client
+	})
+	return usages
+}
+
+// ActiveStreams returns how many clients watch each channel
+func (t *Tracker) ActiveStreams(now time.Time) map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	streams := make(map[string]int)
+	for _, c := range t.clients {
+		c.refresh(now)
+		for channel := range c.channels {
+			streams[channel]++
+		}
+	}
+	return streams
+}
+
+// usage returns the usage of a client
+func (c *client) usage(id string) Usage {
+	channels := make([]string, 0, len(c.channels))
+	for channel := range c.channels {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return Usage{
+		Client:     id,
+		Channels:   channels,
+		BytesToday: c.bytes,
+		LastSeen:   c.lastSeen,
+	}
+}
@@ -0,0 +1,101 @@
+package limits
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRequest(t *testing.T) {
+	tracker := New(Limits{RequestsPerMinute: 2})
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if err := tracker.Request("ip:1", now); err != nil {
+			t.Fatalf("Request() %d error = %v", i, err)
+		}
+	}
+	err := tracker.Request("ip:1", now)
+	var limitErr *LimitError
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &limitErr) || limitErr.RetryAfter != 30*time.Second {
+		t.Fatalf("Request() over the rate error = %v, want %v retrying after 30s", err, ErrRateLimited)
+	}
+	if err := tracker.Request("ip:2", now); err != nil {
+		t.Errorf("Request() of another client error = %v", err)
+	}
+	// A request is allowed again every 30 seconds
+	if err := tracker.Request("ip:1", now.Add(30*time.Second)); err != nil {
+		t.Errorf("Request() after 30s error = %v", err)
+	}
+
+	unlimited := New(Limits{})
+	for i := 0; i < 100; i++ {
+		if err := unlimited.Request("ip:1", now); err != nil {
+			t.Fatalf("Request() without limit error = %v", err)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	tracker := New(Limits{MaxChannels: 2})
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, channel := range []string{"143", "144", "143"} {
+		if err := tracker.Stream("ip:1", channel, now); err != nil {
+			t.Fatalf("Stream(%s) error = %v", channel, err)
+		}
+	}
+	err := tracker.Stream("ip:1", "145", now.Add(10*time.Second))
+	var limitErr *LimitError
+	if !errors.Is(err, ErrTooManyChannels) || !errors.As(err, &limitErr) || limitErr.RetryAfter != 20*time.Second {
+		t.Fatalf("Stream() of a third channel error = %v, want %v retrying after 20s", err, ErrTooManyChannels)
+	}
+
+	// Channels whose playlist is no longer requested stop counting
+	later := now.Add(StreamTimeout + time.Second)
+	if err := tracker.Stream("ip:1", "145", later); err != nil {
+		t.Errorf("Stream() after the other channels stopped error = %v", err)
+	}
+	if got := tracker.Usage("ip:1", later).Channels; !reflect.DeepEqual(got, []string{"145"}) {
+		t.Errorf("Usage().Channels = %v, want [145]", got)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	tracker := New(Limits{DailyQuota: 1024 * 1024})
+	now := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+
+	if err := tracker.Quota("key:a", now); err != nil {
+		t.Fatalf("Quota() error = %v", err)
+	}
+	tracker.AddBytes("key:a", 1024*1024, now)
+	err := tracker.Quota("key:a", now)
+	var limitErr *LimitError
+	if !errors.Is(err, ErrQuotaExceeded) || !errors.As(err, &limitErr) || limitErr.RetryAfter != time.Hour {
+		t.Fatalf("Quota() over the quota error = %v, want %v retrying after an hour", err, ErrQuotaExceeded)
+	}
+	// The quota starts over the next day
+	if err := tracker.Quota("key:a", now.Add(time.Hour)); err != nil {
+		t.Errorf("Quota() the next day error = %v", err)
+	}
+
+	usages := tracker.AllUsage(now.Add(time.Hour))
+	if len(usages) != 1 || usages[0].Client != "key:a" || usages[0].BytesToday != 0 {
+		t.Errorf("AllUsage() = %+v, want the client with no bytes today", usages)
+	}
+}
+
+func TestActiveStreams(t *testing.T) {
+	tracker := New(Limits{})
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	tracker.Stream("ip:1", "143", now)
+	tracker.Stream("ip:1", "144", now.Add(StreamTimeout))
+	tracker.Stream("ip:2", "144", now.Add(StreamTimeout))
+
+	// The first channel of ip:1 is no longer watched
+	got := tracker.ActiveStreams(now.Add(StreamTimeout + time.Second))
+	if want := map[string]int{"144": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ActiveStreams() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,335 @@
+// Package localmedia plays directories of local video files as linear channels.
+// Each configured directory is a channel playing its .ts and .mp4 files in name order, on a loop
+// or restarting every day at a set time, as a live HLS stream with a generated XMLTV schedule.
+package localmedia
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/tasks"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// IDPrefix starts the IDs of local channels, which are custom channels for the rest of the server
+	IDPrefix = "cc_local_"
+	// MetadataFile is the optional file of a channel directory setting its name, category, language, logo and start time
+	MetadataFile = "channel.json"
+	// targetDuration is the duration of segments, which are cut at the first key frame after it
+	targetDuration = 6 * time.Second
+	// scanInterval is how often the directories are scanned for new or changed files
+	scanInterval = 5 * time.Minute
+	// remuxDir is the directory of .mp4 files remuxed to MPEG-TS, under the path prefix
+	remuxDir = "local_media"
+)
+
+// Metadata is the content of the metadata file of a channel directory
+type Metadata struct {
+	Name     string `json:"name"`
+	LogoURL  string `json:"logo_url"`
+	Category int    `json:"category"`
+	Language int    `json:"language"`
+	IsHD     bool   `json:"is_hd"`
+	// Start is the time of day, as HH:MM in local time, at which the channel starts again from its first file.
+	// Without it the channel loops continuously.
+	Start string `json:"start"`
+}
+
+// mediaFile is an indexed file played by a channel
+type mediaFile struct {
+	Path string
+	// Title is the title of the file in the schedule
+	Title    string
+	Index    *tsIndex
+	Duration time.Duration
+	modTime  time.Time
+	size     int64
+}
+
+// Channel is a directory of files played as a linear channel
+type Channel struct {
+	ID       string
+	Name     string
+	Dir      string
+	LogoURL  string
+	Category int
+	Language int
+	IsHD     bool
+	// Start is the time after midnight at which the channel starts again from its first file every day,
+	// or negative for a channel looping continuously
+	Start time.Duration
+
+	files []*mediaFile
+	// fileStarts are the times from the start of the first file to the start of each file
+	fileStarts []time.Duration
+	// segments are the segments of all files in play order
+	segments []playSegment
+	// total is the time to play all files
+	total time.Duration
+	// targetDuration is the duration of the longest segment, rounded up to seconds
+	targetDuration int
+}
+
+// playSegment is a segment of a file of a channel
+type playSegment struct {
+	file int
+	segment
+	// at is the time from the start of the first file to the start of the segment
+	at time.Duration
+}
+
+var (
+	mu sync.RWMutex
+	// channels are the scanned channels by ID
+	channels = map[string]*Channel{}
+	// order are the IDs of the channels in the order of the configured directories
+	order []string
+	// indexed are the indexed files by path, reused while they don't change
+	indexed = map[string]*mediaFile{}
+
+	// slugPattern matches the characters replaced in the IDs of channels
+	slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Init scans the configured directories in the background and keeps scanning them for changes
+func Init() {
+	if len(config.Cfg.LocalMediaDirs) == 0 {
+		return
+	}
+	go func() {
+		Scan(config.Cfg.LocalMediaDirs)
+		scheduler.Add(tasks.LocalMediaScanTaskID, scanInterval, func() error {
+			Scan(config.Cfg.LocalMediaDirs)
+			return nil
+		})
+	}()
+}
+
+// Scan indexes the files of the directories and registers their channels with the television package.
+// Directories without playable files have no channel.
+func Scan(dirs []string) {
+	scanned := map[string]*Channel{}
+	var ids []string
+	var list []television.Channel
+	for _, dir := range dirs {
+		channel, err := scanDir(dir)
+		if err != nil {
+			utils.Log.Printf("ERROR: Local media directory %s: %v", dir, err)
+			continue
+		}
+		// Directories with the same name get a number
+		for base, n := channel.ID, 2; scanned[channel.ID] != nil; n++ {
+			channel.ID = base + "_" + strconv.Itoa(n)
+		}
+		scanned[channel.ID] = channel
+		ids = append(ids, channel.ID)
+		list = append(list, television.Channel{
+			ID:       channel.ID,
+			Name:     channel.Name,
+			URL:      "/local/" + channel.ID + "/index.m3u8",
+			LogoURL:  channel.LogoURL,
+			Category: channel.Category,
+			Language: channel.Language,
+			IsHD:     channel.IsHD,
+		})
+	}
+
+	mu.Lock()
+	channels, order = scanned, ids
+	mu.Unlock()
+	television.SetLocalChannels(list)
+}
+
+// Get returns a channel by ID
+func Get(id string) (*Channel, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	channel, ok := channels[id]
+	return channel, ok
+}
+
+// Channels returns the channels in the order of their directories
+func Channels() []*Channel {
+	mu.RLock()
+	defer mu.RUnlock()
+	list := make([]*Channel, 0, len(order))
+	for _, id := range order {
+		list = append(list, channels[id])
+	}
+	return list
+}
+
+// scanDir builds the channel of a directory
+func scanDir(dir string) (*Channel, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := readMetadata(dir)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(filepath.Clean(dir))
+	channel := &Channel{
+		ID:       IDPrefix + strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(name), "_"), "_"),
+		Name:     name,
+		Dir:      dir,
+		LogoURL:  metadata.LogoURL,
+		Category: television.OtherCategoryID,
+		Language: television.OtherLanguageID,
+		IsHD:     metadata.IsHD,
+		Start:    -1,
+	}
+	if metadata.Name != "" {
+		channel.Name = metadata.Name
+	}
+	if metadata.Category != 0 {
+		channel.Category = metadata.Category
+	}
+	if metadata.Language != 0 {
+		channel.Language = metadata.Language
+	}
+	if metadata.Start != "" {
+		start, err := time.Parse("15:04", metadata.Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start %q in %s, want HH:MM", metadata.Start, MetadataFile)
+		}
+		channel.Start = time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute
+	}
+
+	// Entries are sorted by name, which is the play order
+	for _, entry := range entries {
+		extension := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (extension != ".ts" && extension != ".mp4") {
+			continue
+		}
+		file, err := indexFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			utils.Log.Printf("Skipping local media file %s: %v", entry.Name(), err)
+			continue
+		}
+		channel.addFile(file)
+	}
+	if len(channel.files) == 0 {
+		return nil, errors.New("no playable .ts or .mp4 files")
+	}
+	return channel, nil
+}
+
+// readMetadata reads the metadata file of a directory, which is optional
+func readMetadata(dir string) (Metadata, error) {
+	var metadata Metadata
+	data, err := os.ReadFile(filepath.Join(dir, MetadataFile))
+	if os.IsNotExist(err) {
+		return metadata, nil
+	}
+	if err != nil {
+		return metadata, err
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return metadata, fmt.Errorf("invalid %s: %w", MetadataFile, err)
+	}
+	return metadata, nil
+}
+
+// addFile appends a file to the play order of the channel
+func (ch *Channel) addFile(file *mediaFile) {
+	ch.fileStarts = append(ch.fileStarts, ch.total)
+	for _, s := range file.Index.Segments {
+		ch.segments = append(ch.segments, playSegment{file: len(ch.files), segment: s, at: ch.total})
+		ch.total += s.Duration
+		if seconds := int((s.Duration + time.Second - 1) / time.Second); seconds > ch.targetDuration {
+			ch.targetDuration = seconds
+		}
+	}
+	ch.files = append(ch.files, file)
+}
+
+// indexFile indexes a file, reusing the index of an unchanged file.
+// .mp4 files are remuxed to MPEG-TS with ffmpeg first, which copies the streams without transcoding.
+func indexFile(path string) (*mediaFile, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	mu.RLock()
+	file, ok := indexed[path]
+	mu.RUnlock()
+	if ok && file.modTime.Equal(stat.ModTime()) && file.size == stat.Size() {
+		return file, nil
+	}
+
+	tsPath := path
+	if strings.EqualFold(filepath.Ext(path), ".mp4") {
+		if tsPath, err = remux(path, stat); err != nil {
+			return nil, err
+		}
+	}
+	index, err := indexTS(tsPath, targetDuration)
+	if err != nil {
+		return nil, err
+	}
+	file = &mediaFile{
+		Path:    tsPath,
+		Title:   fileTitle(path),
+		Index:   index,
+		modTime: stat.ModTime(),
+		size:    stat.Size(),
+	}
+	for _, s := range index.Segments {
+		file.Duration += s.Duration
+	}
+
+	mu.Lock()
+	indexed[path] = file
+	mu.Unlock()
+	return file, nil
+}
+
+// remux copies the streams of an .mp4 file to an MPEG-TS file, which is kept until the .mp4 file changes
+func remux(path string, stat os.FileInfo) (string, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return "", fmt.Errorf(".mp4 files need ffmpeg, which was not found in PATH")
+	}
+	dir := filepath.Join(utils.GetPathPrefix(), remuxDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	hash := sha1.Sum([]byte(fmt.Sprintf("%s|%d|%d", path, stat.ModTime().UnixNano(), stat.Size())))
+	tsPath := filepath.Join(dir, hex.EncodeToString(hash[:8])+".ts")
+	if utils.FileExists(tsPath) {
+		return tsPath, nil
+	}
+
+	utils.Log.Printf("Remuxing local media file %s", path)
+	tmpPath := tsPath + ".tmp"
+	output, err := exec.Command(ffmpeg, "-v", "error", "-y", "-i", path, "-map", "0:v:0?", "-map", "0:a?",
+		"-c", "copy", "-f", "mpegts", tmpPath).CombinedOutput()
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("ffmpeg failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return tsPath, os.Rename(tmpPath, tsPath)
+}
+
+// fileTitle returns the title of a file in the schedule, which is its name without extension
+func fileTitle(path string) string {
+	title := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return strings.TrimSpace(strings.NewReplacer("_", " ", ".", " ").Replace(title))
+}
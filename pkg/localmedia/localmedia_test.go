@@ -0,0 +1,189 @@
+package localmedia
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestMain(m *testing.M) {
+	utils.Log = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// scanChannel scans a directory named Family Videos with a.ts of 10 seconds and b.ts of 4 seconds,
+// which are played in segments of 6 and 4 seconds, and 4 seconds. The metadata file has the given content.
+func scanChannel(t *testing.T, metadata string) *Channel {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "Family Videos")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tsFile{frames: 250, fps: 25, keyEvery: 50}.write(t, filepath.Join(dir, "a.ts"))
+	tsFile{frames: 100, fps: 25, keyEvery: 50}.write(t, filepath.Join(dir, "b.ts"))
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if metadata != "" {
+		if err := os.WriteFile(filepath.Join(dir, MetadataFile), []byte(metadata), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Scan([]string{dir, t.TempDir()})
+	t.Cleanup(func() { Scan(nil) })
+	list := Channels()
+	if len(list) != 1 {
+		t.Fatalf("Scan() found %d channels, want the one of the directory with videos", len(list))
+	}
+	return list[0]
+}
+
+func TestScan(t *testing.T) {
+	channel := scanChannel(t, `{"name": "Home", "category": 5, "language": 6}`)
+	if channel.ID != "cc_local_family_videos" || channel.Name != "Home" || channel.Category != 5 || channel.Language != 6 {
+		t.Errorf("Scan() = %+v, want the channel of the metadata file", channel)
+	}
+	if len(channel.files) != 2 || channel.total != 14*time.Second || channel.targetDuration != 6 {
+		t.Errorf("channel plays %d files for %v, want a.ts and b.ts for 14s", len(channel.files), channel.total)
+	}
+
+	registered, ok := television.GetCustomChannelByID(channel.ID)
+	if !ok || registered.URL != "/local/cc_local_family_videos/index.m3u8" {
+		t.Errorf("registered channel = %+v, %v, want the channel with its playlist URL", registered, ok)
+	}
+	Scan(nil)
+	if _, ok := television.GetCustomChannelByID(channel.ID); ok {
+		t.Error("channel is still registered after its directory was removed")
+	}
+}
+
+func TestScan_InvalidStart(t *testing.T) {
+	dir := t.TempDir()
+	tsFile{frames: 50, fps: 25, keyEvery: 25}.write(t, filepath.Join(dir, "a.ts"))
+	if err := os.WriteFile(filepath.Join(dir, MetadataFile), []byte(`{"start": "25:00"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scanDir(dir); err == nil || !strings.Contains(err.Error(), "invalid start") {
+		t.Errorf("scanDir() error = %v, want an invalid start", err)
+	}
+}
+
+func TestPlaylist(t *testing.T) {
+	channel := scanChannel(t, "")
+	epoch := time.Unix(0, 0)
+
+	// At the start of the timeline, only the first segment has played
+	if got, want := string(channel.Playlist(epoch.Add(time.Second))),
+		"#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:6\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-DISCONTINUITY-SEQUENCE:0\n#EXTINF:6.000,\n0.ts\n"; got != want {
+		t.Errorf("Playlist() at the start =\n%s\nwant\n%s", got, want)
+	}
+
+	// 11 seconds into the third loop, b.ts is playing after the two segments of a.ts
+	want := "#EXT-X-MEDIA-SEQUENCE:6\n#EXT-X-DISCONTINUITY-SEQUENCE:4\n" +
+		"#EXTINF:6.000,\n6.ts\n#EXTINF:4.000,\n7.ts\n#EXT-X-DISCONTINUITY\n#EXTINF:4.000,\n8.ts\n"
+	if got := string(channel.Playlist(epoch.Add(2*14*time.Second + 11*time.Second))); !strings.HasSuffix(got, want) {
+		t.Errorf("Playlist() in the third loop =\n%s\nwant it to end with\n%s", got, want)
+	}
+
+	// Segments of the playlist are read from the files, with the PAT and PMT before segments in the middle of a file
+	a, _ := os.ReadFile(channel.files[0].Path)
+	b, _ := os.ReadFile(channel.files[1].Path)
+	tests := []struct {
+		sequence int64
+		want     []byte
+	}{
+		{sequence: 6, want: a[:152*packetSize]},
+		{sequence: 7, want: append(append([]byte(nil), a[:2*packetSize]...), a[152*packetSize:]...)},
+		{sequence: 8, want: b},
+	}
+	for _, tt := range tests {
+		got, err := channel.Segment(tt.sequence)
+		if err != nil || !bytes.Equal(got, tt.want) {
+			t.Errorf("Segment(%d) = %d bytes, %v, want %d bytes", tt.sequence, len(got), err, len(tt.want))
+		}
+	}
+	if _, err := channel.Segment(-1); err != ErrSegmentNotFound {
+		t.Errorf("Segment(-1) error = %v, want %v", err, ErrSegmentNotFound)
+	}
+}
+
+func TestParseSegmentName(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   int64
+		wantOK bool
+	}{
+		{name: "42.ts", want: 42, wantOK: true},
+		{name: "-1.ts"},
+		{name: "42.m3u8"},
+		{name: "a.ts"},
+	}
+	for _, tt := range tests {
+		if got, ok := ParseSegmentName(tt.name); got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseSegmentName(%q) = %d, %v, want %d, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	channel := scanChannel(t, `{"start": "06:00"}`)
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 6, 0, 0, 0, time.Local)
+
+	// The day ends 6 seconds into a loop of 14 seconds, so a.ts is cut short when the channel starts again
+	airings := channel.Schedule(start.Add(-10*time.Second), start.Add(11*time.Second))
+	want := []Airing{
+		{Title: "b", Start: start.Add(-10 * time.Second), Stop: start.Add(-6 * time.Second)},
+		{Title: "a", Start: start.Add(-6 * time.Second), Stop: start},
+		{Title: "a", Start: start, Stop: start.Add(10 * time.Second)},
+		{Title: "b", Start: start.Add(10 * time.Second), Stop: start.Add(14 * time.Second)},
+	}
+	if len(airings) != len(want) {
+		t.Fatalf("Schedule() = %+v, want %+v", airings, want)
+	}
+	for i := range want {
+		if airings[i].Title != want[i].Title || !airings[i].Start.Equal(want[i].Start) || !airings[i].Stop.Equal(want[i].Stop) {
+			t.Errorf("airing %d = %+v, want %+v", i, airings[i], want[i])
+		}
+	}
+
+	// Media sequence numbers keep increasing when the channel starts again
+	before := channel.sequence(channel.positionAt(start.Add(-time.Second)))
+	after := channel.sequence(channel.positionAt(start))
+	if after <= before {
+		t.Errorf("media sequence went from %d to %d when the channel started again", before, after)
+	}
+	if playlist := string(channel.Playlist(start.Add(time.Second))); strings.Count(playlist, ".ts") != 1 {
+		t.Errorf("Playlist() right after the start =\n%s\nwant only the first segment of the day", playlist)
+	}
+}
+
+func TestWriteXMLTV(t *testing.T) {
+	channel := scanChannel(t, `{"logo_url": "https://example.com/logo.png"}`)
+	from := time.Unix(0, 0)
+
+	var b bytes.Buffer
+	if err := WriteXMLTV(&b, from, from.Add(14*time.Second)); err != nil {
+		t.Fatalf("WriteXMLTV() error = %v", err)
+	}
+	for _, want := range []string{
+		`<channel id="` + channel.ID + `">`,
+		`<display-name>Family Videos</display-name>`,
+		`<icon src="https://example.com/logo.png"></icon>`,
+		`<programme channel="` + channel.ID + `" start="` + from.Format(xmltvTimeLayout) + `" stop="` + from.Add(10*time.Second).Format(xmltvTimeLayout) + `">`,
+		`<title>b</title>`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("WriteXMLTV() =\n%s\nwant it to contain %s", b.String(), want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package localmedia
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// windowSegments is the number of segments in the live playlist
+	windowSegments = 3
+	// dayStride separates the media sequence numbers of the days of a channel with a start time,
+	// so that they keep increasing when the channel starts again from its first file
+	dayStride = 10_000_000
+)
+
+// ErrSegmentNotFound is returned for a media sequence number which is not a segment of the channel
+var ErrSegmentNotFound = errors.New("segment not found")
+
+// position is a point of the timeline of a channel
+type position struct {
+	// period is the day of a channel with a start time, or 0 for a looping channel
+	period int64
+	// loop is the number of times all files were played since the start of the period
+	loop int64
+	// index is the index in the segments of the channel
+	index int
+}
+
+// sequence returns the media sequence number of the segment at the position
+func (ch *Channel) sequence(p position) int64 {
+	return p.period*dayStride + p.loop*int64(len(ch.segments)) + int64(p.index)
+}
+
+// discontinuity returns the discontinuity sequence number of the segment at the position.
+// Every file is preceded by a discontinuity, except the first one of the timeline.
+func (ch *Channel) discontinuity(p position) int64 {
+	return p.period*dayStride + p.loop*int64(len(ch.files)) + int64(ch.segments[p.index].file)
+}
+
+// periodStart returns the start of the period playing at t.
+// Looping channels have a single period starting at the Unix epoch.
+func (ch *Channel) periodStart(t time.Time) (int64, time.Time) {
+	if ch.Start < 0 {
+		return 0, time.Unix(0, 0)
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	start := midnight.Add(ch.Start)
+	if start.After(t) {
+		start = midnight.AddDate(0, 0, -1).Add(ch.Start)
+	}
+	// Periods are numbered by the days since the epoch of their local date
+	return time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400, start
+}
+
+// positionAt returns the segment playing at t
+func (ch *Channel) positionAt(t time.Time) position {
+	period, start := ch.periodStart(t)
+	elapsed := t.Sub(start)
+	offset := elapsed % ch.total
+	// The last segment starting at or before the offset is playing
+	index := 0
+	for low, high := 0, len(ch.segments)-1; low <= high; {
+		middle := (low + high) / 2
+		if ch.segments[middle].at <= offset {
+			index, low = middle, middle+1
+		} else {
+			high = middle - 1
+		}
+	}
+	return position{period: period, loop: int64(elapsed / ch.total), index: index}
+}
+
+// previous returns the position of the segment before p in the same period, or false at the start of the period
+func (ch *Channel) previous(p position) (position, bool) {
+	if p.index > 0 {
+		p.index--
+		return p, true
+	}
+	if p.loop == 0 {
+		return p, false
+	}
+	p.loop--
+	p.index = len(ch.segments) - 1
+	return p, true
+}
+
+// Playlist returns the live HLS media playlist of the channel at t, which ends with the segment playing at t
+func (ch *Channel) Playlist(t time.Time) []byte {
+	window := []position{ch.positionAt(t)}
+	for len(window) < windowSegments {
+		p, ok := ch.previous(window[0])
+		if !ok {
+			break
+		}
+		window = append([]position{p}, window...)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", ch.targetDuration)
+	fmt.Fprintf(&b, "#EXT-X-MEDIA-SEQUENCE:%d\n", ch.sequence(window[0]))
+	fmt.Fprintf(&b, "#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", ch.discontinuity(window[0]))
+	for i, p := range window {
+		s := ch.segments[p.index]
+		// A new file has its own timestamps and may have other codecs
+		if i > 0 && s.Offset == 0 {
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n%d.ts\n", s.Duration.Seconds(), ch.sequence(p))
+	}
+	return b.Bytes()
+}
+
+// Segment returns the segment with a media sequence number of the playlist.
+// Segments which don't start their file begin with its PAT and PMT, so that they can be decoded on their own.
+func (ch *Channel) Segment(sequence int64) ([]byte, error) {
+	if sequence < 0 {
+		return nil, ErrSegmentNotFound
+	}
+	if ch.Start >= 0 {
+		sequence %= dayStride
+	}
+	s := ch.segments[sequence%int64(len(ch.segments))]
+	file := ch.files[s.file]
+
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var data []byte
+	if s.Offset > 0 {
+		data = append(data, file.Index.Header...)
+	}
+	data = append(data, make([]byte, s.Size)...)
+	if _, err := io.ReadFull(io.NewSectionReader(f, s.Offset, s.Size), data[len(data)-int(s.Size):]); err != nil {
+		return nil, fmt.Errorf("reading segment of %s: %w", file.Path, err)
+	}
+	return data, nil
+}
+
+// ParseSegmentName returns the media sequence number of a segment name of the playlist, like 42.ts
+func ParseSegmentName(name string) (int64, bool) {
+	number, ok := strings.CutSuffix(name, ".ts")
+	if !ok {
+		return 0, false
+	}
+	sequence, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || sequence < 0 {
+		return 0, false
+	}
+	return sequence, true
+}
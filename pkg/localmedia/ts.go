@@ -0,0 +1,234 @@
+package localmedia
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	// packetSize is the size of an MPEG-TS packet
+	packetSize = 188
+	// syncByte starts every MPEG-TS packet
+	syncByte = 0x47
+	// ptsClock is the frequency of PES timestamps
+	ptsClock = 90000
+	// ptsMask wraps PES timestamps, which have 33 bits
+	ptsMask = 1<<33 - 1
+)
+
+// errNoTimestamps is returned for files without a timestamped elementary stream, which can't be segmented
+var errNoTimestamps = errors.New("no timestamps found in the stream")
+
+// segment is a part of a .ts file served as one HLS segment
+type segment struct {
+	// Offset and Size are the byte range of the segment in the file, in whole packets
+	Offset int64
+	Size   int64
+	// Duration is the play time of the segment
+	Duration time.Duration
+}
+
+// tsIndex is the result of indexing a .ts file
+type tsIndex struct {
+	// Header are the PAT and PMT packets, sent before segments which don't start the file
+	Header   []byte
+	Segments []segment
+}
+
+// cutPoint is a packet of the main elementary stream starting a PES packet
+type cutPoint struct {
+	offset    int64
+	timestamp int64
+	// randomAccess is set by the random access indicator of the packet, which marks key frames
+	randomAccess bool
+}
+
+// indexTS splits a .ts file into segments of about target duration without transcoding.
+// Segments are cut at the key frames of the main elementary stream, which is the first video stream
+// of the PMT, or at the starts of its PES packets when the stream has no random access indicators.
+func indexTS(path string, target time.Duration) (*tsIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		index      tsIndex
+		pat, pmt   []byte
+		pmtPID     = -1
+		esPID      = -1
+		points     []cutPoint
+		offset     int64
+		hasKeys    bool
+		packet     = make([]byte, packetSize)
+		fileReader = bufio.NewReaderSize(file, 64*packetSize)
+	)
+	for {
+		if _, err := io.ReadFull(fileReader, packet); err != nil {
+			// A trailing partial packet is left out
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, err
+		}
+		if packet[0] != syncByte {
+			return nil, fmt.Errorf("lost sync at byte %d", offset)
+		}
+
+		pusi := packet[1]&0x40 != 0
+		pid := int(packet[1]&0x1f)<<8 | int(packet[2])
+		payload, randomAccess := packetPayload(packet)
+		switch {
+		case pid == 0 && pusi && pat == nil:
+			if pmtPID = parsePAT(payload); pmtPID >= 0 {
+				pat = append([]byte(nil), packet...)
+			}
+		case pid == pmtPID && pusi && pmt == nil:
+			if esPID = parsePMT(payload); esPID >= 0 {
+				pmt = append([]byte(nil), packet...)
+			}
+		case pid == esPID && pusi:
+			if timestamp, ok := pesTimestamp(payload); ok {
+				points = append(points, cutPoint{offset: offset, timestamp: timestamp, randomAccess: randomAccess})
+				hasKeys = hasKeys || randomAccess
+			}
+		}
+		offset += packetSize
+	}
+	if len(points) == 0 {
+		return nil, errNoTimestamps
+	}
+	index.Header = append(pat, pmt...)
+	index.Segments = cutSegments(points, offset, hasKeys, target)
+	return &index, nil
+}
+
+// cutSegments splits the file at the cut points so that each segment lasts at least target.
+// The first segment starts at the beginning of the file and the last one ends at its end.
+func cutSegments(points []cutPoint, size int64, hasKeys bool, target time.Duration) []segment {
+	var segments []segment
+	start := cutPoint{offset: 0, timestamp: points[0].timestamp}
+	for _, point := range points[1:] {
+		if hasKeys && !point.randomAccess {
+			continue
+		}
+		if elapsed := timestampDuration(start.timestamp, point.timestamp); elapsed >= target {
+			segments = append(segments, segment{Offset: start.offset, Size: point.offset - start.offset, Duration: elapsed})
+			start = point
+		}
+	}
+
+	// The last segment lasts until the last timestamp, plus the gap between the last two as the duration of the last frame
+	last := points[len(points)-1]
+	duration := timestampDuration(start.timestamp, last.timestamp)
+	if len(points) > 1 {
+		duration += timestampDuration(points[len(points)-2].timestamp, last.timestamp)
+	}
+	if duration <= 0 {
+		duration = time.Second / 25
+	}
+	return append(segments, segment{Offset: start.offset, Size: size - start.offset, Duration: duration})
+}
+
+// timestampDuration returns the time from one PES timestamp to a later one, which may have wrapped around
+func timestampDuration(from, to int64) time.Duration {
+	ticks := (to - from) & ptsMask
+	// Timestamps going back, like those of B-frames, count as no time
+	if ticks > ptsMask/2 {
+		return 0
+	}
+	return time.Duration(ticks) * time.Second / ptsClock
+}
+
+// packetPayload returns the payload of a packet, and whether its adaptation field has the random access indicator
+func packetPayload(packet []byte) ([]byte, bool) {
+	control := packet[3] >> 4 & 0x03
+	start := 4
+	randomAccess := false
+	if control&0x02 != 0 {
+		length := int(packet[4])
+		randomAccess = length > 0 && packet[5]&0x40 != 0
+		start = 5 + length
+	}
+	if control&0x01 == 0 || start >= packetSize {
+		return nil, randomAccess
+	}
+	return packet[start:], randomAccess
+}
+
+// psiSection returns the section of a PSI payload following its pointer field
+func psiSection(payload []byte) []byte {
+	if len(payload) == 0 || 1+int(payload[0]) >= len(payload) {
+		return nil
+	}
+	section := payload[1+int(payload[0]):]
+	if len(section) < 3 {
+		return nil
+	}
+	// The section ends before its CRC
+	end := 3 + (int(section[1]&0x0f)<<8 | int(section[2])) - 4
+	if end > len(section) || end < 3 {
+		return nil
+	}
+	return section[:end]
+}
+
+// parsePAT returns the PID of the PMT of the first programme of a PAT, or -1
+func parsePAT(payload []byte) int {
+	section := psiSection(payload)
+	for i := 8; i+4 <= len(section); i += 4 {
+		// Programme number 0 points to the network information table
+		if number := int(section[i])<<8 | int(section[i+1]); number != 0 {
+			return int(section[i+2]&0x1f)<<8 | int(section[i+3])
+		}
+	}
+	return -1
+}
+
+// parsePMT returns the PID of the first video stream of a PMT, or of its first stream when it has no video, or -1
+func parsePMT(payload []byte) int {
+	section := psiSection(payload)
+	if len(section) < 12 {
+		return -1
+	}
+	first := -1
+	for i := 12 + (int(section[10]&0x0f)<<8 | int(section[11])); i+5 <= len(section); {
+		streamType := section[i]
+		pid := int(section[i+1]&0x1f)<<8 | int(section[i+2])
+		switch streamType {
+		// MPEG-1, MPEG-2, MPEG-4 part 2, H.264 and H.265 video
+		case 0x01, 0x02, 0x10, 0x1b, 0x24:
+			return pid
+		}
+		if first < 0 {
+			first = pid
+		}
+		i += 5 + (int(section[i+3]&0x0f)<<8 | int(section[i+4]))
+	}
+	return first
+}
+
+// pesTimestamp returns the decoding timestamp of a PES packet, which is its presentation timestamp when it has no DTS
+func pesTimestamp(payload []byte) (int64, bool) {
+	if len(payload) < 14 || payload[0] != 0 || payload[1] != 0 || payload[2] != 1 {
+		return 0, false
+	}
+	flags := payload[7] >> 6
+	switch {
+	case flags == 0x03 && len(payload) >= 19:
+		return readTimestamp(payload[14:19]), true
+	case flags&0x02 != 0:
+		return readTimestamp(payload[9:14]), true
+	}
+	return 0, false
+}
+
+// readTimestamp decodes a 33 bit PES timestamp
+func readTimestamp(b []byte) int64 {
+	return int64(b[0]>>1&0x07)<<30 | int64(b[1])<<22 | int64(b[2]>>1)<<15 | int64(b[3])<<7 | int64(b[4]>>1)
+}
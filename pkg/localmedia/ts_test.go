@@ -0,0 +1,148 @@
+package localmedia
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// tsFile describes a synthetic MPEG-TS file with an H.264 stream of one PES packet per frame
+type tsFile struct {
+	frames int
+	fps    int
+	// keyEvery is the number of frames between key frames marked with the random access indicator,
+	// or 0 for a stream without random access indicators
+	keyEvery int
+	// firstTimestamp is the DTS of the first frame
+	firstTimestamp int64
+}
+
+// packet returns an MPEG-TS packet of a PID with the payload, padded with stuffing bytes
+func packet(pid int, pusi, randomAccess bool, payload []byte) []byte {
+	p := []byte{syncByte, byte(pid >> 8 & 0x1f), byte(pid), 0x10}
+	if pusi {
+		p[1] |= 0x40
+	}
+	if randomAccess {
+		p[3] = 0x30
+		p = append(p, 1, 0x40)
+	}
+	p = append(p, payload...)
+	for len(p) < packetSize {
+		p = append(p, 0xff)
+	}
+	return p
+}
+
+// timestamp encodes a 33 bit PES timestamp with a prefix
+func timestamp(prefix byte, ts int64) []byte {
+	return []byte{prefix<<4 | byte(ts>>29&0x0e) | 1, byte(ts >> 22), byte(ts>>14&0xfe) | 1, byte(ts >> 7), byte(ts<<1&0xfe) | 1}
+}
+
+// write writes the file to path and returns the size of its packets
+func (f tsFile) write(t *testing.T, path string) []byte {
+	t.Helper()
+	// PAT pointing to the PMT on PID 0x100, and PMT with an H.264 stream on PID 0x101, without CRCs
+	data := packet(0, true, false, []byte{0, 0x00, 0xb0, 0x0d, 0, 1, 0xc1, 0, 0, 0, 1, 0xe1, 0x00, 0, 0, 0, 0})
+	data = append(data, packet(0x100, true, false, []byte{0, 0x02, 0xb0, 0x12, 0, 1, 0xc1, 0, 0, 0xe1, 0x01, 0xf0, 0, 0x1b, 0xe1, 0x01, 0xf0, 0, 0, 0, 0, 0})...)
+	for i := 0; i < f.frames; i++ {
+		dts := (f.firstTimestamp + int64(i)*ptsClock/int64(f.fps)) & ptsMask
+		pes := append([]byte{0, 0, 1, 0xe0, 0, 0, 0x80, 0xc0, 10}, timestamp(3, dts+3000)...)
+		pes = append(pes, timestamp(1, dts)...)
+		data = append(data, packet(0x101, true, f.keyEvery > 0 && i%f.keyEvery == 0, pes)...)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestIndexTS(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name          string
+		file          tsFile
+		wantDurations []time.Duration
+		// wantFrames are the frames starting each segment after the first one
+		wantFrames []int
+	}{
+		{
+			name:          "Key frames every 2 seconds",
+			file:          tsFile{frames: 500, fps: 25, keyEvery: 50},
+			wantDurations: []time.Duration{6 * time.Second, 6 * time.Second, 6 * time.Second, 2 * time.Second},
+			wantFrames:    []int{150, 300, 450},
+		},
+		{
+			name:          "Key frames every 4 seconds",
+			file:          tsFile{frames: 250, fps: 25, keyEvery: 100},
+			wantDurations: []time.Duration{8 * time.Second, 2 * time.Second},
+			wantFrames:    []int{200},
+		},
+		{
+			name:          "No random access indicators",
+			file:          tsFile{frames: 175, fps: 25},
+			wantDurations: []time.Duration{6 * time.Second, time.Second},
+			wantFrames:    []int{150},
+		},
+		{
+			name:          "Wrapping timestamps",
+			file:          tsFile{frames: 250, fps: 25, keyEvery: 25, firstTimestamp: ptsMask - 2*ptsClock},
+			wantDurations: []time.Duration{6 * time.Second, 4 * time.Second},
+			wantFrames:    []int{150},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "file.ts")
+			data := tt.file.write(t, path)
+			index, err := indexTS(path, targetDuration)
+			if err != nil {
+				t.Fatalf("indexTS() error = %v", err)
+			}
+			if len(index.Header) != 2*packetSize {
+				t.Errorf("header has %d bytes, want the PAT and PMT", len(index.Header))
+			}
+			if len(index.Segments) != len(tt.wantDurations) {
+				t.Fatalf("indexTS() = %+v, want %d segments", index.Segments, len(tt.wantDurations))
+			}
+			var offset int64
+			for i, s := range index.Segments {
+				if s.Duration != tt.wantDurations[i] {
+					t.Errorf("segment %d lasts %v, want %v", i, s.Duration, tt.wantDurations[i])
+				}
+				if s.Offset != offset {
+					t.Errorf("segment %d starts at %d, want %d", i, s.Offset, offset)
+				}
+				if i < len(tt.wantFrames) {
+					if want := int64(2+tt.wantFrames[i]) * packetSize; s.Offset+s.Size != want {
+						t.Errorf("segment %d ends at %d, want the packet of frame %d at %d", i, s.Offset+s.Size, tt.wantFrames[i], want)
+					}
+				}
+				offset += s.Size
+			}
+			if offset != int64(len(data)) {
+				t.Errorf("segments cover %d bytes, want %d", offset, len(data))
+			}
+		})
+	}
+}
+
+func TestIndexTS_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	noTimestamps := filepath.Join(dir, "empty.ts")
+	tsFile{}.write(t, noTimestamps)
+	if _, err := indexTS(noTimestamps, targetDuration); !errors.Is(err, errNoTimestamps) {
+		t.Errorf("indexTS() of a file without frames error = %v, want %v", err, errNoTimestamps)
+	}
+
+	notTS := filepath.Join(dir, "video.ts")
+	if err := os.WriteFile(notTS, make([]byte, 2*packetSize), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := indexTS(notTS, targetDuration); err == nil {
+		t.Error("indexTS() of a file which is not MPEG-TS succeeded")
+	}
+}
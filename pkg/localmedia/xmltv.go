@@ -0,0 +1,101 @@
+package localmedia
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// xmltvTimeLayout is the layout of the times of XMLTV programmes
+const xmltvTimeLayout = "20060102150405 -0700"
+
+// Airing is a file of a channel playing between two times
+type Airing struct {
+	Title string
+	Start time.Time
+	Stop  time.Time
+}
+
+// xmltvChannel is a channel of the generated XMLTV schedule
+type xmltvChannel struct {
+	XMLName xml.Name   `xml:"channel"`
+	ID      string     `xml:"id,attr"`
+	Display string     `xml:"display-name"`
+	Icon    *xmltvIcon `xml:"icon,omitempty"`
+}
+
+// xmltvIcon is the logo of a channel
+type xmltvIcon struct {
+	Src string `xml:"src,attr"`
+}
+
+// xmltvProgramme is an airing of the generated XMLTV schedule
+type xmltvProgramme struct {
+	XMLName xml.Name `xml:"programme"`
+	Channel string   `xml:"channel,attr"`
+	Start   string   `xml:"start,attr"`
+	Stop    string   `xml:"stop,attr"`
+	Title   string   `xml:"title"`
+}
+
+// Schedule returns the files playing between two times.
+// Files of channels with a start time are cut short when the channel starts again from its first file.
+func (ch *Channel) Schedule(from, to time.Time) []Airing {
+	var airings []Airing
+	for t := from; t.Before(to); {
+		_, periodStart := ch.periodStart(t)
+		p := ch.positionAt(t)
+		file := ch.segments[p.index].file
+		start := periodStart.Add(time.Duration(p.loop) * ch.total).Add(ch.fileStarts[file])
+		stop := start.Add(ch.files[file].Duration)
+		if ch.Start >= 0 {
+			if next := periodStart.AddDate(0, 0, 1); stop.After(next) {
+				stop = next
+			}
+		}
+		airings = append(airings, Airing{Title: ch.files[file].Title, Start: start, Stop: stop})
+		t = stop
+	}
+	return airings
+}
+
+// WriteXMLTV writes the schedule of all channels between two times as XMLTV
+func WriteXMLTV(w io.Writer, from, to time.Time) error {
+	list := Channels()
+	if _, err := io.WriteString(w, xml.Header+`<!DOCTYPE tv SYSTEM "http://www.w3.org/2006/05/tv">`+"\n"); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	tv := xml.StartElement{Name: xml.Name{Local: "tv"}}
+	if err := encoder.EncodeToken(tv); err != nil {
+		return err
+	}
+	// XMLTV lists all channels before the programmes
+	for _, channel := range list {
+		entry := xmltvChannel{ID: channel.ID, Display: channel.Name}
+		if channel.LogoURL != "" {
+			entry.Icon = &xmltvIcon{Src: channel.LogoURL}
+		}
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	for _, channel := range list {
+		for _, airing := range channel.Schedule(from, to) {
+			programme := xmltvProgramme{
+				Channel: channel.ID,
+				Start:   airing.Start.Format(xmltvTimeLayout),
+				Stop:    airing.Stop.Format(xmltvTimeLayout),
+				Title:   airing.Title,
+			}
+			if err := encoder.Encode(programme); err != nil {
+				return err
+			}
+		}
+	}
+	if err := encoder.EncodeToken(tv.End()); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
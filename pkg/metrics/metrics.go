@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+)
+
+// Endpoints of the upstream requests
+const (
+	EndpointPlayback = "playback"
+	EndpointChannels = "channels"
+	EndpointEPG      = "epg"
+	EndpointRender   = "render"
+	EndpointKey      = "key"
+	EndpointTS       = "ts"
+)
+
+var (
+	// UpstreamRequests counts the requests to upstream servers by endpoint and status code.
+	// Requests which got no response have the status error.
+	UpstreamRequests = NewCounterVec("jiotv_upstream_requests_total", "Requests to upstream servers by endpoint and status code.", "endpoint", "status")
+	// UpstreamDuration observes how long requests to upstream servers take by endpoint
+	UpstreamDuration = NewHistogramVec("jiotv_upstream_request_duration_seconds", "Duration of requests to upstream servers by endpoint.", DefaultBuckets, "endpoint")
+	// UpstreamForbidden counts the 403 responses of upstream servers by endpoint, usually from expired tokens
+	UpstreamForbidden = NewCounterVec("jiotv_upstream_forbidden_total", "403 responses of upstream servers by endpoint.", "endpoint")
+	// TokenRefreshes counts the refreshes of the JioTV tokens by token and result
+	TokenRefreshes = NewCounterVec("jiotv_token_refreshes_total", "Refreshes of the JioTV access and SSO tokens by result.", "token", "result")
+	// ActiveStreams is the number of clients watching each channel
+	ActiveStreams = NewGaugeVec("jiotv_active_streams", "Clients watching each channel.", "channel")
+	// ProxiedBytes counts the bytes of streams proxied to clients by type
+	ProxiedBytes = NewCounterVec("jiotv_proxied_bytes_total", "Bytes of streams proxied to clients by type.", "type")
+	// CustomChannels is the number of custom channels loaded
+	CustomChannels = NewGaugeVec("jiotv_custom_channels", "Custom channels loaded.")
+	// EPGLastSuccess is the Unix time of the last successful EPG generation
+	EPGLastSuccess = NewGaugeVec("jiotv_epg_last_success_timestamp_seconds", "Unix time of the last successful EPG generation.")
+	// EPGDuration is how long the last successful EPG generation took
+	EPGDuration = NewGaugeVec("jiotv_epg_generation_duration_seconds", "Duration of the last successful EPG generation.")
+	// EPGProgrammes is the number of programmes of the last generated EPG
+	EPGProgrammes = NewGaugeVec("jiotv_epg_programmes", "Programmes of the last generated EPG.")
+)
+
+// ObserveUpstream records a request to an upstream endpoint which started at start.
+// A status code of 0 is a request which got no response.
+func ObserveUpstream(endpoint string, start time.Time, statusCode int) {
+	status := "error"
+	if statusCode > 0 {
+		status = strconv.Itoa(statusCode)
+	}
+	UpstreamRequests.Inc(endpoint, status)
+	UpstreamDuration.Observe(time.Since(start).Seconds(), endpoint)
+	if statusCode == 403 {
+		UpstreamForbidden.Inc(endpoint)
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	counter := NewCounterVec("test_requests_total", "Requests.", "path")
+	counter.Inc("/b")
+	counter.Add(2, `/a"\`)
+	gauge := NewGaugeVec("test_temperature", "Temperature.")
+	histogram := NewHistogramVec("test_duration_seconds", "Duration.", []float64{0.1, 1}, "path")
+	histogram.Observe(0.05, "/a")
+	histogram.Observe(0.5, "/a")
+	histogram.Observe(5, "/a")
+
+	var buf bytes.Buffer
+	if err := Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := `# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{path="/a\"\\"} 2
+test_requests_total{path="/b"} 1
+# HELP test_temperature Temperature.
+# TYPE test_temperature gauge
+test_temperature 0
+# HELP test_duration_seconds Duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{path="/a",le="0.1"} 1
+test_duration_seconds_bucket{path="/a",le="1"} 2
+test_duration_seconds_bucket{path="/a",le="+Inf"} 3
+test_duration_seconds_sum{path="/a"} 5.55
+test_duration_seconds_count{path="/a"} 3
+`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("Write() = %s, want it to contain %s", buf.String(), want)
+	}
+
+	gauge.Set(21.5)
+	buf.Reset()
+	if err := Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "\ntest_temperature 21.5\n") {
+		t.Errorf("Write() after Set() = %s, want test_temperature 21.5", buf.String())
+	}
+}
+
+func TestObserveUpstream(t *testing.T) {
+	start := time.Now()
+	ObserveUpstream(EndpointKey, start, 200)
+	ObserveUpstream(EndpointKey, start, 403)
+	ObserveUpstream(EndpointKey, start, 0)
+
+	var buf bytes.Buffer
+	if err := Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	for _, line := range []string{
+		`jiotv_upstream_requests_total{endpoint="key",status="200"} 1`,
+		`jiotv_upstream_requests_total{endpoint="key",status="403"} 1`,
+		`jiotv_upstream_requests_total{endpoint="key",status="error"} 1`,
+		`jiotv_upstream_forbidden_total{endpoint="key"} 1`,
+		`jiotv_upstream_request_duration_seconds_count{endpoint="key"} 3`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("Write() has no line %s", line)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Package metrics collects the metrics of the server and writes them in the Prometheus text format.
+//
+// Metrics are counters, gauges and histograms, each with a fixed list of labels. A series is created
+// for each set of label values the first time it is updated.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the histogram buckets of request durations
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// family is a metric with its series
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series is the value of a metric for a set of label values
+type series struct {
+	labelValues []string
+	value       float64
+	// counts are the observations in each bucket of a histogram, not cumulative
+	counts []uint64
+	count  uint64
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []*family
+)
+
+// register adds a metric to the ones written by Write
+func register(f *family) *family {
+	f.series = make(map[string]*series)
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, f)
+	return f
+}
+
+// get returns the series of label values, creating it on first use. f.mu must be held.
+func (f *family) get(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s has %d labels, got %d values", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.kind == kindHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// CounterVec is a counter with labels
+type CounterVec struct {
+	f *family
+}
+
+// NewCounterVec creates and registers a counter
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{f: register(&family{name: name, help: help, kind: kindCounter, labels: labels})}
+}
+
+// Inc adds 1 to the counter of the label values
+func (v *CounterVec) Inc(labelValues ...string) {
+	v.Add(1, labelValues...)
+}
+
+// Add adds a value, which must not be negative, to the counter of the label values
+func (v *CounterVec) Add(value float64, labelValues ...string) {
+	v.f.mu.Lock()
+	defer v.f.mu.Unlock()
+	v.f.get(labelValues).value += value
+}
+
+// GaugeVec is a gauge with labels
+type GaugeVec struct {
+	f *family
+}
+
+// NewGaugeVec creates and registers a gauge
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{f: register(&family{name: name, help: help, kind: kindGauge, labels: labels})}
+}
+
+// Set sets the gauge of the label values
+func (v *GaugeVec) Set(value float64, labelValues ...string) {
+	v.f.mu.Lock()
+	defer v.f.mu.Unlock()
+	v.f.get(labelValues).value = value
+}
+
+// Reset removes every series of the gauge, for gauges whose label values come and go
+func (v *GaugeVec) Reset() {
+	v.f.mu.Lock()
+	defer v.f.mu.Unlock()
+	v.f.series = make(map[string]*series)
+}
+
+// HistogramVec is a histogram with labels
+type HistogramVec struct {
+	f *family
+}
+
+// NewHistogramVec creates and registers a histogram with the given bucket upper bounds, in increasing order
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{f: register(&family{name: name, help: help, kind: kindHistogram, labels: labels, buckets: buckets})}
+}
+
+// Observe adds an observation to the histogram of the label values
+func (v *HistogramVec) Observe(value float64, labelValues ...string) {
+	v.f.mu.Lock()
+	defer v.f.mu.Unlock()
+	s := v.f.get(labelValues)
+	s.value += value
+	s.count++
+	if i := sort.SearchFloat64s(v.f.buckets, value); i < len(v.f.buckets) {
+		s.counts[i]++
+	}
+}
+
+// Write writes every metric in the Prometheus text format
+func Write(w io.Writer) error {
+	registryMu.Lock()
+	families := append([]*family(nil), registry...)
+	registryMu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+// write writes a metric with its series sorted by label values
+func (f *family) write(w *bufio.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Metrics without labels always have a value
+	if len(f.labels) == 0 {
+		f.get(nil)
+	}
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != kindHistogram {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(f.labels, s.labelValues, "", ""), formatValue(s.value))
+			continue
+		}
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, formatLabels(f.labels, s.labelValues, "le", formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, formatLabels(f.labels, s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, formatLabels(f.labels, s.labelValues, "", ""), formatValue(s.value))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, formatLabels(f.labels, s.labelValues, "", ""), s.count)
+	}
+}
+
+// labelEscaper escapes label values
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels formats the labels of a series, with an extra label like the le of histogram buckets if given
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+labelEscaper.Replace(values[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatValue formats a value like Prometheus does
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,95 @@
+package reminders
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Notification is posted to the webhook and sent to the browser when a reminder fires
+type Notification struct {
+	Event    string   `json:"event"`
+	Message  string   `json:"message"`
+	Reminder Reminder `json:"reminder"`
+}
+
+// NewNotification creates the notification of a reminder
+func NewNotification(reminder Reminder) Notification {
+	channel := reminder.ChannelName
+	if channel == "" {
+		channel = reminder.ChannelID
+	}
+	message := fmt.Sprintf("%s starts now on %s", reminder.Title, channel)
+	if minutes := int(time.Until(reminder.Start).Round(time.Minute).Minutes()); minutes > 0 {
+		message = fmt.Sprintf("%s starts in %d min on %s", reminder.Title, minutes, channel)
+	}
+	return Notification{
+		Event:    "reminder",
+		Message:  message,
+		Reminder: reminder,
+	}
+}
+
+// webhookClient posts notifications to the webhook. The webhook is usually on the local network, so the proxy is not used.
+var webhookClient = &fasthttp.Client{
+	ReadTimeout:  10 * time.Second,
+	WriteTimeout: 10 * time.Second,
+}
+
+var (
+	// subscribers are the channels of the browser tabs listening for notifications
+	subscribers      = make(map[chan Notification]bool)
+	subscribersMutex sync.Mutex
+)
+
+// Subscribe returns a channel receiving every notification and a function to stop receiving them.
+// Notifications are dropped for subscribers which are not keeping up.
+func Subscribe() (<-chan Notification, func()) {
+	ch := make(chan Notification, 8)
+	subscribersMutex.Lock()
+	subscribers[ch] = true
+	subscribersMutex.Unlock()
+	return ch, func() {
+		subscribersMutex.Lock()
+		delete(subscribers, ch)
+		subscribersMutex.Unlock()
+	}
+}
+
+// notify sends a notification to the subscribers and the webhook
+func notify(notification Notification) {
+	utils.Log.Println("Reminder:", notification.Message)
+
+	subscribersMutex.Lock()
+	for ch := range subscribers {
+		select {
+		case ch <- notification:
+		default:
+		}
+	}
+	subscribersMutex.Unlock()
+
+	if config.Cfg.ReminderWebhook != "" {
+		if err := postWebhook(config.Cfg.ReminderWebhook, notification); err != nil {
+			utils.Log.Printf("ERROR: Failed to post reminder to webhook: %v", err)
+		}
+	}
+}
+
+// postWebhook posts a notification as JSON to the webhook URL
+func postWebhook(url string, notification Notification) error {
+	resp, err := utils.MakeJSONRequest(url, "POST", notification, nil, webhookClient)
+	if err != nil {
+		return err
+	}
+	defer fasthttp.ReleaseResponse(resp)
+	if resp.StatusCode() < 200 || resp.StatusCode() > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode())
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+// Package reminders notifies the user shortly before a programme of the EPG starts.
+// Reminders are saved in the store and scheduled again when the server starts.
+// When a reminder fires it is posted to the configured webhook and sent to the open browser tabs.
+package reminders
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/tasks"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// remindersKey is the store key of the pending reminders
+const remindersKey = "reminders"
+
+var (
+	// ErrNoGuide is returned when a reminder is added before the EPG is loaded
+	ErrNoGuide = errors.New("EPG not available")
+	// ErrProgrammeNotFound is returned when the EPG has no programme of the channel at the start time
+	ErrProgrammeNotFound = errors.New("programme not found")
+	// ErrProgrammeStarted is returned when the programme has already started
+	ErrProgrammeStarted = errors.New("programme has already started")
+	// ErrInvalidMinutes is returned for a negative number of minutes before the start
+	ErrInvalidMinutes = errors.New("minutes_before must not be negative")
+	// ErrNotFound is returned when removing a reminder which does not exist
+	ErrNotFound = errors.New("reminder not found")
+)
+
+// Reminder is a pending reminder of a programme
+type Reminder struct {
+	ID            string    `json:"id"`
+	ChannelID     string    `json:"channel_id"`
+	ChannelName   string    `json:"channel_name"`
+	Title         string    `json:"title"`
+	Start         time.Time `json:"start"`
+	Stop          time.Time `json:"stop"`
+	MinutesBefore int       `json:"minutes_before"`
+}
+
+// FireAt returns the time at which the reminder fires
+func (r Reminder) FireAt() time.Time {
+	return r.Start.Add(-time.Duration(r.MinutesBefore) * time.Minute)
+}
+
+// mu serializes read-modify-write updates of the reminders
+var mu sync.Mutex
+
+// Init schedules the saved reminders and drops the ones of programmes which are no longer in the EPG.
+// It must be called after the store and the scheduler are initialized.
+func Init() {
+	epg.OnGuideLoaded(Prune)
+
+	mu.Lock()
+	defer mu.Unlock()
+	reminders, err := load()
+	if err != nil {
+		utils.Log.Printf("ERROR: Failed to load reminders: %v", err)
+		return
+	}
+	if guide := epg.CurrentGuide(); guide != nil {
+		reminders = prune(reminders, guide)
+		if err := save(reminders); err != nil {
+			utils.Log.Printf("ERROR: Failed to save reminders: %v", err)
+		}
+	}
+	for _, reminder := range reminders {
+		schedule(reminder)
+	}
+	if len(reminders) > 0 {
+		utils.Log.Printf("Scheduled %d programme reminders", len(reminders))
+	}
+}
+
+// DefaultMinutesBefore returns the configured number of minutes before the start at which reminders fire
+func DefaultMinutesBefore() int {
+	if config.Cfg.ReminderMinutes > 0 {
+		return config.Cfg.ReminderMinutes
+	}
+	return constants.DefaultReminderMinutes
+}
+
+// List returns the pending reminders sorted by the time they fire
+func List() ([]Reminder, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return load()
+}
+
+// Add sets a reminder for the programme of a channel starting at start, firing minutesBefore minutes before it.
+// A reminder of the same programme is replaced.
+func Add(channelID string, start time.Time, minutesBefore int) (Reminder, error) {
+	if minutesBefore < 0 {
+		return Reminder{}, ErrInvalidMinutes
+	}
+	guide := epg.CurrentGuide()
+	if guide == nil {
+		return Reminder{}, ErrNoGuide
+	}
+	programme, ok := guide.Programme(channelID, start)
+	if !ok {
+		return Reminder{}, ErrProgrammeNotFound
+	}
+	if !programme.Start.After(time.Now()) {
+		return Reminder{}, ErrProgrammeStarted
+	}
+	reminder := Reminder{
+		ID:            reminderID(channelID, programme.Start),
+		ChannelID:     channelID,
+		ChannelName:   guide.ChannelName(channelID),
+		Title:         programme.Title,
+		Start:         programme.Start,
+		Stop:          programme.Stop,
+		MinutesBefore: minutesBefore,
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	reminders, err := load()
+	if err != nil {
+		return Reminder{}, err
+	}
+	reminders = append(without(reminders, reminder.ID), reminder)
+	if err := save(reminders); err != nil {
+		return Reminder{}, err
+	}
+	schedule(reminder)
+	return reminder, nil
+}
+
+// Remove deletes a pending reminder
+func Remove(id string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	reminders, err := load()
+	if err != nil {
+		return err
+	}
+	kept := without(reminders, id)
+	if len(kept) == len(reminders) {
+		return ErrNotFound
+	}
+	if err := save(kept); err != nil {
+		return err
+	}
+	scheduler.Remove(tasks.ReminderTaskIDPrefix + id)
+	return nil
+}
+
+// Prune drops the reminders of programmes which are no longer in the guide.
+// Channels without any programme in the guide are kept, as their EPG may have failed to download.
+func Prune(guide *epg.Guide) {
+	mu.Lock()
+	defer mu.Unlock()
+	reminders, err := load()
+	if err != nil {
+		utils.Log.Printf("ERROR: Failed to load reminders: %v", err)
+		return
+	}
+	kept := prune(reminders, guide)
+	if len(kept) == len(reminders) {
+		return
+	}
+	if err := save(kept); err != nil {
+		utils.Log.Printf("ERROR: Failed to save reminders: %v", err)
+		return
+	}
+	ids := make(map[string]bool, len(kept))
+	for _, reminder := range kept {
+		ids[reminder.ID] = true
+	}
+	for _, reminder := range reminders {
+		if !ids[reminder.ID] {
+			scheduler.Remove(tasks.ReminderTaskIDPrefix + reminder.ID)
+			utils.Log.Printf("Dropped reminder of %s on channel %s, which is no longer in the EPG", reminder.Title, reminder.ChannelID)
+		}
+	}
+}
+
+// prune returns the reminders whose programme is still in the guide
+func prune(reminders []Reminder, guide *epg.Guide) []Reminder {
+	kept := make([]Reminder, 0, len(reminders))
+	for _, reminder := range reminders {
+		if guide.HasProgrammes(reminder.ChannelID) {
+			if _, ok := guide.Programme(reminder.ChannelID, reminder.Start); !ok {
+				continue
+			}
+		}
+		kept = append(kept, reminder)
+	}
+	return kept
+}
+
+// schedule adds the task firing a reminder. Reminders which should have fired while the server was down
+// fire right away, unless their programme has already ended.
+func schedule(reminder Reminder) {
+	scheduler.AddOnce(tasks.ReminderTaskIDPrefix+reminder.ID, time.Until(reminder.FireAt()), func() error {
+		return fire(reminder.ID)
+	})
+}
+
+// fire removes a reminder from the store and sends its notification
+func fire(id string) error {
+	mu.Lock()
+	reminders, err := load()
+	if err != nil {
+		mu.Unlock()
+		return err
+	}
+	var reminder *Reminder
+	for i := range reminders {
+		if reminders[i].ID == id {
+			reminder = &reminders[i]
+			break
+		}
+	}
+	if reminder == nil {
+		mu.Unlock()
+		return nil
+	}
+	err = save(without(reminders, id))
+	mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if !reminder.Stop.After(time.Now()) {
+		return nil
+	}
+	notify(NewNotification(*reminder))
+	return nil
+}
+
+// reminderID identifies the programme of a reminder, so a programme has at most one reminder
+func reminderID(channelID string, start time.Time) string {
+	return channelID + "-" + strconv.FormatInt(start.Unix(), 10)
+}
+
+// without returns the reminders except the one with the given ID
+func without(reminders []Reminder, id string) []Reminder {
+	kept := make([]Reminder, 0, len(reminders))
+	for _, reminder := range reminders {
+		if reminder.ID != id {
+			kept = append(kept, reminder)
+		}
+	}
+	return kept
+}
+
+// load reads the reminders from the store. A missing key is an empty list.
+func load() ([]Reminder, error) {
+	value, err := store.Get(remindersKey)
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return []Reminder{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var reminders []Reminder
+	if err := json.Unmarshal([]byte(value), &reminders); err != nil {
+		return nil, fmt.Errorf("invalid reminders in store: %w", err)
+	}
+	if reminders == nil {
+		reminders = []Reminder{}
+	}
+	return reminders, nil
+}
+
+// save writes the reminders to the store sorted by the time they fire
+func save(reminders []Reminder) error {
+	sort.SliceStable(reminders, func(i, j int) bool {
+		return reminders[i].FireAt().Before(reminders[j].FireAt())
+	})
+	value, err := json.Marshal(reminders)
+	if err != nil {
+		return err
+	}
+	return store.Set(remindersKey, string(value))
+}
@@ -0,0 +1,227 @@
+package reminders
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/tasks"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/epg"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+func TestMain(m *testing.M) {
+	utils.Log = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+func setup(t *testing.T) {
+	t.Helper()
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	t.Cleanup(cleanup)
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+	scheduler.Init()
+	t.Cleanup(scheduler.Stop)
+}
+
+// loadGuide loads a guide with the given programmes of channel 143 and a channel 144 without programmes.
+// Each programme lasts an hour.
+func loadGuide(t *testing.T, programmes map[string]time.Time) {
+	t.Helper()
+	layout := "20060102150405 -0700"
+	xml := `<tv><channel id="143"><display-name>News</display-name></channel><channel id="144"><display-name>Sports</display-name></channel>`
+	for title, start := range programmes {
+		xml += fmt.Sprintf(`<programme channel="143" start="%s" stop="%s"><title lang="en">%s</title></programme>`,
+			start.Format(layout), start.Add(time.Hour).Format(layout), title)
+	}
+	xml += `</tv>`
+
+	filename := filepath.Join(t.TempDir(), "epg.xml.gz")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(xml))
+	gz.Close()
+	f.Close()
+	if err := epg.LoadGuide(filename); err != nil {
+		t.Fatalf("LoadGuide() error = %v", err)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	setup(t)
+	start := time.Now().Add(2 * time.Hour).Truncate(time.Minute)
+	loadGuide(t, map[string]time.Time{"Current": time.Now().Add(-time.Minute), "Later": start})
+
+	tests := []struct {
+		name          string
+		channelID     string
+		start         time.Time
+		minutesBefore int
+		wantErr       error
+	}{
+		{name: "Unknown programme", channelID: "143", start: start.Add(time.Minute), wantErr: ErrProgrammeNotFound},
+		{name: "Unknown channel", channelID: "999", start: start, wantErr: ErrProgrammeNotFound},
+		{name: "Started programme", channelID: "143", start: time.Now().Add(-time.Minute).Truncate(time.Second), wantErr: ErrProgrammeStarted},
+		{name: "Negative minutes", channelID: "143", start: start, minutesBefore: -1, wantErr: ErrInvalidMinutes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Add(tt.channelID, tt.start, tt.minutesBefore); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Add() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	reminder, err := Add("143", start, 10)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if reminder.Title != "Later" || reminder.ChannelName != "News" || !reminder.FireAt().Equal(start.Add(-10*time.Minute)) {
+		t.Errorf("Add() = %+v, want a reminder of Later firing 10 minutes before it", reminder)
+	}
+	if _, err := scheduler.Scheduler.Lookup(tasks.ReminderTaskIDPrefix + reminder.ID); err != nil {
+		t.Errorf("reminder was not scheduled: %v", err)
+	}
+
+	// A second reminder of the same programme replaces the first
+	if _, err := Add("143", start, 5); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	list, err := List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 1 || list[0].MinutesBefore != 5 {
+		t.Errorf("List() = %+v, want the reminder firing 5 minutes before", list)
+	}
+
+	if err := Remove(reminder.ID); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := Remove(reminder.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Remove() of a removed reminder error = %v, want %v", err, ErrNotFound)
+	}
+	if _, err := scheduler.Scheduler.Lookup(tasks.ReminderTaskIDPrefix + reminder.ID); err == nil {
+		t.Error("removed reminder is still scheduled")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	setup(t)
+	start := time.Now().Add(2 * time.Hour).Truncate(time.Minute)
+	loadGuide(t, map[string]time.Time{"Kept": start, "Dropped": start.Add(time.Hour)})
+	kept, err := Add("143", start, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dropped, err := Add("143", start.Add(time.Hour), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A channel without programmes may have failed to download, so its reminders are kept
+	orphan := Reminder{ID: reminderID("144", start), ChannelID: "144", Start: start, Stop: start.Add(time.Hour)}
+	list, _ := List()
+	if err := save(append(list, orphan)); err != nil {
+		t.Fatal(err)
+	}
+
+	epg.OnGuideLoaded(Prune)
+	loadGuide(t, map[string]time.Time{"Kept": start})
+
+	list, err = List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var ids []string
+	for _, reminder := range list {
+		ids = append(ids, reminder.ID)
+	}
+	if got, want := strings.Join(ids, ","), kept.ID+","+orphan.ID; got != want {
+		t.Errorf("reminders after the guide was reloaded = %s, want %s", got, want)
+	}
+	if _, err := scheduler.Scheduler.Lookup(tasks.ReminderTaskIDPrefix + dropped.ID); err == nil {
+		t.Error("dropped reminder is still scheduled")
+	}
+}
+
+func TestFire(t *testing.T) {
+	setup(t)
+	webhook := make(chan Notification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification Notification
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&notification) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		webhook <- notification
+	}))
+	defer server.Close()
+	config.Cfg.ReminderWebhook = server.URL
+	defer func() { config.Cfg.ReminderWebhook = "" }()
+
+	notifications, unsubscribe := Subscribe()
+	defer unsubscribe()
+
+	// The reminder fires right away, as its time has already passed
+	start := time.Now().Add(30 * time.Minute).Truncate(time.Minute)
+	loadGuide(t, map[string]time.Time{"Soon": start})
+	reminder, err := Add("143", start, 60)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	for name, ch := range map[string]<-chan Notification{"subscriber": notifications, "webhook": webhook} {
+		select {
+		case notification := <-ch:
+			if notification.Event != "reminder" || notification.Reminder.ID != reminder.ID || !strings.HasPrefix(notification.Message, "Soon starts in") {
+				t.Errorf("%s received %+v, want the reminder of Soon", name, notification)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s received no notification", name)
+		}
+	}
+	if list, _ := List(); len(list) != 0 {
+		t.Errorf("List() after the reminder fired = %+v, want none", list)
+	}
+}
+
+func TestInit(t *testing.T) {
+	setup(t)
+	start := time.Now().Add(2 * time.Hour).Truncate(time.Minute)
+	loadGuide(t, map[string]time.Time{"Saved": start})
+	saved := Reminder{ID: reminderID("143", start), ChannelID: "143", Title: "Saved", Start: start, Stop: start.Add(time.Hour), MinutesBefore: 5}
+	gone := Reminder{ID: reminderID("143", start.Add(time.Hour)), ChannelID: "143", Title: "Gone", Start: start.Add(time.Hour), Stop: start.Add(2 * time.Hour)}
+	if err := save([]Reminder{saved, gone}); err != nil {
+		t.Fatal(err)
+	}
+
+	Init()
+
+	if _, err := scheduler.Scheduler.Lookup(tasks.ReminderTaskIDPrefix + saved.ID); err != nil {
+		t.Errorf("saved reminder was not scheduled: %v", err)
+	}
+	if list, _ := List(); len(list) != 1 || list[0].ID != saved.ID {
+		t.Errorf("List() after Init() = %+v, want only the reminder of a programme in the guide", list)
+	}
+}
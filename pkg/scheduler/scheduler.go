@@ -39,3 +39,29 @@ func Add(id string, interval time.Duration, task func() error) {
 	}
 	utils.Log.Printf("Task added with ID: %v\n", id)
 }
+
+// AddOnce adds a task which runs once after the given delay and is then removed.
+// A task with the same ID is replaced.
+func AddOnce(id string, delay time.Duration, task func() error) {
+	// the scheduler rejects tasks without a positive interval, so overdue tasks run right away
+	if delay <= 0 {
+		delay = time.Millisecond
+	}
+	Scheduler.Del(id)
+	err := Scheduler.AddWithID(id, &tasks.Task{
+		Interval: delay,
+		RunOnce:  true,
+		TaskFunc: task,
+		ErrFunc: func(err error) {
+			utils.Log.Printf("Task failed: %v\n", err)
+		},
+	})
+	if err != nil {
+		utils.Log.Printf("Failed to add task: %v\n", err)
+	}
+}
+
+// Remove removes the task with the given ID if it exists
+func Remove(id string) {
+	Scheduler.Del(id)
+}
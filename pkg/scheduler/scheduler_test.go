@@ -132,3 +132,52 @@ func TestAdd(t *testing.T) {
 		Add("nil_task", 1*time.Second, nil)
 	})
 }
+
+func TestAddOnce(t *testing.T) {
+	Init()
+	defer Stop()
+
+	runs := make(chan struct{}, 2)
+	AddOnce("test_once", 10*time.Millisecond, func() error {
+		runs <- struct{}{}
+		return nil
+	})
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("task added with AddOnce did not run")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if len(runs) != 0 {
+		t.Error("task added with AddOnce ran more than once")
+	}
+
+	// Overdue tasks run right away
+	AddOnce("test_overdue", -time.Minute, func() error {
+		runs <- struct{}{}
+		return nil
+	})
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("overdue task did not run")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	Init()
+	defer Stop()
+
+	ran := make(chan struct{}, 1)
+	AddOnce("test_removed", 50*time.Millisecond, func() error {
+		ran <- struct{}{}
+		return nil
+	})
+	Remove("test_removed")
+	Remove("missing_task")
+	select {
+	case <-ran:
+		t.Error("removed task ran")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
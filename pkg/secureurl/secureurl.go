@@ -3,12 +3,15 @@ package secureurl
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"net/url"
+	"strings"
 
 	"github.com/jiotv-go/jiotv_go/v3/internal/config"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
@@ -17,6 +20,8 @@ import (
 var (
 	key                  []byte
 	disableUrlEncryption bool
+	// signKey signs the channels of server URLs. It is used even when URL encryption is disabled.
+	signKey []byte
 )
 
 func generateKey() []byte {
@@ -82,7 +87,24 @@ func DecryptURL(encryptedURL string) (string, error) {
 	return decryptedURL, nil
 }
 
+// SignChannel signs the channel a server URL is for, together with the upstream URLs it carries,
+// so that the channel of a request can be trusted and the URLs can't be swapped for others
+func SignChannel(channelID string, urls ...string) string {
+	mac := hmac.New(sha256.New, signKey)
+	mac.Write([]byte(channelID + "\n" + strings.Join(urls, "\n")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyChannel checks a signature made by SignChannel
+func VerifyChannel(signature, channelID string, urls ...string) bool {
+	if signature == "" {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(SignChannel(channelID, urls...)))
+}
+
 func Init() {
+	signKey = generateKey()
 	disableUrlEncryption = config.Cfg.DisableURLEncryption
 	if disableUrlEncryption {
 		fmt.Println("Warning! URL encryption is disabled. Anyone can pass modified URLs to your server.")
@@ -140,3 +140,28 @@ func TestInit(t *testing.T) {
 		})
 	}
 }
+
+func TestSignChannel(t *testing.T) {
+	Init()
+	signature := SignChannel("143", "https://example.com/a.m3u8")
+	tests := []struct {
+		name      string
+		signature string
+		channelID string
+		urls      []string
+		want      bool
+	}{
+		{"Same channel and URL", signature, "143", []string{"https://example.com/a.m3u8"}, true},
+		{"Other channel", signature, "144", []string{"https://example.com/a.m3u8"}, false},
+		{"Other URL", signature, "143", []string{"https://example.com/b.m3u8"}, false},
+		{"Extra URL", signature, "143", []string{"https://example.com/a.m3u8", ""}, false},
+		{"No signature", "", "143", []string{"https://example.com/a.m3u8"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyChannel(tt.signature, tt.channelID, tt.urls...); got != tt.want {
+				t.Errorf("VerifyChannel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,385 @@
+// Package share mints expiring share links, which give access to some channels without an API key.
+// A link carries its scope and expiry in a token signed with a secret kept in the store,
+// so tokens are checked without looking them up. Revoked links are kept in a list in the store until they expire.
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+const (
+	// secretKey is the store key of the secret signing the tokens
+	secretKey = "share_secret"
+	// linksKey is the store key of the minted links
+	linksKey = "share_links"
+	// revokedKey is the store key of the revoked links
+	revokedKey = "share_revoked"
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid share token")
+	ErrExpired       = errors.New("share link expired")
+	ErrRevoked       = errors.New("share link revoked")
+	ErrNotFound      = errors.New("share link not found")
+	ErrEmptyScope    = errors.New("a share link needs channels, languages or categories")
+	ErrInvalidExpiry = errors.New("the expiry of a share link must be in the future")
+)
+
+// Scope is what a share link gives access to. A channel is in the scope when it is one of
+// ChannelIDs, and in one of Languages and one of Categories. Empty lists don't restrict.
+type Scope struct {
+	ChannelIDs []string `json:"channel_ids,omitempty"`
+	Languages  []int    `json:"languages,omitempty"`
+	Categories []int    `json:"categories,omitempty"`
+}
+
+// Link is a minted share link
+type Link struct {
+	ID string `json:"id"`
+	// Name tells who or what the link is for
+	Name    string    `json:"name"`
+	Scope   Scope     `json:"scope"`
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"`
+	Token   string    `json:"token"`
+}
+
+// claims are the signed content of a token
+type claims struct {
+	ID      string `json:"id"`
+	Scope   Scope  `json:"scope"`
+	Expires int64  `json:"exp"`
+}
+
+// revocation is a revoked link, kept until it expires
+type revocation struct {
+	ID      string    `json:"id"`
+	Expires time.Time `json:"expires"`
+}
+
+var (
+	// mu serializes updates of the stored lists and the creation of the secret
+	mu sync.Mutex
+	// secret signs the tokens. It is loaded from the store on first use.
+	secret []byte
+)
+
+// Init creates the signing secret if there is none yet, so that it is saved before the first link is minted
+func Init() error {
+	mu.Lock()
+	defer mu.Unlock()
+	_, err := signingSecret()
+	return err
+}
+
+// signingSecret returns the secret signing the tokens, creating it on first use. mu must be held.
+func signingSecret() ([]byte, error) {
+	if secret != nil {
+		return secret, nil
+	}
+	value, err := store.Get(secretKey)
+	if err == nil {
+		if secret, err = hex.DecodeString(value); err == nil && len(secret) > 0 {
+			return secret, nil
+		}
+	} else if !errors.Is(err, store.ErrKeyNotFound) {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := store.Set(secretKey, hex.EncodeToString(key)); err != nil {
+		return nil, err
+	}
+	secret = key
+	return secret, nil
+}
+
+// Empty checks if the scope has nothing in it, which would give access to every channel
+func (s Scope) Empty() bool {
+	return len(s.ChannelIDs) == 0 && len(s.Languages) == 0 && len(s.Categories) == 0
+}
+
+// Allows checks if a channel is in the scope
+func (s Scope) Allows(channel television.Channel) bool {
+	if len(s.ChannelIDs) > 0 && !s.AllowsID(channel.ID) {
+		return false
+	}
+	if len(s.Languages) > 0 && !containsInt(s.Languages, channel.Language) {
+		return false
+	}
+	return len(s.Categories) == 0 || containsInt(s.Categories, channel.Category)
+}
+
+// AllowsID checks if a channel ID is in the channel IDs of the scope. SonyLIV channels match with or without their sl prefix.
+// Scopes without channel IDs allow every ID, as languages and categories are only checked by Allows.
+func (s Scope) AllowsID(id string) bool {
+	if len(s.ChannelIDs) == 0 {
+		return true
+	}
+	for _, allowed := range s.ChannelIDs {
+		if allowed == id || strings.TrimPrefix(allowed, "sl") == strings.TrimPrefix(id, "sl") {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the channels in the scope, keeping their order
+func (s Scope) Filter(channels []television.Channel) []television.Channel {
+	filtered := make([]television.Channel, 0, len(channels))
+	for _, channel := range channels {
+		if s.Allows(channel) {
+			filtered = append(filtered, channel)
+		}
+	}
+	return filtered
+}
+
+// containsInt checks if a list has a value
+func containsInt(list []int, value int) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Create mints a share link for the scope, valid until expires
+func Create(name string, scope Scope, expires time.Time, now time.Time) (Link, error) {
+	if scope.Empty() {
+		return Link{}, ErrEmptyScope
+	}
+	if !expires.After(now) {
+		return Link{}, ErrInvalidExpiry
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	key, err := signingSecret()
+	if err != nil {
+		return Link{}, err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Link{}, err
+	}
+	link := Link{
+		ID:      hex.EncodeToString(id),
+		Name:    strings.TrimSpace(name),
+		Scope:   scope,
+		Created: now,
+		Expires: expires,
+	}
+	link.Token, err = sign(key, claims{ID: link.ID, Scope: scope, Expires: expires.Unix()})
+	if err != nil {
+		return Link{}, err
+	}
+
+	links, err := loadLinks()
+	if err != nil {
+		return Link{}, err
+	}
+	// Expired links are dropped from the list when a new one is minted
+	kept := []Link{link}
+	for _, l := range links {
+		if l.Expires.After(now) {
+			kept = append(kept, l)
+		}
+	}
+	if err := saveJSON(linksKey, kept); err != nil {
+		return Link{}, err
+	}
+	return link, nil
+}
+
+// PlaylistURL returns the URL of the M3U playlist of a link on a server, like http://localhost:5001
+func (l Link) PlaylistURL(serverURL string) string {
+	return strings.TrimSuffix(serverURL, "/") + "/playlist.m3u?token=" + url.QueryEscape(l.Token)
+}
+
+// PlayURL returns the URL of the web player of a link giving access to a single channel.
+// It is empty for other links.
+func (l Link) PlayURL(serverURL string) string {
+	if len(l.Scope.ChannelIDs) != 1 {
+		return ""
+	}
+	return strings.TrimSuffix(serverURL, "/") + "/play/" + url.PathEscape(l.Scope.ChannelIDs[0]) + "?token=" + url.QueryEscape(l.Token)
+}
+
+// sign returns the token of the claims, as the base64 encoded claims and their HMAC separated by a dot
+func sign(key []byte, c claims) (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac(key, encoded)), nil
+}
+
+// mac returns the HMAC-SHA256 of a value
+func mac(key []byte, value string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(value))
+	return h.Sum(nil)
+}
+
+// IsToken checks if a value has the form of a share token, to tell share tokens from API keys
+func IsToken(value string) bool {
+	payload, signature, found := strings.Cut(value, ".")
+	return found && payload != "" && signature != "" && !strings.Contains(signature, ".")
+}
+
+// Verify checks the signature, the expiry and the revocation of a token and returns its scope
+func Verify(token string, now time.Time) (Scope, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found {
+		return Scope{}, ErrInvalidToken
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	key, err := signingSecret()
+	if err != nil {
+		return Scope{}, err
+	}
+	got, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(got, mac(key, payload)) {
+		return Scope{}, ErrInvalidToken
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Scope{}, ErrInvalidToken
+	}
+	var c claims
+	if err := json.Unmarshal(decoded, &c); err != nil {
+		return Scope{}, ErrInvalidToken
+	}
+	if !now.Before(time.Unix(c.Expires, 0)) {
+		return Scope{}, ErrExpired
+	}
+	revoked, err := loadRevoked()
+	if err != nil {
+		return Scope{}, err
+	}
+	for _, r := range revoked {
+		if r.ID == c.ID {
+			return Scope{}, ErrRevoked
+		}
+	}
+	return c.Scope, nil
+}
+
+// List returns the links which have not expired, newest first
+func List(now time.Time) ([]Link, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	links, err := loadLinks()
+	if err != nil {
+		return nil, err
+	}
+	active := []Link{}
+	for _, link := range links {
+		if link.Expires.After(now) {
+			active = append(active, link)
+		}
+	}
+	return active, nil
+}
+
+// Revoke revokes a link by its ID, so that its token is no longer accepted
+func Revoke(id string, now time.Time) error {
+	mu.Lock()
+	defer mu.Unlock()
+	links, err := loadLinks()
+	if err != nil {
+		return err
+	}
+	var link Link
+	found := false
+	kept := make([]Link, 0, len(links))
+	for _, l := range links {
+		if l.ID == id {
+			link, found = l, true
+			continue
+		}
+		kept = append(kept, l)
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	revoked, err := loadRevoked()
+	if err != nil {
+		return err
+	}
+	// Revocations of expired links are no longer needed, as their tokens are rejected anyway
+	active := []revocation{{ID: link.ID, Expires: link.Expires}}
+	for _, r := range revoked {
+		if r.Expires.After(now) {
+			active = append(active, r)
+		}
+	}
+	if err := saveJSON(revokedKey, active); err != nil {
+		return err
+	}
+	return saveJSON(linksKey, kept)
+}
+
+// loadLinks returns the stored links, newest first. mu must be held.
+func loadLinks() ([]Link, error) {
+	var links []Link
+	if err := loadJSON(linksKey, &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// loadRevoked returns the stored revocations. mu must be held.
+func loadRevoked() ([]revocation, error) {
+	var revoked []revocation
+	if err := loadJSON(revokedKey, &revoked); err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+// loadJSON decodes the JSON value of a store key. A missing key leaves v unchanged.
+func loadJSON(key string, v interface{}) error {
+	value, err := store.Get(key)
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return fmt.Errorf("invalid %s in store: %w", key, err)
+	}
+	return nil
+}
+
+// saveJSON saves a value as JSON under a store key
+func saveJSON(key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return store.Set(key, string(value))
+}
@@ -0,0 +1,124 @@
+package share
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/television"
+)
+
+func setupStore(t *testing.T) {
+	t.Helper()
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	t.Cleanup(cleanup)
+	if err := store.Init(); err != nil {
+		t.Fatalf("store.Init() error = %v", err)
+	}
+	secret = nil
+}
+
+func TestScope(t *testing.T) {
+	channels := []television.Channel{
+		{ID: "143", Language: 6, Category: 12},
+		{ID: "144", Language: 1, Category: 12},
+		{ID: "sl291", Language: 6, Category: 8},
+	}
+	tests := []struct {
+		name  string
+		scope Scope
+		want  []string
+	}{
+		{name: "Channel IDs", scope: Scope{ChannelIDs: []string{"144", "291"}}, want: []string{"144", "sl291"}},
+		{name: "Language", scope: Scope{Languages: []int{6}}, want: []string{"143", "sl291"}},
+		{name: "Language and category", scope: Scope{Languages: []int{6}, Categories: []int{12}}, want: []string{"143"}},
+		{name: "Channel IDs and category", scope: Scope{ChannelIDs: []string{"143", "sl291"}, Categories: []int{8}}, want: []string{"sl291"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, channel := range tt.scope.Filter(channels) {
+				got = append(got, channel.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	setupStore(t)
+	now := time.Now()
+	scope := Scope{ChannelIDs: []string{"143"}}
+
+	if _, err := Create("empty", Scope{}, now.Add(time.Hour), now); !errors.Is(err, ErrEmptyScope) {
+		t.Errorf("Create() with an empty scope error = %v, want %v", err, ErrEmptyScope)
+	}
+	if _, err := Create("past", scope, now.Add(-time.Hour), now); !errors.Is(err, ErrInvalidExpiry) {
+		t.Errorf("Create() expiring in the past error = %v, want %v", err, ErrInvalidExpiry)
+	}
+
+	link, err := Create(" friend ", scope, now.Add(time.Hour), now)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if link.Name != "friend" || !IsToken(link.Token) {
+		t.Errorf("Create() = %+v, want a trimmed name and a token", link)
+	}
+	if got, err := Verify(link.Token, now); err != nil || !reflect.DeepEqual(got, scope) {
+		t.Errorf("Verify() = %+v, %v, want %+v", got, err, scope)
+	}
+	if _, err := Verify(link.Token, now.Add(2*time.Hour)); !errors.Is(err, ErrExpired) {
+		t.Errorf("Verify() after the expiry error = %v, want %v", err, ErrExpired)
+	}
+
+	// The scope can't be changed without the secret
+	payload, signature, _ := strings.Cut(link.Token, ".")
+	other, err := Create("other", Scope{Languages: []int{1}}, now.Add(time.Hour), now)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	otherPayload, _, _ := strings.Cut(other.Token, ".")
+	for _, token := range []string{otherPayload + "." + signature, payload + ".x", "key"} {
+		if _, err := Verify(token, now); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Verify(%q) error = %v, want %v", token, err, ErrInvalidToken)
+		}
+	}
+
+	if links, err := List(now); err != nil || len(links) != 2 || links[0].ID != other.ID {
+		t.Errorf("List() = %+v, %v, want both links, newest first", links, err)
+	}
+	if err := Revoke(link.ID, now); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := Verify(link.Token, now); !errors.Is(err, ErrRevoked) {
+		t.Errorf("Verify() of a revoked link error = %v, want %v", err, ErrRevoked)
+	}
+	if err := Revoke(link.ID, now); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Revoke() twice error = %v, want %v", err, ErrNotFound)
+	}
+	if links, err := List(now); err != nil || len(links) != 1 || links[0].ID != other.ID {
+		t.Errorf("List() after Revoke() = %+v, %v, want the other link", links, err)
+	}
+}
+
+func TestLinkURLs(t *testing.T) {
+	link := Link{Token: "a+b.c", Scope: Scope{ChannelIDs: []string{"143"}}}
+	if got, want := link.PlaylistURL("http://localhost:5001/"), "http://localhost:5001/playlist.m3u?token=a%2Bb.c"; got != want {
+		t.Errorf("PlaylistURL() = %s, want %s", got, want)
+	}
+	if got, want := link.PlayURL("http://localhost:5001"), "http://localhost:5001/play/143?token=a%2Bb.c"; got != want {
+		t.Errorf("PlayURL() = %s, want %s", got, want)
+	}
+	link.Scope = Scope{Languages: []int{6}}
+	if got := link.PlayURL("http://localhost:5001"); got != "" {
+		t.Errorf("PlayURL() of a language link = %s, want none", got)
+	}
+}
@@ -0,0 +1,232 @@
+package television
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/tasks"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// CHANNELS_REFRESH_TASK_ID is the ID of the background channel list refresh task
+	CHANNELS_REFRESH_TASK_ID = tasks.ChannelsRefreshTaskID
+	// channelsSnapshotFile is the file name of the last good channel list saved under path prefix
+	channelsSnapshotFile = "channels_cache.json"
+	// channelsRetryBackoff is the minimum time between upstream attempts after a failed refresh
+	channelsRetryBackoff = time.Minute
+)
+
+// channelsCache holds the last channel list fetched from JioTV API
+type channelsCache struct {
+	mu         sync.RWMutex
+	response   ChannelsResponse
+	fetchedAt  time.Time
+	retryAfter time.Time
+}
+
+var (
+	// catalogue is the channel list cache shared by all requests
+	catalogue channelsCache
+	// catalogueRefreshMutex makes concurrent requests share a single upstream fetch
+	catalogueRefreshMutex sync.Mutex
+)
+
+// getChannelsCacheTTL returns the configured channel list cache TTL
+func getChannelsCacheTTL() time.Duration {
+	ttl := config.Cfg.ChannelsCacheTTL
+	if ttl <= 0 {
+		ttl = constants.DefaultChannelsCacheTTL
+	}
+	return time.Duration(ttl) * time.Minute
+}
+
+// channelsSnapshotPath returns the path of the channel list snapshot file
+func channelsSnapshotPath() string {
+	return utils.GetPathPrefix() + channelsSnapshotFile
+}
+
+// set replaces the cached channel list
+func (c *channelsCache) set(response ChannelsResponse, fetchedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.response = response
+	c.fetchedAt = fetchedAt
+}
+
+// get returns a copy of the cached channel list and whether the cache holds any data.
+// The copy can be modified by the caller without affecting the cache.
+func (c *channelsCache) get() (ChannelsResponse, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.fetchedAt.IsZero() {
+		return ChannelsResponse{}, false
+	}
+	response := c.response
+	response.Result = make([]Channel, len(c.response.Result))
+	copy(response.Result, c.response.Result)
+	response.FetchedAt = c.fetchedAt
+	response.Stale = time.Since(c.fetchedAt) > getChannelsCacheTTL()
+	return response, true
+}
+
+// shouldRetry reports whether upstream may be contacted again after a failed refresh
+func (c *channelsCache) shouldRetry() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return time.Now().After(c.retryAfter)
+}
+
+// backoff delays the next upstream attempt after a failed refresh
+func (c *channelsCache) backoff() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryAfter = time.Now().Add(channelsRetryBackoff)
+}
+
+// InitChannelsCache loads the last saved channel list from disk and schedules
+// a background task which keeps the channel list cache fresh.
+func InitChannelsCache() {
+	if err := loadChannelsSnapshot(); err != nil && !os.IsNotExist(err) {
+		utils.SafeLogf("Error loading channel list snapshot: %v", err)
+	}
+	if err := loadGhostChannels(); err != nil {
+		utils.SafeLogf("Error loading removed channels: %v", err)
+	}
+
+	if response, ok := catalogue.get(); !ok || response.Stale {
+		go RefreshChannels()
+	}
+
+	// Refresh slightly ahead of expiry so requests rarely have to wait for upstream
+	ttl := getChannelsCacheTTL()
+	go scheduler.Add(CHANNELS_REFRESH_TASK_ID, ttl-ttl/5, RefreshChannels)
+}
+
+// RefreshChannels fetches the channel list from JioTV API and updates the cache and snapshot
+func RefreshChannels() error {
+	catalogueRefreshMutex.Lock()
+	defer catalogueRefreshMutex.Unlock()
+	return refreshChannelsLocked()
+}
+
+// refreshChannelsLocked does the actual refresh. catalogueRefreshMutex must be held.
+func refreshChannelsLocked() error {
+	response, body, err := fetchChannels()
+	if err == nil {
+		err = checkChannelsResponse(response)
+	}
+	if err != nil {
+		catalogue.backoff()
+		return err
+	}
+	previous, hasPrevious := catalogue.get()
+	catalogue.set(response, time.Now())
+	// The first channel list has nothing to be compared with
+	if hasPrevious {
+		if err := recordLineupChanges(previous.Result, response.Result, time.Now()); err != nil {
+			utils.SafeLogf("Error recording channel lineup changes: %v", err)
+		}
+	}
+	if err := saveChannelsSnapshot(body); err != nil {
+		utils.SafeLogf("Error saving channel list snapshot: %v", err)
+	}
+	return nil
+}
+
+// checkChannelsResponse rejects channel lists which must not replace the last good one,
+// like an empty list or a response with an error code
+func checkChannelsResponse(response ChannelsResponse) error {
+	if response.Code != 200 {
+		return fmt.Errorf("JioTV API returned code %d: %s", response.Code, response.Message)
+	}
+	if len(response.Result) == 0 {
+		return errors.New("JioTV API returned an empty channel list")
+	}
+	return nil
+}
+
+// getCachedChannels returns the channel list from cache, refreshing it from JioTV API when expired.
+// If JioTV API fails, the last good channel list is returned with Stale set.
+func getCachedChannels() (ChannelsResponse, error) {
+	if response, ok := catalogue.get(); ok && !response.Stale {
+		return response, nil
+	}
+
+	catalogueRefreshMutex.Lock()
+	defer catalogueRefreshMutex.Unlock()
+
+	// Another request may have refreshed the cache while we were waiting
+	response, ok := catalogue.get()
+	if ok && !response.Stale {
+		return response, nil
+	}
+
+	var err error
+	if catalogue.shouldRetry() {
+		if err = refreshChannelsLocked(); err == nil {
+			response, _ = catalogue.get()
+			return response, nil
+		}
+	}
+
+	// Upstream is unavailable, fall back to the last good channel list
+	if !ok {
+		if loadErr := loadChannelsSnapshot(); loadErr == nil {
+			response, ok = catalogue.get()
+		}
+	}
+	if ok {
+		utils.SafeLogf("Serving channel list fetched %s ago", time.Since(response.FetchedAt).Round(time.Second))
+		return response, nil
+	}
+	if err == nil {
+		err = errChannelsUnavailable
+	}
+	return ChannelsResponse{}, err
+}
+
+// saveChannelsSnapshot writes the raw channel list response to disk.
+// The file is written to a temporary file first so that a failed write never replaces a good snapshot.
+func saveChannelsSnapshot(body []byte) error {
+	path := channelsSnapshotPath()
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, body, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// loadChannelsSnapshot loads the channel list snapshot from disk into the cache.
+// The modification time of the file is used as the fetch time.
+func loadChannelsSnapshot() error {
+	path := channelsSnapshotPath()
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	response, err := parseChannelsResponse(data)
+	if err != nil {
+		return err
+	}
+	if err := checkChannelsResponse(response); err != nil {
+		return err
+	}
+
+	// Never replace newer data with the snapshot
+	if cached, ok := catalogue.get(); ok && !cached.FetchedAt.Before(stat.ModTime()) {
+		return nil
+	}
+	catalogue.set(response, stat.ModTime())
+	return nil
+}
@@ -0,0 +1,154 @@
+package television
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// resetCatalogue clears the channel list cache between tests
+func resetCatalogue() {
+	catalogue.mu.Lock()
+	defer catalogue.mu.Unlock()
+	catalogue.response = ChannelsResponse{}
+	catalogue.fetchedAt = time.Time{}
+	catalogue.retryAfter = time.Time{}
+}
+
+func TestGetCachedChannels_Fresh(t *testing.T) {
+	setupTest()
+	resetCatalogue()
+	defer resetCatalogue()
+
+	catalogue.set(ChannelsResponse{
+		Code:   200,
+		Result: []Channel{{ID: "1", Name: "Channel One"}},
+	}, time.Now())
+
+	response, err := getCachedChannels()
+	if err != nil {
+		t.Fatalf("getCachedChannels() error = %v", err)
+	}
+	if response.Stale {
+		t.Error("getCachedChannels() returned stale data for a fresh cache")
+	}
+	if len(response.Result) != 1 || response.Result[0].ID != "1" {
+		t.Fatalf("getCachedChannels() = %+v, want one channel with ID 1", response.Result)
+	}
+
+	// Modifying the returned channels must not affect the cache
+	response.Result[0].Name = "Modified"
+	cached, _ := catalogue.get()
+	if cached.Result[0].Name != "Channel One" {
+		t.Errorf("cache was modified through returned response, got name %q", cached.Result[0].Name)
+	}
+}
+
+func TestGetCachedChannels_StaleFallback(t *testing.T) {
+	setupTest()
+	resetCatalogue()
+	defer resetCatalogue()
+
+	fetchedAt := time.Now().Add(-2 * getChannelsCacheTTL())
+	catalogue.set(ChannelsResponse{
+		Result: []Channel{{ID: "1", Name: "Channel One"}},
+	}, fetchedAt)
+	// Pretend upstream just failed so no request is made
+	catalogue.backoff()
+
+	response, err := getCachedChannels()
+	if err != nil {
+		t.Fatalf("getCachedChannels() error = %v", err)
+	}
+	if !response.Stale {
+		t.Error("getCachedChannels() should mark expired data as stale")
+	}
+	if !response.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("FetchedAt = %v, want %v", response.FetchedAt, fetchedAt)
+	}
+}
+
+func TestGetCachedChannels_NoData(t *testing.T) {
+	setupTest()
+	resetCatalogue()
+	defer resetCatalogue()
+	os.Remove(channelsSnapshotPath())
+
+	catalogue.backoff()
+
+	if _, err := getCachedChannels(); err == nil {
+		t.Error("getCachedChannels() should fail without cache, snapshot or upstream")
+	}
+}
+
+func TestChannelsSnapshot(t *testing.T) {
+	setupTest()
+	resetCatalogue()
+	defer resetCatalogue()
+	defer os.Remove(channelsSnapshotPath())
+
+	body := []byte(`{"code":200,"message":"success","result":[{"channel_id":143,"channel_name":"Test","logoUrl":"test.png","channelCategoryId":5,"channelLanguageId":1,"isHD":true}]}`)
+	if err := saveChannelsSnapshot(body); err != nil {
+		t.Fatalf("saveChannelsSnapshot() error = %v", err)
+	}
+
+	if err := loadChannelsSnapshot(); err != nil {
+		t.Fatalf("loadChannelsSnapshot() error = %v", err)
+	}
+
+	response, ok := catalogue.get()
+	if !ok {
+		t.Fatal("loadChannelsSnapshot() did not populate the cache")
+	}
+	if len(response.Result) != 1 || response.Result[0].ID != "143" || !response.Result[0].IsHD {
+		t.Errorf("loaded channels = %+v, want channel 143", response.Result)
+	}
+	if time.Since(response.FetchedAt) > time.Minute {
+		t.Errorf("FetchedAt = %v, want snapshot modification time", response.FetchedAt)
+	}
+}
+
+func TestCheckChannelsResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response ChannelsResponse
+		wantErr  bool
+	}{
+		{"valid", ChannelsResponse{Code: 200, Result: []Channel{{ID: "1"}}}, false},
+		{"empty result", ChannelsResponse{Code: 200, Result: []Channel{}}, true},
+		{"error code", ChannelsResponse{Code: 500, Message: "Internal error", Result: []Channel{{ID: "1"}}}, true},
+		{"missing code", ChannelsResponse{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkChannelsResponse(tt.response); (err != nil) != tt.wantErr {
+				t.Errorf("checkChannelsResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChannelsSnapshot_Empty(t *testing.T) {
+	setupTest()
+	resetCatalogue()
+	defer resetCatalogue()
+	defer os.Remove(channelsSnapshotPath())
+
+	fetchedAt := time.Now().Add(-time.Hour)
+	catalogue.set(ChannelsResponse{
+		Code:   200,
+		Result: []Channel{{ID: "1", Name: "Channel One"}},
+	}, fetchedAt)
+
+	if err := saveChannelsSnapshot([]byte(`{"code":200,"message":"success","result":[]}`)); err != nil {
+		t.Fatalf("saveChannelsSnapshot() error = %v", err)
+	}
+	if err := loadChannelsSnapshot(); err == nil {
+		t.Error("loadChannelsSnapshot() should reject an empty channel list")
+	}
+
+	response, _ := catalogue.get()
+	if len(response.Result) != 1 || response.Result[0].ID != "1" {
+		t.Errorf("cached channels = %+v, want the previous list", response.Result)
+	}
+}
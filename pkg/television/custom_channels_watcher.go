@@ -0,0 +1,158 @@
+package television
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/tasks"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/scheduler"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// CUSTOM_CHANNELS_WATCH_TASK_ID is the ID of the task watching the custom channels file for changes
+	CUSTOM_CHANNELS_WATCH_TASK_ID = tasks.CustomChannelsWatchTaskID
+	// customChannelsWatchInterval is how often the custom channels file is checked for changes
+	customChannelsWatchInterval = 10 * time.Second
+)
+
+// customChannelsFileState tracks the last seen state of the custom channels file
+type customChannelsFileState struct {
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+}
+
+// customChannelsWatch holds the state of the custom channels file when it was last loaded
+var customChannelsWatch customChannelsFileState
+
+// changed reports whether the file changed since its state was last recorded, along with its current state.
+// A file which can't be stat'ed is reported as unchanged.
+func (s *customChannelsFileState) changed(path string) (os.FileInfo, bool) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return stat, !stat.ModTime().Equal(s.modTime) || stat.Size() != s.size
+}
+
+// record remembers the state of the file once it was loaded, so that the watcher only reloads it on changes.
+// The state is taken before the file is read, so a change made while it is read is still reloaded.
+func (s *customChannelsFileState) record(stat os.FileInfo) {
+	if stat == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.modTime = stat.ModTime()
+	s.size = stat.Size()
+}
+
+// WatchCustomChannels schedules a task which reloads the custom channels file whenever it changes.
+// Custom channels given as URL are fetched again at the configured refresh interval instead.
+func WatchCustomChannels() {
+	filePath := config.Cfg.CustomChannelsFile
+	if filePath == "" {
+		return
+	}
+	if isRemoteChannelsSource(filePath) {
+		go scheduler.Add(CUSTOM_CHANNELS_WATCH_TASK_ID, getCustomChannelsRefreshInterval(), refreshRemoteCustomChannels)
+		return
+	}
+	go scheduler.Add(CUSTOM_CHANNELS_WATCH_TASK_ID, customChannelsWatchInterval, checkCustomChannelsFile)
+}
+
+// getCustomChannelsRefreshInterval returns the configured refresh interval for custom channels given as URL
+func getCustomChannelsRefreshInterval() time.Duration {
+	interval := config.Cfg.CustomChannelsRefreshInterval
+	if interval <= 0 {
+		interval = constants.DefaultCustomChannelsRefreshInterval
+	}
+	return time.Duration(interval) * time.Minute
+}
+
+// refreshRemoteCustomChannels fetches custom channels from URL again.
+// If the download or the new playlist is invalid, the previously loaded channels are kept.
+func refreshRemoteCustomChannels() error {
+	filePath := config.Cfg.CustomChannelsFile
+	if err := reloadCustomChannels(filePath); err != nil {
+		utils.SafeLogf("Keeping previously loaded custom channels: %v", err)
+	}
+	return nil
+}
+
+// checkCustomChannelsFile reloads the custom channels file if it changed since it was last loaded.
+// If the new file is invalid, the previously loaded channels are kept.
+func checkCustomChannelsFile() error {
+	filePath := config.Cfg.CustomChannelsFile
+	if filePath == "" {
+		return nil
+	}
+	stat, changed := customChannelsWatch.changed(filePath)
+	if !changed {
+		return nil
+	}
+
+	utils.SafeLogf("Custom channels file %s changed, reloading", filePath)
+	if err := reloadCustomChannels(filePath); err != nil {
+		utils.SafeLogf("Keeping previously loaded custom channels: %v", err)
+		return nil
+	}
+	customChannelsWatch.record(stat)
+	return nil
+}
+
+// reloadCustomChannels parses and validates the custom channels file and swaps it in
+func reloadCustomChannels(filePath string) error {
+	channels, err := LoadCustomChannels(filePath)
+	if err != nil {
+		return err
+	}
+	if err := validateCustomChannels(channels); err != nil {
+		return fmt.Errorf("invalid custom channels file: %w", err)
+	}
+	setCustomChannels(channels)
+	return nil
+}
+
+// logDuplicateCustomChannels logs the IDs used by several custom channels.
+// Only the last channel with an ID is kept, as the channels are cached by ID.
+func logDuplicateCustomChannels(channels []Channel) {
+	seen := make(map[string]struct{}, len(channels))
+	for _, channel := range channels {
+		if _, exists := seen[channel.ID]; exists {
+			utils.SafeLogf("Custom channel %s: duplicate id, keeping the last channel with it", channel.ID)
+		}
+		seen[channel.ID] = struct{}{}
+	}
+}
+
+// validateCustomChannels checks that every custom channel has an ID, name and URL, and that IDs are unique.
+// Reloads are checked, so that a broken edit doesn't replace the channels which play.
+func validateCustomChannels(channels []Channel) error {
+	seen := make(map[string]struct{}, len(channels))
+	for i, channel := range channels {
+		if strings.TrimPrefix(channel.ID, "cc_") == "" {
+			return fmt.Errorf("channel %d: id is required", i+1)
+		}
+		if channel.Name == "" {
+			return fmt.Errorf("channel %s: name is required", channel.ID)
+		}
+		if channel.URL == "" {
+			return fmt.Errorf("channel %s: url is required", channel.ID)
+		}
+		if _, exists := seen[channel.ID]; exists {
+			return fmt.Errorf("channel %s: duplicate id", channel.ID)
+		}
+		seen[channel.ID] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package television
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestValidateCustomChannels(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels []Channel
+		wantErr  bool
+	}{
+		{
+			name:     "Valid channels",
+			channels: []Channel{{ID: "cc_1", Name: "One", URL: "https://example.com/1.m3u8"}, {ID: "cc_2", Name: "Two", URL: "https://example.com/2.m3u8"}},
+			wantErr:  false,
+		},
+		{
+			name:     "Empty list",
+			channels: []Channel{},
+			wantErr:  false,
+		},
+		{
+			name:     "Missing ID",
+			channels: []Channel{{ID: "cc_", Name: "One", URL: "https://example.com/1.m3u8"}},
+			wantErr:  true,
+		},
+		{
+			name:     "Missing name",
+			channels: []Channel{{ID: "cc_1", URL: "https://example.com/1.m3u8"}},
+			wantErr:  true,
+		},
+		{
+			name:     "Missing URL",
+			channels: []Channel{{ID: "cc_1", Name: "One"}},
+			wantErr:  true,
+		},
+		{
+			name:     "Duplicate ID",
+			channels: []Channel{{ID: "cc_1", Name: "One", URL: "https://example.com/1.m3u8"}, {ID: "cc_1", Name: "Two", URL: "https://example.com/2.m3u8"}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateCustomChannels(tt.channels); (err != nil) != tt.wantErr {
+				t.Errorf("validateCustomChannels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckCustomChannelsFile(t *testing.T) {
+	setupTest()
+
+	originalCustomChannelsFile := config.Cfg.CustomChannelsFile
+	defer func() {
+		config.Cfg.CustomChannelsFile = originalCustomChannelsFile
+	}()
+
+	customChannelsFile := filepath.Join(t.TempDir(), "channels.yml")
+	writeFile := func(content string, modTime time.Time) {
+		if err := os.WriteFile(customChannelsFile, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write custom channels file: %v", err)
+		}
+		// Set an explicit modification time so changes are detected regardless of filesystem resolution
+		if err := os.Chtimes(customChannelsFile, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+	}
+
+	now := time.Now()
+	writeFile("channels:\n  - id: first\n    name: First\n    url: https://example.com/first.m3u8\n", now.Add(-time.Minute))
+	config.Cfg.CustomChannelsFile = customChannelsFile
+	InitCustomChannels()
+
+	if _, exists := GetCustomChannelByID("cc_first"); !exists {
+		t.Fatal("Expected cc_first to be loaded at startup")
+	}
+
+	t.Run("Unchanged file is not reloaded", func(t *testing.T) {
+		setCustomChannels(nil)
+		if err := checkCustomChannelsFile(); err != nil {
+			t.Fatalf("checkCustomChannelsFile() error = %v", err)
+		}
+		if _, exists := GetCustomChannelByID("cc_first"); exists {
+			t.Error("Unchanged file should not be reloaded")
+		}
+		loadAndCacheCustomChannels()
+	})
+
+	t.Run("Changed file is reloaded", func(t *testing.T) {
+		writeFile("channels:\n  - id: second\n    name: Second\n    url: https://example.com/second.m3u8\n", now)
+		if err := checkCustomChannelsFile(); err != nil {
+			t.Fatalf("checkCustomChannelsFile() error = %v", err)
+		}
+		if _, exists := GetCustomChannelByID("cc_second"); !exists {
+			t.Error("Expected cc_second after reload")
+		}
+		if _, exists := GetCustomChannelByID("cc_first"); exists {
+			t.Error("Expected cc_first to be removed after reload")
+		}
+	})
+
+	t.Run("Invalid file keeps previous channels", func(t *testing.T) {
+		writeFile("channels: [this is: not valid", now.Add(time.Minute))
+		if err := checkCustomChannelsFile(); err != nil {
+			t.Fatalf("checkCustomChannelsFile() error = %v", err)
+		}
+		if _, exists := GetCustomChannelByID("cc_second"); !exists {
+			t.Error("Expected cc_second to be kept after invalid reload")
+		}
+		if _, changed := customChannelsWatch.changed(customChannelsFile); !changed {
+			t.Error("The state of a file which failed to load should not be recorded")
+		}
+	})
+
+	t.Run("Duplicate IDs at startup keep the last channel", func(t *testing.T) {
+		writeFile("channels:\n  - id: dup\n    name: First\n    url: https://example.com/first.m3u8\n  - id: dup\n    name: Last\n    url: https://example.com/last.m3u8\n", now.Add(90*time.Second))
+		loadAndCacheCustomChannels()
+		if channel, exists := GetCustomChannelByID("cc_dup"); !exists || channel.Name != "Last" {
+			t.Errorf("GetCustomChannelByID(cc_dup) = %+v, %v, want the last channel", channel, exists)
+		}
+		if _, changed := customChannelsWatch.changed(customChannelsFile); changed {
+			t.Error("The state of a loaded file should be recorded")
+		}
+		// Reloads still reject duplicates
+		writeFile("channels:\n  - id: dup\n    name: First\n    url: https://example.com/first.m3u8\n  - id: dup\n    name: Second\n    url: https://example.com/second.m3u8\n", now.Add(100*time.Second))
+		if err := checkCustomChannelsFile(); err != nil {
+			t.Fatalf("checkCustomChannelsFile() error = %v", err)
+		}
+		if channel, _ := GetCustomChannelByID("cc_dup"); channel.Name != "Last" {
+			t.Errorf("GetCustomChannelByID(cc_dup) = %+v, want the channel before the rejected reload", channel)
+		}
+		// Back to the channels the next test expects
+		writeFile("channels:\n  - id: second\n    name: Second\n    url: https://example.com/second.m3u8\n", now.Add(110*time.Second))
+		if err := checkCustomChannelsFile(); err != nil {
+			t.Fatalf("checkCustomChannelsFile() error = %v", err)
+		}
+	})
+
+	t.Run("File failing validation keeps previous channels", func(t *testing.T) {
+		writeFile("channels:\n  - id: third\n    name: Third\n", now.Add(2*time.Minute))
+		if err := checkCustomChannelsFile(); err != nil {
+			t.Fatalf("checkCustomChannelsFile() error = %v", err)
+		}
+		if _, exists := GetCustomChannelByID("cc_third"); exists {
+			t.Error("Channel without URL should not be loaded")
+		}
+		if _, exists := GetCustomChannelByID("cc_second"); !exists {
+			t.Error("Expected cc_second to be kept after failed validation")
+		}
+	})
+}
+
+func TestCustomChannelsConcurrentAccess(t *testing.T) {
+	setupTest()
+
+	channels := []Channel{{ID: "cc_concurrent", Name: "Concurrent", URL: "https://example.com/c.m3u8"}}
+	setCustomChannels(channels)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			setCustomChannels(channels)
+		}()
+		go func() {
+			defer wg.Done()
+			GetCustomChannelByID("cc_concurrent")
+			getCustomChannels()
+		}()
+	}
+	wg.Wait()
+
+	if _, exists := GetCustomChannelByID("cc_concurrent"); !exists {
+		t.Error("Expected cc_concurrent to be present")
+	}
+}
@@ -0,0 +1,58 @@
+package television
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/metrics"
+)
+
+// maxCustomChannelRedirects is the number of redirects followed when fetching a custom channel playlist
+const maxCustomChannelRedirects = 5
+
+// customChannelHeaders merges the headers, user_agent and referer options of a custom channel.
+// user_agent and referer take precedence over the same keys in headers.
+func customChannelHeaders(customChannel CustomChannel) map[string]string {
+	if len(customChannel.Headers) == 0 && customChannel.UserAgent == "" && customChannel.Referer == "" {
+		return nil
+	}
+	headers := make(map[string]string, len(customChannel.Headers)+2)
+	for key, value := range customChannel.Headers {
+		headers[key] = value
+	}
+	if customChannel.UserAgent != "" {
+		headers["User-Agent"] = customChannel.UserAgent
+	}
+	if customChannel.Referer != "" {
+		headers["Referer"] = customChannel.Referer
+	}
+	return headers
+}
+
+// RenderCustom fetches a custom channel playlist with the channel's headers.
+// Redirects are followed and the final URL is returned so relative URIs can be resolved against it.
+func (tv *Television) RenderCustom(playlistURL string, channel Channel) ([]byte, int, string, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+
+	req.SetRequestURI(playlistURL)
+	req.Header.SetMethod("GET")
+	for key, value := range channel.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	start := time.Now()
+	if err := tv.Client.DoRedirects(req, resp, maxCustomChannelRedirects); err != nil {
+		metrics.ObserveUpstream(metrics.EndpointRender, start, 0)
+		return nil, 0, "", fmt.Errorf("failed to fetch playlist of custom channel %s: %w", channel.ID, err)
+	}
+	metrics.ObserveUpstream(metrics.EndpointRender, start, resp.StatusCode())
+
+	body := append([]byte(nil), resp.Body()...)
+	return body, resp.StatusCode(), req.URI().String(), nil
+}
@@ -0,0 +1,115 @@
+package television
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestCustomChannelHeaders(t *testing.T) {
+	if headers := customChannelHeaders(CustomChannel{}); headers != nil {
+		t.Errorf("customChannelHeaders() = %v, want nil without options", headers)
+	}
+
+	headers := customChannelHeaders(CustomChannel{
+		Headers:   map[string]string{"Cookie": "a=b", "User-Agent": "ignored"},
+		UserAgent: "TestAgent/1.0",
+		Referer:   "https://example.com/",
+	})
+	want := map[string]string{"Cookie": "a=b", "User-Agent": "TestAgent/1.0", "Referer": "https://example.com/"}
+	if len(headers) != len(want) {
+		t.Fatalf("customChannelHeaders() = %v, want %v", headers, want)
+	}
+	for key, value := range want {
+		if headers[key] != value {
+			t.Errorf("header %s = %q, want %q", key, headers[key], value)
+		}
+	}
+}
+
+func TestLoadCustomChannels_Proxy(t *testing.T) {
+	setupTest()
+
+	filePath := filepath.Join(t.TempDir(), "channels.yml")
+	content := `channels:
+  - id: plain
+    name: Plain
+    url: https://example.com/plain.m3u8
+  - id: proxied
+    name: Proxied
+    url: https://example.com/proxied.m3u8
+    proxy: true
+  - id: headers
+    name: Headers
+    url: https://example.com/headers.m3u8
+    referer: https://example.com/
+    headers:
+      Cookie: session=1
+`
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write custom channels file: %v", err)
+	}
+
+	channels, err := LoadCustomChannels(filePath)
+	if err != nil {
+		t.Fatalf("LoadCustomChannels() error = %v", err)
+	}
+	if len(channels) != 3 {
+		t.Fatalf("LoadCustomChannels() returned %d channels, want 3", len(channels))
+	}
+	if channels[0].Proxy {
+		t.Error("channel without proxy options should not be proxied")
+	}
+	if !channels[1].Proxy {
+		t.Error("channel with proxy: true should be proxied")
+	}
+	if !channels[2].Proxy {
+		t.Error("channel with headers should be proxied")
+	}
+	if channels[2].Headers["Cookie"] != "session=1" || channels[2].Headers["Referer"] != "https://example.com/" {
+		t.Errorf("channel headers = %v", channels[2].Headers)
+	}
+}
+
+func TestRenderCustom(t *testing.T) {
+	setupTest()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/redirect.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/live/index.m3u8", http.StatusFound)
+	})
+	mux.HandleFunc("/live/index.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		if r.UserAgent() != "TestAgent/1.0" || r.Header.Get("Cookie") != "session=1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("#EXTM3U\n"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tv := &Television{Client: &fasthttp.Client{}}
+	channel := Channel{
+		ID:      "cc_test",
+		Headers: map[string]string{"User-Agent": "TestAgent/1.0", "Cookie": "session=1"},
+		Proxy:   true,
+	}
+
+	body, statusCode, finalURL, err := tv.RenderCustom(server.URL+"/redirect.m3u8", channel)
+	if err != nil {
+		t.Fatalf("RenderCustom() error = %v", err)
+	}
+	if statusCode != http.StatusOK {
+		t.Fatalf("RenderCustom() status = %d, want 200 (headers not sent?)", statusCode)
+	}
+	if string(body) != "#EXTM3U\n" {
+		t.Errorf("RenderCustom() body = %q", body)
+	}
+	if finalURL != server.URL+"/live/index.m3u8" {
+		t.Errorf("RenderCustom() final URL = %q, want %q", finalURL, server.URL+"/live/index.m3u8")
+	}
+}
@@ -0,0 +1,58 @@
+package television
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/metrics"
+)
+
+// SetKeyHeaders sets the cookies and headers with which JioTV serves the AES-128 key of a channel's stream.
+// JioTV authenticates key requests with cookies, so the params of the key URL are sent as cookies.
+func (tv *Television) SetKeyHeaders(header *fasthttp.RequestHeader, keyURL, channelID string) {
+	if _, params, found := strings.Cut(keyURL, "?"); found {
+		for _, param := range strings.Split(params, "&") {
+			key, value, found := strings.Cut(param, "=")
+			if !found {
+				continue
+			}
+			header.SetCookie(key, value)
+			// the token is also expected in the __hdnea__ cookie
+			if key == "hdnea" {
+				header.SetCookie("__hdnea__", value)
+			}
+		}
+	}
+
+	for key, value := range tv.Headers {
+		header.Set(key, value)
+	}
+	header.Set("srno", "230203144000")
+	header.Set("ssotoken", tv.SsoToken)
+	header.Set("channelId", channelID)
+	header.Set("User-Agent", headers.UserAgentPlayTV)
+}
+
+// Key fetches the AES-128 key of a channel's stream. It returns the key and the status code of the response.
+func (tv *Television) Key(keyURL, channelID string) ([]byte, int, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(keyURL)
+	req.Header.SetMethod("GET")
+	tv.SetKeyHeaders(&req.Header, keyURL, channelID)
+
+	start := time.Now()
+	if err := tv.Client.Do(req, resp); err != nil {
+		metrics.ObserveUpstream(metrics.EndpointKey, start, 0)
+		return nil, 0, fmt.Errorf("failed to fetch key of channel %s: %w", channelID, err)
+	}
+	metrics.ObserveUpstream(metrics.EndpointKey, start, resp.StatusCode())
+	return append([]byte(nil), resp.Body()...), resp.StatusCode(), nil
+}
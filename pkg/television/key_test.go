@@ -0,0 +1,35 @@
+package television
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("__hdnea__")
+		if err != nil || cookie.Value != "st=1~exp=2" || r.Header.Get("channelId") != "143" || r.Header.Get("ssotoken") != "sso" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("0123456789abcdef"))
+	}))
+	defer server.Close()
+
+	tv := &Television{Client: &fasthttp.Client{}, SsoToken: "sso", Headers: map[string]string{}}
+	key, statusCode, err := tv.Key(server.URL+"/key?hdnea=st=1~exp=2", "143")
+	if err != nil {
+		t.Fatalf("Key() error = %v", err)
+	}
+	if statusCode != http.StatusOK || string(key) != "0123456789abcdef" {
+		t.Errorf("Key() = %q, %d, want the key (cookies or headers not sent?)", key, statusCode)
+	}
+
+	// URLs without params must not panic
+	if _, _, err := tv.Key(server.URL+"/key", "143"); err != nil {
+		t.Errorf("Key() without params error = %v", err)
+	}
+}
@@ -0,0 +1,297 @@
+package television
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+const (
+	// lineupChangesKey is the store key of the channel lineup changes
+	lineupChangesKey = "channel_changes"
+	// ghostChannelsKey is the store key of the removed channels kept in the channel list
+	ghostChannelsKey = "ghost_channels"
+	// maxLineupChanges is the number of lineup changes kept, the oldest being dropped first
+	maxLineupChanges = 1000
+)
+
+// LineupChangeType is the kind of a change of the JioTV channel lineup
+type LineupChangeType string
+
+const (
+	ChannelAdded   LineupChangeType = "added"
+	ChannelRemoved LineupChangeType = "removed"
+	// ChannelRenumbered is a channel which was removed and added again with another ID
+	ChannelRenumbered      LineupChangeType = "renumbered"
+	ChannelRenamed         LineupChangeType = "renamed"
+	ChannelCategoryChanged LineupChangeType = "category"
+	ChannelLanguageChanged LineupChangeType = "language"
+	ChannelHDChanged       LineupChangeType = "hd"
+)
+
+// LineupChange is a change of a channel between two fetches of the JioTV channel list
+type LineupChange struct {
+	Time      time.Time        `json:"time"`
+	Type      LineupChangeType `json:"type"`
+	ChannelID string           `json:"channel_id"`
+	Name      string           `json:"name"`
+	// Old and New are the values before and after the change, like the names of a renamed channel
+	// or the IDs of a renumbered one. Categories and languages are given by name.
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+// ghostChannel is a channel removed from JioTV, kept in the channel list for a grace period
+// so that the positions of the other channels in playlists don't change
+type ghostChannel struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	LogoURL  string `json:"logo_url"`
+	Category int    `json:"category"`
+	Language int    `json:"language"`
+	IsHD     bool   `json:"is_hd"`
+	// Position is the index of the channel in the channel list before it was removed
+	Position  int       `json:"position"`
+	RemovedAt time.Time `json:"removed_at"`
+}
+
+var (
+	// lineupMutex guards ghosts and serializes updates of the stored changes
+	lineupMutex sync.RWMutex
+	// ghosts are the removed channels still in their grace period
+	ghosts []ghostChannel
+)
+
+// getGhostChannelPeriod returns the configured time for which removed channels are kept
+func getGhostChannelPeriod() time.Duration {
+	days := config.Cfg.GhostChannelDays
+	if days <= 0 {
+		days = constants.DefaultGhostChannelDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// diffChannels returns the changes from the previous channel list to the current one
+func diffChannels(previous, current []Channel, at time.Time) []LineupChange {
+	previousByID := make(map[string]Channel, len(previous))
+	for _, channel := range previous {
+		previousByID[channel.ID] = channel
+	}
+	currentByID := make(map[string]Channel, len(current))
+	for _, channel := range current {
+		currentByID[channel.ID] = channel
+	}
+
+	var changes, added []LineupChange
+	for _, channel := range current {
+		old, ok := previousByID[channel.ID]
+		if !ok {
+			added = append(added, LineupChange{Time: at, Type: ChannelAdded, ChannelID: channel.ID, Name: channel.Name})
+			continue
+		}
+		change := LineupChange{Time: at, ChannelID: channel.ID, Name: channel.Name}
+		if old.Name != channel.Name {
+			change.Type, change.Old, change.New = ChannelRenamed, old.Name, channel.Name
+			changes = append(changes, change)
+		}
+		if old.Category != channel.Category {
+			change.Type, change.Old, change.New = ChannelCategoryChanged, CategoryMap[old.Category], CategoryMap[channel.Category]
+			changes = append(changes, change)
+		}
+		if old.Language != channel.Language {
+			change.Type, change.Old, change.New = ChannelLanguageChanged, LanguageMap[old.Language], LanguageMap[channel.Language]
+			changes = append(changes, change)
+		}
+		if old.IsHD != channel.IsHD {
+			change.Type, change.Old, change.New = ChannelHDChanged, definition(old.IsHD), definition(channel.IsHD)
+			changes = append(changes, change)
+		}
+	}
+
+	for _, channel := range previous {
+		if _, ok := currentByID[channel.ID]; ok {
+			continue
+		}
+		// A channel added with the same name is the removed one with a new ID
+		renumbered := false
+		for i, addition := range added {
+			if strings.EqualFold(strings.TrimSpace(addition.Name), strings.TrimSpace(channel.Name)) {
+				changes = append(changes, LineupChange{Time: at, Type: ChannelRenumbered, ChannelID: addition.ChannelID, Name: addition.Name, Old: channel.ID, New: addition.ChannelID})
+				added = append(added[:i], added[i+1:]...)
+				renumbered = true
+				break
+			}
+		}
+		if !renumbered {
+			changes = append(changes, LineupChange{Time: at, Type: ChannelRemoved, ChannelID: channel.ID, Name: channel.Name})
+		}
+	}
+	return append(changes, added...)
+}
+
+// definition returns the name of the HD status of a channel
+func definition(isHD bool) string {
+	if isHD {
+		return "HD"
+	}
+	return "SD"
+}
+
+// recordLineupChanges saves the changes from the previous channel list to the current one,
+// and keeps the removed channels as ghosts for the grace period
+func recordLineupChanges(previous, current []Channel, at time.Time) error {
+	changes := diffChannels(previous, current, at)
+
+	lineupMutex.Lock()
+	defer lineupMutex.Unlock()
+
+	currentIDs := make(map[string]bool, len(current))
+	for _, channel := range current {
+		currentIDs[channel.ID] = true
+	}
+	// Ghosts which came back or whose grace period is over are dropped
+	kept := make([]ghostChannel, 0, len(ghosts))
+	for _, ghost := range ghosts {
+		if !currentIDs[ghost.ID] && at.Sub(ghost.RemovedAt) < getGhostChannelPeriod() {
+			kept = append(kept, ghost)
+		}
+	}
+	for i, channel := range previous {
+		if currentIDs[channel.ID] {
+			continue
+		}
+		for _, change := range changes {
+			if change.Type == ChannelRemoved && change.ChannelID == channel.ID {
+				kept = append(kept, ghostChannel{
+					ID:        channel.ID,
+					Name:      channel.Name,
+					LogoURL:   channel.LogoURL,
+					Category:  channel.Category,
+					Language:  channel.Language,
+					IsHD:      channel.IsHD,
+					Position:  i,
+					RemovedAt: at,
+				})
+			}
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Position < kept[j].Position })
+	if err := saveJSON(ghostChannelsKey, kept); err != nil {
+		return err
+	}
+	ghosts = kept
+
+	if len(changes) == 0 {
+		return nil
+	}
+	utils.SafeLogf("Channel lineup changed: %d changes", len(changes))
+	saved, err := loadLineupChanges()
+	if err != nil {
+		return err
+	}
+	saved = append(saved, changes...)
+	if len(saved) > maxLineupChanges {
+		saved = saved[len(saved)-maxLineupChanges:]
+	}
+	return saveJSON(lineupChangesKey, saved)
+}
+
+// withGhostChannels inserts the ghost channels at their former positions of the channel list
+func withGhostChannels(channels []Channel, now time.Time) []Channel {
+	lineupMutex.RLock()
+	defer lineupMutex.RUnlock()
+
+	present := make(map[string]bool, len(channels))
+	for _, channel := range channels {
+		present[channel.ID] = true
+	}
+	for _, ghost := range ghosts {
+		if present[ghost.ID] || now.Sub(ghost.RemovedAt) >= getGhostChannelPeriod() {
+			continue
+		}
+		channel := Channel{
+			ID:       ghost.ID,
+			Name:     ghost.Name,
+			LogoURL:  ghost.LogoURL,
+			Category: ghost.Category,
+			Language: ghost.Language,
+			IsHD:     ghost.IsHD,
+			Ghost:    true,
+		}
+		position := min(ghost.Position, len(channels))
+		channels = append(channels[:position], append([]Channel{channel}, channels[position:]...)...)
+	}
+	return channels
+}
+
+// loadGhostChannels loads the ghost channels saved in the store
+func loadGhostChannels() error {
+	var saved []ghostChannel
+	if err := loadJSON(ghostChannelsKey, &saved); err != nil {
+		return err
+	}
+	lineupMutex.Lock()
+	ghosts = saved
+	lineupMutex.Unlock()
+	return nil
+}
+
+// LineupChanges returns the changes of the channel lineup since a time, newest first
+func LineupChanges(since time.Time) ([]LineupChange, error) {
+	lineupMutex.RLock()
+	defer lineupMutex.RUnlock()
+	saved, err := loadLineupChanges()
+	if err != nil {
+		return nil, err
+	}
+	changes := []LineupChange{}
+	for i := len(saved) - 1; i >= 0; i-- {
+		if saved[i].Time.Before(since) {
+			break
+		}
+		changes = append(changes, saved[i])
+	}
+	return changes, nil
+}
+
+// loadLineupChanges returns the saved changes, oldest first
+func loadLineupChanges() ([]LineupChange, error) {
+	var changes []LineupChange
+	if err := loadJSON(lineupChangesKey, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// loadJSON decodes the JSON value of a store key. A missing key leaves v unchanged.
+func loadJSON(key string, v interface{}) error {
+	value, err := store.Get(key)
+	if errors.Is(err, store.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return fmt.Errorf("invalid %s in store: %w", key, err)
+	}
+	return nil
+}
+
+// saveJSON saves a value as JSON under a store key
+func saveJSON(key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return store.Set(key, string(value))
+}
@@ -0,0 +1,111 @@
+package television
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+)
+
+// resetLineup forgets the recorded lineup changes and ghost channels
+func resetLineup() {
+	store.Delete(lineupChangesKey)
+	store.Delete(ghostChannelsKey)
+	lineupMutex.Lock()
+	ghosts = nil
+	lineupMutex.Unlock()
+}
+
+func TestDiffChannels(t *testing.T) {
+	at := time.Now()
+	previous := []Channel{
+		{ID: "1", Name: "News", Category: 12, Language: 1},
+		{ID: "2", Name: "Movies", Category: 6, Language: 1},
+		{ID: "3", Name: "Sports", Category: 8, Language: 6},
+		{ID: "4", Name: "Kids", Category: 7, Language: 6},
+	}
+	current := []Channel{
+		{ID: "1", Name: "News 24", Category: 12, Language: 6, IsHD: true},
+		{ID: "2", Name: "Movies", Category: 5, Language: 1},
+		{ID: "5", Name: "Music", Category: 13, Language: 1},
+		{ID: "6", Name: "sports ", Category: 8, Language: 6},
+	}
+
+	want := []LineupChange{
+		{Type: ChannelRenamed, ChannelID: "1", Name: "News 24", Old: "News", New: "News 24"},
+		{Type: ChannelLanguageChanged, ChannelID: "1", Name: "News 24", Old: "Hindi", New: "English"},
+		{Type: ChannelHDChanged, ChannelID: "1", Name: "News 24", Old: "SD", New: "HD"},
+		{Type: ChannelCategoryChanged, ChannelID: "2", Name: "Movies", Old: "Movies", New: "Entertainment"},
+		{Type: ChannelRenumbered, ChannelID: "6", Name: "sports ", Old: "3", New: "6"},
+		{Type: ChannelRemoved, ChannelID: "4", Name: "Kids"},
+		{Type: ChannelAdded, ChannelID: "5", Name: "Music"},
+	}
+	got := diffChannels(previous, current, at)
+	if len(got) != len(want) {
+		t.Fatalf("diffChannels() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		want[i].Time = at
+		if got[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if changes := diffChannels(previous, previous, at); len(changes) != 0 {
+		t.Errorf("diffChannels() of the same list = %+v, want no changes", changes)
+	}
+}
+
+func TestRecordLineupChanges(t *testing.T) {
+	setupTest()
+	resetLineup()
+	defer resetLineup()
+
+	start := time.Now()
+	previous := []Channel{{ID: "1", Name: "One"}, {ID: "2", Name: "Two"}, {ID: "3", Name: "Three"}}
+	current := []Channel{{ID: "1", Name: "One"}, {ID: "3", Name: "Three"}, {ID: "4", Name: "Four"}}
+	if err := recordLineupChanges(previous, current, start); err != nil {
+		t.Fatalf("recordLineupChanges() error = %v", err)
+	}
+
+	// The removed channel keeps its position during the grace period
+	list := withGhostChannels(append([]Channel(nil), current...), start.Add(time.Hour))
+	if len(list) != 4 || list[1].ID != "2" || !list[1].Ghost || list[2].ID != "3" {
+		t.Errorf("withGhostChannels() = %+v, want channel 2 as a ghost at its former position", list)
+	}
+	if list := withGhostChannels(append([]Channel(nil), current...), start.Add(getGhostChannelPeriod())); len(list) != 3 {
+		t.Errorf("withGhostChannels() after the grace period = %+v, want no ghosts", list)
+	}
+
+	later := start.Add(time.Minute)
+	if err := recordLineupChanges(current, previous, later); err != nil {
+		t.Fatalf("recordLineupChanges() error = %v", err)
+	}
+	// Channel 2 came back, and channel 4 is a ghost at its former position
+	list = withGhostChannels(append([]Channel(nil), previous...), later)
+	if len(list) != 4 || list[1].Ghost || list[2].ID != "4" || !list[2].Ghost {
+		t.Errorf("withGhostChannels() = %+v, want only channel 4 as a ghost", list)
+	}
+
+	changes, err := LineupChanges(time.Time{})
+	if err != nil {
+		t.Fatalf("LineupChanges() error = %v", err)
+	}
+	if len(changes) != 4 || !changes[0].Time.Equal(later) || !changes[3].Time.Equal(start) {
+		t.Errorf("LineupChanges() = %+v, want the 4 changes newest first", changes)
+	}
+	if changes, _ := LineupChanges(later); len(changes) != 2 {
+		t.Errorf("LineupChanges(later) = %+v, want the 2 latest changes", changes)
+	}
+
+	// Ghosts are restored from the store
+	lineupMutex.Lock()
+	ghosts = nil
+	lineupMutex.Unlock()
+	if err := loadGhostChannels(); err != nil {
+		t.Fatalf("loadGhostChannels() error = %v", err)
+	}
+	if list := withGhostChannels(nil, later); len(list) != 1 || list[0].ID != "4" {
+		t.Errorf("ghosts after loading = %+v, want channel 4", list)
+	}
+}
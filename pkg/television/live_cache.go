@@ -0,0 +1,134 @@
+package television
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
+)
+
+// liveURLExpiryMargin is subtracted from the hdnea token expiry so players get a URL which is still valid
+// for a while after it is served
+const liveURLExpiryMargin = time.Minute
+
+// liveCacheEntry is a cached Live response of a channel
+type liveCacheEntry struct {
+	output  LiveURLOutput
+	expires time.Time
+}
+
+// liveURLCache holds Live responses per account and channel, so switching channels doesn't wait for the playback API
+type liveURLCache struct {
+	mu      sync.Mutex
+	entries map[string]liveCacheEntry
+}
+
+var liveCache = &liveURLCache{entries: make(map[string]liveCacheEntry)}
+
+// getLiveURLCacheTTL returns the configured fallback TTL for live URLs without hdnea expiry
+func getLiveURLCacheTTL() time.Duration {
+	ttl := config.Cfg.LiveURLCacheTTL
+	if ttl <= 0 {
+		ttl = constants.DefaultLiveURLCacheTTL
+	}
+	return time.Duration(ttl) * time.Minute
+}
+
+// LiveCached returns the live stream URLs of a channel like Live, reusing the last response
+// until its hdnea token expires or, without a token, until the configured TTL has passed.
+func (tv *Television) LiveCached(channelID string) (*LiveURLOutput, error) {
+	key := tv.liveCacheKey(channelID)
+	if output, ok := liveCache.get(key); ok {
+		return output, nil
+	}
+
+	output, err := tv.Live(channelID)
+	if err != nil {
+		return nil, err
+	}
+	// Responses without a stream are not worth keeping
+	if output.Bitrates.Auto != "" || output.Mpd.Result != "" {
+		liveCache.set(key, *output, liveURLExpiry(output.Hdnea, time.Now()))
+	}
+	return output, nil
+}
+
+// InvalidateLive removes the cached live URLs of a channel, e.g. after the stream returned 403
+func (tv *Television) InvalidateLive(channelID string) {
+	liveCache.mu.Lock()
+	defer liveCache.mu.Unlock()
+	delete(liveCache.entries, tv.liveCacheKey(channelID))
+}
+
+// liveCacheKey returns the cache key of a channel. Live URLs are tied to the account which requested them.
+func (tv *Television) liveCacheKey(channelID string) string {
+	return tv.Crm + "/" + channelID
+}
+
+// ClearLiveCache removes all cached live URLs, e.g. after login or logout
+func ClearLiveCache() {
+	liveCache.mu.Lock()
+	defer liveCache.mu.Unlock()
+	liveCache.entries = make(map[string]liveCacheEntry)
+}
+
+// get returns a copy of the cached response of a channel if it has not expired
+func (l *liveURLCache) get(channelID string) (*LiveURLOutput, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.entries[channelID]
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(entry.expires) {
+		delete(l.entries, channelID)
+		return nil, false
+	}
+	output := entry.output
+	return &output, true
+}
+
+// set stores the response of a channel until expires. Responses which are already expired are not stored.
+func (l *liveURLCache) set(channelID string, output LiveURLOutput, expires time.Time) {
+	if !time.Now().Before(expires) {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[channelID] = liveCacheEntry{output: output, expires: expires}
+}
+
+// liveURLExpiry returns until when live URLs with the given hdnea token can be reused.
+// The expiry is taken from the exp field of the token, or the configured TTL if there is none.
+func liveURLExpiry(hdnea string, now time.Time) time.Time {
+	if exp, ok := hdneaExpiry(hdnea); ok {
+		return exp.Add(-liveURLExpiryMargin)
+	}
+	return now.Add(getLiveURLCacheTTL())
+}
+
+// hdneaExpiry parses the exp field of an Akamai token like st=1700000000~exp=1700003600~acl=/*~hmac=...
+func hdneaExpiry(hdnea string) (time.Time, bool) {
+	if hdnea == "" {
+		return time.Time{}, false
+	}
+	if unescaped, err := url.QueryUnescape(hdnea); err == nil {
+		hdnea = unescaped
+	}
+	for _, field := range strings.Split(hdnea, "~") {
+		value, found := strings.CutPrefix(field, "exp=")
+		if !found {
+			continue
+		}
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(seconds, 0), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,94 @@
+package television
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/internal/config"
+)
+
+func TestHdneaExpiry(t *testing.T) {
+	tests := []struct {
+		name   string
+		hdnea  string
+		want   int64
+		wantOK bool
+	}{
+		{name: "Plain token", hdnea: "st=1700000000~exp=1700003600~acl=/*~hmac=abc", want: 1700003600, wantOK: true},
+		{name: "Escaped token", hdnea: "st%3D1700000000%7Eexp%3D1700003600%7Eacl%3D%2F*%7Ehmac%3Dabc", want: 1700003600, wantOK: true},
+		{name: "No exp field", hdnea: "st=1700000000~acl=/*~hmac=abc"},
+		{name: "Invalid exp", hdnea: "exp=soon~hmac=abc"},
+		{name: "Empty", hdnea: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := hdneaExpiry(tt.hdnea)
+			if ok != tt.wantOK {
+				t.Fatalf("hdneaExpiry() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got.Unix() != tt.want {
+				t.Errorf("hdneaExpiry() = %d, want %d", got.Unix(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLiveURLExpiry(t *testing.T) {
+	originalTTL := config.Cfg.LiveURLCacheTTL
+	defer func() { config.Cfg.LiveURLCacheTTL = originalTTL }()
+
+	now := time.Unix(1700000000, 0)
+	if got := liveURLExpiry("exp=1700003600~hmac=abc", now); !got.Equal(time.Unix(1700003600, 0).Add(-liveURLExpiryMargin)) {
+		t.Errorf("liveURLExpiry() with token = %v, want token expiry minus margin", got)
+	}
+
+	config.Cfg.LiveURLCacheTTL = 0
+	if got := liveURLExpiry("", now); !got.Equal(now.Add(5 * time.Minute)) {
+		t.Errorf("liveURLExpiry() without token = %v, want default TTL", got)
+	}
+	config.Cfg.LiveURLCacheTTL = 10
+	if got := liveURLExpiry("", now); !got.Equal(now.Add(10 * time.Minute)) {
+		t.Errorf("liveURLExpiry() without token = %v, want configured TTL", got)
+	}
+}
+
+func TestLiveCached(t *testing.T) {
+	setupTest()
+	ClearLiveCache()
+	defer ClearLiveCache()
+
+	output := LiveURLOutput{Code: 200}
+	output.Bitrates.Auto = "https://example.com/index.m3u8?hdnea=token"
+	tv := &Television{Crm: "crm-1"}
+	liveCache.set(tv.liveCacheKey("143"), output, time.Now().Add(time.Hour))
+	liveCache.set(tv.liveCacheKey("144"), output, time.Now().Add(-time.Second))
+
+	got, err := tv.LiveCached("143")
+	if err != nil {
+		t.Fatalf("LiveCached() error = %v", err)
+	}
+	if got.Bitrates.Auto != output.Bitrates.Auto {
+		t.Errorf("LiveCached() = %q, want cached URL", got.Bitrates.Auto)
+	}
+
+	// Changes by callers must not affect the cache
+	got.Bitrates.Auto = "modified"
+	if cached, _ := liveCache.get(tv.liveCacheKey("143")); cached.Bitrates.Auto != output.Bitrates.Auto {
+		t.Error("cache was modified through returned output")
+	}
+
+	if _, ok := liveCache.get(tv.liveCacheKey("144")); ok {
+		t.Error("expired entries should not be returned")
+	}
+
+	// Live URLs of one account are not shared with another
+	other := &Television{Crm: "crm-2"}
+	if _, ok := liveCache.get(other.liveCacheKey("143")); ok {
+		t.Error("cached entry was returned for another account")
+	}
+
+	tv.InvalidateLive("143")
+	if _, ok := liveCache.get(tv.liveCacheKey("143")); ok {
+		t.Error("InvalidateLive() did not remove the entry")
+	}
+}
@@ -0,0 +1,219 @@
+package television
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+)
+
+// m3uAttributePattern matches key="value" attributes of an #EXTINF line
+var m3uAttributePattern = regexp.MustCompile(`([A-Za-z0-9_-]+)="([^"]*)"`)
+
+// m3uIDPattern matches characters which are not allowed in generated channel IDs
+var m3uIDPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// isRemoteChannelsSource reports whether the custom channels source is an HTTP(S) URL
+func isRemoteChannelsSource(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// fetchRemoteChannelsFile downloads custom channels file from the given URL
+func fetchRemoteChannelsFile(url string) ([]byte, error) {
+	resp, err := utils.MakeHTTPRequest(utils.HTTPRequestConfig{
+		URL:    url,
+		Method: "GET",
+	}, utils.GetRequestClient())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch custom channels from %s: %w", url, err)
+	}
+	defer fasthttp.ReleaseResponse(resp)
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, fmt.Errorf("failed to fetch custom channels from %s: status code %d", url, resp.StatusCode())
+	}
+	return append([]byte(nil), resp.Body()...), nil
+}
+
+// isM3UFile reports whether the file path or URL has an M3U extension
+func isM3UFile(filePath string) bool {
+	if i := strings.IndexByte(filePath, '?'); i != -1 {
+		filePath = filePath[:i]
+	}
+	filePath = strings.ToLower(filePath)
+	return strings.HasSuffix(filePath, ".m3u") || strings.HasSuffix(filePath, ".m3u8")
+}
+
+// isM3UContent reports whether the data looks like an extended M3U playlist
+func isM3UContent(data []byte) bool {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // UTF-8 BOM
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("#EXTM3U"))
+}
+
+// parseM3UChannels parses an extended M3U playlist into custom channels.
+// tvg-id, tvg-name, tvg-logo, tvg-language and group-title attributes of #EXTINF lines are used
+// when present. Entries without tvg-id get an ID derived from their name.
+func parseM3UChannels(data []byte) (CustomChannelsConfig, error) {
+	var customConfig CustomChannelsConfig
+	var current *CustomChannel
+	usedIDs := make(map[string]int)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#EXTINF:"):
+			channel := parseM3UExtInf(line)
+			current = &channel
+		case strings.HasPrefix(line, "#EXTVLCOPT:"):
+			if current != nil {
+				parseM3UVLCOption(current, strings.TrimPrefix(line, "#EXTVLCOPT:"))
+			}
+		case strings.HasPrefix(line, "#"):
+			// Ignore other directives such as #EXTM3U and #EXTGRP
+			continue
+		default:
+			// A URL line completes the current entry. URLs without #EXTINF are skipped.
+			if current == nil {
+				continue
+			}
+			current.URL = line
+			current.ID = uniqueM3UChannelID(current.ID, current.Name, usedIDs)
+			customConfig.Channels = append(customConfig.Channels, *current)
+			current = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return customConfig, fmt.Errorf("failed to read M3U playlist: %w", err)
+	}
+	return customConfig, nil
+}
+
+// parseM3UExtInf parses a single #EXTINF line into a custom channel without URL
+func parseM3UExtInf(line string) CustomChannel {
+	attributes := make(map[string]string)
+	for _, match := range m3uAttributePattern.FindAllStringSubmatch(line, -1) {
+		attributes[strings.ToLower(match[1])] = strings.TrimSpace(match[2])
+	}
+
+	// Display name follows the first comma outside of quoted attribute values
+	name := ""
+	inQuotes := false
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ',' && !inQuotes {
+			name = strings.TrimSpace(line[i+1:])
+			break
+		}
+	}
+	if name == "" {
+		name = attributes["tvg-name"]
+	}
+
+	groupTitle := attributes["group-title"]
+	language := attributes["tvg-language"]
+	if language == "" {
+		language = groupTitle
+	}
+
+	return CustomChannel{
+		ID:       attributes["tvg-id"],
+		Name:     name,
+		LogoURL:  attributes["tvg-logo"],
+		Category: m3uCategoryID(groupTitle),
+		Language: m3uLanguageID(language),
+		IsHD:     isHDName(name),
+	}
+}
+
+// parseM3UVLCOption applies the http-user-agent and http-referrer options of an #EXTVLCOPT line
+func parseM3UVLCOption(channel *CustomChannel, option string) {
+	key, value, found := strings.Cut(option, "=")
+	if !found {
+		return
+	}
+	switch strings.ToLower(strings.TrimSpace(key)) {
+	case "http-user-agent":
+		channel.UserAgent = strings.TrimSpace(value)
+	case "http-referrer", "http-referer":
+		channel.Referer = strings.TrimSpace(value)
+	}
+}
+
+// uniqueM3UChannelID returns a channel ID which has not been used yet in the playlist.
+// If id is empty, it is derived from the channel name.
+func uniqueM3UChannelID(id, name string, usedIDs map[string]int) string {
+	if id == "" {
+		id = strings.Trim(m3uIDPattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	}
+	if id == "" {
+		id = "channel"
+	}
+	usedIDs[id]++
+	if count := usedIDs[id]; count > 1 {
+		return id + "_" + strconv.Itoa(count)
+	}
+	return id
+}
+
+// isHDName reports whether a channel name marks the channel as HD
+func isHDName(name string) bool {
+	for _, field := range strings.Fields(strings.ToUpper(name)) {
+		if field == "HD" || field == "FHD" || field == "UHD" || field == "4K" {
+			return true
+		}
+	}
+	return false
+}
+
+// m3uCategoryID maps an M3U group title to a CategoryMap ID, falling back to OtherCategoryID
+func m3uCategoryID(groupTitle string) int {
+	if id, ok := lookupM3UName(groupTitle, CategoryMap); ok {
+		return id
+	}
+	return OtherCategoryID
+}
+
+// m3uLanguageID maps an M3U language name to a LanguageMap ID, falling back to OtherLanguageID
+func m3uLanguageID(language string) int {
+	if id, ok := lookupM3UName(language, LanguageMap); ok {
+		return id
+	}
+	return OtherLanguageID
+}
+
+// lookupM3UName finds the ID of a name in a category or language map.
+// Names are compared case-insensitively, and combined titles like "Movies - Hindi" or "News;English"
+// are matched by their parts. ID 0 ("All ...") is never returned.
+func lookupM3UName(value string, names map[int]string) (int, bool) {
+	candidates := []string{value}
+	candidates = append(candidates, strings.FieldsFunc(value, func(r rune) bool {
+		return r == '-' || r == '|' || r == ';' || r == '/' || r == ','
+	})...)
+
+	for _, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "" {
+			continue
+		}
+		for id, name := range names {
+			if id == 0 {
+				continue
+			}
+			if strings.EqualFold(candidate, name) || strings.EqualFold(candidate+"s", name) {
+				return id, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,121 @@
+package television
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testM3UPlaylist = `#EXTM3U x-tvg-url="https://example.com/epg.xml"
+#EXTINF:-1 tvg-id="news.one" tvg-name="News One" tvg-logo="https://example.com/news.png" tvg-language="English" group-title="News",News One HD
+https://example.com/news/index.m3u8
+#EXTINF:-1 tvg-logo="https://example.com/movies.png" group-title="Movies - Hindi",Movie Channel
+#EXTVLCOPT:http-user-agent=Mozilla/5.0
+https://example.com/movies/index.m3u8?token=abc
+#EXTINF:-1 group-title="Unknown Group, With Comma",Movie Channel
+https://example.com/movies2/index.m3u8
+
+https://example.com/orphan.m3u8
+#EXTINF:-1 tvg-id="news.one" group-title="sport",Sports Feed
+https://example.com/sports.m3u8
+`
+
+func TestParseM3UChannels(t *testing.T) {
+	customConfig, err := parseM3UChannels([]byte(testM3UPlaylist))
+	if err != nil {
+		t.Fatalf("parseM3UChannels() error = %v", err)
+	}
+
+	want := []CustomChannel{
+		{ID: "news.one", Name: "News One HD", URL: "https://example.com/news/index.m3u8", LogoURL: "https://example.com/news.png", Category: 12, Language: 6, IsHD: true},
+		{ID: "movie_channel", Name: "Movie Channel", URL: "https://example.com/movies/index.m3u8?token=abc", LogoURL: "https://example.com/movies.png", Category: 6, Language: 1, UserAgent: "Mozilla/5.0"},
+		{ID: "movie_channel_2", Name: "Movie Channel", URL: "https://example.com/movies2/index.m3u8", Category: OtherCategoryID, Language: OtherLanguageID},
+		{ID: "news.one_2", Name: "Sports Feed", URL: "https://example.com/sports.m3u8", Category: 8, Language: OtherLanguageID},
+	}
+
+	if len(customConfig.Channels) != len(want) {
+		t.Fatalf("parseM3UChannels() returned %d channels, want %d: %+v", len(customConfig.Channels), len(want), customConfig.Channels)
+	}
+	for i, channel := range customConfig.Channels {
+		if !reflect.DeepEqual(channel, want[i]) {
+			t.Errorf("channel[%d] = %+v, want %+v", i, channel, want[i])
+		}
+	}
+}
+
+func TestDetectAndParseFormat_M3U(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		data     string
+	}{
+		{name: "By extension", filePath: "channels.m3u", data: "#EXTINF:-1,Channel\nhttps://example.com/a.m3u8\n"},
+		{name: "By URL extension with query", filePath: "https://example.com/list.m3u8?x=1", data: "#EXTINF:-1,Channel\nhttps://example.com/a.m3u8\n"},
+		{name: "By content", filePath: "channels.txt", data: "\xef\xbb\xbf#EXTM3U\n#EXTINF:-1,Channel\nhttps://example.com/a.m3u8\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customConfig, err := detectAndParseFormat([]byte(tt.data), tt.filePath)
+			if err != nil {
+				t.Fatalf("detectAndParseFormat() error = %v", err)
+			}
+			if len(customConfig.Channels) != 1 || customConfig.Channels[0].Name != "Channel" {
+				t.Errorf("detectAndParseFormat() = %+v, want one channel named Channel", customConfig.Channels)
+			}
+		})
+	}
+}
+
+func TestLoadCustomChannels_M3U(t *testing.T) {
+	setupTest()
+
+	t.Run("Local file", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "channels.m3u")
+		if err := os.WriteFile(filePath, []byte(testM3UPlaylist), 0644); err != nil {
+			t.Fatalf("Failed to write playlist: %v", err)
+		}
+
+		channels, err := LoadCustomChannels(filePath)
+		if err != nil {
+			t.Fatalf("LoadCustomChannels() error = %v", err)
+		}
+		if len(channels) != 4 {
+			t.Fatalf("LoadCustomChannels() returned %d channels, want 4", len(channels))
+		}
+		if channels[0].ID != "cc_news.one" {
+			t.Errorf("channel ID = %q, want cc_news.one", channels[0].ID)
+		}
+		if err := validateCustomChannels(channels); err != nil {
+			t.Errorf("channels from M3U failed validation: %v", err)
+		}
+	})
+
+	t.Run("URL", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(testM3UPlaylist))
+		}))
+		defer server.Close()
+
+		channels, err := LoadCustomChannels(server.URL + "/playlist")
+		if err != nil {
+			t.Fatalf("LoadCustomChannels() error = %v", err)
+		}
+		if len(channels) != 4 {
+			t.Errorf("LoadCustomChannels() returned %d channels, want 4", len(channels))
+		}
+	})
+
+	t.Run("URL with error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		if _, err := LoadCustomChannels(server.URL + "/playlist.m3u"); err == nil {
+			t.Error("LoadCustomChannels() should fail when the URL returns an error status")
+		}
+	})
+}
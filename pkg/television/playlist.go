@@ -0,0 +1,116 @@
+package television
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+)
+
+// PlaylistRewriter rewrites every URI of an HLS playlist to our own server URLs
+type PlaylistRewriter struct {
+	// PlaylistURL is the upstream URL of the playlist. Relative URIs are resolved against it.
+	PlaylistURL string
+	// Params are query params forwarded to every URI, like JioTV auth tokens
+	Params string
+	// ChannelID is used for key requests and added to all rewritten URLs
+	ChannelID string
+	// Quality is added to rewritten playlist URLs
+	Quality string
+	// Custom is set for proxied custom channels. Their segments are always proxied and tagged
+	// with ChannelID, so the channel's headers are used upstream.
+	Custom bool
+	// Prefix is prepended to the rewritten server paths, like /p/<profile> for playlists of a named profile
+	Prefix string
+}
+
+// Rewrite parses an HLS playlist and returns it with all URIs rewritten
+func (r PlaylistRewriter) Rewrite(data []byte) ([]byte, error) {
+	base, err := url.Parse(r.PlaylistURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid playlist URL: %w", err)
+	}
+	playlist, err := hls.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	err = playlist.RewriteURIs(func(uri string, uriType hls.URIType) (string, error) {
+		return r.rewriteURI(base, uri, uriType)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return playlist.Bytes(), nil
+}
+
+// rewriteURI resolves a single URI against the playlist URL and replaces it with our own server URL.
+// URIs which are not HTTP(S), like data: or skd:, are returned unchanged.
+func (r PlaylistRewriter) rewriteURI(base *url.URL, uri string, uriType hls.URIType) (string, error) {
+	ref, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid URI %q in playlist: %w", uri, err)
+	}
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return uri, nil
+	}
+	match := []byte(resolved.String())
+	params := missingParams(resolved, r.Params)
+
+	var result []byte
+	switch {
+	case uriType.IsPlaylist():
+		result = ReplaceM3U8(nil, match, params, r.ChannelID, r.Quality)
+	case uriType == hls.URIKey:
+		result = ReplaceKey(match, params, r.ChannelID)
+	case r.Custom:
+		result, err = CreateEncryptedURL(EncryptedURLConfig{
+			Match:       string(match),
+			Params:      params,
+			ChannelID:   r.ChannelID,
+			EndpointURL: "/render.ts",
+		})
+	case strings.HasSuffix(resolved.Path, ".aac"):
+		result = ReplaceAAC(nil, match, params, r.ChannelID)
+	default:
+		result = ReplaceTS(nil, match, params, r.ChannelID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to rewrite URI %q: %w", uri, err)
+	}
+	if result == nil {
+		return "", fmt.Errorf("failed to rewrite URI %q", uri)
+	}
+	if r.Prefix != "" && result[0] == '/' {
+		return r.Prefix + string(result), nil
+	}
+	return string(result), nil
+}
+
+// missingParams returns the params which are not already in the query of u
+func missingParams(u *url.URL, params string) string {
+	if params == "" || u.RawQuery == "" {
+		return params
+	}
+	existing := u.Query()
+	var missing []string
+	for _, param := range strings.Split(params, "&") {
+		key, _, _ := strings.Cut(param, "=")
+		if _, ok := existing[key]; !ok {
+			missing = append(missing, param)
+		}
+	}
+	return strings.Join(missing, "&")
+}
+
+// appendParams appends query params to a URL which may already have a query
+func appendParams(rawURL, params string) string {
+	if params == "" {
+		return rawURL
+	}
+	if strings.Contains(rawURL, "?") {
+		return rawURL + "&" + params
+	}
+	return rawURL + "?" + params
+}
@@ -0,0 +1,230 @@
+package television
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/hls"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
+)
+
+// decodeProxyURL returns the endpoint, decrypted upstream URL and query of a rewritten URL
+func decodeProxyURL(t *testing.T, proxyURL string) (string, string, url.Values) {
+	t.Helper()
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		t.Fatalf("Failed to parse rewritten URL %q: %v", proxyURL, err)
+	}
+	upstream, err := secureurl.DecryptURL(parsed.Query().Get("auth"))
+	if err != nil {
+		t.Fatalf("Failed to decrypt auth of %q: %v", proxyURL, err)
+	}
+	return parsed.Path, upstream, parsed.Query()
+}
+
+// playlistURIs returns every URI of a playlist in order, from URI lines and URI attributes
+func playlistURIs(t *testing.T, data []byte) []string {
+	t.Helper()
+	playlist, err := hls.Parse(data)
+	if err != nil {
+		t.Fatalf("rewritten playlist can't be parsed: %v", err)
+	}
+	var uris []string
+	playlist.RewriteURIs(func(uri string, uriType hls.URIType) (string, error) {
+		uris = append(uris, uri)
+		return uri, nil
+	})
+	return uris
+}
+
+func TestPlaylistRewriter_Master(t *testing.T) {
+	setupTest()
+
+	playlist := `#EXTM3U
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aud",NAME="English",URI="audio/en.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",URI="https://subs.example.com/en.m3u8?lang=en"
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,CODECS="avc1.4d401f,mp4a.40.2",AUDIO="aud"
+Channel-video=1280000.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=86000,URI="iframe.m3u8"
+`
+	rewriter := PlaylistRewriter{
+		PlaylistURL: "https://jiotvmblive.cdn.jio.com/bpk-tv/Channel/output/index.m3u8?minrate=80000&hdnea=token",
+		Params:      "minrate=80000&hdnea=token",
+		ChannelID:   "143",
+		Quality:     "high",
+	}
+	rewritten, err := rewriter.Rewrite([]byte(playlist))
+	if err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+	if !strings.Contains(string(rewritten), `CODECS="avc1.4d401f,mp4a.40.2"`) {
+		t.Errorf("attributes with commas should be kept, got:\n%s", rewritten)
+	}
+
+	want := []string{
+		"https://jiotvmblive.cdn.jio.com/bpk-tv/Channel/output/audio/en.m3u8?minrate=80000&hdnea=token",
+		"https://subs.example.com/en.m3u8?lang=en&minrate=80000&hdnea=token",
+		"https://jiotvmblive.cdn.jio.com/bpk-tv/Channel/output/Channel-video=1280000.m3u8?minrate=80000&hdnea=token",
+		"https://jiotvmblive.cdn.jio.com/bpk-tv/Channel/output/iframe.m3u8?minrate=80000&hdnea=token",
+	}
+	uris := playlistURIs(t, rewritten)
+	if len(uris) != len(want) {
+		t.Fatalf("rewritten playlist has %d URIs, want %d", len(uris), len(want))
+	}
+	for i, uri := range uris {
+		endpoint, upstream, query := decodeProxyURL(t, uri)
+		if endpoint != "/render.m3u8" {
+			t.Errorf("URI %d endpoint = %q, want /render.m3u8", i, endpoint)
+		}
+		if upstream != want[i] {
+			t.Errorf("URI %d upstream = %q, want %q", i, upstream, want[i])
+		}
+		if query.Get("channel_key_id") != "143" || query.Get("q") != "high" || query.Get("hdnea") != "token" {
+			t.Errorf("URI %d query = %v, want channel_key_id, q and hdnea", i, query)
+		}
+	}
+}
+
+func TestPlaylistRewriter_Media(t *testing.T) {
+	setupTest()
+
+	playlist := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-KEY:METHOD=AES-128,URI="https://tv.media.jio.com/streams_live/key.pkey",IV=0x1234
+#EXT-X-MAP:URI="init.mp4",BYTERANGE="720@0"
+#EXTINF:6.0,
+#EXT-X-BYTERANGE:1000@720
+seg-1.m4s?part=1
+#EXTINF:6.0,
+/abs/seg-2.ts
+#EXTINF:6.0,
+audio-3.aac
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://asset"
+#EXTINF:6.0,
+https://other.example.com/seg-4.ts
+`
+	rewriter := PlaylistRewriter{
+		PlaylistURL: "https://cdn.example.com/live/index.m3u8?hdnea=token",
+		Params:      "hdnea=token",
+		ChannelID:   "143",
+	}
+	rewritten, err := rewriter.Rewrite([]byte(playlist))
+	if err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+	for _, kept := range []string{"#EXT-X-BYTERANGE:1000@720", `BYTERANGE="720@0"`, "IV=0x1234", `URI="skd://asset"`, "#EXT-X-TARGETDURATION:6"} {
+		if !strings.Contains(string(rewritten), kept) {
+			t.Errorf("rewritten playlist is missing %q:\n%s", kept, rewritten)
+		}
+	}
+
+	tests := []struct {
+		endpoint string
+		upstream string
+	}{
+		{"/render.key", "https://tv.media.jio.com/streams_live/key.pkey?hdnea=token"},
+		{"/render.ts", "https://cdn.example.com/live/init.mp4?hdnea=token"},
+		{"/render.ts", "https://cdn.example.com/live/seg-1.m4s?part=1&hdnea=token"},
+		{"/render.ts", "https://cdn.example.com/abs/seg-2.ts?hdnea=token"},
+		{"/render.ts", "https://cdn.example.com/live/audio-3.aac?hdnea=token"},
+		{"", "skd://asset"},
+		{"/render.ts", "https://other.example.com/seg-4.ts?hdnea=token"},
+	}
+	uris := playlistURIs(t, rewritten)
+	if len(uris) != len(tests) {
+		t.Fatalf("rewritten playlist has %d URIs, want %d", len(uris), len(tests))
+	}
+	for i, tt := range tests {
+		if tt.endpoint == "" {
+			if uris[i] != tt.upstream {
+				t.Errorf("URI %d = %q, want unchanged %q", i, uris[i], tt.upstream)
+			}
+			continue
+		}
+		endpoint, upstream, query := decodeProxyURL(t, uris[i])
+		if endpoint != tt.endpoint || upstream != tt.upstream {
+			t.Errorf("URI %d = %s %s, want %s %s", i, endpoint, upstream, tt.endpoint, tt.upstream)
+		}
+		if !secureurl.VerifyChannel(query.Get("channel_sig"), "143", upstream) {
+			t.Errorf("URI %d = %s, want channel 143 signed with its upstream URL", i, uris[i])
+		}
+	}
+}
+
+func TestPlaylistRewriter_Custom(t *testing.T) {
+	setupTest()
+
+	playlist := "#EXTM3U\n#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:6.0,\nseg-1.m4s\n"
+	rewriter := PlaylistRewriter{
+		PlaylistURL: "https://cdn.example.com/live/index.m3u8",
+		ChannelID:   "cc_test",
+		Custom:      true,
+	}
+	rewritten, err := rewriter.Rewrite([]byte(playlist))
+	if err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+	for _, uri := range playlistURIs(t, rewritten) {
+		endpoint, _, query := decodeProxyURL(t, uri)
+		if endpoint != "/render.ts" || query.Get("channel_key_id") != "cc_test" {
+			t.Errorf("custom channel segment URI = %q, want /render.ts with channel_key_id", uri)
+		}
+	}
+}
+
+func TestPlaylistRewriter_Prefix(t *testing.T) {
+	setupTest()
+
+	playlist := "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1\nlow.m3u8\n"
+	rewriter := PlaylistRewriter{
+		PlaylistURL: "https://jiotv.example.com/bpk-tv/channel/index.m3u8",
+		ChannelID:   "143",
+		Prefix:      "/p/work",
+	}
+	rewritten, err := rewriter.Rewrite([]byte(playlist))
+	if err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+	for _, uri := range playlistURIs(t, rewritten) {
+		if endpoint, _, _ := decodeProxyURL(t, uri); endpoint != "/p/work/render.m3u8" {
+			t.Errorf("variant URI = %q, want it under /p/work", uri)
+		}
+	}
+}
+
+func TestPlaylistRewriter_MalformedTag(t *testing.T) {
+	setupTest()
+
+	playlist := "#EXTM3U\n#EXT-X-MEDIA:TYPE=AUDIO,URI=\"audio.m3u8\n#EXT-X-STREAM-INF:BANDWIDTH=1\nlow.m3u8\n"
+	rewriter := PlaylistRewriter{
+		PlaylistURL: "https://jiotv.example.com/bpk-tv/channel/index.m3u8",
+		ChannelID:   "143",
+	}
+	rewritten, err := rewriter.Rewrite([]byte(playlist))
+	if err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+	lines := strings.Split(string(rewritten), "\n")
+	if lines[1] != "#EXT-X-MEDIA:TYPE=AUDIO,URI=\"audio.m3u8" {
+		t.Errorf("malformed tag = %q, want it unchanged", lines[1])
+	}
+	uris := playlistURIs(t, rewritten)
+	if len(uris) != 1 {
+		t.Fatalf("rewritten URIs = %v, want the variant", uris)
+	}
+	if endpoint, upstream, _ := decodeProxyURL(t, uris[0]); endpoint != "/render.m3u8" || upstream != "https://jiotv.example.com/bpk-tv/channel/low.m3u8" {
+		t.Errorf("variant URI = %q, want it proxied", uris[0])
+	}
+}
+
+func TestPlaylistRewriter_NotPlaylist(t *testing.T) {
+	setupTest()
+
+	rewriter := PlaylistRewriter{PlaylistURL: "https://cdn.example.com/index.m3u8"}
+	if _, err := rewriter.Rewrite([]byte(`{"message":"error"}`)); err == nil {
+		t.Error("Rewrite() should fail for data that is not a playlist")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/valyala/fasthttp"
 	"gopkg.in/yaml.v3"
@@ -14,6 +16,7 @@ import (
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/headers"
 	"github.com/jiotv-go/jiotv_go/v3/internal/constants/urls"
+	"github.com/jiotv-go/jiotv_go/v3/pkg/metrics"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
 )
 
@@ -31,6 +34,37 @@ const (
 	maxRecommendedChannels = constants.MaxRecommendedChannels
 )
 
+// ErrCatchupUnavailable is returned for channels without catch-up
+var ErrCatchupUnavailable = errors.New("catch-up is not available for this channel")
+
+// PlaybackError is the error of a playback API request which got a response other than 200
+type PlaybackError struct {
+	StatusCode int
+	// Response is the body of the response
+	Response string
+}
+
+func (e *PlaybackError) Error() string {
+	return fmt.Sprintf("Request failed with status code: %d\nresponse: %s", e.StatusCode, e.Response)
+}
+
+// Unauthorized reports if the playback API refused the tokens of the request
+func (e *PlaybackError) Unauthorized() bool {
+	return e.StatusCode == fasthttp.StatusUnauthorized || e.StatusCode == fasthttp.StatusForbidden || e.StatusCode == 419
+}
+
+// catchupRefusedStatus holds the status codes with which the playback API refuses a catch-up window
+var catchupRefusedStatus = map[int]bool{
+	fasthttp.StatusBadRequest: true,
+	fasthttp.StatusNotFound:   true,
+}
+
+// catchupTimeLayout is the layout of the begin and end of catch-up requests
+const catchupTimeLayout = "20060102T150405"
+
+// errChannelsUnavailable is returned when JioTV API can't be reached and no cached channel list exists
+var errChannelsUnavailable = errors.New("channel list is unavailable and no cached copy exists")
+
 // logExcessiveChannelsWarning logs a comprehensive warning when the number of custom channels exceeds the recommended limit
 func logExcessiveChannelsWarning(channelCount int, context string) {
 	if channelCount <= maxRecommendedChannels {
@@ -49,6 +83,12 @@ func logExcessiveChannelsWarning(channelCount int, context string) {
 var (
 	// customChannelsCacheMap holds cached custom channels indexed by ID for efficient lookups
 	customChannelsCacheMap map[string]Channel
+	// customChannelsMutex guards customChannelsCacheMap, which is swapped when the custom channels file is reloaded
+	customChannelsMutex sync.RWMutex
+	// localChannels are the channels played from local media files, registered by the localmedia package
+	localChannels []Channel
+	// localChannelsMap holds localChannels indexed by ID
+	localChannelsMap map[string]Channel
 )
 
 // New function creates a new Television instance with the provided credentials
@@ -106,11 +146,13 @@ func InitCustomChannels() {
 
 // getCustomChannelByID efficiently looks up a custom channel by ID
 func getCustomChannelByID(channelID string) (Channel, bool) {
-	if customChannelsCacheMap == nil {
-		return Channel{}, false
-	}
+	customChannelsMutex.RLock()
+	defer customChannelsMutex.RUnlock()
 
-	channel, exists := customChannelsCacheMap[channelID]
+	if channel, exists := customChannelsCacheMap[channelID]; exists {
+		return channel, true
+	}
+	channel, exists := localChannelsMap[channelID]
 	return channel, exists
 }
 
@@ -119,26 +161,62 @@ func GetCustomChannelByID(channelID string) (Channel, bool) {
 	return getCustomChannelByID(channelID)
 }
 
-// loadAndCacheCustomChannels loads custom channels from file and caches them
+// loadAndCacheCustomChannels loads custom channels from file and caches them.
+// Unlike reloads, it doesn't reject the file for duplicate IDs, see logDuplicateCustomChannels.
 func loadAndCacheCustomChannels() {
+	stat, _ := customChannelsWatch.changed(config.Cfg.CustomChannelsFile)
+
 	// Load channels from file
 	channels, err := LoadCustomChannels(config.Cfg.CustomChannelsFile)
 	if err != nil {
 		utils.SafeLogf("Error loading custom channels: %v", err)
 		// Cache empty result to avoid repeated file I/O errors
-		customChannelsCacheMap = make(map[string]Channel)
+		setCustomChannels(nil)
 	} else {
-		// Populate the map for efficient lookups
-		customChannelsCacheMap = make(map[string]Channel)
-		for _, channel := range channels {
-			customChannelsCacheMap[channel.ID] = channel
-		}
+		logDuplicateCustomChannels(channels)
+		setCustomChannels(channels)
+		// Remember the file state so that the watcher only reloads on changes
+		customChannelsWatch.record(stat)
 
 		// Warn user about performance implications if too many channels
 		logExcessiveChannelsWarning(len(channels), "Cached")
 	}
 }
 
+// setCustomChannels atomically replaces the cached custom channels
+func setCustomChannels(channels []Channel) {
+	// Populate the map for efficient lookups
+	channelsMap := make(map[string]Channel, len(channels))
+	for _, channel := range channels {
+		channelsMap[channel.ID] = channel
+	}
+
+	customChannelsMutex.Lock()
+	customChannelsCacheMap = channelsMap
+	customChannelsMutex.Unlock()
+	metrics.CustomChannels.Set(float64(len(channelsMap)))
+}
+
+// SetLocalChannels replaces the channels played from local media files, which are listed after the custom channels
+func SetLocalChannels(channels []Channel) {
+	channelsMap := make(map[string]Channel, len(channels))
+	for _, channel := range channels {
+		channelsMap[channel.ID] = channel
+	}
+
+	customChannelsMutex.Lock()
+	localChannels = channels
+	localChannelsMap = channelsMap
+	customChannelsMutex.Unlock()
+}
+
+// HasLocalChannels reports whether any channel is played from local media files
+func HasLocalChannels() bool {
+	customChannelsMutex.RLock()
+	defer customChannelsMutex.RUnlock()
+	return len(localChannels) > 0
+}
+
 // Live method generates m3u8 link from JioTV API with the provided channel ID
 func (tv *Television) Live(channelID string) (*LiveURLOutput, error) {
 	// If channelID starts with sl, then it is a Sony Channel
@@ -154,6 +232,39 @@ func (tv *Television) Live(channelID string) (*LiveURLOutput, error) {
 	formData.Add("begin", utils.GenerateCurrentTime())
 	formData.Add("srno", utils.GenerateDate())
 
+	return tv.playback(channelID, formData)
+}
+
+// Catchup method generates m3u8 link of the part of a channel which aired between start and end.
+// Sony channels have no catch-up, and windows refused by the playback API are not available either.
+func (tv *Television) Catchup(channelID string, start, end time.Time) (*LiveURLOutput, error) {
+	if strings.HasPrefix(channelID, "sl") {
+		return nil, ErrCatchupUnavailable
+	}
+
+	formData := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(formData)
+	setCatchupForm(formData, channelID, start, end)
+
+	result, err := tv.playback(channelID, formData)
+	var playbackErr *PlaybackError
+	if errors.As(err, &playbackErr) && catchupRefusedStatus[playbackErr.StatusCode] {
+		return nil, fmt.Errorf("%w, the playback API answered with status code %d", ErrCatchupUnavailable, playbackErr.StatusCode)
+	}
+	return result, err
+}
+
+// setCatchupForm sets the form of a catch-up request of the playback API
+func setCatchupForm(formData *fasthttp.Args, channelID string, start, end time.Time) {
+	formData.Add("channel_id", channelID)
+	formData.Add("stream_type", "Catchup")
+	formData.Add("begin", start.UTC().Format(catchupTimeLayout))
+	formData.Add("end", end.UTC().Format(catchupTimeLayout))
+	formData.Add("srno", start.UTC().Format("20060102"))
+}
+
+// playback requests the stream URLs of a channel from the playback API with the given form
+func (tv *Television) playback(channelID string, formData *fasthttp.Args) (*LiveURLOutput, error) {
 	req := fasthttp.AcquireRequest()
 	defer fasthttp.ReleaseRequest(req)
 
@@ -177,14 +288,16 @@ func (tv *Television) Live(channelID string) (*LiveURLOutput, error) {
 	defer fasthttp.ReleaseResponse(resp)
 
 	// Perform the HTTP POST request
+	start := time.Now()
 	if err := tv.Client.Do(req, resp); err != nil {
+		metrics.ObserveUpstream(metrics.EndpointPlayback, start, 0)
 		if strings.Contains(err.Error(), "server closed connection before returning the first response byte") {
 			utils.Log.Println("Retrying the request...")
-			return tv.Live(channelID)
+			return tv.playback(channelID, formData)
 		}
-		utils.Log.Panic(err)
-		return nil, err
+		return nil, fmt.Errorf("playback request of channel %s failed: %w", channelID, err)
 	}
+	metrics.ObserveUpstream(metrics.EndpointPlayback, start, resp.StatusCode())
 	if resp.StatusCode() != fasthttp.StatusOK {
 		// Store the response body as a string
 		response := string(resp.Body())
@@ -192,15 +305,14 @@ func (tv *Television) Live(channelID string) (*LiveURLOutput, error) {
 		// Log headers and request data
 		utils.Log.Println("Request headers:", req.Header.String())
 		utils.Log.Println("Request data:", formData.String())
-		utils.Log.Panicln("Response: ", response)
+		utils.Log.Println("Response: ", response)
 
-		return nil, fmt.Errorf("Request failed with status code: %d\nresponse: %s", resp.StatusCode(), response)
+		return nil, &PlaybackError{StatusCode: resp.StatusCode(), Response: response}
 	}
 
 	var result LiveURLOutput
 	if err := json.Unmarshal(resp.Body(), &result); err != nil {
-		utils.Log.Panic(err)
-		return nil, err
+		return nil, fmt.Errorf("invalid playback response of channel %s: %w", channelID, err)
 	}
 
 	// Extract hdnea from any URL fields in the response (Live does not set Set-Cookie)
@@ -258,6 +370,16 @@ func (tv *Television) Live(channelID string) (*LiveURLOutput, error) {
 
 // Render method does HTTP GET request to the provided URL and return the response body
 func (tv *Television) Render(url string) ([]byte, int, string) {
+	buf, statusCode, newHdnea, err := tv.Fetch(url)
+	if err != nil {
+		utils.Log.Panic(err)
+	}
+	return buf, statusCode, newHdnea
+}
+
+// Fetch does the same request as Render, but returns an error instead of panicking when the request fails.
+// It returns the response body, the status code and the __hdnea__ token if upstream rotated it.
+func (tv *Television) Fetch(url string) ([]byte, int, string, error) {
 	req := fasthttp.AcquireRequest()
 	defer fasthttp.ReleaseRequest(req)
 
@@ -286,11 +408,15 @@ func (tv *Television) Render(url string) ([]byte, int, string) {
 	defer fasthttp.ReleaseResponse(resp)
 
 	// Perform the HTTP GET request
+	start := time.Now()
 	if err := tv.Client.Do(req, resp); err != nil {
-		utils.Log.Panic(err)
+		metrics.ObserveUpstream(metrics.EndpointRender, start, 0)
+		return nil, 0, "", err
 	}
+	metrics.ObserveUpstream(metrics.EndpointRender, start, resp.StatusCode())
 
-	buf := resp.Body()
+	// Copy the body as resp is released on return
+	buf := append([]byte(nil), resp.Body()...)
 	// Capture any __hdnea__ Set-Cookie returned by upstream so caller can set cookie on client
 	var newHdnea string
 	// Iterate Set-Cookie headers (avoid deprecated VisitAll)
@@ -305,13 +431,18 @@ func (tv *Television) Render(url string) ([]byte, int, string) {
 		}
 	}
 
-	return buf, resp.StatusCode(), newHdnea
+	return buf, resp.StatusCode(), newHdnea, nil
 }
 
 // detectAndParseFormat attempts to detect the format of custom channels data and parse it
 func detectAndParseFormat(data []byte, filePath string) (CustomChannelsConfig, error) {
 	var customConfig CustomChannelsConfig
 
+	// M3U playlists are detected by extension or by the #EXTM3U header
+	if isM3UFile(filePath) || isM3UContent(data) {
+		return parseM3UChannels(data)
+	}
+
 	// Determine file format by extension and parse accordingly, fallback to content-based detection
 	if strings.HasSuffix(filePath, ".json") {
 		err := json.Unmarshal(data, &customConfig)
@@ -353,25 +484,37 @@ func detectAndParseFormat(data []byte, filePath string) (CustomChannelsConfig, e
 	return customConfig, nil
 }
 
-// LoadCustomChannels loads custom channels from configuration file
+// LoadCustomChannels loads custom channels from configuration file or M3U playlist.
+// filePath can be a local path or an HTTP(S) URL.
 func LoadCustomChannels(filePath string) ([]Channel, error) {
 	if filePath == "" {
 		return []Channel{}, nil
 	}
 
-	// Check if file exists and read it
-	fileResult := utils.CheckAndReadFile(filePath)
-	if !fileResult.Exists {
-		utils.SafeLogf("Custom channels file not found: %s", filePath)
-		return []Channel{}, nil
-	}
+	var data []byte
+	if isRemoteChannelsSource(filePath) {
+		// Download custom channels from URL
+		remoteData, err := fetchRemoteChannelsFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		data = remoteData
+	} else {
+		// Check if file exists and read it
+		fileResult := utils.CheckAndReadFile(filePath)
+		if !fileResult.Exists {
+			utils.SafeLogf("Custom channels file not found: %s", filePath)
+			return []Channel{}, nil
+		}
 
-	if fileResult.Error != nil {
-		return nil, fileResult.Error
+		if fileResult.Error != nil {
+			return nil, fileResult.Error
+		}
+		data = fileResult.Data
 	}
 
 	// Parse the file using format detection
-	customConfig, err := detectAndParseFormat(fileResult.Data, filePath)
+	customConfig, err := detectAndParseFormat(data, filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse custom channels file: %w", err)
 	}
@@ -385,6 +528,8 @@ func LoadCustomChannels(filePath string) ([]Channel, error) {
 			channelID = "cc_" + channelID
 		}
 
+		// Headers can't be applied to a redirect, so setting any of them turns on proxy mode
+		headers := customChannelHeaders(customChannel)
 		channel := Channel{
 			ID:       channelID,
 			Name:     customChannel.Name,
@@ -393,6 +538,8 @@ func LoadCustomChannels(filePath string) ([]Channel, error) {
 			Category: customChannel.Category,
 			Language: customChannel.Language,
 			IsHD:     customChannel.IsHD,
+			Headers:  headers,
+			Proxy:    customChannel.Proxy || len(headers) > 0,
 		}
 		channels = append(channels, channel)
 	}
@@ -405,6 +552,9 @@ func LoadCustomChannels(filePath string) ([]Channel, error) {
 }
 
 func getCustomChannels() []Channel {
+	customChannelsMutex.RLock()
+	defer customChannelsMutex.RUnlock()
+
 	// Iterate over the custom channels cache map and collect the channels
 	var customChannels []Channel
 	for _, channel := range customChannelsCacheMap {
@@ -413,8 +563,36 @@ func getCustomChannels() []Channel {
 	return customChannels
 }
 
-// Channels fetch channels from JioTV API and merge with custom channels
+// Channels returns channels from JioTV API merged with custom channels.
+// The JioTV channel list is served from cache while it is fresh, see getCachedChannels.
 func Channels() (ChannelsResponse, error) {
+	apiResponse, err := getCachedChannels()
+	if err != nil {
+		return ChannelsResponse{}, err
+	}
+
+	// disable sony channels temporarily
+	// apiResponse.Result = append(apiResponse.Result, SONY_CHANNELS_API...)
+
+	// Channels removed from JioTV keep their position for a while
+	apiResponse.Result = withGhostChannels(apiResponse.Result, time.Now())
+
+	// Load and append custom channels if configured
+	if config.Cfg.CustomChannelsFile != "" {
+		customChannels := getCustomChannels()
+		apiResponse.Result = append(apiResponse.Result, customChannels...)
+	}
+
+	customChannelsMutex.RLock()
+	apiResponse.Result = append(apiResponse.Result, localChannels...)
+	customChannelsMutex.RUnlock()
+
+	return apiResponse, nil
+}
+
+// fetchChannels fetches channels from JioTV API.
+// It returns the parsed response along with the raw response body.
+func fetchChannels() (ChannelsResponse, []byte, error) {
 	// Create a fasthttp.Client
 	client := utils.GetRequestClient()
 
@@ -430,34 +608,39 @@ func Channels() (ChannelsResponse, error) {
 	}
 
 	// Make the HTTP request
+	start := time.Now()
 	resp, err := utils.MakeHTTPRequest(utils.HTTPRequestConfig{
 		URL:     CHANNELS_API_URL,
 		Method:  "GET",
 		Headers: requestHeaders,
 	}, client)
 	if err != nil {
-		utils.Log.Printf("Error fetching channels from JioTV API: %v", err)
-		return ChannelsResponse{}, err
+		metrics.ObserveUpstream(metrics.EndpointChannels, start, 0)
+		utils.SafeLogf("Error fetching channels from JioTV API: %v", err)
+		return ChannelsResponse{}, nil, err
 	}
+	metrics.ObserveUpstream(metrics.EndpointChannels, start, resp.StatusCode())
 	defer fasthttp.ReleaseResponse(resp)
 
 	var apiResponse ChannelsResponse
 
 	// Parse JSON response
 	if err := utils.ParseJSONResponse(resp, &apiResponse); err != nil {
-		utils.Log.Printf("Error parsing channels API response: %v", err)
-		return ChannelsResponse{}, err
+		utils.SafeLogf("Error parsing channels API response: %v", err)
+		return ChannelsResponse{}, nil, err
 	}
 
-	// disable sony channels temporarily
-	// apiResponse.Result = append(apiResponse.Result, SONY_CHANNELS_API...)
+	// Copy the body as it is released along with the response
+	body := append([]byte(nil), resp.Body()...)
+	return apiResponse, body, nil
+}
 
-	// Load and append custom channels if configured
-	if config.Cfg.CustomChannelsFile != "" {
-		customChannels := getCustomChannels()
-		apiResponse.Result = append(apiResponse.Result, customChannels...)
+// parseChannelsResponse parses a raw channel list response from JioTV API
+func parseChannelsResponse(data []byte) (ChannelsResponse, error) {
+	var apiResponse ChannelsResponse
+	if err := json.Unmarshal(data, &apiResponse); err != nil {
+		return ChannelsResponse{}, err
 	}
-
 	return apiResponse, nil
 }
 
@@ -557,9 +740,9 @@ func ReplaceM3U8(baseUrl, match []byte, params, channel_id string, quality strin
 	return result
 }
 
-func ReplaceTS(baseUrl, match []byte, params string) []byte {
+func ReplaceTS(baseUrl, match []byte, params, channel_id string) []byte {
 	if config.Cfg.DisableTSHandler {
-		return []byte(string(baseUrl) + string(match) + "?" + params)
+		return []byte(appendParams(string(baseUrl)+string(match), params))
 	}
 
 	hdnea := ""
@@ -575,6 +758,7 @@ func ReplaceTS(baseUrl, match []byte, params string) []byte {
 		BaseURL:     string(baseUrl),
 		Match:       string(match),
 		Params:      params,
+		ChannelID:   channel_id,
 		EndpointURL: "/render.ts",
 		Hdnea:       hdnea,
 	}
@@ -586,9 +770,9 @@ func ReplaceTS(baseUrl, match []byte, params string) []byte {
 	return result
 }
 
-func ReplaceAAC(baseUrl, match []byte, params string) []byte {
+func ReplaceAAC(baseUrl, match []byte, params, channel_id string) []byte {
 	if config.Cfg.DisableTSHandler {
-		return []byte(string(baseUrl) + string(match) + "?" + params)
+		return []byte(appendParams(string(baseUrl)+string(match), params))
 	}
 
 	hdnea := ""
@@ -604,6 +788,7 @@ func ReplaceAAC(baseUrl, match []byte, params string) []byte {
 		BaseURL:     string(baseUrl),
 		Match:       string(match),
 		Params:      params,
+		ChannelID:   channel_id,
 		EndpointURL: "/render.ts",
 		Hdnea:       hdnea,
 	}
@@ -649,7 +834,6 @@ func getSLChannel(channelID string) (*LiveURLOutput, error) {
 
 		chu, err := base64.StdEncoding.DecodeString(SONY_CHANNELS[val])
 		if err != nil {
-			utils.Log.Panic(err)
 			return nil, err
 		}
 
@@ -667,12 +851,12 @@ func getSLChannel(channelID string) (*LiveURLOutput, error) {
 
 		// Perform the HTTP GET request
 		if err := utils.GetRequestClient().Do(req, resp); err != nil {
-			utils.Log.Panic(err)
+			return nil, fmt.Errorf("request of channel %s failed: %w", channelID, err)
 		}
 
 		if resp.StatusCode() != fasthttp.StatusFound {
-			utils.Log.Panicf("Request failed with status code: %d", resp.StatusCode())
-			utils.Log.Panicln("Response: ", string(resp.Body()))
+			utils.Log.Println("Response: ", string(resp.Body()))
+			return nil, fmt.Errorf("request of channel %s failed with status code: %d", channelID, resp.StatusCode())
 		}
 
 		// Store the location header in actual_url
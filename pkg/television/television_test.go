@@ -1,19 +1,27 @@
 package television
 
 import (
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/jiotv-go/jiotv_go/v3/internal/config"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/secureurl"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
 	"github.com/jiotv-go/jiotv_go/v3/pkg/utils"
+	"github.com/valyala/fasthttp"
 )
 
 var (
@@ -232,7 +240,7 @@ func TestReplaceTS(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := ReplaceTS(tt.args.baseUrl, tt.args.match, tt.args.params)
+			got := ReplaceTS(tt.args.baseUrl, tt.args.match, tt.args.params, "")
 			// The function encrypts URLs, so we check that it produces some output
 			if len(got) == 0 {
 				t.Errorf("ReplaceTS() returned empty result")
@@ -283,7 +291,7 @@ func TestReplaceAAC(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := ReplaceAAC(tt.args.baseUrl, tt.args.match, tt.args.params)
+			got := ReplaceAAC(tt.args.baseUrl, tt.args.match, tt.args.params, "")
 			// The function encrypts URLs, so we check that it produces some output
 			if len(got) == 0 {
 				t.Errorf("ReplaceAAC() returned empty result")
@@ -643,3 +651,73 @@ func TestLoadAndCacheCustomChannels(t *testing.T) {
 		}
 	})
 }
+
+func TestSetCatchupForm(t *testing.T) {
+	ist := time.FixedZone("IST", 5*60*60+30*60)
+	start := time.Date(2024, 1, 1, 5, 0, 0, 0, ist)
+	end := time.Date(2024, 1, 1, 6, 30, 0, 0, ist)
+
+	formData := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(formData)
+	setCatchupForm(formData, "143", start, end)
+
+	want := map[string]string{
+		"channel_id":  "143",
+		"stream_type": "Catchup",
+		"begin":       "20231231T233000",
+		"end":         "20240101T010000",
+		"srno":        "20231231",
+	}
+	for key, value := range want {
+		if got := string(formData.Peek(key)); got != value {
+			t.Errorf("form %s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestCatchup_SonyChannel(t *testing.T) {
+	tv := &Television{}
+	if _, err := tv.Catchup("sl291", time.Now().Add(-time.Hour), time.Now()); err != ErrCatchupUnavailable {
+		t.Errorf("Catchup() error = %v, want %v", err, ErrCatchupUnavailable)
+	}
+}
+
+func TestCatchup_Refused(t *testing.T) {
+	setupTest()
+	tests := []struct {
+		name            string
+		status          int
+		wantUnavailable bool
+		wantAuth        bool
+	}{
+		{name: "Window refused", status: http.StatusBadRequest, wantUnavailable: true},
+		{name: "No archive", status: http.StatusNotFound, wantUnavailable: true},
+		{name: "Expired tokens", status: 419, wantAuth: true},
+		{name: "Forbidden", status: http.StatusForbidden, wantAuth: true},
+		{name: "Server error", status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"code": 419, "message": "Invalid window"}`, tt.status)
+			}))
+			defer server.Close()
+
+			// The playback API is reached on the test server
+			tv := &Television{Client: &fasthttp.Client{
+				TLSConfig: &tls.Config{InsecureSkipVerify: true},
+				Dial: func(addr string) (net.Conn, error) {
+					return net.Dial("tcp", server.Listener.Addr().String())
+				},
+			}}
+			_, err := tv.Catchup("143", time.Now().Add(-time.Hour), time.Now())
+			if errors.Is(err, ErrCatchupUnavailable) != tt.wantUnavailable || !strings.Contains(err.Error(), strconv.Itoa(tt.status)) {
+				t.Errorf("Catchup() error = %v, want unavailable %v with the status code", err, tt.wantUnavailable)
+			}
+			var playbackErr *PlaybackError
+			if unauthorized := errors.As(err, &playbackErr) && playbackErr.Unauthorized(); unauthorized != tt.wantAuth {
+				t.Errorf("Catchup() error = %v, want unauthorized %v", err, tt.wantAuth)
+			}
+		})
+	}
+}
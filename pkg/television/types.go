@@ -3,6 +3,7 @@ package television
 import (
 	"encoding/json"
 	"strconv"
+	"time"
 
 	"github.com/valyala/fasthttp"
 )
@@ -26,6 +27,14 @@ type Channel struct {
 	Category int    `json:"channelCategoryId"`
 	Language int    `json:"channelLanguageId"`
 	IsHD     bool   `json:"isHD"`
+	// IsCatchupAvailable is set for channels whose past programmes can be played
+	IsCatchupAvailable bool `json:"isCatchupAvailable"`
+	// Headers sent upstream when a custom channel is proxied. Never exposed to clients.
+	Headers map[string]string `json:"-"`
+	// Proxy streams a custom channel through the server instead of redirecting to its URL
+	Proxy bool `json:"-"`
+	// Ghost is set for channels removed from JioTV, which are kept in the list for a grace period
+	Ghost bool `json:"ghost,omitempty"`
 }
 
 // UnmarshalJSON to Override Channel.ID to convert int from json to string
@@ -49,6 +58,10 @@ type ChannelsResponse struct {
 	Code    int       `json:"code"`
 	Message string    `json:"message"`
 	Result  []Channel `json:"result"`
+	// FetchedAt is the time the channel list was fetched from JioTV API
+	FetchedAt time.Time `json:"fetched_at"`
+	// Stale is set when the channel list is older than the cache TTL because JioTV API could not be reached
+	Stale bool `json:"stale"`
 }
 
 // Bitrates represents Quality levels for live streams for JioTV API
@@ -89,6 +102,13 @@ type LiveURLOutput struct {
 	Hdnea       string   `json:"-"` // parsed from URLs in Live response (hdnea query param); may rotate via Set-Cookie (__hdnea__) on m3u8/ts requests
 }
 
+const (
+	// OtherCategoryID is the category ID used when a channel's category is unknown
+	OtherCategoryID = 20
+	// OtherLanguageID is the language ID used when a channel's language is unknown
+	OtherLanguageID = 18
+)
+
 // CategoryMap represents Categories for channels
 var CategoryMap = map[int]string{
 	0:  "All Categories",
@@ -105,6 +125,8 @@ var CategoryMap = map[int]string{
 	17: "Educational",
 	18: "Shopping",
 	19: "JioDarshan",
+	// Not a JioTV category. Used for custom channels whose category is unknown
+	20: "Other",
 }
 
 // LanguageMap represents Languages for channels
@@ -129,6 +151,26 @@ var LanguageMap = map[int]string{
 	18: "Other",
 }
 
+// LanguageCodeMap maps the IDs of LanguageMap to ISO 639 language codes. Unknown languages have no code.
+var LanguageCodeMap = map[int]string{
+	1:  "hi",
+	2:  "mr",
+	3:  "pa",
+	4:  "ur",
+	5:  "bn",
+	6:  "en",
+	7:  "ml",
+	8:  "ta",
+	9:  "gu",
+	10: "or",
+	11: "te",
+	12: "bho",
+	13: "kn",
+	14: "as",
+	15: "ne",
+	16: "fr",
+}
+
 var SONY_CHANNELS = map[string]string{
 	"sonyhd":         "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L2RCZHdPaUdhUXZ5MFRBMXpPc2pWNncvbWFzdGVyLm0zdTg=",
 	"sonysabhd":      "aHR0cHM6Ly9kYWkuZ29vZ2xlLmNvbS9saW5lYXIvaGxzL2V2ZW50L0NyVGl2a0RFU1dxd3ZVajN6RkVZRUEvbWFzdGVyLm0zdTg=",
@@ -170,6 +212,12 @@ type CustomChannel struct {
 	Category int    `json:"category" yaml:"category"`
 	Language int    `json:"language" yaml:"language"`
 	IsHD     bool   `json:"is_hd" yaml:"is_hd"`
+	// Optional request headers for the stream, e.g. Cookie or Origin
+	Headers   map[string]string `json:"headers" yaml:"headers"`
+	UserAgent string            `json:"user_agent" yaml:"user_agent"`
+	Referer   string            `json:"referer" yaml:"referer"`
+	// Proxy streams the channel through the server. Implied when any header is set.
+	Proxy bool `json:"proxy" yaml:"proxy"`
 }
 
 // CustomChannelsConfig represents the structure of custom channels configuration file
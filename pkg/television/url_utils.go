@@ -20,7 +20,7 @@ type EncryptedURLConfig struct {
 
 // CreateEncryptedURL creates an encrypted URL with auth parameters for various endpoints
 func CreateEncryptedURL(config EncryptedURLConfig) ([]byte, error) {
-	fullURL := config.BaseURL + config.Match + "?" + config.Params
+	fullURL := appendParams(config.BaseURL+config.Match, config.Params)
 
 	encryptedURL, err := secureurl.EncryptURL(fullURL)
 	if err != nil {
@@ -30,7 +30,9 @@ func CreateEncryptedURL(config EncryptedURLConfig) ([]byte, error) {
 
 	var result string
 	if config.ChannelID != "" {
-		result = fmt.Sprintf("%s?auth=%s&channel_key_id=%s", config.EndpointURL, encryptedURL, config.ChannelID)
+		// The signature lets the channel of the request be trusted, like by the scope of share links
+		result = fmt.Sprintf("%s?auth=%s&channel_key_id=%s&channel_sig=%s", config.EndpointURL, encryptedURL, config.ChannelID,
+			secureurl.SignChannel(config.ChannelID, fullURL))
 	} else {
 		result = fmt.Sprintf("%s?auth=%s", config.EndpointURL, encryptedURL)
 	}
@@ -35,6 +35,11 @@ func VersionFlag() *cli.StringFlag {
 	return StringFlag("version", "", "Update to a custom specific version that is not latest", "v")
 }
 
+// ProfileFlag creates a standardized login profile flag
+func ProfileFlag() *cli.StringFlag {
+	return StringFlag("profile", "", "Name of the login profile, defaults to the default profile", "p")
+}
+
 // CommonServerFlags returns common server-related flags
 func CommonServerFlags() []cli.Flag {
 	return []cli.Flag{
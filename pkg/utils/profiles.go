@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+)
+
+// DefaultProfile is the name of the credentials which were stored before profiles existed.
+// Its credentials are kept under the plain store keys like ssoToken and crm.
+const DefaultProfile = "default"
+
+const (
+	// profilesKey is the store key of the list of named profiles
+	profilesKey = "profiles"
+	// defaultProfileKey is the store key of the profile used when a request doesn't select one
+	defaultProfileKey = "defaultProfile"
+)
+
+// credentialKeys are the store keys holding the credentials of a profile
+var credentialKeys = []string{
+	"ssoToken",
+	"crm",
+	"uniqueId",
+	"accessToken",
+	"refreshToken",
+	"lastTokenRefreshTime",
+	"lastSSOTokenRefreshTime",
+}
+
+var profileNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Profile errors
+var (
+	ErrInvalidProfile  = errors.New("invalid profile name, use up to 32 letters, digits, - or _")
+	ErrProfileNotFound = errors.New("profile not found")
+)
+
+// ValidateProfileName checks if name can be used as a profile name
+func ValidateProfileName(name string) error {
+	if !profileNameRegex.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidProfile, name)
+	}
+	return nil
+}
+
+// profileStoreKey returns the store key of a credential of a profile
+func profileStoreKey(profile, key string) string {
+	if profile == "" || profile == DefaultProfile {
+		return key
+	}
+	return "profile." + profile + "." + key
+}
+
+// ListProfiles returns the names of all profiles. The default profile is always first.
+func ListProfiles() []string {
+	profiles := []string{DefaultProfile}
+	value, err := store.Get(profilesKey)
+	if err != nil {
+		return profiles
+	}
+	var named []string
+	if err := json.Unmarshal([]byte(value), &named); err != nil {
+		SafeLogf("Error parsing profiles: %v", err)
+		return profiles
+	}
+	return append(profiles, named...)
+}
+
+// ProfileExists checks if a profile with the given name exists
+func ProfileExists(name string) bool {
+	return ContainsString(name, ListProfiles())
+}
+
+// addProfile adds a named profile to the list of profiles
+func addProfile(name string) error {
+	if ProfileExists(name) {
+		return nil
+	}
+	named := ListProfiles()[1:]
+	value, err := json.Marshal(append(named, name))
+	if err != nil {
+		return err
+	}
+	return store.Set(profilesKey, string(value))
+}
+
+// removeProfile removes a named profile from the list of profiles
+func removeProfile(name string) error {
+	var named []string
+	for _, profile := range ListProfiles()[1:] {
+		if profile != name {
+			named = append(named, profile)
+		}
+	}
+	value, err := json.Marshal(named)
+	if err != nil {
+		return err
+	}
+	return store.Set(profilesKey, string(value))
+}
+
+// GetDefaultProfile returns the profile used when a request or command doesn't select one
+func GetDefaultProfile() string {
+	name, err := store.Get(defaultProfileKey)
+	if err != nil || !ProfileExists(name) {
+		return DefaultProfile
+	}
+	return name
+}
+
+// SetDefaultProfile sets the profile used when a request or command doesn't select one
+func SetDefaultProfile(name string) error {
+	if !ProfileExists(name) {
+		return fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	return store.Set(defaultProfileKey, name)
+}
+
+// GetProfileCredentials returns the credentials of a profile
+func GetProfileCredentials(profile string) (*JIOTV_CREDENTIALS, error) {
+	values := make(map[string]string, len(credentialKeys))
+	for i, key := range credentialKeys {
+		value, err := store.Get(profileStoreKey(profile, key))
+		if err != nil {
+			// ssoToken, crm and uniqueId are required, the others only exist after OTP login
+			if i < 3 {
+				return nil, err
+			}
+			return nil, nil
+		}
+		values[key] = value
+	}
+
+	return &JIOTV_CREDENTIALS{
+		SSOToken:                values["ssoToken"],
+		CRM:                     values["crm"],
+		UniqueID:                values["uniqueId"],
+		AccessToken:             values["accessToken"],
+		RefreshToken:            values["refreshToken"],
+		LastTokenRefreshTime:    values["lastTokenRefreshTime"],
+		LastSSOTokenRefreshTime: values["lastSSOTokenRefreshTime"],
+	}, nil
+}
+
+// WriteProfileCredentials writes the credentials of a profile to the store, creating the profile if needed
+func WriteProfileCredentials(profile string, credentials *JIOTV_CREDENTIALS) error {
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	if err := ValidateProfileName(profile); err != nil {
+		return err
+	}
+
+	// Prepare batch operations
+	sets := map[string]string{
+		profileStoreKey(profile, "ssoToken"):     credentials.SSOToken,
+		profileStoreKey(profile, "crm"):          credentials.CRM,
+		profileStoreKey(profile, "uniqueId"):     credentials.UniqueID,
+		profileStoreKey(profile, "accessToken"):  credentials.AccessToken,
+		profileStoreKey(profile, "refreshToken"): credentials.RefreshToken,
+	}
+
+	// Handle timestamp fields
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	if credentials.LastTokenRefreshTime != "" {
+		sets[profileStoreKey(profile, "lastTokenRefreshTime")] = credentials.LastTokenRefreshTime
+	} else {
+		sets[profileStoreKey(profile, "lastTokenRefreshTime")] = now
+	}
+
+	if credentials.LastSSOTokenRefreshTime != "" {
+		sets[profileStoreKey(profile, "lastSSOTokenRefreshTime")] = credentials.LastSSOTokenRefreshTime
+	} else {
+		sets[profileStoreKey(profile, "lastSSOTokenRefreshTime")] = now
+	}
+
+	// Execute batch operations
+	if err := ExecuteBatchStoreOperations(BatchStoreOperations{Sets: sets}); err != nil {
+		return err
+	}
+	if profile == DefaultProfile {
+		return nil
+	}
+	return addProfile(profile)
+}
+
+// deleteProfileCredentials removes the credentials of a profile from the store.
+// Named profiles are removed from the list of profiles as well.
+func deleteProfileCredentials(profile string) error {
+	deletes := make([]string, len(credentialKeys))
+	for i, key := range credentialKeys {
+		deletes[i] = profileStoreKey(profile, key)
+	}
+	if err := ExecuteBatchStoreOperations(BatchStoreOperations{Deletes: deletes}); err != nil {
+		return err
+	}
+	if profile == "" || profile == DefaultProfile {
+		return nil
+	}
+	if err := removeProfile(profile); err != nil {
+		return err
+	}
+	if name, err := store.Get(defaultProfileKey); err == nil && name == profile {
+		return store.Delete(defaultProfileKey)
+	}
+	return nil
+}
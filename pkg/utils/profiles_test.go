@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jiotv-go/jiotv_go/v3/pkg/store"
+)
+
+func TestValidateProfileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		wantErr bool
+	}{
+		{name: "Simple name", profile: "work"},
+		{name: "Digits, dash and underscore", profile: "home_2-tv"},
+		{name: "Empty", profile: "", wantErr: true},
+		{name: "Path separator", profile: "a/b", wantErr: true},
+		{name: "Dot", profile: "a.b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateProfileName(tt.profile); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProfileName(%q) error = %v, wantErr %v", tt.profile, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	cleanup, err := store.SetupTestPathPrefix()
+	if err != nil {
+		t.Fatalf("Failed to setup test environment: %v", err)
+	}
+	defer cleanup()
+	if err := store.Init(); err != nil {
+		t.Fatalf("Failed to initialize store: %v", err)
+	}
+
+	home := &JIOTV_CREDENTIALS{SSOToken: "sso-home", CRM: "crm-home", UniqueID: "uid-home", AccessToken: "at-home", RefreshToken: "rt-home"}
+	work := &JIOTV_CREDENTIALS{SSOToken: "sso-work", CRM: "crm-work", UniqueID: "uid-work", AccessToken: "at-work", RefreshToken: "rt-work"}
+	if err := WriteJIOTVCredentials(home); err != nil {
+		t.Fatalf("WriteJIOTVCredentials() error = %v", err)
+	}
+	if err := WriteProfileCredentials("work", work); err != nil {
+		t.Fatalf("WriteProfileCredentials() error = %v", err)
+	}
+
+	if got, want := ListProfiles(), []string{DefaultProfile, "work"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListProfiles() = %v, want %v", got, want)
+	}
+
+	// The default profile keeps using the plain store keys
+	if crm, _ := store.Get("crm"); crm != "crm-home" {
+		t.Errorf("crm of the default profile = %q, want crm-home", crm)
+	}
+	got, err := GetProfileCredentials("work")
+	if err != nil || got.CRM != "crm-work" || got.AccessToken != "at-work" {
+		t.Errorf("GetProfileCredentials(work) = %+v, %v", got, err)
+	}
+
+	if err := SetDefaultProfile("missing"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("SetDefaultProfile(missing) error = %v, want ErrProfileNotFound", err)
+	}
+	if err := SetDefaultProfile("work"); err != nil {
+		t.Fatalf("SetDefaultProfile() error = %v", err)
+	}
+	if credentials, _ := GetJIOTVCredentials(); credentials == nil || credentials.CRM != "crm-work" {
+		t.Errorf("GetJIOTVCredentials() = %+v, want credentials of the default profile", credentials)
+	}
+
+	if err := deleteProfileCredentials("work"); err != nil {
+		t.Fatalf("deleteProfileCredentials() error = %v", err)
+	}
+	if ProfileExists("work") {
+		t.Error("deleted profile still exists")
+	}
+	if profile := GetDefaultProfile(); profile != DefaultProfile {
+		t.Errorf("GetDefaultProfile() after deleting it = %q, want %q", profile, DefaultProfile)
+	}
+	if _, err := GetProfileCredentials("work"); err == nil {
+		t.Error("credentials of a deleted profile are still returned")
+	}
+}
@@ -138,8 +138,21 @@ func LoginSendOTP(number string) (bool, error) {
 	}
 }
 
-// LoginVerifyOTP verifies OTP for login
+// LoginVerifyOTP verifies OTP for login to the default profile
 func LoginVerifyOTP(number, otp string) (map[string]string, error) {
+	return LoginVerifyOTPProfile(GetDefaultProfile(), number, otp)
+}
+
+// LoginVerifyOTPProfile verifies OTP for login and saves the credentials to the given profile.
+// An empty profile is the default profile.
+func LoginVerifyOTPProfile(profile, number, otp string) (map[string]string, error) {
+	if profile == "" {
+		profile = GetDefaultProfile()
+	}
+	if err := ValidateProfileName(profile); err != nil {
+		return nil, err
+	}
+
 	// convert number string to base64
 	encoded_number := base64.StdEncoding.EncodeToString([]byte(number))
 
@@ -193,7 +206,7 @@ func LoginVerifyOTP(number, otp string) (map[string]string, error) {
 		crm := result.SessionAttributes.User.SubscriberID
 		uniqueId := result.SessionAttributes.User.Unique
 
-		WriteJIOTVCredentials(&JIOTV_CREDENTIALS{
+		WriteProfileCredentials(profile, &JIOTV_CREDENTIALS{
 			SSOToken:             ssoToken,
 			CRM:                  crm,
 			UniqueID:             uniqueId,
@@ -244,86 +257,14 @@ func GetDeviceID() string {
 	return deviceID
 }
 
-// GetJIOTVCredentials return credentials from environment variables or credentials file
-// Important note: If credentials are provided from environment variables, they will be used instead of credentials file
+// GetJIOTVCredentials returns the credentials of the default profile from the store
 func GetJIOTVCredentials() (*JIOTV_CREDENTIALS, error) {
-	ssoToken, err := store.Get("ssoToken")
-	if err != nil {
-		return nil, err
-	}
-
-	crm, err := store.Get("crm")
-	if err != nil {
-		return nil, err
-	}
-
-	uniqueId, err := store.Get("uniqueId")
-	if err != nil {
-		return nil, err
-	}
-
-	// Required for OTP login
-	accessToken, err := store.Get("accessToken")
-	if err != nil {
-		return nil, nil
-	}
-
-	// Required for OTP login
-	refreshToken, err := store.Get("refreshToken")
-	if err != nil {
-		return nil, nil
-	}
-
-	// Required for OTP login
-	lastTokenRefreshTime, err := store.Get("lastTokenRefreshTime")
-	if err != nil {
-		return nil, nil
-	}
-
-	lastSSOTokenRefreshTime, err := store.Get("lastSSOTokenRefreshTime")
-	if err != nil {
-		return nil, nil
-	}
-
-	return &JIOTV_CREDENTIALS{
-		SSOToken:                ssoToken,
-		CRM:                     crm,
-		UniqueID:                uniqueId,
-		AccessToken:             accessToken,
-		RefreshToken:            refreshToken,
-		LastTokenRefreshTime:    lastTokenRefreshTime,
-		LastSSOTokenRefreshTime: lastSSOTokenRefreshTime,
-	}, nil
+	return GetProfileCredentials(GetDefaultProfile())
 }
 
-// WriteJIOTVCredentials writes credentials data to file
+// WriteJIOTVCredentials writes the credentials of the default profile to the store
 func WriteJIOTVCredentials(credentials *JIOTV_CREDENTIALS) error {
-	// Prepare batch operations
-	sets := map[string]string{
-		"ssoToken":     credentials.SSOToken,
-		"crm":          credentials.CRM,
-		"uniqueId":     credentials.UniqueID,
-		"accessToken":  credentials.AccessToken,
-		"refreshToken": credentials.RefreshToken,
-	}
-
-	// Handle timestamp fields
-	if credentials.LastTokenRefreshTime != "" {
-		sets["lastTokenRefreshTime"] = credentials.LastTokenRefreshTime
-	} else {
-		sets["lastTokenRefreshTime"] = strconv.FormatInt(time.Now().Unix(), 10)
-	}
-
-	if credentials.LastSSOTokenRefreshTime != "" {
-		sets["lastSSOTokenRefreshTime"] = credentials.LastSSOTokenRefreshTime
-	} else {
-		sets["lastSSOTokenRefreshTime"] = strconv.FormatInt(time.Now().Unix(), 10)
-	}
-
-	// Execute batch operations
-	return ExecuteBatchStoreOperations(BatchStoreOperations{
-		Sets: sets,
-	})
+	return WriteProfileCredentials(GetDefaultProfile(), credentials)
 }
 
 // CheckLoggedIn function checks if user is logged in
@@ -338,33 +279,32 @@ func CheckLoggedIn() bool {
 	}
 }
 
-// Logout function deletes credentials file
+// Logout function deletes the credentials of the default profile
 func Logout() error {
+	return LogoutProfile(GetDefaultProfile())
+}
+
+// LogoutProfile logs a profile out on the server and deletes its credentials
+func LogoutProfile(profile string) error {
 	// Perform server-side logout first
-	if err := PerformServerLogout(); err != nil {
+	if err := performServerLogout(profile); err != nil {
 		// Log the error but continue with local logout
 		Log.Printf("PerformServerLogout failed: %v", err)
 	}
 
-	// Delete all key-value pairs from the store using batch operations
-	return ExecuteBatchStoreOperations(BatchStoreOperations{
-		Deletes: []string{
-			"ssoToken",
-			"crm",
-			"uniqueId",
-			"accessToken",
-			"refreshToken",
-			"lastTokenRefreshTime",
-			"lastSSOTokenRefreshTime",
-		},
-	})
+	return deleteProfileCredentials(profile)
 }
 
 // PerformServerLogout attempts to log out the user from the JioTV servers.
 func PerformServerLogout() error {
+	return performServerLogout(GetDefaultProfile())
+}
+
+// performServerLogout attempts to log out a profile from the JioTV servers.
+func performServerLogout(profile string) error {
 	Log.Println("Attempting server-side logout...")
 
-	creds, err := GetJIOTVCredentials()
+	creds, err := GetProfileCredentials(profile)
 	if err != nil {
 		Log.Printf("Error getting credentials for server logout: %v\n", err)
 		// Depending on the error, we might still proceed if critical info like refreshToken is available
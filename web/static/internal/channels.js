@@ -79,14 +79,51 @@ const scrollToTop = () => {
 };
 
 // Favorite Channels Functionality
+// Favorites and the channel order are stored on the server through /api/favorites and /api/channel-order.
+// Favorites saved in localStorage by older versions are moved to the server once.
 const FAVORITES_STORAGE_KEY = "favoriteChannels";
 
+let favoriteChannelIds = Array.isArray(window.SERVER_FAVORITES) ? window.SERVER_FAVORITES : [];
+
 function getFavoriteChannels() {
-  return getLocalStorageItem(FAVORITES_STORAGE_KEY, []);
+  return favoriteChannelIds.slice();
 }
 
 function saveFavoriteChannels(favoriteIds) {
-  setLocalStorageItem(FAVORITES_STORAGE_KEY, favoriteIds);
+  favoriteChannelIds = Array.isArray(favoriteIds) ? favoriteIds : [];
+}
+
+async function requestFavoritesAPI(path, method, body) {
+  const options = { method };
+  if (body !== undefined) {
+    options.headers = { "Content-Type": "application/json" };
+    options.body = JSON.stringify(body);
+  }
+  const response = await fetch(path, options);
+  if (!response.ok) {
+    throw new Error(`${method} ${path} failed with status ${response.status}`);
+  }
+  return response.json();
+}
+
+async function migrateLocalFavorites() {
+  const localFavorites = getLocalStorageItem(FAVORITES_STORAGE_KEY, []);
+  if (!Array.isArray(localFavorites) || localFavorites.length === 0) {
+    return;
+  }
+  const merged = getFavoriteChannels();
+  localFavorites.forEach(id => {
+    if (!merged.includes(id)) {
+      merged.push(id);
+    }
+  });
+  try {
+    const data = await requestFavoritesAPI("/api/favorites", "PUT", { favorites: merged });
+    saveFavoriteChannels(data.favorites);
+    removeLocalStorageItem(FAVORITES_STORAGE_KEY);
+  } catch (error) {
+    console.error("Error migrating favorites to the server:", error);
+  }
 }
 
 function displayFavoriteChannels() {
@@ -134,7 +171,9 @@ function displayFavoriteChannels() {
 
   allChannelCards.forEach(card => {
     const cardChannelId = card.dataset.channelId;
-    if (favoriteIds.includes(cardChannelId)) {
+    const isFavorite = favoriteIds.includes(cardChannelId);
+    card.draggable = isFavorite;
+    if (isFavorite) {
       favoriteFragment.appendChild(card);
     } else {
       originalFragment.appendChild(card);
@@ -146,22 +185,68 @@ function displayFavoriteChannels() {
   originalChannelsGrid.appendChild(originalFragment);
 }
 
-function toggleFavorite(channelId) {
-  const favoriteIds = getFavoriteChannels();
-  const index = favoriteIds.indexOf(channelId);
-
-  if (index > -1) { // Channel was a favorite, removing it
-    favoriteIds.splice(index, 1);
-    updateFavoriteButtonState(channelId, false);
-  } else { // Channel was not a favorite, adding it
-    favoriteIds.push(channelId);
-    updateFavoriteButtonState(channelId, true);
+async function toggleFavorite(channelId) {
+  const isFavorite = getFavoriteChannels().includes(channelId);
+  const path = `/api/favorites/${encodeURIComponent(channelId)}`;
+
+  try {
+    const data = await requestFavoritesAPI(path, isFavorite ? "DELETE" : "POST");
+    saveFavoriteChannels(data.favorites);
+  } catch (error) {
+    console.error("Error updating favorites:", error);
+    return;
   }
-  
-  saveFavoriteChannels(favoriteIds);
+
+  updateFavoriteButtonState(channelId, !isFavorite);
   displayFavoriteChannels(); // Refresh the channel lists
 }
 
+// Favorite cards can be dragged to change the custom channel order.
+// The new order of the favorites is saved in front of the rest of the stored order.
+let draggedFavoriteCard = null;
+
+function setupFavoriteReordering() {
+  const favoriteChannelsContainer = document.getElementById("favorite-channels-container");
+  if (!favoriteChannelsContainer) {
+    return;
+  }
+
+  favoriteChannelsContainer.addEventListener("dragstart", event => {
+    draggedFavoriteCard = event.target.closest("a.card[data-channel-id]");
+  });
+
+  favoriteChannelsContainer.addEventListener("dragover", event => {
+    if (!draggedFavoriteCard) return;
+    event.preventDefault();
+    const target = event.target.closest("a.card[data-channel-id]");
+    if (target && target !== draggedFavoriteCard) {
+      const rect = target.getBoundingClientRect();
+      const after = event.clientX > rect.left + rect.width / 2;
+      favoriteChannelsContainer.insertBefore(draggedFavoriteCard, after ? target.nextSibling : target);
+    }
+  });
+
+  favoriteChannelsContainer.addEventListener("drop", async event => {
+    event.preventDefault();
+    draggedFavoriteCard = null;
+    const favoriteOrder = Array.from(
+      favoriteChannelsContainer.querySelectorAll("a.card[data-channel-id]")
+    ).map(card => card.dataset.channelId);
+
+    try {
+      const current = await requestFavoritesAPI("/api/channel-order", "GET");
+      const order = favoriteOrder.concat(current.order.filter(id => !favoriteOrder.includes(id)));
+      await requestFavoritesAPI("/api/channel-order", "PUT", { order });
+    } catch (error) {
+      console.error("Error saving channel order:", error);
+    }
+  });
+
+  favoriteChannelsContainer.addEventListener("dragend", () => {
+    draggedFavoriteCard = null;
+  });
+}
+
 function updateFavoriteButtonStates() {
   const favoriteIds = getFavoriteChannels();
   const favoriteButtons = document.querySelectorAll(".favorite-btn");
@@ -172,7 +257,90 @@ function updateFavoriteButtonStates() {
   });
 }
 
-document.addEventListener('DOMContentLoaded', () => {
+// Programme search
+// Searches the guide of every channel through /api/epg/search and lists the hits by channel.
+function formatProgrammeTime(time) {
+  return new Date(time).toLocaleString([], {
+    weekday: "short",
+    hour: "2-digit",
+    minute: "2-digit",
+  });
+}
+
+function renderGuideSearchResults(resultsElement, channels) {
+  resultsElement.innerHTML = "";
+  if (channels.length === 0) {
+    resultsElement.appendChild(createElement("p", { className: "text-lg" }, "No programmes found."));
+    return;
+  }
+
+  channels.forEach(channel => {
+    const group = createElement("div", { className: "mt-4" });
+    group.appendChild(createElement("a", {
+      href: `/play/${encodeURIComponent(channel.channel_id)}`,
+      className: "text-2xl font-bold link"
+    }, channel.channel_name || channel.channel_id));
+
+    const list = createElement("ul", { className: "flex flex-col gap-2 mt-2" });
+    channel.programmes.forEach(programme => {
+      const item = createElement("li", { className: "flex flex-row gap-2 items-center" });
+      item.appendChild(createElement("span", { className: "text-sm" },
+        `${formatProgrammeTime(programme.start)} - ${formatProgrammeTime(programme.stop)}`));
+      item.appendChild(createElement("span", { className: "font-bold" }, programme.title));
+      if (programme.category) {
+        item.appendChild(createElement("span", { className: "badge badge-outline" }, programme.category));
+      }
+      list.appendChild(item);
+    });
+    group.appendChild(list);
+    resultsElement.appendChild(group);
+  });
+}
+
+async function searchGuide(query) {
+  const resultsElement = document.getElementById("epg-search-results");
+  if (!resultsElement) return;
+
+  if (!query) {
+    resultsElement.style.display = "none";
+    resultsElement.innerHTML = "";
+    return;
+  }
+
+  resultsElement.style.display = "block";
+  resultsElement.textContent = "Searching...";
+  try {
+    const response = await fetch(`/api/epg/search?q=${encodeURIComponent(query)}`);
+    if (response.status === 404) {
+      resultsElement.textContent = "The guide is not available. Enable EPG to search programmes.";
+      return;
+    }
+    if (!response.ok) {
+      throw new Error(`Search failed with status ${response.status}`);
+    }
+    const data = await response.json();
+    renderGuideSearchResults(resultsElement, data.channels);
+  } catch (error) {
+    console.error("Error searching the guide:", error);
+    resultsElement.textContent = "Search failed. Please try again.";
+  }
+}
+
+function setupGuideSearch() {
+  const form = document.getElementById("epg-search-form");
+  const input = document.getElementById("epg-search-input");
+  if (!form || !input) return;
+
+  form.addEventListener("submit", event => {
+    event.preventDefault();
+    searchGuide(input.value.trim());
+  });
+}
+
+document.addEventListener('DOMContentLoaded', async () => {
+  await migrateLocalFavorites();
   updateFavoriteButtonStates();
-  displayFavoriteChannels(); 
+  displayFavoriteChannels();
+  setupFavoriteReordering();
+  setupGuideSearch();
 });
@@ -45,3 +45,36 @@ const initializeTheme = () => {
 };
 
 initializeTheme();
+
+// Shows a message in the corner of the page for a few seconds
+const showToast = (message) => {
+  const toast = createElement("div", {
+    className: "alert shadow-lg fixed bottom-4 right-4 z-10",
+    style: "width: auto; max-width: 24rem",
+    role: "status",
+  }, message);
+  document.body.appendChild(toast);
+  setTimeout(() => toast.remove(), 10000);
+};
+
+// Shows the programme reminders firing on the server as browser notifications,
+// or as a toast when notifications are not allowed
+const listenForReminders = () => {
+  if (!window.EventSource) return;
+  const events = new EventSource("/api/reminders/events");
+  events.addEventListener("reminder", (event) => {
+    const { message, reminder } = JSON.parse(event.data);
+    if (window.Notification && Notification.permission === "granted") {
+      // The tag shows the reminder once when several tabs are open
+      const notification = new Notification(message, { tag: reminder.id });
+      notification.onclick = () => {
+        window.focus();
+        window.location.href = `/play/${reminder.channel_id}`;
+      };
+    } else {
+      showToast(message);
+    }
+  });
+};
+
+listenForReminders();
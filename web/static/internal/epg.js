@@ -234,16 +234,168 @@ function updateEPG(epgData) {
     const timerInterval = setInterval(updateTimer, 1000);
 }
 
+// Function to get the EPG of a channel from the server's local guide in the shape of the JioTV EPG API
+async function getLocalEPG(channelID) {
+    const guide = await getJSON(`/api/epg/${channelID}`);
+    return {
+        epg: guide.programmes.map(programme => ({
+            startEpoch: new Date(programme.start).getTime(),
+            endEpoch: new Date(programme.stop).getTime(),
+            showname: programme.title,
+            description: programme.description,
+            // Posters are loaded through /jtvposter/, which expects the path below the JioTV poster URL
+            episodePoster: programme.poster.replace(/^.*\/dare_images\/shows\//, ''),
+            keywords: programme.category ? [programme.category] : []
+        }))
+    };
+}
+
+// Function to get the EPG of a channel, preferring the local guide over JioTV
+async function getEPG(channelID) {
+    try {
+        const epgData = await getLocalEPG(channelID);
+        if (epgData.epg.length > 0) return epgData;
+    } catch (error) {
+        // The local guide is not loaded or has no such channel
+    }
+    return getJSON(`/epg/${channelID}/${offset}`);
+}
+
+// Function to format the start and stop of a programme of the local guide
+function formatProgrammeSlot(programme) {
+    const options = { hour: '2-digit', minute: '2-digit' };
+    const start = new Date(programme.start).toLocaleTimeString([], options);
+    const stop = new Date(programme.stop).toLocaleTimeString([], options);
+    return `${start} - ${stop}`;
+}
+
+// Function to set or cancel the reminder of a programme, returning the reminder or null
+async function toggleReminder(programme, reminder) {
+    if (reminder) {
+        const response = await fetch(`/api/reminders/${reminder.id}`, { method: 'DELETE' });
+        if (!response.ok && response.status !== 404) {
+            throw new Error(`Removing reminder failed with status ${response.status}`);
+        }
+        return null;
+    }
+    // Reminders are shown as browser notifications once allowed
+    if (window.Notification && Notification.permission === 'default') {
+        Notification.requestPermission();
+    }
+    const response = await fetch('/api/reminders', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ channel_id: programme.channel_id, start: programme.start })
+    });
+    if (!response.ok) {
+        throw new Error(`Setting reminder failed with status ${response.status}`);
+    }
+    return response.json();
+}
+
+// Function to schedule the recording of a programme
+async function recordProgramme(programme) {
+    const response = await fetch('/api/recordings', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ channel_id: programme.channel_id, start: programme.start })
+    });
+    // A programme which is already scheduled is fine
+    if (!response.ok && response.status !== 409) {
+        throw new Error(`Scheduling recording failed with status ${response.status}`);
+    }
+}
+
+// Function to render the upcoming programmes of the channel with a reminder and a record button each
+function renderUpcoming(programmes, reminders) {
+    const elements = safeGetElementsById(['upcoming', 'upcoming_parent']);
+    const { upcoming: upcomingContainer, upcoming_parent: upcomingParent } = elements;
+    if (!upcomingContainer || !upcomingParent) return;
+
+    upcomingContainer.innerHTML = '';
+    if (programmes.length === 0) {
+        setElementVisibility(upcomingParent, false);
+        return;
+    }
+
+    programmes.forEach(programme => {
+        let reminder = reminders.find(r => r.channel_id === programme.channel_id &&
+            new Date(r.start).getTime() === new Date(programme.start).getTime()) || null;
+
+        const item = createElement('div', {
+            className: 'card bg-base-200 shadow-lg p-2 flex flex-row items-center justify-between gap-2'
+        });
+        const details = createElement('div');
+        details.appendChild(createElement('div', { className: 'text-sm font-bold' }, programme.title));
+        details.appendChild(createElement('div', { className: 'text-sm' }, formatProgrammeSlot(programme)));
+
+        const button = createElement('button', { className: 'btn btn-sm' });
+        const updateButton = () => {
+            button.textContent = reminder ? 'Reminder set' : 'Remind me';
+            toggleClasses(button, 'btn-primary', 'btn-outline', reminder !== null);
+        };
+        button.addEventListener('click', async () => {
+            button.disabled = true;
+            try {
+                reminder = await toggleReminder(programme, reminder);
+            } catch (error) {
+                console.error('Failed to update reminder:', error);
+            }
+            button.disabled = false;
+            updateButton();
+        });
+        updateButton();
+
+        const recordButton = createElement('button', { className: 'btn btn-sm btn-outline' }, 'Record');
+        recordButton.addEventListener('click', async () => {
+            recordButton.disabled = true;
+            try {
+                await recordProgramme(programme);
+                recordButton.textContent = 'Scheduled';
+            } catch (error) {
+                console.error('Failed to schedule recording:', error);
+                recordButton.disabled = false;
+            }
+        });
+
+        const actions = createElement('div', { className: 'flex flex-row gap-2' });
+        actions.appendChild(button);
+        actions.appendChild(recordButton);
+        item.appendChild(details);
+        item.appendChild(actions);
+        upcomingContainer.appendChild(item);
+    });
+    setElementVisibility(upcomingParent, true);
+}
+
+// Function to load the next programmes of the channel from the local guide, which reminders need
+async function loadUpcoming(channelID) {
+    try {
+        const [guide, reminders] = await Promise.all([
+            getJSON(`/api/epg/${channelID}`),
+            getJSON('/api/reminders')
+        ]);
+        const now = Date.now();
+        const upcoming = guide.programmes.filter(programme => new Date(programme.start).getTime() > now).slice(0, 5);
+        renderUpcoming(upcoming, reminders.reminders);
+    } catch (error) {
+        // The local guide is not loaded, so there are no reminders
+    }
+}
+
 const epgParent = safeGetElementById('epg_parent');
 if (epgParent) epgParent.style.display = 'none';
 
 (async () => {
     // Load EPG data
     try {
-        const epgData = await getJSON(`/epg/${channelID}/${offset}`);
+        const epgData = await getEPG(channelID);
         if (epgParent) epgParent.style.display = 'block';
         updateEPG(epgData);
 
+        // Load upcoming programmes to set reminders on
+        await loadUpcoming(channelID);
+
         // Load similar channels
         await loadSimilarChannels();
     } catch (error) {
@@ -0,0 +1,175 @@
+// Badge classes of the recording statuses
+const STATUS_BADGES = {
+    scheduled: 'badge-info',
+    recording: 'badge-error',
+    completed: 'badge-success',
+    failed: 'badge-warning',
+    cancelled: 'badge-outline',
+    skipped: 'badge-ghost'
+};
+
+// Function to format the size of a recorded file
+function formatSize(bytes) {
+    if (bytes >= 1024 * 1024 * 1024) return `${(bytes / 1024 / 1024 / 1024).toFixed(1)} GB`;
+    if (bytes >= 1024 * 1024) return `${(bytes / 1024 / 1024).toFixed(1)} MB`;
+    return `${Math.round(bytes / 1024)} KB`;
+}
+
+// Function to send a request to the recordings API, throwing the error message of failed requests
+async function recordingsRequest(method, url, data) {
+    const options = { method };
+    if (data !== undefined) {
+        options.headers = { 'Content-Type': 'application/json' };
+        options.body = JSON.stringify(data);
+    }
+    const response = await fetch(url, options);
+    if (!response.ok) {
+        const body = await response.json().catch(() => ({}));
+        throw new Error(body.message || `Request failed with status ${response.status}`);
+    }
+    return response.json();
+}
+
+// Function to create a small button running an action and reloading the lists
+function createActionButton(label, className, action) {
+    const button = createElement('button', { className: `btn btn-sm ${className}` }, label);
+    button.addEventListener('click', async () => {
+        button.disabled = true;
+        try {
+            await action();
+        } catch (error) {
+            showToast(error.message);
+        }
+        loadRecordings();
+    });
+    return button;
+}
+
+// Function to render the recordings with their actions
+function renderRecordings(recordings) {
+    const container = safeGetElementById('recordings');
+    if (!container) return;
+    container.innerHTML = '';
+    if (recordings.length === 0) {
+        container.appendChild(createElement('div', { className: 'text-sm' }, 'No recordings yet.'));
+        return;
+    }
+
+    const options = { dateStyle: 'medium', timeStyle: 'short' };
+    recordings.slice().reverse().forEach(recording => {
+        const item = createElement('div', {
+            className: 'card bg-base-200 shadow-lg p-2 flex flex-row items-center justify-between gap-2'
+        });
+        const details = createElement('div');
+        const title = createElement('div', { className: 'text-sm font-bold flex flex-row items-center gap-2' });
+        title.appendChild(createElement('span', {}, recording.title));
+        title.appendChild(createElement('span', { className: `badge ${STATUS_BADGES[recording.status] || ''}` }, recording.status));
+        details.appendChild(title);
+        const start = new Date(recording.start).toLocaleString([], options);
+        const end = new Date(recording.end).toLocaleTimeString([], { timeStyle: 'short' });
+        details.appendChild(createElement('div', { className: 'text-sm' },
+            `${recording.channel_name || recording.channel_id} · ${start} - ${end}` +
+            (recording.size ? ` · ${formatSize(recording.size)}` : '')));
+        if (recording.error) {
+            details.appendChild(createElement('div', { className: 'text-sm text-error' }, recording.error));
+        }
+
+        const actions = createElement('div', { className: 'flex flex-row gap-2' });
+        if (recording.size) {
+            const download = createElement('a', {
+                className: 'btn btn-sm btn-outline',
+                href: `/api/recordings/${encodeURIComponent(recording.id)}/file`
+            }, 'Download');
+            actions.appendChild(download);
+        }
+        if (recording.status === 'scheduled' || recording.status === 'recording') {
+            actions.appendChild(createActionButton('Cancel', 'btn-outline', () =>
+                recordingsRequest('POST', `/api/recordings/${encodeURIComponent(recording.id)}/cancel`)));
+        }
+        // Skipped recordings stay until their programme starts, so that their rule doesn't schedule them again
+        if (recording.status !== 'skipped') {
+            actions.appendChild(createActionButton('Delete', 'btn-outline btn-error', () =>
+                recordingsRequest('DELETE', `/api/recordings/${encodeURIComponent(recording.id)}`)));
+        }
+
+        item.appendChild(details);
+        item.appendChild(actions);
+        container.appendChild(item);
+    });
+}
+
+// Function to render the series rules with a button removing each
+function renderRules(rules) {
+    const container = safeGetElementById('rules');
+    if (!container) return;
+    container.innerHTML = '';
+    rules.forEach(rule => {
+        const item = createElement('div', {
+            className: 'card bg-base-200 shadow-lg p-2 flex flex-row items-center justify-between gap-2'
+        });
+        const details = createElement('div');
+        details.appendChild(createElement('div', { className: 'text-sm font-bold' }, rule.pattern));
+        details.appendChild(createElement('div', { className: 'text-sm' },
+            `${rule.channel_id ? `Channel ${rule.channel_id}` : 'All channels'} · ` +
+            (rule.keep_last ? `keeps the last ${rule.keep_last}` : 'keeps all recordings')));
+        item.appendChild(details);
+        item.appendChild(createActionButton('Remove', 'btn-outline btn-error', () =>
+            recordingsRequest('DELETE', `/api/recording-rules/${encodeURIComponent(rule.id)}`)));
+        container.appendChild(item);
+    });
+}
+
+// Function to load the recordings and series rules
+async function loadRecordings() {
+    try {
+        const [recordings, rules] = await Promise.all([
+            getJSON('/api/recordings'),
+            getJSON('/api/recording-rules')
+        ]);
+        renderRecordings(recordings.recordings);
+        renderRules(rules.rules);
+    } catch (error) {
+        showToast('Failed to load recordings');
+    }
+}
+
+// Function to convert the value of a datetime-local input to RFC 3339
+function inputTime(id) {
+    const value = safeGetElementById(id).value;
+    return value ? new Date(value).toISOString() : '';
+}
+
+safeGetElementById('recording_form').addEventListener('submit', async (event) => {
+    event.preventDefault();
+    try {
+        await recordingsRequest('POST', '/api/recordings', {
+            channel_id: safeGetElementById('recording_channel').value.trim(),
+            title: safeGetElementById('recording_title').value.trim(),
+            start: inputTime('recording_start'),
+            end: inputTime('recording_end')
+        });
+        event.target.reset();
+    } catch (error) {
+        showToast(error.message);
+    }
+    loadRecordings();
+});
+
+safeGetElementById('rule_form').addEventListener('submit', async (event) => {
+    event.preventDefault();
+    try {
+        await recordingsRequest('POST', '/api/recording-rules', {
+            pattern: safeGetElementById('rule_pattern').value.trim(),
+            channel_id: safeGetElementById('rule_channel').value.trim(),
+            keep_last: parseInt(safeGetElementById('rule_keep').value, 10) || 0
+        });
+        event.target.reset();
+    } catch (error) {
+        showToast(error.message);
+    }
+    loadRecordings();
+});
+
+loadRecordings();
+// Keep the status and size of running recordings up to date
+setInterval(loadRecordings, 10000);
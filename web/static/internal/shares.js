@@ -0,0 +1,125 @@
+// Function to send a request to the share links API, throwing the error message of failed requests
+async function sharesRequest(method, url, data) {
+    const options = { method };
+    if (data !== undefined) {
+        options.headers = { 'Content-Type': 'application/json' };
+        options.body = JSON.stringify(data);
+    }
+    const response = await fetch(url, options);
+    if (!response.ok) {
+        const body = await response.json().catch(() => ({}));
+        throw new Error(body.message || `Request failed with status ${response.status}`);
+    }
+    return response.json();
+}
+
+// Function to describe the scope of a share link
+function describeScope(scope) {
+    const parts = [];
+    if (scope.channel_ids && scope.channel_ids.length) {
+        parts.push(`Channels ${scope.channel_ids.join(', ')}`);
+    }
+    if (scope.languages && scope.languages.length) {
+        parts.push(scope.languages.map(id => optionLabel('share_language', id)).join(', '));
+    }
+    if (scope.categories && scope.categories.length) {
+        parts.push(scope.categories.map(id => optionLabel('share_category', id)).join(', '));
+    }
+    return parts.join(' · ');
+}
+
+// Function to get the label of an option of a select by its value
+function optionLabel(selectId, value) {
+    const select = safeGetElementById(selectId);
+    const option = select && Array.from(select.options).find(o => o.value === String(value));
+    return option ? option.textContent : String(value);
+}
+
+// Function to create a button copying a URL to the clipboard
+function createCopyButton(label, url) {
+    const button = createElement('button', { className: 'btn btn-sm btn-outline' }, label);
+    button.addEventListener('click', async () => {
+        try {
+            await navigator.clipboard.writeText(url);
+            showToast('Copied to the clipboard');
+        } catch (error) {
+            window.prompt('Copy the link', url);
+        }
+    });
+    return button;
+}
+
+// Function to render the share links with their actions
+function renderShares(shares) {
+    const container = safeGetElementById('shares');
+    if (!container) return;
+    container.innerHTML = '';
+    if (shares.length === 0) {
+        container.appendChild(createElement('div', { className: 'text-sm' }, 'No share links yet.'));
+        return;
+    }
+
+    const options = { dateStyle: 'medium', timeStyle: 'short' };
+    shares.forEach(link => {
+        const item = createElement('div', {
+            className: 'card bg-base-200 shadow-lg p-2 flex flex-row items-center justify-between gap-2'
+        });
+        const details = createElement('div');
+        details.appendChild(createElement('div', { className: 'text-sm font-bold' }, link.name || link.id));
+        details.appendChild(createElement('div', { className: 'text-sm' },
+            `${describeScope(link.scope)} · expires ${new Date(link.expires).toLocaleString([], options)}`));
+
+        const actions = createElement('div', { className: 'flex flex-row gap-2' });
+        actions.appendChild(createCopyButton('Playlist', link.playlist_url));
+        if (link.play_url) {
+            actions.appendChild(createCopyButton('Player', link.play_url));
+        }
+        const revoke = createElement('button', { className: 'btn btn-sm btn-outline btn-error' }, 'Revoke');
+        revoke.addEventListener('click', async () => {
+            revoke.disabled = true;
+            try {
+                await sharesRequest('DELETE', `/api/shares/${encodeURIComponent(link.id)}`);
+            } catch (error) {
+                showToast(error.message);
+            }
+            loadShares();
+        });
+        actions.appendChild(revoke);
+
+        item.appendChild(details);
+        item.appendChild(actions);
+        container.appendChild(item);
+    });
+}
+
+// Function to load the share links
+async function loadShares() {
+    try {
+        const response = await getJSON('/api/shares');
+        renderShares(response.shares);
+    } catch (error) {
+        showToast('Failed to load share links');
+    }
+}
+
+safeGetElementById('share_form').addEventListener('submit', async (event) => {
+    event.preventDefault();
+    // 0 is "All Languages" and "All Categories"
+    const language = parseInt(safeGetElementById('share_language').value, 10);
+    const category = parseInt(safeGetElementById('share_category').value, 10);
+    try {
+        await sharesRequest('POST', '/api/shares', {
+            name: safeGetElementById('share_name').value.trim(),
+            channel_ids: safeGetElementById('share_channels').value.split(',').map(id => id.trim()).filter(Boolean),
+            languages: language ? [language] : [],
+            categories: category ? [category] : [],
+            expires_in: safeGetElementById('share_expires').value
+        });
+        event.target.reset();
+    } catch (error) {
+        showToast(error.message);
+    }
+    loadShares();
+});
+
+loadShares();